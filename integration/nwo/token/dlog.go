@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger/fabric/msp"
 
 	cryptodlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type DLogPublicParamsGenerator struct {
@@ -41,6 +42,8 @@ func (d *DLogPublicParamsGenerator) Generate(p *Platform, tms *TMS) ([]byte, err
 	if err != nil {
 		return nil, err
 	}
+	// issuing is denied by default, the test networks let any issuer issue any type
+	pp.TypeRegistry = pp.TypeRegistry.Register(&token2.TypeInfo{Type: token2.AnyType})
 
 	ppRaw, err := pp.Serialize()
 	if err != nil {
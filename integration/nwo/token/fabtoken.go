@@ -7,6 +7,7 @@ package token
 
 import (
 	cryptofabtoken "github.com/hyperledger-labs/fabric-token-sdk/token/core/fabtoken"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type FabTokenPublicParamsGenerator struct {
@@ -17,6 +18,8 @@ func (f *FabTokenPublicParamsGenerator) Generate(p *Platform, tms *TMS) ([]byte,
 	if err != nil {
 		return nil, err
 	}
+	// issuing is denied by default, the test networks let any issuer issue any type
+	pp.TypeRegistry = pp.TypeRegistry.Register(&token2.TypeInfo{Type: token2.AnyType})
 	ppRaw, err := pp.Serialize()
 	if err != nil {
 		return nil, err
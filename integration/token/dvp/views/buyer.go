@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type BuyHouseView struct{}
@@ -26,7 +27,7 @@ func (b *BuyHouseView) Call(context view.Context) (interface{}, error) {
 
 	err = tokenTx.Transfer(
 		ttx.MyWalletForChannel(context, tokenTx.Channel()),
-		action.Type, []uint64{action.Amount}, []view.Identity{action.Recipient},
+		action.Type, []token2.Quantity{token2.NewQuantityFromUInt64(action.Amount)}, []view.Identity{action.Recipient},
 	)
 	assert.NoError(err, "failed appending transfer")
 
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type IssueCash struct {
@@ -41,7 +42,7 @@ func (p *IssueCashView) Call(context view.Context) (interface{}, error) {
 		ttx.GetIssuerWallet(context, p.Wallet),
 		recipient,
 		p.Typ,
-		p.Quantity,
+		token2.NewQuantityFromUInt64(p.Quantity),
 	), "failed issuing token")
 
 	_, err = context.RunView(ttx.NewCollectEndorsementsView(tx))
@@ -102,7 +102,7 @@ func (p *IssueCashView) Call(context view.Context) (interface{}, error) {
 		wallet,
 		recipient,
 		p.TokenType,
-		p.Quantity,
+		token2.NewQuantityFromUInt64(p.Quantity),
 	)
 	assert.NoError(err, "failed adding new issued token")
 
@@ -59,7 +59,7 @@ func (t *RedeemView) Call(context view.Context) (interface{}, error) {
 	err = tx.Redeem(
 		senderWallet,
 		t.Type,
-		t.Amount,
+		token.NewQuantityFromUInt64(t.Amount),
 		token2.WithTokenIDs(t.TokenIDs...),
 	)
 	assert.NoError(err, "failed adding new tokens")
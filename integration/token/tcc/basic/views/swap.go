@@ -59,7 +59,7 @@ func (t *SwapInitiatorView) Call(context view.Context) (interface{}, error) {
 	err = tx.Transfer(
 		senderWallet,
 		t.FromAliceType,
-		[]uint64{t.FromAliceAmount},
+		[]token2.Quantity{token2.NewQuantityFromUInt64(t.FromAliceAmount)},
 		[]view.Identity{other},
 	)
 	assert.NoError(err, "failed adding output")
@@ -135,7 +135,7 @@ func (t *SwapResponderView) Call(context view.Context) (interface{}, error) {
 	err = tx.Transfer(
 		bobWallet,
 		action.Type,
-		[]uint64{action.Amount},
+		[]token2.Quantity{token2.NewQuantityFromUInt64(action.Amount)},
 		[]view.Identity{action.Recipient},
 	)
 	assert.NoError(err, "failed appending transfer")
@@ -73,7 +73,7 @@ func (t *TransferView) Call(context view.Context) (interface{}, error) {
 	err = tx.Transfer(
 		senderWallet,
 		t.Type,
-		[]uint64{t.Amount},
+		[]token.Quantity{token.NewQuantityFromUInt64(t.Amount)},
 		[]view.Identity{recipient},
 		token2.WithTokenIDs(t.TokenIDs...),
 	)
@@ -204,7 +204,7 @@ func (t *TransferWithSelectorView) Call(context view.Context) (interface{}, erro
 	err = tx.Transfer(
 		ttxcc.GetWallet(context, t.Wallet),
 		t.Type,
-		[]uint64{t.Amount},
+		[]token.Quantity{token.NewQuantityFromUInt64(t.Amount)},
 		[]view.Identity{recipient},
 		token2.WithTokenIDs(t.TokenIDs...),
 	)
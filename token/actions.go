@@ -5,7 +5,10 @@ SPDX-License-Identifier: Apache-2.0
 */
 package token
 
-import "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 type IssueAction struct {
 	a driver.IssueAction
@@ -31,6 +34,10 @@ func (i *IssueAction) GetIssuer() []byte {
 	return i.a.GetIssuer()
 }
 
+func (i *IssueAction) GetNFTs() []*token2.NFT {
+	return i.a.GetNFTs()
+}
+
 type TransferAction struct {
 	a driver.TransferAction
 }
@@ -5,6 +5,8 @@ SPDX-License-Identifier: Apache-2.0
 */
 package token
 
+import "time"
+
 type InteractiveCertification struct {
 	IDs []string `yaml:"ids,omitempty"`
 }
@@ -24,12 +26,51 @@ type Wallets struct {
 	Certifiers []*Identity `yaml:"certifiers,omitempty"`
 }
 
+type Selection struct {
+	// Strategy is the name of the token selection strategy
+	Strategy string `yaml:"strategy,omitempty"`
+}
+
+// AuditLimit bounds the amounts of a token type an enrollment ID can send.
+// The amounts are decimal strings, in base units. Empty amounts are unlimited.
+type AuditLimit struct {
+	// Type is the token type the limit applies to
+	Type string `yaml:"type"`
+	// PerTransaction is the maximum amount sent in a transaction
+	PerTransaction string `yaml:"perTransaction,omitempty"`
+	// Daily is the maximum amount sent in a UTC day
+	Daily string `yaml:"daily,omitempty"`
+	// Rolling is the maximum amount sent in any window of length Window
+	Rolling string `yaml:"rolling,omitempty"`
+	// Window is the length of the rolling window
+	Window time.Duration `yaml:"window,omitempty"`
+}
+
+// AuditPolicy is the policy an auditor enforces before signing a transaction
+type AuditPolicy struct {
+	// AllowedTypes are the token types the auditor accepts, all if empty
+	AllowedTypes []string `yaml:"allowedTypes,omitempty"`
+	// BlockedIDs are the enrollment IDs whose transactions are rejected
+	BlockedIDs []string `yaml:"blockedIDs,omitempty"`
+	// Limits are the limits on the amounts sent, per token type
+	Limits []*AuditLimit `yaml:"limits,omitempty"`
+}
+
+type Auditor struct {
+	// Wallet is the identifier of the auditor wallet of this node. The node audits the transactions of the TMS
+	// only if it is set. The empty identifier is not a valid value, use the label of the auditor identity.
+	Wallet string       `yaml:"wallet,omitempty"`
+	Policy *AuditPolicy `yaml:"policy,omitempty"`
+}
+
 type TMS struct {
 	Network       string         `yaml:"network,omitempty"`
 	Channel       string         `yaml:"channel,omitempty"`
 	Namespace     string         `yaml:"namespace,omitempty"`
 	Certification *Certification `yaml:"certification,omitempty"`
 	Wallets       *Wallets       `yaml:"wallets,omitempty"`
+	Selection     *Selection     `yaml:"selection,omitempty"`
+	Auditor       *Auditor       `yaml:"auditor,omitempty"`
 }
 
 type Token struct {
@@ -21,6 +21,7 @@ import (
 
 	packager2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/cmd/pp/packager"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 const (
@@ -34,17 +35,25 @@ var output string
 var base int64
 var exponent int
 var cc bool
+var bitLength int
+var rangeProof string
+var rangeBitLength int
+var openIssuance bool
 
 // Cmd returns the Cobra Command for Version
 func Cmd() *cobra.Command {
 	// Set the flags on the node start command.
 	flags := cobraCommand.Flags()
-	flags.StringVarP(&driver, "driver", "d", "dlog", "driver (dlog)")
+	flags.StringVarP(&driver, "driver", "d", "dlog", "driver (dlog, dloggh)")
 	flags.StringVarP(&idemixMSPDir, "idemix", "i", "", "idemix msp dir")
 	flags.StringVarP(&output, "output", "o", ".", "output folder")
 	flags.Int64VarP(&base, "base", "b", 100, "max token quantity")
 	flags.IntVarP(&exponent, "exponent", "e", 2, "max token quantity")
 	flags.BoolVarP(&cc, "cc", "", false, "generate chaincode package")
+	flags.IntVarP(&bitLength, "anonymity", "a", 4, "log2 of the size of the anonymity set of spent tokens (dloggh only)")
+	flags.StringVarP(&rangeProof, "rangeproof", "r", "ps", "range proof system (ps, bulletproof)")
+	flags.IntVarP(&rangeBitLength, "bits", "", 64, "bit length of token quantities (bulletproof only)")
+	flags.BoolVarP(&openIssuance, "open-issuance", "", false, "let any issuer issue any token type, issuing is denied by default")
 
 	return cobraCommand
 }
@@ -70,9 +79,11 @@ func gen(args []string) error {
 	fmt.Printf("Generate public parameters for [%s]...\n", driver)
 	switch driver {
 	case "dlog":
-		raw, err = dlogGen(args)
+		raw, err = dlogGen(args, false)
+	case "dloggh":
+		raw, err = dlogGen(args, true)
 	default:
-		errors.Errorf("Invalid crypto type, expected 'dlog', got [%s]", driver)
+		err = errors.Errorf("Invalid crypto type, expected 'dlog' or 'dloggh', got [%s]", driver)
 	}
 	if err != nil {
 		return err
@@ -89,7 +100,7 @@ func gen(args []string) error {
 	return nil
 }
 
-func dlogGen(args []string) ([]byte, error) {
+func dlogGen(args []string, graphHiding bool) ([]byte, error) {
 	// Load Idemix Issuer Public Key
 	if len(idemixMSPDir) == 0 {
 		return nil, errors.New("identity mixer msp dir is required")
@@ -101,10 +112,26 @@ func dlogGen(args []string) ([]byte, error) {
 	}
 
 	// Setup
-	pp, err := crypto.Setup(base, exponent, ipkBytes)
+	var pp *crypto.PublicParams
+	switch rangeProof {
+	case "ps":
+		pp, err = crypto.Setup(base, exponent, ipkBytes)
+	case "bulletproof":
+		pp, err = crypto.SetupWithBulletproofs(rangeBitLength, ipkBytes)
+	default:
+		err = errors.Errorf("invalid range proof system, expected 'ps' or 'bulletproof', got [%s]", rangeProof)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed setting up public parameters")
 	}
+	if graphHiding {
+		if err := pp.GenerateSerialNumberParameters(bitLength); err != nil {
+			return nil, errors.Wrap(err, "failed setting up public parameters")
+		}
+	}
+	if openIssuance {
+		pp.TypeRegistry = pp.TypeRegistry.Register(&token2.TypeInfo{Type: token2.AnyType})
+	}
 	// Store Public Params
 	raw, err := pp.Serialize()
 	if err != nil {
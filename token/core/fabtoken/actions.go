@@ -8,6 +8,8 @@ package fabtoken
 import (
 	"encoding/json"
 
+	"github.com/pkg/errors"
+
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -42,6 +44,8 @@ func (t *TransferOutput) IsRedeem() bool {
 type IssueAction struct {
 	Issuer  view.Identity
 	Outputs []*TransferOutput
+	// NFTs describes the non-fungible tokens created by this action
+	NFTs []*token2.NFT `json:",omitempty"`
 }
 
 func (i *IssueAction) Serialize() ([]byte, error) {
@@ -84,6 +88,43 @@ func (i *IssueAction) GetIssuer() []byte {
 	return i.Issuer
 }
 
+func (i *IssueAction) GetNFTs() []*token2.NFT {
+	return i.NFTs
+}
+
+// GetTokenTypes returns the distinct types of the outputs of this action
+func (i *IssueAction) GetTokenTypes() []string {
+	var types []string
+	seen := map[string]bool{}
+	for _, output := range i.Outputs {
+		if output.Output == nil || seen[output.Output.Type] {
+			continue
+		}
+		seen[output.Output.Type] = true
+		types = append(types, output.Output.Type)
+	}
+	return types
+}
+
+// GetIssuedQuantities returns the sum of the quantities of the outputs of each type
+func (i *IssueAction) GetIssuedQuantities() (map[string]token2.Quantity, error) {
+	res := map[string]token2.Quantity{}
+	for _, output := range i.Outputs {
+		if output.Output == nil {
+			continue
+		}
+		q, err := token2.ToQuantity(output.Output.Quantity, token2.MaxPrecision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s]", output.Output.Quantity)
+		}
+		if sum, ok := res[output.Output.Type]; ok {
+			q = sum.Add(q)
+		}
+		res[output.Output.Type] = q
+	}
+	return res, nil
+}
+
 type TransferAction struct {
 	Sender  view.Identity
 	Inputs  []string
@@ -126,6 +167,22 @@ func (t *TransferAction) IsGraphHiding() bool {
 	return false
 }
 
+// GetNFTs returns the non-fungible tokens held by the outputs, whose types are in the clear
+func (t *TransferAction) GetNFTs() []*token2.NFT {
+	var nfts []*token2.NFT
+	for i, output := range t.Outputs {
+		if output.Output == nil || !token2.IsNFTType(output.Output.Type) {
+			continue
+		}
+		if nfts == nil {
+			nfts = make([]*token2.NFT, len(t.Outputs))
+		}
+		id, _ := token2.NFTIDFromType(output.Output.Type)
+		nfts[i] = &token2.NFT{ID: id}
+	}
+	return nfts
+}
+
 func (t *TransferAction) SerializeOutputAt(index int) ([]byte, error) {
 	return t.Outputs[index].Serialize()
 }
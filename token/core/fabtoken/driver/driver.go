@@ -29,7 +29,7 @@ func (d *Driver) PublicParametersFromBytes(params []byte) (driver.PublicParamete
 }
 
 func (d *Driver) NewTokenService(sp view2.ServiceProvider, publicParamsFetcher driver.PublicParamsFetcher, network string, channel driver.Channel, namespace string) (driver.TokenManagerService, error) {
-	qe := vault.NewVault(sp, channel, namespace).QueryEngine()
+	qe := vault.NewVault(sp, network, channel, namespace).QueryEngine()
 	nodeIdentity := view2.GetIdentityProvider(sp).DefaultIdentity()
 	return fabtoken.NewService(
 		sp,
@@ -5,7 +5,15 @@ SPDX-License-Identifier: Apache-2.0
 */
 package fabtoken
 
-import "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 type PublicParamsManager struct {
 	pp *PublicParams
@@ -34,8 +42,82 @@ func (v *PublicParamsManager) SetAuditor(auditor []byte) ([]byte, error) {
 	return raw, nil
 }
 
+// AddIssuer allows an identity to issue tokens of a given type.
+// The passed bytes are the json encoding of a token2.TypeIssuer.
 func (v *PublicParamsManager) AddIssuer(bytes []byte) ([]byte, error) {
-	panic("implement me")
+	issuer := &token2.TypeIssuer{}
+	if err := json.Unmarshal(bytes, issuer); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuer")
+	}
+	if len(issuer.Issuer) == 0 {
+		return nil, errors.New("invalid issuer: empty identity")
+	}
+	if _, err := (&fabric.MSPX509IdentityDeserializer{}).GetVerifier(issuer.Issuer); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve issuer's identity")
+	}
+	if err := (&token2.TypeInfo{Type: issuer.Type}).Validate(v.pp.Precision()); err != nil {
+		return nil, err
+	}
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	pp := &PublicParams{}
+	if err := pp.Deserialize(raw); err != nil {
+		return nil, err
+	}
+	pp.TypeRegistry = pp.TypeRegistry.AddIssuer(issuer.Type, issuer.Issuer)
+
+	raw, err = pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	v.pp = pp
+	return raw, nil
+}
+
+func (v *PublicParamsManager) RegisterTokenType(info *token2.TypeInfo) ([]byte, error) {
+	if err := info.Validate(v.pp.Precision()); err != nil {
+		return nil, err
+	}
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	pp := &PublicParams{}
+	if err := pp.Deserialize(raw); err != nil {
+		return nil, err
+	}
+	pp.TypeRegistry = pp.TypeRegistry.Register(info)
+
+	raw, err = pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	v.pp = pp
+	return raw, nil
+}
+
+func (v *PublicParamsManager) SetAuthority(authority []byte) ([]byte, error) {
+	if _, err := (&fabric.MSPX509IdentityDeserializer{}).GetVerifier(authority); err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve authority's identity")
+	}
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	pp := &PublicParams{}
+	if err := pp.Deserialize(raw); err != nil {
+		return nil, err
+	}
+	pp.AuthorityID = authority
+
+	raw, err = pp.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	v.pp = pp
+	return raw, nil
 }
 
 func (v *PublicParamsManager) SetCertifier(bytes []byte) ([]byte, error) {
@@ -7,6 +7,7 @@ package fabtoken
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -17,6 +18,8 @@ import (
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 )
@@ -33,9 +36,12 @@ type PublicParamsLoader interface {
 type QueryEngine interface {
 	IsMine(id *token2.Id) (bool, error)
 	ListUnspentTokens() (*token2.UnspentTokens, error)
+	ListUnspentTokensOf(wallet string, typ string) (*token2.UnspentTokens, error)
+	UnspentTokensIterator(filter *driver.TokenFilter, cursor string) (driver.UnspentTokensIterator, error)
 	ListAuditTokens(ids ...*token2.Id) ([]*token2.Token, error)
 	ListHistoryIssuedTokens() (*token2.IssuedTokens, error)
 	PublicParams() ([]byte, error)
+	GetNFT(id string) (*token2.NFT, error)
 }
 
 type service struct {
@@ -141,26 +147,44 @@ func (s *service) GetAuditInfo(id view.Identity) ([]byte, error) {
 }
 
 func (s *service) GetEnrollmentID(auditInfo []byte) (string, error) {
+	if si, ok := htlc.GetScriptInfo(auditInfo); ok {
+		if len(si.Recipient) != 0 {
+			return string(si.Recipient), nil
+		}
+		return string(si.Sender), nil
+	}
+	if mi, ok := multisig.GetAuditInfo(auditInfo); ok {
+		eIDs := make([]string, len(mi.Infos))
+		for i, info := range mi.Infos {
+			eIDs[i] = string(info)
+		}
+		return strings.Join(eIDs, ","), nil
+	}
 	return string(auditInfo), nil
 }
 
-func (s *service) Issue(issuerIdentity view.Identity, typ string, values []uint64, owners [][]byte) (driver.IssueAction, [][]byte, view.Identity, error) {
+func (s *service) Issue(issuerIdentity view.Identity, typ string, values []token2.Quantity, owners [][]byte) (driver.IssueAction, [][]byte, view.Identity, error) {
 	for _, owner := range owners {
 		if len(owner) == 0 {
 			return nil, nil, nil, errors.Errorf("all recipients should be defined")
 		}
 	}
 
+	precision := s.PublicParams().(*PublicParams).Precision()
 	var outs []*TransferOutput
 	var infos [][]byte
 	for i, v := range values {
+		q, err := token2.ToQuantity(v.Decimal(), precision)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "invalid quantity [%s]", v.Decimal())
+		}
 		outs = append(outs, &TransferOutput{
 			Output: &token2.Token{
 				Owner: &token2.Owner{
 					Raw: owners[i],
 				},
 				Type:     typ,
-				Quantity: token2.NewQuantityFromUInt64(v).Hex(),
+				Quantity: q.Hex(),
 			},
 		})
 
@@ -180,6 +204,19 @@ func (s *service) Issue(issuerIdentity view.Identity, typ string, values []uint6
 		nil
 }
 
+func (s *service) IssueNFT(issuerIdentity view.Identity, nft *token2.NFT, owner []byte) (driver.IssueAction, [][]byte, view.Identity, error) {
+	if err := nft.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	ia, infos, issuer, err := s.Issue(issuerIdentity, nft.Type(), []token2.Quantity{token2.NewQuantityFromUInt64(token2.NFTQuantity)}, [][]byte{owner})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	action := ia.(*IssueAction)
+	action.NFTs = []*token2.NFT{nft}
+	return action, infos, issuer, nil
+}
+
 func (s *service) VerifyIssue(tr driver.IssueAction, tokenInfos [][]byte) error {
 	// TODO:
 	return nil
@@ -245,9 +282,13 @@ func (s *service) Transfer(txID string, wallet driver.OwnerWallet, ids []*token2
 		signerIds = append(signerIds, ser)
 	}
 
+	precision := s.PublicParams().(*PublicParams).Precision()
 	var outs []*TransferOutput
 	var infos [][]byte
 	for _, output := range Outputs {
+		if _, err := token2.ToQuantity(output.Quantity, precision); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid quantity [%s]", output.Quantity)
+		}
 		outs = append(outs, &TransferOutput{
 			Output: output,
 		})
@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 const Coin = uint64(1000000000)
@@ -20,6 +21,14 @@ const PublicParameters = "fabtoken"
 type PublicParams struct {
 	MTV     uint64
 	Auditor []byte
+	// AuthorityID is the identity of the authority that can freeze and seize tokens, if any
+	AuthorityID []byte `json:"Authority,omitempty"`
+	// QuantityPrecision is the number of bits used to represent token quantities.
+	// When zero, token2.DefaultPrecision is used.
+	QuantityPrecision uint64 `json:",omitempty"`
+	// TypeRegistry lists the registered token types.
+	// When empty, any token type can be issued.
+	TypeRegistry token2.TypeRegistry `json:",omitempty"`
 }
 
 func NewPublicParamsFromBytes(raw []byte) (*PublicParams, error) {
@@ -42,6 +51,10 @@ func (pp *PublicParams) CertificationDriver() string {
 	return PublicParameters
 }
 
+func (pp *PublicParams) Authority() []byte {
+	return pp.AuthorityID
+}
+
 func (pp *PublicParams) GraphHiding() bool {
 	return false
 }
@@ -50,6 +63,17 @@ func (pp *PublicParams) MaxTokenValue() uint64 {
 	return pp.MTV
 }
 
+func (pp *PublicParams) Precision() uint64 {
+	if pp.QuantityPrecision == 0 {
+		return token2.DefaultPrecision
+	}
+	return pp.QuantityPrecision
+}
+
+func (pp *PublicParams) TokenTypes() token2.TypeRegistry {
+	return pp.TypeRegistry
+}
+
 func (pp *PublicParams) Bytes() ([]byte, error) {
 	return json.Marshal(pp)
 }
@@ -77,7 +101,16 @@ func (pp *PublicParams) Deserialize(raw []byte) error {
 }
 
 func Setup() (*PublicParams, error) {
+	return SetupWithPrecision(token2.DefaultPrecision)
+}
+
+// SetupWithPrecision returns public parameters whose token quantities are represented with the passed number of bits
+func SetupWithPrecision(precision uint64) (*PublicParams, error) {
+	if precision == 0 || precision > token2.MaxPrecision {
+		return nil, errors.Errorf("invalid precision [%d], it must be in (0,%d]", precision, token2.MaxPrecision)
+	}
 	return &PublicParams{
-		MTV: MaxMoney,
+		MTV:               MaxMoney,
+		QuantityPrecision: precision,
 	}, nil
 }
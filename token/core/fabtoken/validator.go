@@ -7,6 +7,7 @@ package fabtoken
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -14,16 +15,20 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type Validator struct {
-	pp *PublicParams
+	pp               *PublicParams
+	issuingValidator translator.IssuingValidator
 }
 
 func NewValidator(pp *PublicParams) *Validator {
-	return &Validator{pp: pp}
+	return &Validator{pp: pp, issuingValidator: translator.NewIssuingValidator(pp.TypeRegistry)}
 }
 
 func (v *Validator) VerifyTokenRequest(ledger driver.Ledger, signatureProvider driver.SignatureProvider, binding string, tr *driver.TokenRequest) ([]interface{}, error) {
@@ -34,7 +39,7 @@ func (v *Validator) VerifyTokenRequest(ledger driver.Ledger, signatureProvider d
 	if err != nil {
 		return nil, err
 	}
-	err = v.verifyIssues(ia, signatureProvider)
+	err = v.verifyIssues(ledger, ia, signatureProvider)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to verify issuers' signatures [%s]", binding)
 	}
@@ -66,7 +71,7 @@ func UnmarshalIssueTransferActions(tr *driver.TokenRequest, binding string) ([]*
 	return ia, ta, nil
 }
 
-func (v *Validator) VerifyTokenRequestFromRaw(getState driver.GetStateFnc, binding string, raw []byte) ([]interface{}, error) {
+func (v *Validator) VerifyTokenRequestFromRaw(ledger driver.Ledger, binding string, raw []byte) ([]interface{}, error) {
 	if len(raw) == 0 {
 		return nil, errors.New("empty token request")
 	}
@@ -97,7 +102,7 @@ func (v *Validator) VerifyTokenRequestFromRaw(getState driver.GetStateFnc, bindi
 	}
 
 	backend := &backend{
-		getState:   getState,
+		ledger:     ledger,
 		message:    signed,
 		signatures: signatures,
 	}
@@ -141,9 +146,11 @@ func (v *Validator) verifyAuditorSignature(signatureProvider driver.SignaturePro
 	return nil
 }
 
-func (v *Validator) verifyIssues(issues []*IssueAction, signatureProvider driver.SignatureProvider) error {
+func (v *Validator) verifyIssues(ledger driver.Ledger, issues []*IssueAction, signatureProvider driver.SignatureProvider) error {
+	// the supply of each type, the quantities issued by previous transactions and by the request so far
+	supply := &supply{ledger: ledger, quantities: map[string]token2.Quantity{}}
 	for _, issue := range issues {
-		if err := v.verifyIssue(issue); err != nil {
+		if err := v.verifyIssue(issue, supply); err != nil {
 			return errors.Wrapf(err, "failed to verify issue action")
 		}
 
@@ -160,7 +167,15 @@ func (v *Validator) verifyIssues(issues []*IssueAction, signatureProvider driver
 }
 
 func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []*TransferAction, signatureProvider driver.SignatureProvider) error {
-	identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+	// scripts are checked against the time of the transaction
+	now, err := driver.GetTxTimestamp(ledger)
+	if err != nil {
+		return errors.Wrap(err, "failed getting the timestamp of the transaction")
+	}
+	identityDeserializer := htlc.NewDeserializer(
+		multisig.NewDeserializer(&fabric.MSPX509IdentityDeserializer{}),
+		func() time.Time { return now },
+	)
 	logger.Debugf("check sender start...")
 	defer logger.Debugf("check sender finished.")
 	for i, t := range transferActions {
@@ -184,6 +199,9 @@ func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []*Tra
 			if err != nil {
 				return errors.Wrapf(err, "failed to deserialize input to spend [%s]", in)
 			}
+			if err := translator.CheckNotFrozen(ledger.GetState, in, tok.Owner.Raw); err != nil {
+				return errors.Wrapf(err, "input to spend [%s] cannot be spent", in)
+			}
 			logger.Debugf("check sender [%d][%s]", i, view.Identity(tok.Owner.Raw).UniqueID())
 
 			verifier, err := identityDeserializer.GetVerifier(tok.Owner.Raw)
@@ -195,23 +213,173 @@ func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []*Tra
 				return errors.Wrapf(err, "failed signature verification [%d][%s][%s]", i, in, view.Identity(tok.Owner.Raw).UniqueID())
 			}
 		}
-		if err := v.verifyTransfer(inputTokens, t); err != nil {
+		if err := v.verifyTransfer(inputTokens, t, now); err != nil {
 			return errors.Wrapf(err, "failed to verify transfer action")
 		}
 	}
 	return nil
 }
 
-func (v *Validator) verifyIssue(issue driver.IssueAction) error {
+// verifyIssue checks that the quantities of the outputs fit the precision declared by the public parameters,
+// that the outputs respect the token type registry, and that the outputs of non-fungible token type match one-to-one the non-fungible tokens declared by the action
+func (v *Validator) verifyIssue(issue driver.IssueAction, supply *supply) error {
+	action := issue.(*IssueAction)
+
+	nfts := map[string]*token2.NFT{}
+	for _, nft := range action.NFTs {
+		if err := nft.Validate(); err != nil {
+			return err
+		}
+		if _, ok := nfts[nft.Type()]; ok {
+			return errors.Errorf("nft [%s] is issued more than once", nft.ID)
+		}
+		nfts[nft.Type()] = nft
+	}
+	for i, output := range action.Outputs {
+		if output.Output == nil {
+			return errors.Errorf("invalid output [%d]: missing token", i)
+		}
+		if err := v.checkQuantity(output.Output); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		if err := v.checkRegistry(action.Issuer, output.Output, supply); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		if !token2.IsNFTType(output.Output.Type) {
+			continue
+		}
+		if _, ok := nfts[output.Output.Type]; !ok {
+			return errors.Errorf("invalid output [%d]: type [%s] is reserved to non-fungible tokens", i, output.Output.Type)
+		}
+		if err := v.checkNFTQuantity(output.Output); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		delete(nfts, output.Output.Type)
+	}
+	for _, nft := range nfts {
+		return errors.Errorf("nft [%s] has no matching output", nft.ID)
+	}
 	return nil
 }
 
-func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferAction) error {
+// verifyTransfer checks that the quantities of the outputs fit the precision declared by the public parameters,
+// that each spent non-fungible token is moved whole to exactly one output,
+// that no output has a non-fungible token type that does not appear among the inputs,
+// and that the scripts owning the outputs have not expired at the passed time of the transaction
+func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferAction, now time.Time) error {
+	action := tr.(*TransferAction)
+
+	nfts := map[string]bool{}
+	for i, raw := range inputTokens {
+		tok := &token2.Token{}
+		if err := json.Unmarshal(raw, tok); err != nil {
+			return errors.Wrapf(err, "failed to deserialize input [%d]", i)
+		}
+		if token2.IsNFTType(tok.Type) {
+			nfts[tok.Type] = false
+		}
+	}
+	for i, output := range action.Outputs {
+		if output.Output == nil {
+			return errors.Errorf("invalid output [%d]: missing token", i)
+		}
+		if err := v.checkQuantity(output.Output); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		if output.Output.Owner != nil {
+			if err := htlc.ValidateOwner(output.Output.Owner.Raw, now); err != nil {
+				return errors.Wrapf(err, "invalid output [%d]", i)
+			}
+			if err := multisig.ValidateOwner(output.Output.Owner.Raw); err != nil {
+				return errors.Wrapf(err, "invalid output [%d]", i)
+			}
+		}
+		if !token2.IsNFTType(output.Output.Type) {
+			continue
+		}
+		moved, ok := nfts[output.Output.Type]
+		if !ok {
+			return errors.Errorf("invalid output [%d]: nft type [%s] is not spent by this action", i, output.Output.Type)
+		}
+		if moved {
+			return errors.Errorf("invalid output [%d]: nft type [%s] cannot be split", i, output.Output.Type)
+		}
+		if err := v.checkNFTQuantity(output.Output); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		nfts[output.Output.Type] = true
+	}
+	for typ, moved := range nfts {
+		if !moved {
+			return errors.Errorf("nft type [%s] is spent but not transferred", typ)
+		}
+	}
+	return nil
+}
+
+// checkRegistry returns an error if the passed issuer cannot issue the type of the passed token,
+// or if the quantity issued so far, tracked in supply, exceeds the max supply of the type
+func (v *Validator) checkRegistry(issuer []byte, tok *token2.Token, supply *supply) error {
+	if err := v.issuingValidator.Validate(issuer, tok.Type); err != nil {
+		return err
+	}
+	info := v.pp.TypeRegistry.Lookup(tok.Type)
+	if info == nil || len(info.MaxSupply) == 0 {
+		return nil
+	}
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	total, err := supply.add(tok.Type, q)
+	if err != nil {
+		return err
+	}
+	return info.CheckSupply(total)
+}
+
+// supply tracks the total quantity issued of each token type, starting from the supply recorded on the ledger
+type supply struct {
+	ledger     driver.Ledger
+	quantities map[string]token2.Quantity
+}
+
+// add adds the passed quantity to the supply of the passed type and returns the new supply
+func (s *supply) add(tokenType string, q token2.Quantity) (token2.Quantity, error) {
+	total, ok := s.quantities[tokenType]
+	if !ok {
+		var err error
+		total, err = translator.GetSupply(s.ledger.GetState, tokenType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	total = total.Add(q)
+	s.quantities[tokenType] = total
+	return total, nil
+}
+
+// checkQuantity returns an error if the quantity of the passed token does not fit the precision declared by the public parameters
+func (v *Validator) checkQuantity(tok *token2.Token) error {
+	if _, err := token2.ToQuantity(tok.Quantity, v.pp.Precision()); err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	return nil
+}
+
+func (v *Validator) checkNFTQuantity(tok *token2.Token) error {
+	q, err := token2.ToQuantity(tok.Quantity, v.pp.Precision())
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	if q.Cmp(token2.NewQuantityFromUInt64(token2.NFTQuantity)) != 0 {
+		return errors.Errorf("nft [%s] must have quantity [%d], got [%s]", tok.Type, token2.NFTQuantity, q.Decimal())
+	}
 	return nil
 }
 
 type backend struct {
-	getState   driver.GetStateFnc
+	ledger     driver.Ledger
 	message    []byte
 	index      int
 	signatures [][]byte
@@ -228,5 +396,9 @@ func (b *backend) HasBeenSignedBy(id view.Identity, verifier driver.Verifier) er
 }
 
 func (b *backend) GetState(key string) ([]byte, error) {
-	return b.getState(key)
+	return b.ledger.GetState(key)
+}
+
+func (b *backend) TxTimestamp() (time.Time, error) {
+	return driver.GetTxTimestamp(b.ledger)
 }
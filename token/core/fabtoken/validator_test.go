@@ -0,0 +1,255 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabtoken_test
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/fabtoken"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// ledger is a world state that knows the timestamp of the transaction being validated
+type ledger struct {
+	state     map[string][]byte
+	timestamp time.Time
+}
+
+func (l *ledger) GetState(key string) ([]byte, error) {
+	return l.state[key], nil
+}
+
+func (l *ledger) TxTimestamp() (time.Time, error) {
+	return l.timestamp, nil
+}
+
+// stateOnly is a world state that does not know the timestamp of the transaction being validated
+type stateOnly struct {
+	state map[string][]byte
+}
+
+func (l *stateOnly) GetState(key string) ([]byte, error) {
+	return l.state[key], nil
+}
+
+var _ = Describe("Validator with hash time-locked scripts", func() {
+	var (
+		engine          *fabtoken.Validator
+		recipient       view.Identity
+		recipientSigner driver.Signer
+		sender          view.Identity
+		senderSigner    driver.Signer
+		script          *htlc.Script
+		owner           view.Identity
+		preimage        []byte
+		inKey           string
+		l               *ledger
+	)
+
+	BeforeEach(func() {
+		pp, err := fabtoken.Setup()
+		Expect(err).NotTo(HaveOccurred())
+		engine = fabtoken.NewValidator(pp)
+
+		sender, senderSigner, _, err = fabric.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+		recipient, recipientSigner, _, err = fabric.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+		preimage = []byte("a secret preimage")
+		hash := sha256.Sum256(preimage)
+		script = &htlc.Script{
+			Sender:    sender,
+			Recipient: recipient,
+			Deadline:  time.Now().Add(time.Hour),
+			HashInfo:  htlc.HashInfo{Hash: hash[:], HashFunc: crypto.SHA256},
+		}
+		owner, err = script.Identity()
+		Expect(err).NotTo(HaveOccurred())
+
+		inKey, err = keys.CreateTokenKey("tx0", 0)
+		Expect(err).NotTo(HaveOccurred())
+		in, err := json.Marshal(&token2.Token{Owner: &token2.Owner{Raw: owner}, Type: "ABC", Quantity: "0x0a"})
+		Expect(err).NotTo(HaveOccurred())
+		l = &ledger{state: map[string][]byte{inKey: in}, timestamp: time.Now()}
+	})
+
+	// spend returns a request that moves the locked token to the passed owner, signed by the passed signer
+	spend := func(newOwner view.Identity, signer *htlc.Signer) []byte {
+		action := &fabtoken.TransferAction{
+			Sender:  owner,
+			Inputs:  []string{inKey},
+			Outputs: []*fabtoken.TransferOutput{{Output: &token2.Token{Owner: &token2.Owner{Raw: newOwner}, Type: "ABC", Quantity: "0x0a"}}},
+		}
+		raw, err := action.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+		tr := &driver.TokenRequest{Transfers: [][]byte{raw}}
+		message, err := json.Marshal(tr)
+		Expect(err).NotTo(HaveOccurred())
+		sigma, err := signer.Sign(append(message, []byte("1")...))
+		Expect(err).NotTo(HaveOccurred())
+		tr.Signatures = [][]byte{sigma}
+		raw, err = json.Marshal(tr)
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+	claimer := func(now time.Time) *htlc.Signer {
+		signer := htlc.NewSigner(script)
+		signer.Now = func() time.Time { return now }
+		Expect(signer.SetRecipient(recipientSigner, preimage)).To(Succeed())
+		return signer
+	}
+	reclaimer := func(now time.Time) *htlc.Signer {
+		signer := htlc.NewSigner(script)
+		signer.Now = func() time.Time { return now }
+		signer.SetSender(senderSigner)
+		return signer
+	}
+
+	It("accepts a claim before the deadline of the transaction", func() {
+		actions, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(recipient, claimer(time.Now())))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(1))
+	})
+
+	It("rejects a claim once the transaction is past the deadline", func() {
+		l.timestamp = script.Deadline
+		_, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(recipient, claimer(time.Now())))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cannot claim: the deadline has expired"))
+	})
+
+	It("accepts a reclaim once the transaction is past the deadline", func() {
+		l.timestamp = script.Deadline.Add(time.Second)
+		actions, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(sender, reclaimer(script.Deadline)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(1))
+	})
+
+	It("rejects a reclaim before the deadline of the transaction", func() {
+		_, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(sender, reclaimer(script.Deadline)))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cannot reclaim: the deadline has not expired yet"))
+	})
+
+	It("rejects the spending of a script when the time of the transaction is unknown", func() {
+		_, err := engine.VerifyTokenRequestFromRaw(&stateOnly{state: l.state}, "1", spend(recipient, claimer(time.Now())))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("the time of the transaction is unknown"))
+	})
+
+	It("rejects an output owned by a script that has expired at the time of the transaction", func() {
+		expired := *script
+		expired.Deadline = l.timestamp.Add(time.Minute)
+		newOwner, err := expired.Identity()
+		Expect(err).NotTo(HaveOccurred())
+		raw := spend(newOwner, claimer(time.Now()))
+
+		_, err = engine.VerifyTokenRequestFromRaw(l, "1", raw)
+		Expect(err).NotTo(HaveOccurred())
+
+		l.timestamp = l.timestamp.Add(2 * time.Minute)
+		_, err = engine.VerifyTokenRequestFromRaw(l, "1", raw)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("the deadline has already expired"))
+	})
+})
+
+var _ = Describe("Validator with multisig owners", func() {
+	var (
+		engine  *fabtoken.Validator
+		ids     []view.Identity
+		signers []driver.Signer
+		owner   view.Identity
+		inKey   string
+		l       *ledger
+	)
+
+	BeforeEach(func() {
+		pp, err := fabtoken.Setup()
+		Expect(err).NotTo(HaveOccurred())
+		engine = fabtoken.NewValidator(pp)
+
+		ids, signers = nil, nil
+		for i := 0; i < 3; i++ {
+			id, signer, _, err := fabric.NewSigner()
+			Expect(err).NotTo(HaveOccurred())
+			ids = append(ids, id)
+			signers = append(signers, signer)
+		}
+		owner, err = (&multisig.MultiIdentity{Identities: ids, Threshold: 2}).Identity()
+		Expect(err).NotTo(HaveOccurred())
+
+		inKey, err = keys.CreateTokenKey("tx0", 0)
+		Expect(err).NotTo(HaveOccurred())
+		in, err := json.Marshal(&token2.Token{Owner: &token2.Owner{Raw: owner}, Type: "ABC", Quantity: "0x0a"})
+		Expect(err).NotTo(HaveOccurred())
+		l = &ledger{state: map[string][]byte{inKey: in}, timestamp: time.Now()}
+	})
+
+	// spend returns a request that moves the multisig token to the passed owner,
+	// signed by the co-owners at the passed indexes
+	spend := func(newOwner view.Identity, indexes ...int) []byte {
+		action := &fabtoken.TransferAction{
+			Sender:  owner,
+			Inputs:  []string{inKey},
+			Outputs: []*fabtoken.TransferOutput{{Output: &token2.Token{Owner: &token2.Owner{Raw: newOwner}, Type: "ABC", Quantity: "0x0a"}}},
+		}
+		raw, err := action.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+		tr := &driver.TokenRequest{Transfers: [][]byte{raw}}
+		message, err := json.Marshal(tr)
+		Expect(err).NotTo(HaveOccurred())
+		sig := &multisig.Signature{Signatures: make([][]byte, len(ids))}
+		for _, i := range indexes {
+			sig.Signatures[i], err = signers[i].Sign(append(message, []byte("1")...))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		sigma, err := json.Marshal(sig)
+		Expect(err).NotTo(HaveOccurred())
+		tr.Signatures = [][]byte{sigma}
+		raw, err = json.Marshal(tr)
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+
+	It("accepts a transfer signed by the threshold of co-owners", func() {
+		actions, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(ids[0], 0, 2))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(1))
+	})
+
+	It("rejects a transfer signed by less than the threshold of co-owners", func() {
+		_, err := engine.VerifyTokenRequestFromRaw(l, "1", spend(ids[0], 1))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not enough signatures: expected [2], got [1]"))
+	})
+
+	It("rejects a transfer bound to another transaction", func() {
+		_, err := engine.VerifyTokenRequestFromRaw(l, "2", spend(ids[0], 0, 1))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid signature of identity at index [0]"))
+	})
+
+	It("rejects an output owned by a multisig identity that is not well-formed", func() {
+		invalid, err := (&multisig.MultiIdentity{Identities: ids, Threshold: 4}).Identity()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = engine.VerifyTokenRequestFromRaw(l, "1", spend(invalid, 0, 1))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid threshold [4] for [3] identities"))
+	})
+})
@@ -190,29 +190,63 @@ func (w *ownerWallet) GetSigner(identity view.Identity) (api2.Signer, error) {
 
 func (w *ownerWallet) ListTokens(opts *api2.ListTokensOptions) (*token2.UnspentTokens, error) {
 	logger.Debugf("wallet: list tokens, type [%s]", opts.TokenType)
-	source, err := w.tokenService.ListTokens()
+	unspentTokens, err := w.tokenService.qe.ListUnspentTokensOf(w.ID(), opts.TokenType)
 	if err != nil {
 		return nil, errors.Wrap(err, "token selection failed")
 	}
+	logger.Debugf("wallet: list tokens done, found [%d] unspent tokens", len(unspentTokens.Tokens))
 
-	unspentTokens := &token2.UnspentTokens{}
-	for _, t := range source.Tokens {
-		if len(opts.TokenType) != 0 && t.Type != opts.TokenType {
-			logger.Debugf("wallet: discarding token of type [%s]!=[%s]", t.Type, opts.TokenType)
+	return unspentTokens, nil
+}
+
+// UnspentTokensIterator returns an iterator over the unspent tokens of this wallet that match the passed filter
+func (w *ownerWallet) UnspentTokensIterator(filter *api2.TokenFilter, cursor string) (api2.UnspentTokensIterator, error) {
+	f := &api2.TokenFilter{}
+	if filter != nil {
+		*f = *filter
+	}
+	f.Wallet = w.ID()
+	it, err := w.tokenService.qe.UnspentTokensIterator(f, cursor)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed iterating over unspent tokens")
+	}
+	return it, nil
+}
+
+func (w *ownerWallet) ListNFTs() ([]*token2.NFT, error) {
+	logger.Debugf("wallet: list nfts")
+	unspentTokens, err := w.ListTokens(&api2.ListTokensOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	one := token2.NewQuantityFromUInt64(token2.NFTQuantity)
+	var nfts []*token2.NFT
+	for _, t := range unspentTokens.Tokens {
+		if !token2.IsNFTType(t.Type) {
 			continue
 		}
-
-		if !w.Contains(t.Owner.Raw) {
-			logger.Debugf("wallet: discarding token, owner does not belong to this wallet")
+		q, err := token2.ToQuantity(t.Quantity, token2.MaxPrecision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s] for token [%s]", t.Quantity, t.Id)
+		}
+		if q.Cmp(one) != 0 {
+			logger.Debugf("wallet: discarding token of type [%s], quantity [%s] is not one", t.Type, t.Quantity)
 			continue
 		}
-
-		logger.Debugf("wallet: adding token of type [%s], quantity [%s]", t.Type, t.Quantity)
-		unspentTokens.Tokens = append(unspentTokens.Tokens, t)
+		id, err := token2.NFTIDFromType(t.Type)
+		if err != nil {
+			return nil, err
+		}
+		nft, err := w.tokenService.qe.GetNFT(id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting nft [%s]", id)
+		}
+		nfts = append(nfts, nft)
 	}
-	logger.Debugf("wallet: list tokens done, found [%d] unspent tokens", len(unspentTokens.Tokens))
+	logger.Debugf("wallet: list nfts done, found [%d] nfts", len(nfts))
 
-	return unspentTokens, nil
+	return nfts, nil
 }
 
 type issuerWallet struct {
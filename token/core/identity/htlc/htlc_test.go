@@ -0,0 +1,222 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc_test
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+var _ = Describe("HTLC", func() {
+	var (
+		sender          view.Identity
+		senderSigner    driver.Signer
+		recipient       view.Identity
+		recipientSigner driver.Signer
+		preimage        []byte
+		script          *htlc.Script
+		owner           view.Identity
+		deserializer    *htlc.Deserializer
+		message         []byte
+	)
+	BeforeEach(func() {
+		var err error
+		sender, senderSigner, _, err = fabric.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+		recipient, recipientSigner, _, err = fabric.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+
+		preimage = []byte("a secret preimage")
+		hash := sha256.Sum256(preimage)
+		script = &htlc.Script{
+			Sender:    sender,
+			Recipient: recipient,
+			Deadline:  time.Now().Add(time.Hour),
+			HashInfo:  htlc.HashInfo{Hash: hash[:], HashFunc: crypto.SHA256},
+		}
+		owner, err = script.Identity()
+		Expect(err).NotTo(HaveOccurred())
+		deserializer = htlc.NewDeserializer(&fabric.MSPX509IdentityDeserializer{}, time.Now)
+		message = []byte("a token request")
+	})
+
+	Describe("Script", func() {
+		It("is encoded in the owner identity", func() {
+			Expect(htlc.IsScript(owner)).To(BeTrue())
+			Expect(htlc.IsScript(sender)).To(BeFalse())
+			decoded, err := htlc.ScriptFromIdentity(owner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded.Sender).To(Equal(script.Sender))
+			Expect(decoded.Recipient).To(Equal(script.Recipient))
+			Expect(decoded.HashInfo).To(Equal(script.HashInfo))
+			Expect(decoded.Deadline.Equal(script.Deadline)).To(BeTrue())
+		})
+		When("the script is not well-formed", func() {
+			It("fails", func() {
+				script.HashInfo.Hash = script.HashInfo.Hash[:10]
+				owner, err := script.Identity()
+				Expect(err).NotTo(HaveOccurred())
+				err = htlc.ValidateOwner(owner, time.Now())
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("hash length [10] does not match the hash function"))
+			})
+		})
+		When("the deadline has expired", func() {
+			It("cannot own new tokens", func() {
+				Expect(htlc.ValidateOwner(owner, time.Now())).To(Succeed())
+				err := htlc.ValidateOwner(owner, time.Now().Add(2*time.Hour))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the deadline has already expired"))
+			})
+		})
+		When("the time of the transaction is unknown", func() {
+			It("cannot own new tokens", func() {
+				err := htlc.ValidateOwner(owner, time.Time{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the time of the transaction is unknown"))
+			})
+		})
+	})
+
+	Describe("Claim", func() {
+		var (
+			signer   *htlc.Signer
+			verifier *htlc.Verifier
+		)
+		BeforeEach(func() {
+			signer = htlc.NewSigner(script)
+			signer.Now = time.Now
+			Expect(signer.SetRecipient(recipientSigner, preimage)).To(Succeed())
+			v, err := deserializer.GetVerifier(owner)
+			Expect(err).NotTo(HaveOccurred())
+			verifier = v.(*htlc.Verifier)
+		})
+		It("succeeds before the deadline", func() {
+			sigma, err := signer.Sign(message)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier.Verify(message, sigma)).To(Succeed())
+		})
+		When("the time of the transaction is unknown", func() {
+			It("fails", func() {
+				sigma, err := signer.Sign(message)
+				Expect(err).NotTo(HaveOccurred())
+				verifier.Now = func() time.Time { return time.Time{} }
+				err = verifier.Verify(message, sigma)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the time of the transaction is unknown"))
+
+				signer.Now = nil
+				_, err = signer.Sign(message)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the time of the transaction is unknown"))
+			})
+		})
+		When("the deadline has expired", func() {
+			It("fails", func() {
+				sigma, err := signer.Sign(message)
+				Expect(err).NotTo(HaveOccurred())
+				verifier.Now = func() time.Time { return script.Deadline }
+				err = verifier.Verify(message, sigma)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot claim: the deadline has expired"))
+			})
+		})
+		When("the preimage is wrong", func() {
+			It("fails", func() {
+				Expect(signer.SetRecipient(recipientSigner, []byte("wrong"))).NotTo(Succeed())
+				sigma, err := recipientSigner.Sign(append(message, []byte("wrong")...))
+				Expect(err).NotTo(HaveOccurred())
+				raw, err := json.Marshal(&htlc.Signature{Preimage: []byte("wrong"), Signature: sigma})
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("preimage does not match the hash lock"))
+			})
+		})
+		When("the sender signs with the preimage", func() {
+			It("fails", func() {
+				sigma, err := senderSigner.Sign(append(message, preimage...))
+				Expect(err).NotTo(HaveOccurred())
+				raw, err := json.Marshal(&htlc.Signature{Preimage: preimage, Signature: sigma})
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid signature of the recipient"))
+			})
+		})
+	})
+
+	Describe("Reclaim", func() {
+		var (
+			signer   *htlc.Signer
+			verifier *htlc.Verifier
+		)
+		BeforeEach(func() {
+			signer = htlc.NewSigner(script)
+			signer.Now = time.Now
+			signer.SetSender(senderSigner)
+			v, err := deserializer.GetVerifier(owner)
+			Expect(err).NotTo(HaveOccurred())
+			verifier = v.(*htlc.Verifier)
+		})
+		It("succeeds after the deadline", func() {
+			signer.Now = func() time.Time { return script.Deadline }
+			verifier.Now = func() time.Time { return script.Deadline.Add(time.Second) }
+			sigma, err := signer.Sign(message)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier.Verify(message, sigma)).To(Succeed())
+		})
+		When("the deadline has not expired", func() {
+			It("fails", func() {
+				_, err := signer.Sign(message)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("neither a claim nor a reclaim is possible"))
+
+				sigma, err := senderSigner.Sign(message)
+				Expect(err).NotTo(HaveOccurred())
+				raw, err := json.Marshal(&htlc.Signature{Signature: sigma})
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot reclaim: the deadline has not expired yet"))
+			})
+		})
+		When("the recipient signs", func() {
+			It("fails", func() {
+				sigma, err := recipientSigner.Sign(message)
+				Expect(err).NotTo(HaveOccurred())
+				raw, err := json.Marshal(&htlc.Signature{Signature: sigma})
+				Expect(err).NotTo(HaveOccurred())
+				verifier.Now = func() time.Time { return script.Deadline }
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid signature of the sender"))
+			})
+		})
+	})
+
+	Describe("Audit info", func() {
+		It("is recognized", func() {
+			raw, err := (&htlc.ScriptInfo{Recipient: []byte("alice")}).Bytes()
+			Expect(err).NotTo(HaveOccurred())
+			info, ok := htlc.GetScriptInfo(raw)
+			Expect(ok).To(BeTrue())
+			Expect(info.Recipient).To(Equal([]byte("alice")))
+			_, ok = htlc.GetScriptInfo([]byte("alice"))
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
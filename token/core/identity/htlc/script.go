@@ -0,0 +1,179 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+)
+
+// ScriptType is the type of the owners that carry a hash time-locked script
+const ScriptType = "htlc"
+
+// HashInfo describes the hash lock of a script
+type HashInfo struct {
+	// Hash is the image of the preimage that unlocks the script
+	Hash []byte
+	// HashFunc is the hash function used to compute Hash
+	HashFunc crypto.Hash
+}
+
+// Image returns the image of the passed preimage under the hash function of the lock
+func (i *HashInfo) Image(preimage []byte) ([]byte, error) {
+	if !i.HashFunc.Available() {
+		return nil, errors.Errorf("hash function [%d] not available", i.HashFunc)
+	}
+	h := i.HashFunc.New()
+	if _, err := h.Write(preimage); err != nil {
+		return nil, errors.Wrap(err, "failed hashing preimage")
+	}
+	return h.Sum(nil), nil
+}
+
+// Match returns an error if the passed preimage does not unlock the hash lock
+func (i *HashInfo) Match(preimage []byte) error {
+	image, err := i.Image(preimage)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(image, i.Hash) {
+		return errors.New("preimage does not match the hash lock")
+	}
+	return nil
+}
+
+// Script locks a token until either the recipient reveals the preimage of the hash,
+// or the deadline expires and the sender takes the token back
+type Script struct {
+	Sender    view.Identity
+	Recipient view.Identity
+	Deadline  time.Time
+	HashInfo  HashInfo
+}
+
+// Validate returns an error if the script is not well-formed
+func (s *Script) Validate() error {
+	if len(s.Sender) == 0 {
+		return errors.New("sender not set")
+	}
+	if len(s.Recipient) == 0 {
+		return errors.New("recipient not set")
+	}
+	if s.Deadline.IsZero() {
+		return errors.New("deadline not set")
+	}
+	if len(s.HashInfo.Hash) == 0 {
+		return errors.New("hash not set")
+	}
+	if !s.HashInfo.HashFunc.Available() {
+		return errors.Errorf("hash function [%d] not available", s.HashInfo.HashFunc)
+	}
+	if len(s.HashInfo.Hash) != s.HashInfo.HashFunc.Size() {
+		return errors.Errorf("hash length [%d] does not match the hash function", len(s.HashInfo.Hash))
+	}
+	return nil
+}
+
+// Expired returns true if the deadline of the script is not after the passed time
+func (s *Script) Expired(now time.Time) bool {
+	return !now.Before(s.Deadline)
+}
+
+// typedIdentity is the encoding of an owner that carries a script
+type typedIdentity struct {
+	Type     string          `json:"type"`
+	Identity json.RawMessage `json:"identity"`
+}
+
+// Identity returns the owner identity that encodes the script
+func (s *Script) Identity() (view.Identity, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling script")
+	}
+	return json.Marshal(&typedIdentity{Type: ScriptType, Identity: raw})
+}
+
+// IsScript returns true if the passed owner identity carries a script.
+// Plain owners, X509 or Idemix, are not json encoded.
+func IsScript(id view.Identity) bool {
+	ti := &typedIdentity{}
+	if err := json.Unmarshal(id, ti); err != nil {
+		return false
+	}
+	return ti.Type == ScriptType
+}
+
+// ScriptFromIdentity returns the script encoded in the passed owner identity
+func ScriptFromIdentity(id view.Identity) (*Script, error) {
+	ti := &typedIdentity{}
+	if err := json.Unmarshal(id, ti); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling owner")
+	}
+	if ti.Type != ScriptType {
+		return nil, errors.Errorf("owner of type [%s] is not a script", ti.Type)
+	}
+	script := &Script{}
+	if err := json.Unmarshal(ti.Identity, script); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling script")
+	}
+	return script, nil
+}
+
+// ValidateOwner returns an error if the passed owner of a new token carries a script that is not
+// well-formed or that has already expired at the passed time, the time of the transaction.
+// Scripts are rejected if the passed time is zero. Owners that are not scripts are accepted.
+func ValidateOwner(id view.Identity, now time.Time) error {
+	if !IsScript(id) {
+		return nil
+	}
+	if now.IsZero() {
+		return errors.New("invalid script: the time of the transaction is unknown")
+	}
+	script, err := ScriptFromIdentity(id)
+	if err != nil {
+		return err
+	}
+	if err := script.Validate(); err != nil {
+		return errors.Wrap(err, "invalid script")
+	}
+	if script.Expired(now) {
+		return errors.New("invalid script: the deadline has already expired")
+	}
+	return nil
+}
+
+// ScriptInfo is the audit information of a script owner.
+// It carries the audit information of the sender and the recipient.
+// When spending, it is enough that the audit information of the spender is present.
+type ScriptInfo struct {
+	Sender    []byte `json:"htlc_sender,omitempty"`
+	Recipient []byte `json:"htlc_recipient,omitempty"`
+}
+
+// Bytes returns the json encoding of the audit information
+func (si *ScriptInfo) Bytes() ([]byte, error) {
+	return json.Marshal(si)
+}
+
+// GetScriptInfo returns the script audit information in the passed audit information, if any
+func GetScriptInfo(raw []byte) (*ScriptInfo, bool) {
+	si := &ScriptInfo{}
+	if err := json.Unmarshal(raw, si); err != nil {
+		return nil, false
+	}
+	if len(si.Sender) == 0 && len(si.Recipient) == 0 {
+		return nil, false
+	}
+	return si, true
+}
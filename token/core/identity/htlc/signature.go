@@ -0,0 +1,189 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// Signature is the witness that spends a token owned by a script.
+// A claim carries the preimage and the signature of the recipient.
+// A reclaim carries the signature of the sender only.
+type Signature struct {
+	Preimage  []byte `json:",omitempty"`
+	Signature []byte
+}
+
+// VerifierDeserializer returns the verifier of the parties of a script
+type VerifierDeserializer interface {
+	GetVerifier(id view.Identity) (driver.Verifier, error)
+}
+
+// Verifier checks the signatures that spend a token owned by a script
+type Verifier struct {
+	Script    *Script
+	Sender    driver.Verifier
+	Recipient driver.Verifier
+	// Now returns the time of the spending transaction. The validators return the timestamp of the transaction.
+	// When nil, or when it returns the zero time, no signature is valid.
+	Now func() time.Time
+}
+
+// Verify returns an error if the passed signature neither claims nor reclaims the token.
+// A claim is valid before the deadline, a reclaim after it.
+func (v *Verifier) Verify(message, sigma []byte) error {
+	sig := &Signature{}
+	if err := json.Unmarshal(sigma, sig); err != nil {
+		return errors.Wrap(err, "failed unmarshalling script signature")
+	}
+	now, err := txTime(v.Now)
+	if err != nil {
+		return errors.Wrap(err, "cannot verify script signature")
+	}
+	if len(sig.Preimage) != 0 {
+		// claim
+		if v.Script.Expired(now) {
+			return errors.New("cannot claim: the deadline has expired")
+		}
+		if err := v.Script.HashInfo.Match(sig.Preimage); err != nil {
+			return errors.Wrap(err, "cannot claim")
+		}
+		if err := v.Recipient.Verify(claimMessage(message, sig.Preimage), sig.Signature); err != nil {
+			return errors.Wrap(err, "cannot claim: invalid signature of the recipient")
+		}
+		return nil
+	}
+	// reclaim
+	if !v.Script.Expired(now) {
+		return errors.New("cannot reclaim: the deadline has not expired yet")
+	}
+	if err := v.Sender.Verify(message, sig.Signature); err != nil {
+		return errors.Wrap(err, "cannot reclaim: invalid signature of the sender")
+	}
+	return nil
+}
+
+// Signer signs on behalf of a script owner.
+// It claims the token when the recipient signer and the preimage are set and the deadline has not expired,
+// otherwise it reclaims the token, if the sender signer is set.
+type Signer struct {
+	Script *Script
+	// Now returns the time at which the spending transaction is expected to be validated.
+	// When nil, or when it returns the zero time, the signer does not sign.
+	Now func() time.Time
+
+	lock      sync.RWMutex
+	sender    driver.Signer
+	recipient driver.Signer
+	preimage  []byte
+}
+
+func NewSigner(script *Script) *Signer {
+	return &Signer{Script: script}
+}
+
+// SetSender sets the signer of the sender of the script
+func (s *Signer) SetSender(signer driver.Signer) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sender = signer
+}
+
+// SetRecipient sets the signer of the recipient of the script, and the preimage of the hash lock
+func (s *Signer) SetRecipient(signer driver.Signer, preimage []byte) error {
+	if err := s.Script.HashInfo.Match(preimage); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.recipient = signer
+	s.preimage = preimage
+	return nil
+}
+
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now, err := txTime(s.Now)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot sign")
+	}
+	sig := &Signature{}
+	switch {
+	case s.recipient != nil && !s.Script.Expired(now):
+		sigma, err := s.recipient.Sign(claimMessage(message, s.preimage))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed signing claim")
+		}
+		sig.Preimage = s.preimage
+		sig.Signature = sigma
+	case s.sender != nil && s.Script.Expired(now):
+		sigma, err := s.sender.Sign(message)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed signing reclaim")
+		}
+		sig.Signature = sigma
+	default:
+		return nil, errors.New("cannot sign: neither a claim nor a reclaim is possible")
+	}
+	return json.Marshal(sig)
+}
+
+// txTime returns the time returned by the passed function, or an error if the time is unknown
+func txTime(now func() time.Time) (time.Time, error) {
+	if now == nil {
+		return time.Time{}, errors.New("the time of the transaction is unknown")
+	}
+	t := now()
+	if t.IsZero() {
+		return time.Time{}, errors.New("the time of the transaction is unknown")
+	}
+	return t, nil
+}
+
+// claimMessage binds the preimage to the signature of the recipient
+func claimMessage(message, preimage []byte) []byte {
+	return append(append([]byte{}, message...), preimage...)
+}
+
+// Deserializer returns the verifiers of owners.
+// It handles script owners and delegates the other owners to the underlying deserializer.
+type Deserializer struct {
+	Deserializer VerifierDeserializer
+	// Now is passed to the verifiers of the scripts, see Verifier
+	Now func() time.Time
+}
+
+func NewDeserializer(deserializer VerifierDeserializer, now func() time.Time) *Deserializer {
+	return &Deserializer{Deserializer: deserializer, Now: now}
+}
+
+func (d *Deserializer) GetVerifier(id view.Identity) (driver.Verifier, error) {
+	if !IsScript(id) {
+		return d.Deserializer.GetVerifier(id)
+	}
+	script, err := ScriptFromIdentity(id)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := d.Deserializer.GetVerifier(script.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting verifier of the sender")
+	}
+	recipient, err := d.Deserializer.GetVerifier(script.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting verifier of the recipient")
+	}
+	return &Verifier{Script: script, Sender: sender, Recipient: recipient, Now: d.Now}, nil
+}
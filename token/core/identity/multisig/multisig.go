@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package multisig
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// Type is the type of the owners that require the signatures of m out of n identities
+const Type = "multisig"
+
+// MultiIdentity is owned jointly by n identities. Spending requires the signatures of Threshold of them.
+type MultiIdentity struct {
+	Identities []view.Identity
+	Threshold  int
+}
+
+// Validate returns an error if the identity is not well-formed
+func (m *MultiIdentity) Validate() error {
+	if len(m.Identities) == 0 {
+		return errors.New("no identities")
+	}
+	if m.Threshold < 1 || m.Threshold > len(m.Identities) {
+		return errors.Errorf("invalid threshold [%d] for [%d] identities", m.Threshold, len(m.Identities))
+	}
+	for i, id := range m.Identities {
+		if len(id) == 0 {
+			return errors.Errorf("identity at index [%d] is empty", i)
+		}
+		for j := 0; j < i; j++ {
+			if m.Identities[j].Equal(id) {
+				return errors.Errorf("identity at index [%d] is repeated", i)
+			}
+		}
+	}
+	return nil
+}
+
+// typedIdentity is the encoding of a multisig owner
+type typedIdentity struct {
+	Type     string          `json:"type"`
+	Identity json.RawMessage `json:"identity"`
+}
+
+// Identity returns the owner identity that encodes the multisig identity
+func (m *MultiIdentity) Identity() (view.Identity, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling multisig identity")
+	}
+	return json.Marshal(&typedIdentity{Type: Type, Identity: raw})
+}
+
+// IsMultisig returns true if the passed owner identity is a multisig identity
+func IsMultisig(id view.Identity) bool {
+	ti := &typedIdentity{}
+	if err := json.Unmarshal(id, ti); err != nil {
+		return false
+	}
+	return ti.Type == Type
+}
+
+// FromIdentity returns the multisig identity encoded in the passed owner identity
+func FromIdentity(id view.Identity) (*MultiIdentity, error) {
+	ti := &typedIdentity{}
+	if err := json.Unmarshal(id, ti); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling owner")
+	}
+	if ti.Type != Type {
+		return nil, errors.Errorf("owner of type [%s] is not a multisig identity", ti.Type)
+	}
+	m := &MultiIdentity{}
+	if err := json.Unmarshal(ti.Identity, m); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling multisig identity")
+	}
+	return m, nil
+}
+
+// ValidateOwner returns an error if the passed owner of a new token is a multisig identity that is not well-formed.
+// Owners that are not multisig identities are accepted.
+func ValidateOwner(id view.Identity) error {
+	if !IsMultisig(id) {
+		return nil
+	}
+	m, err := FromIdentity(id)
+	if err != nil {
+		return err
+	}
+	if err := m.Validate(); err != nil {
+		return errors.Wrap(err, "invalid multisig identity")
+	}
+	return nil
+}
+
+// Signature carries the signatures of the identities of a multisig owner.
+// Signatures[i] is the signature of the i-th identity, or empty if that identity did not sign.
+type Signature struct {
+	Signatures [][]byte
+}
+
+// VerifierDeserializer returns the verifier of the identities of a multisig owner
+type VerifierDeserializer interface {
+	GetVerifier(id view.Identity) (driver.Verifier, error)
+}
+
+// Verifier checks that a signature carries valid signatures of at least the threshold of identities
+type Verifier struct {
+	Identity  *MultiIdentity
+	Verifiers []driver.Verifier
+}
+
+func (v *Verifier) Verify(message, sigma []byte) error {
+	sig := &Signature{}
+	if err := json.Unmarshal(sigma, sig); err != nil {
+		return errors.Wrap(err, "failed unmarshalling multisig signature")
+	}
+	if len(sig.Signatures) != len(v.Verifiers) {
+		return errors.Errorf("expected [%d] signature slots, got [%d]", len(v.Verifiers), len(sig.Signatures))
+	}
+	valid := 0
+	for i, s := range sig.Signatures {
+		if len(s) == 0 {
+			continue
+		}
+		if err := v.Verifiers[i].Verify(message, s); err != nil {
+			return errors.Wrapf(err, "invalid signature of identity at index [%d]", i)
+		}
+		valid++
+	}
+	if valid < v.Identity.Threshold {
+		return errors.Errorf("not enough signatures: expected [%d], got [%d]", v.Identity.Threshold, valid)
+	}
+	return nil
+}
+
+// Deserializer returns the verifiers of owners.
+// It handles multisig owners and delegates the other owners to the underlying deserializer.
+type Deserializer struct {
+	Deserializer VerifierDeserializer
+}
+
+func NewDeserializer(deserializer VerifierDeserializer) *Deserializer {
+	return &Deserializer{Deserializer: deserializer}
+}
+
+func (d *Deserializer) GetVerifier(id view.Identity) (driver.Verifier, error) {
+	if !IsMultisig(id) {
+		return d.Deserializer.GetVerifier(id)
+	}
+	m, err := FromIdentity(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid multisig identity")
+	}
+	verifiers := make([]driver.Verifier, len(m.Identities))
+	for i, identity := range m.Identities {
+		verifiers[i], err = d.Deserializer.GetVerifier(identity)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed getting verifier of identity at index [%d]", i)
+		}
+	}
+	return &Verifier{Identity: m, Verifiers: verifiers}, nil
+}
+
+// AuditInfo is the audit information of a multisig owner.
+// It carries the audit information of each identity, in the same order.
+type AuditInfo struct {
+	Infos [][]byte `json:"multisig_infos"`
+}
+
+// Bytes returns the json encoding of the audit information
+func (a *AuditInfo) Bytes() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// GetAuditInfo returns the multisig audit information in the passed audit information, if any
+func GetAuditInfo(raw []byte) (*AuditInfo, bool) {
+	a := &AuditInfo{}
+	if err := json.Unmarshal(raw, a); err != nil {
+		return nil, false
+	}
+	if len(a.Infos) == 0 {
+		return nil, false
+	}
+	return a, true
+}
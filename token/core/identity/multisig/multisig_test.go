@@ -0,0 +1,157 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package multisig_test
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+var _ = Describe("Multisig", func() {
+	var (
+		ids          []view.Identity
+		signers      []driver.Signer
+		m            *multisig.MultiIdentity
+		owner        view.Identity
+		deserializer *multisig.Deserializer
+		message      []byte
+	)
+	BeforeEach(func() {
+		ids = nil
+		signers = nil
+		for i := 0; i < 3; i++ {
+			id, signer, _, err := fabric.NewSigner()
+			Expect(err).NotTo(HaveOccurred())
+			ids = append(ids, id)
+			signers = append(signers, signer)
+		}
+		m = &multisig.MultiIdentity{Identities: ids, Threshold: 2}
+		var err error
+		owner, err = m.Identity()
+		Expect(err).NotTo(HaveOccurred())
+		deserializer = multisig.NewDeserializer(&fabric.MSPX509IdentityDeserializer{})
+		message = []byte("a token request")
+	})
+
+	sign := func(indexes ...int) []byte {
+		sig := &multisig.Signature{Signatures: make([][]byte, len(ids))}
+		for _, i := range indexes {
+			sigma, err := signers[i].Sign(message)
+			Expect(err).NotTo(HaveOccurred())
+			sig.Signatures[i] = sigma
+		}
+		raw, err := json.Marshal(sig)
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+
+	Describe("MultiIdentity", func() {
+		It("is encoded in the owner identity", func() {
+			Expect(multisig.IsMultisig(owner)).To(BeTrue())
+			Expect(multisig.IsMultisig(ids[0])).To(BeFalse())
+			decoded, err := multisig.FromIdentity(owner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded).To(Equal(m))
+			Expect(multisig.ValidateOwner(owner)).To(Succeed())
+			Expect(multisig.ValidateOwner(ids[0])).To(Succeed())
+		})
+		When("the threshold is not valid", func() {
+			It("fails", func() {
+				m.Threshold = 4
+				owner, err := m.Identity()
+				Expect(err).NotTo(HaveOccurred())
+				err = multisig.ValidateOwner(owner)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid threshold [4] for [3] identities"))
+			})
+		})
+		When("an identity is repeated", func() {
+			It("fails", func() {
+				m.Identities[2] = m.Identities[0]
+				owner, err := m.Identity()
+				Expect(err).NotTo(HaveOccurred())
+				err = multisig.ValidateOwner(owner)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("identity at index [2] is repeated"))
+			})
+		})
+	})
+
+	Describe("Verifier", func() {
+		var verifier driver.Verifier
+		BeforeEach(func() {
+			var err error
+			verifier, err = deserializer.GetVerifier(owner)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		When("the threshold is met", func() {
+			It("succeeds", func() {
+				Expect(verifier.Verify(message, sign(0, 2))).To(Succeed())
+				Expect(verifier.Verify(message, sign(0, 1, 2))).To(Succeed())
+			})
+		})
+		When("there are not enough signatures", func() {
+			It("fails", func() {
+				err := verifier.Verify(message, sign(1))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("not enough signatures: expected [2], got [1]"))
+			})
+		})
+		When("a signature is not valid", func() {
+			It("fails", func() {
+				sig := &multisig.Signature{}
+				Expect(json.Unmarshal(sign(0, 1), sig)).To(Succeed())
+				sig.Signatures[2] = sig.Signatures[1]
+				raw, err := json.Marshal(sig)
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid signature of identity at index [2]"))
+			})
+		})
+		When("the number of signature slots is wrong", func() {
+			It("fails", func() {
+				sig := &multisig.Signature{}
+				Expect(json.Unmarshal(sign(0, 1), sig)).To(Succeed())
+				sig.Signatures = sig.Signatures[:2]
+				raw, err := json.Marshal(sig)
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(message, raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("expected [3] signature slots, got [2]"))
+			})
+		})
+		It("delegates the other owners", func() {
+			v, err := deserializer.GetVerifier(ids[0])
+			Expect(err).NotTo(HaveOccurred())
+			sigma, err := signers[0].Sign(message)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v.Verify(message, sigma)).To(Succeed())
+		})
+	})
+
+	Describe("AuditInfo", func() {
+		It("is recognized", func() {
+			raw, err := (&multisig.AuditInfo{Infos: [][]byte{[]byte("a"), []byte("b")}}).Bytes()
+			Expect(err).NotTo(HaveOccurred())
+			info, ok := multisig.GetAuditInfo(raw)
+			Expect(ok).To(BeTrue())
+			Expect(info.Infos).To(HaveLen(2))
+
+			_, ok = multisig.GetAuditInfo([]byte("an enrollment id"))
+			Expect(ok).To(BeFalse())
+			_, ok = multisig.GetAuditInfo([]byte(`{"htlc_sender":"YQ=="}`))
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
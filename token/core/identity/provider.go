@@ -7,6 +7,7 @@ package identity
 
 import (
 	"fmt"
+	"strings"
 
 	idemix2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/msp/idemix"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -15,6 +16,8 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/pkg/errors"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 )
 
@@ -124,7 +127,27 @@ func (i *Provider) RegisterAuditInfo(id view.Identity, auditInfo []byte) error {
 	return nil
 }
 
+// GetEnrollmentID returns the enrollment ID in the passed audit information.
+// For script owners, it returns the enrollment ID of the recipient, if available, and of the sender otherwise.
+// For multisig owners, it returns the comma-separated enrollment IDs of the identities.
 func (i *Provider) GetEnrollmentID(auditInfo []byte) (string, error) {
+	if si, ok := htlc.GetScriptInfo(auditInfo); ok {
+		if len(si.Recipient) != 0 {
+			return i.GetEnrollmentID(si.Recipient)
+		}
+		return i.GetEnrollmentID(si.Sender)
+	}
+	if mi, ok := multisig.GetAuditInfo(auditInfo); ok {
+		eIDs := make([]string, len(mi.Infos))
+		for j, info := range mi.Infos {
+			eID, err := i.GetEnrollmentID(info)
+			if err != nil {
+				return "", errors.WithMessagef(err, "failed getting enrollment id of identity at index [%d]", j)
+			}
+			eIDs[j] = eID
+		}
+		return strings.Join(eIDs, ","), nil
+	}
 	ai := &idemix2.AuditInfo{}
 	if err := ai.FromBytes(auditInfo); err != nil {
 		return "", errors.Wrapf(err, "failed unamrshalling audit info [%s]", auditInfo)
@@ -13,6 +13,8 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/pkg/errors"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
@@ -37,19 +39,30 @@ type AuditableToken struct {
 }
 
 func NewAuditableToken(token *token.Token, ownerInfo []byte, ttype string, value *bn256.Zr, bf *bn256.Zr) (*AuditableToken, error) {
-	auditInfo := &idemix.AuditInfo{}
+	owner := &ownerOpening{ownerInfo: &idemix.AuditInfo{}}
 	if !token.IsRedeem() {
 		// this is not a redeem
-		err := json.Unmarshal(ownerInfo, auditInfo)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed unmarshalling audit info")
+		var err error
+		switch {
+		case htlc.IsScript(token.Owner):
+			owner, err = newScriptOwnerOpening(ownerInfo)
+			if err != nil {
+				return nil, err
+			}
+		case multisig.IsMultisig(token.Owner):
+			owner, err = newMultisigOwnerOpening(ownerInfo)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			if err = json.Unmarshal(ownerInfo, owner.ownerInfo); err != nil {
+				return nil, errors.Wrap(err, "failed unmarshalling audit info")
+			}
 		}
 	}
 	return &AuditableToken{
 		Token: token,
-		owner: &ownerOpening{
-			ownerInfo: auditInfo,
-		},
+		owner: owner,
 		data: &tokenDataOpening{
 			ttype: ttype,
 			value: value,
@@ -66,6 +79,94 @@ type tokenDataOpening struct {
 
 type ownerOpening struct {
 	ownerInfo *idemix.AuditInfo
+	// script carries the openings of the sender and the recipient of a script owner
+	script *scriptOwnerOpening
+	// multisig carries the openings of the identities of a multisig owner
+	multisig []*idemix.AuditInfo
+}
+
+type scriptOwnerOpening struct {
+	sender    *idemix.AuditInfo
+	recipient *idemix.AuditInfo
+}
+
+func newScriptOwnerOpening(ownerInfo []byte) (*ownerOpening, error) {
+	si, ok := htlc.GetScriptInfo(ownerInfo)
+	if !ok {
+		return nil, errors.New("failed unmarshalling audit info: expected script audit info")
+	}
+	opening := &scriptOwnerOpening{}
+	if len(si.Sender) != 0 {
+		opening.sender = &idemix.AuditInfo{}
+		if err := json.Unmarshal(si.Sender, opening.sender); err != nil {
+			return nil, errors.Wrap(err, "failed unmarshalling audit info of the script sender")
+		}
+	}
+	if len(si.Recipient) != 0 {
+		opening.recipient = &idemix.AuditInfo{}
+		if err := json.Unmarshal(si.Recipient, opening.recipient); err != nil {
+			return nil, errors.Wrap(err, "failed unmarshalling audit info of the script recipient")
+		}
+	}
+	return &ownerOpening{script: opening}, nil
+}
+
+func newMultisigOwnerOpening(ownerInfo []byte) (*ownerOpening, error) {
+	mi, ok := multisig.GetAuditInfo(ownerInfo)
+	if !ok {
+		return nil, errors.New("failed unmarshalling audit info: expected multisig audit info")
+	}
+	openings := make([]*idemix.AuditInfo, len(mi.Infos))
+	for i, info := range mi.Infos {
+		openings[i] = &idemix.AuditInfo{}
+		if err := json.Unmarshal(info, openings[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed unmarshalling audit info of multisig identity at index [%d]", i)
+		}
+	}
+	return &ownerOpening{multisig: openings}, nil
+}
+
+// match returns an error if the opening does not open the passed owner.
+// For multisig owners, each identity must be opened.
+// For script owners, the openings that are present must open the parties of the script.
+// If full is true, both parties must be opened.
+func (o *ownerOpening) match(owner []byte, full bool) error {
+	if o.multisig != nil {
+		m, err := multisig.FromIdentity(owner)
+		if err != nil {
+			return err
+		}
+		if len(m.Identities) != len(o.multisig) {
+			return errors.Errorf("expected the openings of [%d] multisig identities, got [%d]", len(m.Identities), len(o.multisig))
+		}
+		for i, opening := range o.multisig {
+			if err := opening.Match(m.Identities[i]); err != nil {
+				return errors.Wrapf(err, "multisig identity at index [%d] does not match the provided opening", i)
+			}
+		}
+		return nil
+	}
+	if o.script == nil {
+		return o.ownerInfo.Match(owner)
+	}
+	script, err := htlc.ScriptFromIdentity(owner)
+	if err != nil {
+		return err
+	}
+	if full && (o.script.sender == nil || o.script.recipient == nil) {
+		return errors.New("expected the openings of both the sender and the recipient of the script")
+	}
+	if o.script.sender != nil {
+		if err := o.script.sender.Match(script.Sender); err != nil {
+			return errors.Wrap(err, "sender of the script does not match the provided opening")
+		}
+	}
+	if o.script.recipient != nil {
+		if err := o.script.recipient.Match(script.Recipient); err != nil {
+			return errors.Wrap(err, "recipient of the script does not match the provided opening")
+		}
+	}
+	return nil
 }
 
 type Auditor struct {
@@ -151,7 +252,7 @@ func (a *Auditor) inspectOutputs(tokens []*AuditableToken) error {
 			return errors.Wrapf(err, "failed inspecting output [%d]", i)
 		}
 		if !t.Token.IsRedeem() { // this is not a redeemed output
-			err = t.owner.ownerInfo.Match(t.Token.Owner)
+			err = t.owner.match(t.Token.Owner, true)
 			if err != nil {
 				return errors.Wrapf(err, "output at index [%d] does not match the provided opening", i)
 			}
@@ -183,7 +284,7 @@ func (a *Auditor) inspectInputs(inputs []*AuditableToken) error {
 
 		if !input.Token.IsRedeem() {
 			// this is not a redeem
-			err := input.owner.ownerInfo.Match(input.Token.Owner)
+			err := input.owner.match(input.Token.Owner, false)
 			if err != nil {
 				return errors.Errorf("input at index [%d] does not match the provided opening", i)
 			}
@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package certification
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Signer signs the certifications
+type Signer interface {
+	Sign(message []byte) ([]byte, error)
+}
+
+// Verifier verifies the signature of the certifier
+type Verifier interface {
+	Verify(message, sigma []byte) error
+}
+
+// CertificationRequest is sent by the owner of tokens to get them certified.
+// It carries the tokens as stored in the vault of the owner, the certifier checks them against the ledger.
+type CertificationRequest struct {
+	IDs    []*token2.Id
+	Tokens [][]byte
+}
+
+// NewCertificationRequest returns a certification request for the passed tokens
+func NewCertificationRequest(ids []*token2.Id, tokens [][]byte) (*CertificationRequest, error) {
+	if len(ids) != len(tokens) {
+		return nil, errors.Errorf("number of ids [%d] does not match number of tokens [%d]", len(ids), len(tokens))
+	}
+	return &CertificationRequest{IDs: ids, Tokens: tokens}, nil
+}
+
+func (r *CertificationRequest) Serialize() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *CertificationRequest) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, r)
+}
+
+// Certifier certifies that tokens exist on the ledger
+type Certifier struct {
+	Signer Signer
+}
+
+func NewCertifier(signer Signer) *Certifier {
+	return &Certifier{Signer: signer}
+}
+
+// Certify checks that the tokens in the request are the tokens stored on the ledger under the passed ids.
+// For each token, it returns a signature binding the token id to the token commitment.
+func (c *Certifier) Certify(ids []*token2.Id, tokens [][]byte, raw []byte) ([][]byte, error) {
+	request := &CertificationRequest{}
+	if err := request.Deserialize(raw); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal certification request")
+	}
+	if len(ids) != len(request.IDs) || len(ids) != len(tokens) || len(ids) != len(request.Tokens) {
+		return nil, errors.Errorf("invalid certification request: expected [%d] tokens", len(ids))
+	}
+
+	certifications := make([][]byte, len(ids))
+	for i, id := range ids {
+		if id == nil || request.IDs[i] == nil || id.TxId != request.IDs[i].TxId || id.Index != request.IDs[i].Index {
+			return nil, errors.Errorf("invalid certification request: token id at index [%d] does not match", i)
+		}
+		if len(tokens[i]) == 0 {
+			return nil, errors.Errorf("token %s does not exist", id)
+		}
+		if !bytes.Equal(tokens[i], request.Tokens[i]) {
+			return nil, errors.Errorf("token %s does not match the ledger", id)
+		}
+		msg, err := message(id, tokens[i])
+		if err != nil {
+			return nil, err
+		}
+		certifications[i], err = c.Signer.Sign(msg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to certify token %s", id)
+		}
+	}
+	return certifications, nil
+}
+
+// CertificationVerifier checks the certifications of tokens
+type CertificationVerifier struct {
+	Verifier Verifier
+}
+
+func NewCertificationVerifier(verifier Verifier) *CertificationVerifier {
+	return &CertificationVerifier{Verifier: verifier}
+}
+
+// Verify returns an error if any of the passed certifications is not a valid certification of the
+// token with the same index
+func (v *CertificationVerifier) Verify(ids []*token2.Id, tokens [][]byte, certifications [][]byte) error {
+	if len(ids) != len(tokens) || len(ids) != len(certifications) {
+		return errors.Errorf("expected [%d] certifications, got [%d]", len(ids), len(certifications))
+	}
+	for i, id := range ids {
+		if len(tokens[i]) == 0 {
+			return errors.Errorf("token %s does not exist", id)
+		}
+		msg, err := message(id, tokens[i])
+		if err != nil {
+			return err
+		}
+		if err := v.Verifier.Verify(msg, certifications[i]); err != nil {
+			return errors.Wrapf(err, "invalid certification for token %s", id)
+		}
+	}
+	return nil
+}
+
+// message returns the bytes signed by the certifier for the passed token
+func message(id *token2.Id, raw []byte) ([]byte, error) {
+	tok := &token.Token{}
+	if err := tok.Deserialize(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal token %s", id)
+	}
+	if tok.Data == nil {
+		return nil, errors.Errorf("token %s carries no commitment", id)
+	}
+	return json.Marshal(&struct {
+		ID    *token2.Id
+		Token []byte
+	}{ID: id, Token: raw})
+}
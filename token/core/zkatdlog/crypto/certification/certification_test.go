@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package certification_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ecdsa"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var _ = Describe("Certification", func() {
+	var (
+		signer    *ecdsa.ECDSASigner
+		certifier *certification.Certifier
+		verifier  *certification.CertificationVerifier
+		ids       []*token2.Id
+		tokens    [][]byte
+		request   []byte
+	)
+	BeforeEach(func() {
+		var err error
+		signer, err = ecdsa.NewECDSASigner()
+		Expect(err).NotTo(HaveOccurred())
+		certifier = certification.NewCertifier(signer)
+		verifier = certification.NewCertificationVerifier(signer.ECDSAVerifier)
+
+		ids = []*token2.Id{{TxId: "tx1", Index: 0}, {TxId: "tx2", Index: 1}}
+		tokens = make([][]byte, len(ids))
+		for i := range ids {
+			tok := &token.Token{Owner: []byte("alice"), Data: bn256.G1Gen().Mul(bn256.NewZrInt(i + 1))}
+			tokens[i], err = tok.Serialize()
+			Expect(err).NotTo(HaveOccurred())
+		}
+		cr, err := certification.NewCertificationRequest(ids, tokens)
+		Expect(err).NotTo(HaveOccurred())
+		request, err = cr.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Certify", func() {
+		When("the tokens match the ledger", func() {
+			It("succeeds", func() {
+				certifications, err := certifier.Certify(ids, tokens, request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(certifications).To(HaveLen(2))
+				Expect(verifier.Verify(ids, tokens, certifications)).To(Succeed())
+			})
+		})
+		When("a token does not exist on the ledger", func() {
+			It("fails", func() {
+				_, err := certifier.Certify(ids, [][]byte{tokens[0], nil}, request)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("token [tx2:1] does not exist"))
+			})
+		})
+		When("a token does not match the ledger", func() {
+			It("fails", func() {
+				_, err := certifier.Certify(ids, [][]byte{tokens[1], tokens[1]}, request)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("token [tx1:0] does not match the ledger"))
+			})
+		})
+		When("the request is for different tokens", func() {
+			It("fails", func() {
+				_, err := certifier.Certify([]*token2.Id{ids[1], ids[0]}, tokens, request)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("token id at index [0] does not match"))
+			})
+		})
+	})
+
+	Describe("Verify", func() {
+		var certifications [][]byte
+		BeforeEach(func() {
+			var err error
+			certifications, err = certifier.Certify(ids, tokens, request)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		When("a certification is bound to another token", func() {
+			It("fails", func() {
+				err := verifier.Verify(ids, tokens, [][]byte{certifications[1], certifications[0]})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid certification for token [tx1:0]"))
+			})
+		})
+		When("the token is bound to another id", func() {
+			It("fails", func() {
+				err := verifier.Verify([]*token2.Id{{TxId: "tx3"}, ids[1]}, tokens, certifications)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid certification for token [tx3:0]"))
+			})
+		})
+		When("a certification is missing", func() {
+			It("fails", func() {
+				err := verifier.Verify(ids, tokens, certifications[:1])
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("expected [2] certifications, got [1]"))
+			})
+		})
+		When("the certifier is a different one", func() {
+			It("fails", func() {
+				other, err := ecdsa.NewECDSASigner()
+				Expect(err).NotTo(HaveOccurred())
+				err = certification.NewCertificationVerifier(other.ECDSAVerifier).Verify(ids, tokens, certifications)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
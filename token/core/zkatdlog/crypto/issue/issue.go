@@ -16,6 +16,7 @@ import (
 	rp "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/range"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 // Issue specifies an issue of one or more tokens
@@ -27,6 +28,10 @@ type IssueAction struct {
 	Proof []byte
 	// flag to indicate type of issue
 	Anonymous bool
+	// NFTs describes the non-fungible tokens created by this action, one for each output
+	NFTs []*token2.NFT `json:",omitempty"`
+	// NFTProof shows that the outputs open to the non-fungible tokens
+	NFTProof []byte `json:",omitempty"`
 }
 
 func (i *IssueAction) GetProof() []byte {
@@ -69,6 +74,19 @@ func (i *IssueAction) GetIssuer() []byte {
 	return i.Issuer
 }
 
+func (i *IssueAction) GetNFTs() []*token2.NFT {
+	return i.NFTs
+}
+
+func (i *IssueAction) GetTokenTypes() []string {
+	return TokenTypes(i.Anonymous, i.Proof)
+}
+
+// GetIssuedQuantities returns nil, quantities are hidden
+func (i *IssueAction) GetIssuedQuantities() (map[string]token2.Quantity, error) {
+	return nil, nil
+}
+
 func (i *IssueAction) Deserialize(raw []byte) error {
 	return json.Unmarshal(raw, i)
 }
@@ -132,7 +150,11 @@ func NewProver(tw []*token.TokenDataWitness, tokens []*bn256.G1, anonymous bool,
 	p := &Prover{}
 	p.WellFormedness = NewWellFormednessProver(tw, tokens, anonymous, pp.ZKATPedParams)
 
-	p.RangeCorrectness = rp.NewProver(tw, tokens, pp.RangeProofParams.SignedValues, pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	if pp.UseBulletproofs() {
+		p.RangeCorrectness = rp.NewBulletproofProver(tw, tokens, pp.ZKATPedParams, pp.BulletproofParams.LeftGenerators, pp.BulletproofParams.RightGenerators, pp.BulletproofParams.P, pp.BulletproofParams.BitLength)
+	} else {
+		p.RangeCorrectness = rp.NewProver(tw, tokens, pp.RangeProofParams.SignedValues, pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	}
 
 	return p
 }
@@ -140,7 +162,11 @@ func NewProver(tw []*token.TokenDataWitness, tokens []*bn256.G1, anonymous bool,
 func NewVerifier(tokens []*bn256.G1, anonymous bool, pp *crypto.PublicParams) *Verifier {
 	v := &Verifier{}
 	v.WellFormedness = NewWellFormednessVerifier(tokens, anonymous, pp.ZKATPedParams)
-	v.RangeCorrectness = rp.NewVerifier(tokens, uint64(len(pp.RangeProofParams.SignedValues)), pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	if pp.UseBulletproofs() {
+		v.RangeCorrectness = rp.NewBulletproofVerifier(tokens, pp.ZKATPedParams, pp.BulletproofParams.LeftGenerators, pp.BulletproofParams.RightGenerators, pp.BulletproofParams.P, pp.BulletproofParams.BitLength)
+	} else {
+		v.RangeCorrectness = rp.NewVerifier(tokens, uint64(len(pp.RangeProofParams.SignedValues)), pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	}
 	return v
 }
 
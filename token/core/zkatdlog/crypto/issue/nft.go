@@ -0,0 +1,189 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package issue
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// NFTProof shows that each commitment issued for a non-fungible token opens to
+// the type of that token and to value one
+type NFTProof struct {
+	BlindingFactors []*bn256.Zr
+	Challenge       *bn256.Zr
+}
+
+func (p *NFTProof) Serialize() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *NFTProof) Deserialize(bytes []byte) error {
+	return json.Unmarshal(bytes, p)
+}
+
+type NFTVerifier struct {
+	*common.SchnorrVerifier
+	Commitments []*bn256.G1
+	NFTs        []*token2.NFT
+	// PedParams are the Pedersen parameters used to commit to type, value and blinding factor
+	PedParams []*bn256.G1
+}
+
+type NFTProver struct {
+	*NFTVerifier
+	blindingFactors []*bn256.Zr
+}
+
+func NewNFTProver(blindingFactors []*bn256.Zr, commitments []*bn256.G1, nfts []*token2.NFT, pp []*bn256.G1) *NFTProver {
+	return &NFTProver{
+		NFTVerifier:     NewNFTVerifier(commitments, nfts, pp),
+		blindingFactors: blindingFactors,
+	}
+}
+
+func NewNFTVerifier(commitments []*bn256.G1, nfts []*token2.NFT, pp []*bn256.G1) *NFTVerifier {
+	return &NFTVerifier{
+		SchnorrVerifier: &common.SchnorrVerifier{PedParams: []*bn256.G1{pp[2]}},
+		Commitments:     commitments,
+		NFTs:            nfts,
+		PedParams:       pp,
+	}
+}
+
+func (p *NFTProver) Prove() ([]byte, error) {
+	if len(p.blindingFactors) != len(p.Commitments) {
+		return nil, errors.New("cannot compute nft proof: number of blinding factors does not match number of commitments")
+	}
+	statements, err := p.statements()
+	if err != nil {
+		return nil, err
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get random number generator")
+	}
+	randomness := make([]*bn256.Zr, len(p.blindingFactors))
+	commitments := make([]*bn256.G1, len(p.blindingFactors))
+	for i := 0; i < len(randomness); i++ {
+		randomness[i] = bn256.RandModOrder(rand)
+		commitments[i] = p.PedParams[2].Mul(randomness[i])
+	}
+	chal := common.ComputeChallenge(common.GetG1Array(commitments, statements, p.PedParams))
+	sp := &common.SchnorrProver{
+		Witness:    p.blindingFactors,
+		Randomness: randomness,
+		Challenge:  chal,
+	}
+	bfs, err := sp.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot compute nft proof")
+	}
+	return (&NFTProof{BlindingFactors: bfs, Challenge: chal}).Serialize()
+}
+
+func (v *NFTVerifier) Verify(raw []byte) error {
+	proof := &NFTProof{}
+	if err := proof.Deserialize(raw); err != nil {
+		return errors.Wrap(err, "invalid nft proof")
+	}
+	if proof.Challenge == nil || len(proof.BlindingFactors) != len(v.Commitments) {
+		return errors.New("invalid nft proof: missing values")
+	}
+	statements, err := v.statements()
+	if err != nil {
+		return err
+	}
+	zkps := make([]*common.SchnorrProof, len(statements))
+	for i, statement := range statements {
+		if proof.BlindingFactors[i] == nil {
+			return errors.New("invalid nft proof: missing values")
+		}
+		zkps[i] = &common.SchnorrProof{Statement: statement, Proof: []*bn256.Zr{proof.BlindingFactors[i]}}
+	}
+	commitments := v.RecomputeCommitments(zkps, proof.Challenge)
+	chal := common.ComputeChallenge(common.GetG1Array(commitments, statements, v.PedParams))
+	if chal.Cmp(proof.Challenge) != 0 {
+		return errors.New("invalid nft proof: commitments do not match the non-fungible tokens")
+	}
+	return nil
+}
+
+// statements returns, for each commitment, the commitment divided by the contribution of the nft type and of value one.
+// What is left is a commitment to the blinding factor only.
+func (v *NFTVerifier) statements() ([]*bn256.G1, error) {
+	if len(v.Commitments) != len(v.NFTs) {
+		return nil, errors.New("invalid nft proof: number of commitments does not match number of non-fungible tokens")
+	}
+	one := bn256.NewZrInt(int(token2.NFTQuantity))
+	statements := make([]*bn256.G1, len(v.Commitments))
+	for i, com := range v.Commitments {
+		if com == nil || v.NFTs[i] == nil {
+			return nil, errors.New("invalid nft proof: missing values")
+		}
+		statements[i] = bn256.NewG1().Copy(com)
+		statements[i].Sub(v.PedParams[0].Mul(bn256.HashModOrder([]byte(v.NFTs[i].Type()))))
+		statements[i].Sub(v.PedParams[1].Mul(one))
+	}
+	return statements, nil
+}
+
+// VerifyNFTs checks the non-fungible tokens declared by an issue action.
+// Each declared non-fungible token must be matched by the commitment at the same position.
+// When the issue is not anonymous, the type in the clear must not be reserved to non-fungible tokens
+// unless non-fungible tokens are declared.
+// Anonymous issues hide their type, when the passed registry reserves the non-fungible token types
+// they must declare non-fungible tokens, proving that they do not create other tokens of those types.
+func VerifyNFTs(types token2.TypeRegistry, nfts []*token2.NFT, commitments []*bn256.G1, anonymous bool, proof []byte, nftProof []byte, pp []*bn256.G1) error {
+	if len(nfts) == 0 {
+		if anonymous {
+			if types.ReservesNFTTypes() {
+				return errors.New("anonymous issues must prove their non-fungible tokens when non-fungible token types are reserved")
+			}
+			return nil
+		}
+		typ, err := typeInTheClear(proof)
+		if err != nil {
+			return err
+		}
+		if token2.IsNFTType(typ) {
+			return errors.Errorf("type [%s] is reserved to non-fungible tokens", typ)
+		}
+		return nil
+	}
+
+	if len(nfts) != len(commitments) {
+		return errors.Errorf("number of non-fungible tokens does not match number of outputs [%d]!=[%d]", len(nfts), len(commitments))
+	}
+	ids := map[string]bool{}
+	for _, nft := range nfts {
+		if err := nft.Validate(); err != nil {
+			return err
+		}
+		if ids[nft.ID] {
+			return errors.Errorf("nft [%s] is issued more than once", nft.ID)
+		}
+		ids[nft.ID] = true
+	}
+	return NewNFTVerifier(commitments, nfts, pp).Verify(nftProof)
+}
+
+func typeInTheClear(raw []byte) (string, error) {
+	proof := &Proof{}
+	if err := proof.Deserialize(raw); err != nil {
+		return "", errors.Wrap(err, "failed to deserialize issue proof")
+	}
+	wf := &WellFormedness{}
+	if err := wf.Deserialize(proof.WellFormedness); err != nil {
+		return "", errors.Wrap(err, "failed to deserialize well-formedness proof")
+	}
+	return wf.TypeInTheClear, nil
+}
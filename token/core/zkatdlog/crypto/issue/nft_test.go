@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package issue_test
+
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NFT Proof", func() {
+	var (
+		pp     []*bn256.G1
+		nfts   []*token2.NFT
+		bf     []*bn256.Zr
+		tokens []*bn256.G1
+	)
+	BeforeEach(func() {
+		pp = preparePedersenParameters()
+		nfts = []*token2.NFT{{ID: "painting", URI: "ipfs://painting"}}
+		rand, err := bn256.GetRand()
+		Expect(err).NotTo(HaveOccurred())
+		bf = []*bn256.Zr{bn256.RandModOrder(rand)}
+		tokens = PrepareTokens([]*bn256.Zr{bn256.NewZrInt(1)}, bf, nfts[0].Type(), pp)
+	})
+	Describe("Prove", func() {
+		Context("the commitment opens to the nft type and value one", func() {
+			It("Succeeds", func() {
+				proof, err := issue.NewNFTProver(bf, tokens, nfts, pp).Prove()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(issue.NewNFTVerifier(tokens, nfts, pp).Verify(proof)).To(Succeed())
+				Expect(issue.VerifyNFTs(nil, nfts, tokens, false, nil, proof, pp)).To(Succeed())
+			})
+		})
+		Context("the verifier expects a different nft", func() {
+			It("fails", func() {
+				proof, err := issue.NewNFTProver(bf, tokens, nfts, pp).Prove()
+				Expect(err).NotTo(HaveOccurred())
+				err = issue.NewNFTVerifier(tokens, []*token2.NFT{{ID: "another painting"}}, pp).Verify(proof)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("invalid nft proof: commitments do not match the non-fungible tokens"))
+			})
+		})
+		Context("the commitment opens to a value different from one", func() {
+			BeforeEach(func() {
+				tokens = PrepareTokens([]*bn256.Zr{bn256.NewZrInt(2)}, bf, nfts[0].Type(), pp)
+			})
+			It("fails", func() {
+				proof, err := issue.NewNFTProver(bf, tokens, nfts, pp).Prove()
+				Expect(err).NotTo(HaveOccurred())
+				err = issue.NewNFTVerifier(tokens, nfts, pp).Verify(proof)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("invalid nft proof: commitments do not match the non-fungible tokens"))
+			})
+		})
+		Context("the same nft is declared twice", func() {
+			It("fails", func() {
+				nfts = append(nfts, nfts[0])
+				tokens = append(tokens, tokens[0])
+				err := issue.VerifyNFTs(nil, nfts, tokens, false, nil, nil, pp)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("nft [painting] is issued more than once"))
+			})
+		})
+		Context("a fungible issue uses a type reserved to nfts", func() {
+			It("fails", func() {
+				tw := []*token.TokenDataWitness{{Type: nfts[0].Type(), Value: bn256.NewZrInt(1), BlindingFactor: bf[0]}}
+				wf, err := issue.NewWellFormednessProver(tw, tokens, false, pp).Prove()
+				Expect(err).NotTo(HaveOccurred())
+				proof, err := (&issue.Proof{WellFormedness: wf}).Serialize()
+				Expect(err).NotTo(HaveOccurred())
+
+				err = issue.VerifyNFTs(nil, nil, tokens, false, proof, nil, pp)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("type [nft:painting] is reserved to non-fungible tokens"))
+				Expect(issue.VerifyNFTs(nil, nil, tokens, true, proof, nil, pp)).To(Succeed())
+			})
+		})
+		Context("an anonymous issue does not declare nfts while nft types are reserved", func() {
+			It("fails", func() {
+				types := token2.TypeRegistry{}.Register(&token2.TypeInfo{Type: token2.NFTTypePrefix, Issuers: [][]byte{[]byte("alice")}})
+				err := issue.VerifyNFTs(types, nil, tokens, true, nil, nil, pp)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("anonymous issues must prove their non-fungible tokens when non-fungible token types are reserved"))
+
+				proof, err := issue.NewNFTProver(bf, tokens, nfts, pp).Prove()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(issue.VerifyNFTs(types, nfts, tokens, true, nil, proof, pp)).To(Succeed())
+			})
+		})
+	})
+})
@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package issue
+
+import (
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// TokenTypes returns the type of the tokens created by an issue action with the passed proof.
+// Anonymous issues hide the type, in that case it returns nil.
+func TokenTypes(anonymous bool, proof []byte) []string {
+	if anonymous {
+		return nil
+	}
+	typ, err := typeInTheClear(proof)
+	if err != nil || len(typ) == 0 {
+		return nil
+	}
+	return []string{typ}
+}
+
+// VerifyTokenType checks that the type issued by an issue action is registered and that the issuer is allowed to issue it.
+// Issuing is denied by default, see token.TypeRegistry.CheckIssue.
+// Quantities are hidden, therefore the max supply of the type cannot be checked here.
+// Anonymous issues hide the type, but the type is bound to the issuer key the issue proof refers to,
+// and only the public parameters list the keys of anonymous issuers. Therefore, they are not checked against the registry.
+func VerifyTokenType(types token2.TypeRegistry, issuer []byte, nfts []*token2.NFT, anonymous bool, proof []byte) error {
+	for _, nft := range nfts {
+		if _, err := types.CheckIssue(nft.Type(), issuer); err != nil {
+			return err
+		}
+	}
+	if len(nfts) != 0 {
+		return nil
+	}
+	if anonymous {
+		return nil
+	}
+	typ, err := typeInTheClear(proof)
+	if err != nil {
+		return err
+	}
+	_, err = types.CheckIssue(typ, issuer)
+	return err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package issue_test
+
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token Type Registry", func() {
+	var (
+		registry token2.TypeRegistry
+		proof    []byte
+	)
+	BeforeEach(func() {
+		registry = token2.TypeRegistry{{Type: "USD", Decimals: 2, Issuers: [][]byte{[]byte("alice")}}}
+		pp := preparePedersenParameters()
+		rand, err := bn256.GetRand()
+		Expect(err).NotTo(HaveOccurred())
+		bf := []*bn256.Zr{bn256.RandModOrder(rand)}
+		tokens := PrepareTokens([]*bn256.Zr{bn256.NewZrInt(50)}, bf, "USD", pp)
+		tw := []*token.TokenDataWitness{{Type: "USD", Value: bn256.NewZrInt(50), BlindingFactor: bf[0]}}
+		wf, err := issue.NewWellFormednessProver(tw, tokens, false, pp).Prove()
+		Expect(err).NotTo(HaveOccurred())
+		proof, err = (&issue.Proof{WellFormedness: wf}).Serialize()
+		Expect(err).NotTo(HaveOccurred())
+	})
+	Describe("Verify Token Type", func() {
+		Context("the type is registered and the issuer is allowed", func() {
+			It("succeeds", func() {
+				Expect(issue.VerifyTokenType(registry, []byte("alice"), nil, false, proof)).To(Succeed())
+			})
+		})
+		Context("the registry is empty", func() {
+			It("succeeds", func() {
+				Expect(issue.VerifyTokenType(nil, []byte("bob"), nil, true, proof)).To(Succeed())
+			})
+		})
+		Context("the issuer is not allowed", func() {
+			It("fails", func() {
+				err := issue.VerifyTokenType(registry, []byte("bob"), nil, false, proof)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("issuer is not allowed to issue token type [USD]"))
+			})
+		})
+		Context("the type is not registered", func() {
+			It("fails", func() {
+				registry = token2.TypeRegistry{{Type: "EUR"}}
+				err := issue.VerifyTokenType(registry, []byte("alice"), nil, false, proof)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("token type [USD] is not registered"))
+			})
+		})
+		Context("the issue is anonymous", func() {
+			It("succeeds, the type is bound to the issuer key", func() {
+				Expect(issue.VerifyTokenType(registry, []byte("bob"), nil, true, nil)).To(Succeed())
+			})
+		})
+		Context("the issue is anonymous and declares non-fungible tokens", func() {
+			It("fails if the issuer is not allowed", func() {
+				registry = registry.AddIssuer(token2.NFTTypePrefix, []byte("alice"))
+				err := issue.VerifyTokenType(registry, []byte("bob"), []*token2.NFT{{ID: "painting"}}, true, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("issuer is not allowed to issue non-fungible tokens"))
+			})
+		})
+	})
+})
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.zkatdlog")
@@ -60,8 +61,54 @@ func (v *PublicParamsManager) PublicParameters() driver.PublicParameters {
 	return v.pp
 }
 
-func (v *PublicParamsManager) SetCertifier(bytes []byte) ([]byte, error) {
-	panic("SetCertifier cannot be called from zkatdlog without graph hiding")
+func (v *PublicParamsManager) RegisterTokenType(info *token2.TypeInfo) ([]byte, error) {
+	if err := info.Validate(v.pp.Precision()); err != nil {
+		return nil, errors.Wrap(err, "failed to register token type")
+	}
+	// work on a copy, the public parameters in use change only if the new ones can be serialized
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize public parameters")
+	}
+	pp := &crypto.PublicParams{}
+	if err := pp.Deserialize(raw); err != nil {
+		return nil, errors.Wrap(err, "failed to deserialize public parameters")
+	}
+	pp.TypeRegistry = pp.TypeRegistry.Register(info)
+	raw, err = pp.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize public parameters")
+	}
+	v.pp = pp
+	return raw, nil
+}
+
+func (v *PublicParamsManager) SetCertifier(certifier []byte) ([]byte, error) {
+	identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+	_, err := identityDeserializer.GetVerifier(certifier)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve certifier's identity")
+	}
+	v.pp.Certifier = certifier
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize public parameters")
+	}
+	return raw, nil
+}
+
+func (v *PublicParamsManager) SetAuthority(authority []byte) ([]byte, error) {
+	identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+	_, err := identityDeserializer.GetVerifier(authority)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve authority's identity")
+	}
+	v.pp.AuthorityID = authority
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize public parameters")
+	}
+	return raw, nil
 }
 
 func (v *PublicParamsManager) NewCertifierKeyPair() ([]byte, []byte, error) {
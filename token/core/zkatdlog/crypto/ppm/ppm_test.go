@@ -19,6 +19,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ecdsa"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/anonym"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ppm"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var _ = Describe("PublicParamsManager", func() {
@@ -85,6 +86,29 @@ var _ = Describe("PublicParamsManager", func() {
 		})
 	})
 
+	Describe("Set Certifier", func() {
+		When("SetCertifier is called correctly", func() {
+			It("succeeds", func() {
+				csigner, _ := prepareECDSASigner()
+				raw, err := csigner.Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				ppbytes, err := engine.SetCertifier(raw)
+				Expect(err).NotTo(HaveOccurred())
+				pp := &crypto.PublicParams{}
+				Expect(pp.Deserialize(ppbytes)).To(Succeed())
+				Expect(pp.Certifier).To(Equal(raw))
+			})
+		})
+		When("SetCertifier is called with invalid identity", func() {
+			It("fails", func() {
+				ppbytes, err := engine.SetCertifier([]byte("invalid certifier"))
+				Expect(err).To(HaveOccurred())
+				Expect(ppbytes).To(BeNil())
+				Expect(err.Error()).To(ContainSubstring("failed to retrieve certifier's identity"))
+			})
+		})
+	})
+
 	Describe("Add Issuer", func() {
 		Context("AddIssuer is called correctly to add a new anonymissuer", func() {
 			var (
@@ -104,6 +128,28 @@ var _ = Describe("PublicParamsManager", func() {
 			})
 		})
 	})
+
+	Describe("Register Token Type", func() {
+		When("RegisterTokenType is called with a valid type", func() {
+			It("succeeds", func() {
+				ppbytes, err := engine.RegisterTokenType(&token2.TypeInfo{Type: "USD", Symbol: "$", Decimals: 2, MaxSupply: "9999"})
+				Expect(err).NotTo(HaveOccurred())
+				pp := &crypto.PublicParams{}
+				Expect(pp.Deserialize(ppbytes)).To(Succeed())
+				Expect(pp.TokenTypes()).To(HaveLen(1))
+				Expect(pp.TokenTypes().Lookup("USD").Symbol).To(Equal("$"))
+				Expect(engine.PublicParameters().TokenTypes()).To(Equal(pp.TokenTypes()))
+			})
+		})
+		When("the max supply exceeds the precision", func() {
+			It("fails", func() {
+				ppbytes, err := engine.RegisterTokenType(&token2.TypeInfo{Type: "USD", MaxSupply: "100000"})
+				Expect(err).To(HaveOccurred())
+				Expect(ppbytes).To(BeNil())
+				Expect(err.Error()).To(ContainSubstring("invalid max supply for token type [USD]"))
+			})
+		})
+	})
 })
 
 func prepareECDSASigner() (*ecdsa.ECDSASigner, *ecdsa.ECDSAVerifier) {
@@ -0,0 +1,380 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package rangeproof
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+)
+
+// Bulletproof shows that the value committed in a token is in [0, 2^BitLength).
+// The range proof is computed for a commitment to the value only (ValueCommitment)
+// and an equality proof shows that the token hides the same value
+type Bulletproof struct {
+	ValueCommitment *bn256.G1
+	// commitments to the bits of the value and to their blinding vectors
+	A *bn256.G1
+	S *bn256.G1
+	// commitments to the coefficients of the polynomial t(X) = <l(X), r(X)>
+	T1 *bn256.G1
+	T2 *bn256.G1
+	// InnerProduct is the evaluation of t(X) at the challenge
+	InnerProduct      *bn256.Zr
+	InnerProductBF    *bn256.Zr
+	VectorBF          *bn256.Zr
+	InnerProductProof *InnerProductProof
+	EqualityProofs    *BulletproofEqualityProofs
+}
+
+// BulletproofEqualityProofs shows that the token minus the value commitment is a commitment to the type only
+type BulletproofEqualityProofs struct {
+	Type           *bn256.Zr
+	BlindingFactor *bn256.Zr
+	Challenge      *bn256.Zr
+}
+
+type BulletproofVerifier struct {
+	Token           []*bn256.G1
+	PedersenParams  []*bn256.G1
+	LeftGenerators  []*bn256.G1
+	RightGenerators []*bn256.G1
+	P               *bn256.G1
+	BitLength       int
+}
+
+type BulletproofProver struct {
+	*BulletproofVerifier
+	tokenWitness []*token.TokenDataWitness
+}
+
+func NewBulletproofProver(tw []*token.TokenDataWitness, token []*bn256.G1, pp []*bn256.G1, leftGens, rightGens []*bn256.G1, P *bn256.G1, bitLength int) *BulletproofProver {
+	return &BulletproofProver{
+		BulletproofVerifier: NewBulletproofVerifier(token, pp, leftGens, rightGens, P, bitLength),
+		tokenWitness:        tw,
+	}
+}
+
+func NewBulletproofVerifier(token []*bn256.G1, pp []*bn256.G1, leftGens, rightGens []*bn256.G1, P *bn256.G1, bitLength int) *BulletproofVerifier {
+	return &BulletproofVerifier{
+		Token:           token,
+		PedersenParams:  pp,
+		LeftGenerators:  leftGens,
+		RightGenerators: rightGens,
+		P:               P,
+		BitLength:       bitLength,
+	}
+}
+
+func (p *BulletproofProver) Prove() ([]byte, error) {
+	if len(p.tokenWitness) != len(p.Token) {
+		return nil, errors.Errorf("can't compute range proof: number of witnesses does not match number of tokens")
+	}
+	if len(p.LeftGenerators) != p.BitLength || len(p.RightGenerators) != p.BitLength {
+		return nil, errors.Errorf("can't compute range proof: invalid parameters")
+	}
+	proofs := make([]*Bulletproof, len(p.Token))
+	for k := 0; k < len(p.Token); k++ {
+		var err error
+		proofs[k], err = p.prove(p.tokenWitness[k], p.Token[k])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(proofs)
+}
+
+func (v *BulletproofVerifier) Verify(raw []byte) error {
+	var proofs []*Bulletproof
+	if err := json.Unmarshal(raw, &proofs); err != nil {
+		return errors.Wrap(err, "invalid range proof: cannot parse proof")
+	}
+	if len(proofs) != len(v.Token) {
+		return errors.Errorf("failed to verify range proof: expected [%d] proofs, got [%d]", len(v.Token), len(proofs))
+	}
+	if len(v.LeftGenerators) != v.BitLength || len(v.RightGenerators) != v.BitLength {
+		return errors.Errorf("failed to verify range proof: invalid parameters")
+	}
+	for k := 0; k < len(v.Token); k++ {
+		if err := v.verify(proofs[k], v.Token[k]); err != nil {
+			return errors.Wrapf(err, "failed to verify range proof for token [%d]", k)
+		}
+	}
+	return nil
+}
+
+func (p *BulletproofProver) prove(tw *token.TokenDataWitness, tok *bn256.G1) (*Bulletproof, error) {
+	value := (*big.Int)(tw.Value)
+	if value.Sign() < 0 || value.BitLen() > p.BitLength {
+		return nil, errors.Errorf("can't compute range proof: value of token outside authorized range")
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get random number generator")
+	}
+	g, h := p.PedersenParams[1], p.PedersenParams[2]
+	n := p.BitLength
+
+	// commit to the value only
+	bf := bn256.RandModOrder(rand)
+	proof := &Bulletproof{}
+	proof.ValueCommitment, err = common.ComputePedersenCommitment([]*bn256.Zr{tw.Value, bf}, []*bn256.G1{g, h})
+	if err != nil {
+		return nil, err
+	}
+
+	// commit to the bits of the value
+	left := make([]*bn256.Zr, n)
+	right := make([]*bn256.Zr, n)
+	leftBF := make([]*bn256.Zr, n)
+	rightBF := make([]*bn256.Zr, n)
+	for i := 0; i < n; i++ {
+		left[i] = bn256.NewZrInt(int(value.Bit(i)))
+		right[i] = bn256.ModSub(left[i], bn256.NewZrInt(1), bn256.Order)
+		leftBF[i] = bn256.RandModOrder(rand)
+		rightBF[i] = bn256.RandModOrder(rand)
+	}
+	alpha := bn256.RandModOrder(rand)
+	rho := bn256.RandModOrder(rand)
+	proof.A, err = p.commitVectors(left, right, alpha)
+	if err != nil {
+		return nil, err
+	}
+	proof.S, err = p.commitVectors(leftBF, rightBF, rho)
+	if err != nil {
+		return nil, err
+	}
+
+	y, z := p.computeVectorChallenges(tok, proof)
+	yPowers := powers(y, n)
+	twoPowers := powers(bn256.NewZrInt(2), n)
+	z2 := bn256.ModMul(z, z, bn256.Order)
+
+	// l(X) = left - z + leftBF*X
+	// r(X) = y^n o (right + z + rightBF*X) + z^2*2^n
+	l0 := make([]*bn256.Zr, n)
+	r0 := make([]*bn256.Zr, n)
+	r1 := make([]*bn256.Zr, n)
+	for i := 0; i < n; i++ {
+		l0[i] = bn256.ModSub(left[i], z, bn256.Order)
+		r0[i] = bn256.ModAdd(
+			bn256.ModMul(yPowers[i], bn256.ModAdd(right[i], z, bn256.Order), bn256.Order),
+			bn256.ModMul(z2, twoPowers[i], bn256.Order),
+			bn256.Order,
+		)
+		r1[i] = bn256.ModMul(yPowers[i], rightBF[i], bn256.Order)
+	}
+	t1 := bn256.ModAdd(innerProduct(l0, r1), innerProduct(leftBF, r0), bn256.Order)
+	t2 := innerProduct(leftBF, r1)
+	tau1 := bn256.RandModOrder(rand)
+	tau2 := bn256.RandModOrder(rand)
+	proof.T1, err = common.ComputePedersenCommitment([]*bn256.Zr{t1, tau1}, []*bn256.G1{g, h})
+	if err != nil {
+		return nil, err
+	}
+	proof.T2, err = common.ComputePedersenCommitment([]*bn256.Zr{t2, tau2}, []*bn256.G1{g, h})
+	if err != nil {
+		return nil, err
+	}
+
+	x := p.computePolynomialChallenge(z, proof)
+	l := make([]*bn256.Zr, n)
+	r := make([]*bn256.Zr, n)
+	for i := 0; i < n; i++ {
+		l[i] = bn256.ModAdd(l0[i], bn256.ModMul(leftBF[i], x, bn256.Order), bn256.Order)
+		r[i] = bn256.ModAdd(r0[i], bn256.ModMul(r1[i], x, bn256.Order), bn256.Order)
+	}
+	proof.InnerProduct = innerProduct(l, r)
+	proof.InnerProductBF = bn256.ModAdd(
+		bn256.ModAdd(bn256.ModMul(tau2, bn256.ModMul(x, x, bn256.Order), bn256.Order), bn256.ModMul(tau1, x, bn256.Order), bn256.Order),
+		bn256.ModMul(z2, bf, bn256.Order),
+		bn256.Order,
+	)
+	proof.VectorBF = bn256.ModAdd(alpha, bn256.ModMul(rho, x, bn256.Order), bn256.Order)
+
+	// show that l and r are the vectors committed in A and S with inner product t(x)
+	w := p.computeInnerProductChallenge(x, proof)
+	P := p.P.Mul(w)
+	rightGens := p.rightGenerators(y)
+	com, err := common.ComputePedersenCommitment(
+		common.GetZrArray(l, r, []*bn256.Zr{proof.InnerProduct}),
+		common.GetG1Array(p.LeftGenerators, rightGens, []*bn256.G1{P}).Elements,
+	)
+	if err != nil {
+		return nil, err
+	}
+	proof.InnerProductProof, err = newInnerProductProver(l, r, p.LeftGenerators, rightGens, P, com, w.Bytes()).prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't compute range proof")
+	}
+
+	// show that the token and the value commitment hide the same value
+	proof.EqualityProofs, err = p.proveEquality(tw, tok, proof.ValueCommitment, bf)
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (v *BulletproofVerifier) verify(proof *Bulletproof, tok *bn256.G1) error {
+	if proof == nil || proof.ValueCommitment == nil || proof.A == nil || proof.S == nil || proof.T1 == nil || proof.T2 == nil ||
+		proof.InnerProduct == nil || proof.InnerProductBF == nil || proof.VectorBF == nil || proof.EqualityProofs == nil {
+		return errors.New("invalid range proof: missing values")
+	}
+	g, h := v.PedersenParams[1], v.PedersenParams[2]
+	n := v.BitLength
+
+	y, z := v.computeVectorChallenges(tok, proof)
+	x := v.computePolynomialChallenge(z, proof)
+	w := v.computeInnerProductChallenge(x, proof)
+	yPowers := powers(y, n)
+	twoPowers := powers(bn256.NewZrInt(2), n)
+	z2 := bn256.ModMul(z, z, bn256.Order)
+	z3 := bn256.ModMul(z2, z, bn256.Order)
+
+	// check that t(x) is consistent with the value commitment and T1, T2
+	// delta = (z - z^2)*<1, y^n> - z^3*<1, 2^n>
+	delta := bn256.ModSub(
+		bn256.ModMul(bn256.ModSub(z, z2, bn256.Order), bn256.Sum(yPowers), bn256.Order),
+		bn256.ModMul(z3, bn256.Sum(twoPowers), bn256.Order),
+		bn256.Order,
+	)
+	lhs, err := common.ComputePedersenCommitment([]*bn256.Zr{proof.InnerProduct, proof.InnerProductBF}, []*bn256.G1{g, h})
+	if err != nil {
+		return err
+	}
+	rhs, err := common.ComputePedersenCommitment(
+		[]*bn256.Zr{z2, delta, x, bn256.ModMul(x, x, bn256.Order)},
+		[]*bn256.G1{proof.ValueCommitment, g, proof.T1, proof.T2},
+	)
+	if err != nil {
+		return err
+	}
+	if !lhs.Equals(rhs) {
+		return errors.New("invalid range proof: polynomial commitment does not match")
+	}
+
+	// recompute the commitment to l(x) and r(x)
+	P := v.P.Mul(w)
+	rightGens := v.rightGenerators(y)
+	com := bn256.NewG1().Copy(proof.A)
+	com.Add(proof.S.Mul(x))
+	minusZ := bn256.ModNeg(z, bn256.Order)
+	for i := 0; i < n; i++ {
+		com.Add(v.LeftGenerators[i].Mul(minusZ))
+		com.Add(rightGens[i].Mul(bn256.ModAdd(bn256.ModMul(z, yPowers[i], bn256.Order), bn256.ModMul(z2, twoPowers[i], bn256.Order), bn256.Order)))
+	}
+	com.Sub(h.Mul(proof.VectorBF))
+	com.Add(P.Mul(proof.InnerProduct))
+	if err := newInnerProductVerifier(v.LeftGenerators, rightGens, P, com, w.Bytes()).verify(proof.InnerProductProof); err != nil {
+		return errors.Wrap(err, "invalid range proof")
+	}
+
+	return v.verifyEquality(proof.EqualityProofs, tok, proof.ValueCommitment)
+}
+
+func (p *BulletproofProver) proveEquality(tw *token.TokenDataWitness, tok, valueCommitment *bn256.G1, bf *bn256.Zr) (*BulletproofEqualityProofs, error) {
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get random number generator")
+	}
+	r := []*bn256.Zr{bn256.RandModOrder(rand), bn256.RandModOrder(rand)}
+	com, err := common.ComputePedersenCommitment(r, []*bn256.G1{p.PedersenParams[0], p.PedersenParams[2]})
+	if err != nil {
+		return nil, err
+	}
+	chal := common.ComputeChallenge(common.GetG1Array([]*bn256.G1{com, tok, valueCommitment}, p.PedersenParams))
+	sp := &common.SchnorrProver{
+		Witness:    []*bn256.Zr{bn256.HashModOrder([]byte(tw.Type)), bn256.ModSub(tw.BlindingFactor, bf, bn256.Order)},
+		Randomness: r,
+		Challenge:  chal,
+	}
+	proofs, err := sp.Prove()
+	if err != nil {
+		return nil, err
+	}
+	return &BulletproofEqualityProofs{Type: proofs[0], BlindingFactor: proofs[1], Challenge: chal}, nil
+}
+
+func (v *BulletproofVerifier) verifyEquality(proof *BulletproofEqualityProofs, tok, valueCommitment *bn256.G1) error {
+	if proof.Type == nil || proof.BlindingFactor == nil || proof.Challenge == nil {
+		return errors.New("invalid range proof: missing values")
+	}
+	// token - value commitment is a commitment to the type
+	statement := bn256.NewG1().Copy(tok)
+	statement.Sub(valueCommitment)
+	com := (&common.SchnorrVerifier{PedParams: []*bn256.G1{v.PedersenParams[0], v.PedersenParams[2]}}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: statement,
+		Proof:     []*bn256.Zr{proof.Type, proof.BlindingFactor},
+		Challenge: proof.Challenge,
+	})
+	chal := common.ComputeChallenge(common.GetG1Array([]*bn256.G1{com, tok, valueCommitment}, v.PedersenParams))
+	if chal.Cmp(proof.Challenge) != 0 {
+		return errors.New("invalid range proof: token does not match the value commitment")
+	}
+	return nil
+}
+
+func (p *BulletproofProver) commitVectors(left, right []*bn256.Zr, bf *bn256.Zr) (*bn256.G1, error) {
+	return common.ComputePedersenCommitment(
+		common.GetZrArray(left, right, []*bn256.Zr{bf}),
+		common.GetG1Array(p.LeftGenerators, p.RightGenerators, []*bn256.G1{p.PedersenParams[2]}).Elements,
+	)
+}
+
+// rightGenerators returns RightGenerators[i]^(y^-i)
+func (v *BulletproofVerifier) rightGenerators(y *bn256.Zr) []*bn256.G1 {
+	yInvPowers := powers(inverse(y), v.BitLength)
+	gens := make([]*bn256.G1, v.BitLength)
+	for i := 0; i < v.BitLength; i++ {
+		gens[i] = v.RightGenerators[i].Mul(yInvPowers[i])
+	}
+	return gens
+}
+
+func (v *BulletproofVerifier) computeVectorChallenges(tok *bn256.G1, proof *Bulletproof) (*bn256.Zr, *bn256.Zr) {
+	array := common.GetG1Array([]*bn256.G1{tok, proof.ValueCommitment, proof.A, proof.S, v.P}, v.PedersenParams, v.LeftGenerators, v.RightGenerators)
+	y := common.ComputeChallenge(array)
+	z := bn256.HashModOrder(common.GetBytesArray(y.Bytes(), array.Bytes()))
+	return y, z
+}
+
+func (v *BulletproofVerifier) computePolynomialChallenge(z *bn256.Zr, proof *Bulletproof) *bn256.Zr {
+	return bn256.HashModOrder(common.GetBytesArray(z.Bytes(), proof.T1.Bytes(), proof.T2.Bytes()))
+}
+
+func (v *BulletproofVerifier) computeInnerProductChallenge(x *bn256.Zr, proof *Bulletproof) *bn256.Zr {
+	return bn256.HashModOrder(common.GetBytesArray(x.Bytes(), proof.InnerProduct.Bytes(), proof.InnerProductBF.Bytes(), proof.VectorBF.Bytes()))
+}
+
+// powers returns 1, x, ..., x^(n-1)
+func powers(x *bn256.Zr, n int) []*bn256.Zr {
+	p := make([]*bn256.Zr, n)
+	p[0] = bn256.NewZrInt(1)
+	for i := 1; i < n; i++ {
+		p[i] = bn256.ModMul(p[i-1], x, bn256.Order)
+	}
+	return p
+}
+
+func innerProduct(a, b []*bn256.Zr) *bn256.Zr {
+	res := bn256.NewZrInt(0)
+	for i := 0; i < len(a); i++ {
+		res = bn256.ModAdd(res, bn256.ModMul(a[i], b[i], bn256.Order), bn256.Order)
+	}
+	return res
+}
+
+func inverse(x *bn256.Zr) *bn256.Zr {
+	inv := bn256.NewZrCopy(x)
+	inv.InvModP(bn256.Order)
+	return inv
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package rangeproof_test
+
+import (
+	"encoding/json"
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	rp "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/range"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+)
+
+var _ = Describe("bulletproof", func() {
+	var (
+		prover   *rp.BulletproofProver
+		verifier *rp.BulletproofVerifier
+	)
+	Context("when the values are in range", func() {
+		BeforeEach(func() {
+			prover = getBulletproofProver(64, 0, 1, 115, math.MaxUint64)
+			verifier = prover.BulletproofVerifier
+		})
+		It("succeeds", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proof).NotTo(BeNil())
+			err = verifier.Verify(proof)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+	Context("when a value is out of range", func() {
+		BeforeEach(func() {
+			prover = getBulletproofProver(8, 255, 256)
+		})
+		It("fails during proof generation", func() {
+			proof, err := prover.Prove()
+			Expect(proof).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("can't compute range proof: value of token outside authorized range"))
+		})
+	})
+	Context("when the proof is for a different token", func() {
+		BeforeEach(func() {
+			prover = getBulletproofProver(16, 10, 20)
+			verifier = prover.BulletproofVerifier
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			verifier.Token = []*bn256.G1{verifier.Token[1], verifier.Token[0]}
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to verify range proof for token [0]"))
+		})
+	})
+	Context("when the inner product proof is tampered with", func() {
+		BeforeEach(func() {
+			prover = getBulletproofProver(16, 10)
+			verifier = prover.BulletproofVerifier
+		})
+		It("fails", func() {
+			raw, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			var proofs []*rp.Bulletproof
+			Expect(json.Unmarshal(raw, &proofs)).To(Succeed())
+			proofs[0].InnerProductProof.Left = bn256.ModAdd(proofs[0].InnerProductProof.Left, bn256.NewZrInt(1), bn256.Order)
+			raw, err = json.Marshal(proofs)
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid inner product proof"))
+		})
+	})
+})
+
+func getBulletproofProver(bitLength int, values ...uint64) *rp.BulletproofProver {
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	pp := preparePedersenParameters()
+
+	leftGens := make([]*bn256.G1, bitLength)
+	rightGens := make([]*bn256.G1, bitLength)
+	for i := 0; i < bitLength; i++ {
+		leftGens[i] = bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+		rightGens[i] = bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+	}
+	P := bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+
+	tokens := make([]*bn256.G1, len(values))
+	tw := make([]*token.TokenDataWitness, len(values))
+	for i, v := range values {
+		tw[i] = &token.TokenDataWitness{Value: bn256.NewZr().SetUint64(v), Type: "ABC", BlindingFactor: bn256.RandModOrder(rand)}
+		tokens[i] = bn256.NewG1()
+		tokens[i].Add(pp[0].Mul(bn256.HashModOrder([]byte(tw[i].Type))))
+		tokens[i].Add(pp[1].Mul(tw[i].Value))
+		tokens[i].Add(pp[2].Mul(tw[i].BlindingFactor))
+	}
+
+	return rp.NewBulletproofProver(tw, tokens, pp, leftGens, rightGens, P, bitLength)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package rangeproof
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+)
+
+// InnerProductProof shows knowledge of two vectors left and right such that
+// Commitment = LeftGenerators^left * RightGenerators^right * P^<left, right>.
+// The proof contains 2*log(n) group elements, where n is the length of the vectors
+type InnerProductProof struct {
+	L     []*bn256.G1
+	R     []*bn256.G1
+	Left  *bn256.Zr
+	Right *bn256.Zr
+}
+
+type innerProductVerifier struct {
+	LeftGenerators  []*bn256.G1
+	RightGenerators []*bn256.G1
+	P               *bn256.G1
+	Commitment      *bn256.G1
+	// Seed binds the inner product argument to the proof that embeds it
+	Seed []byte
+}
+
+type innerProductProver struct {
+	*innerProductVerifier
+	left  []*bn256.Zr
+	right []*bn256.Zr
+}
+
+func newInnerProductProver(left, right []*bn256.Zr, leftGens, rightGens []*bn256.G1, P, com *bn256.G1, seed []byte) *innerProductProver {
+	return &innerProductProver{
+		innerProductVerifier: newInnerProductVerifier(leftGens, rightGens, P, com, seed),
+		left:                 left,
+		right:                right,
+	}
+}
+
+func newInnerProductVerifier(leftGens, rightGens []*bn256.G1, P, com *bn256.G1, seed []byte) *innerProductVerifier {
+	return &innerProductVerifier{
+		LeftGenerators:  leftGens,
+		RightGenerators: rightGens,
+		P:               P,
+		Commitment:      com,
+		Seed:            seed,
+	}
+}
+
+func (p *innerProductProver) prove() (*InnerProductProof, error) {
+	if len(p.left) != len(p.right) || len(p.left) != len(p.LeftGenerators) || len(p.left) != len(p.RightGenerators) {
+		return nil, errors.New("cannot compute inner product proof: lengths do not match")
+	}
+	left, right := p.left, p.right
+	leftGens, rightGens := p.LeftGenerators, p.RightGenerators
+	seed := p.Seed
+
+	proof := &InnerProductProof{}
+	for len(left) > 1 {
+		n := len(left) / 2
+		cL := innerProduct(left[:n], right[n:])
+		cR := innerProduct(left[n:], right[:n])
+		L, err := common.ComputePedersenCommitment(
+			common.GetZrArray(left[:n], right[n:], []*bn256.Zr{cL}),
+			common.GetG1Array(leftGens[n:], rightGens[:n], []*bn256.G1{p.P}).Elements,
+		)
+		if err != nil {
+			return nil, err
+		}
+		R, err := common.ComputePedersenCommitment(
+			common.GetZrArray(left[n:], right[:n], []*bn256.Zr{cR}),
+			common.GetG1Array(leftGens[:n], rightGens[n:], []*bn256.G1{p.P}).Elements,
+		)
+		if err != nil {
+			return nil, err
+		}
+		proof.L = append(proof.L, L)
+		proof.R = append(proof.R, R)
+
+		x := roundChallenge(seed, L, R)
+		xInv := inverse(x)
+		seed = x.Bytes()
+
+		leftGens, rightGens = foldGenerators(leftGens, rightGens, x, xInv)
+		nextLeft := make([]*bn256.Zr, n)
+		nextRight := make([]*bn256.Zr, n)
+		for i := 0; i < n; i++ {
+			nextLeft[i] = bn256.ModAdd(bn256.ModMul(left[i], x, bn256.Order), bn256.ModMul(left[n+i], xInv, bn256.Order), bn256.Order)
+			nextRight[i] = bn256.ModAdd(bn256.ModMul(right[i], xInv, bn256.Order), bn256.ModMul(right[n+i], x, bn256.Order), bn256.Order)
+		}
+		left, right = nextLeft, nextRight
+	}
+	proof.Left = left[0]
+	proof.Right = right[0]
+	return proof, nil
+}
+
+func (v *innerProductVerifier) verify(proof *InnerProductProof) error {
+	if proof == nil || proof.Left == nil || proof.Right == nil {
+		return errors.New("invalid inner product proof: missing values")
+	}
+	rounds := 0
+	for n := len(v.LeftGenerators); n > 1; n = n / 2 {
+		rounds++
+	}
+	if len(proof.L) != rounds || len(proof.R) != rounds || len(v.LeftGenerators) != len(v.RightGenerators) {
+		return errors.New("invalid inner product proof: lengths do not match")
+	}
+
+	leftGens, rightGens := v.LeftGenerators, v.RightGenerators
+	com := bn256.NewG1().Copy(v.Commitment)
+	seed := v.Seed
+	for i := 0; i < rounds; i++ {
+		if proof.L[i] == nil || proof.R[i] == nil {
+			return errors.New("invalid inner product proof: missing values")
+		}
+		x := roundChallenge(seed, proof.L[i], proof.R[i])
+		xInv := inverse(x)
+		seed = x.Bytes()
+
+		leftGens, rightGens = foldGenerators(leftGens, rightGens, x, xInv)
+		x2 := bn256.ModMul(x, x, bn256.Order)
+		com.Add(proof.L[i].Mul(x2))
+		com.Add(proof.R[i].Mul(bn256.ModMul(xInv, xInv, bn256.Order)))
+	}
+
+	expected, err := common.ComputePedersenCommitment(
+		[]*bn256.Zr{proof.Left, proof.Right, bn256.ModMul(proof.Left, proof.Right, bn256.Order)},
+		[]*bn256.G1{leftGens[0], rightGens[0], v.P},
+	)
+	if err != nil {
+		return err
+	}
+	if !expected.Equals(com) {
+		return errors.New("invalid inner product proof")
+	}
+	return nil
+}
+
+// foldGenerators halves the generators at each round of the inner product argument
+func foldGenerators(leftGens, rightGens []*bn256.G1, x, xInv *bn256.Zr) ([]*bn256.G1, []*bn256.G1) {
+	n := len(leftGens) / 2
+	nextLeft := make([]*bn256.G1, n)
+	nextRight := make([]*bn256.G1, n)
+	for i := 0; i < n; i++ {
+		nextLeft[i] = leftGens[i].Mul(xInv)
+		nextLeft[i].Add(leftGens[n+i].Mul(x))
+		nextRight[i] = rightGens[i].Mul(x)
+		nextRight[i].Add(rightGens[n+i].Mul(xInv))
+	}
+	return nextLeft, nextRight
+}
+
+func roundChallenge(seed []byte, L, R *bn256.G1) *bn256.Zr {
+	return bn256.HashModOrder(common.GetBytesArray(seed, L.Bytes(), R.Bytes()))
+}
@@ -8,16 +8,19 @@ package crypto
 import (
 	"encoding/json"
 	math2 "math"
+	"math/big"
 
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 const (
-	DLogPublicParameters = "zkatdlog"
+	DLogPublicParameters            = "zkatdlog"
+	DLogGraphHidingPublicParameters = "zkatdloggh"
 )
 
 type PublicParams struct {
@@ -27,6 +30,17 @@ type PublicParams struct {
 	IdemixPK         []byte
 	IssuingPolicy    []byte
 	Auditor          []byte
+	// Certifier is the identity of the certifier of tokens, if any
+	Certifier []byte `json:",omitempty"`
+	// AuthorityID is the identity of the authority that can freeze and seize tokens, if any
+	AuthorityID []byte `json:"Authority,omitempty"`
+	// SerialNumberParams is set only when the public parameters enable graph hiding
+	SerialNumberParams *SerialNumberParams `json:",omitempty"`
+	// BulletproofParams is set when range proofs are bulletproofs instead of PS signature based proofs
+	BulletproofParams *BulletproofParams `json:",omitempty"`
+	// TypeRegistry lists the registered token types.
+	// When empty, any token type can be issued.
+	TypeRegistry token2.TypeRegistry `json:",omitempty"`
 }
 
 type RangeProofParams struct {
@@ -36,6 +50,29 @@ type RangeProofParams struct {
 	Exponent     int
 }
 
+// BulletproofParams contains the generators used by range proofs of logarithmic size
+type BulletproofParams struct {
+	// LeftGenerators and RightGenerators are used to commit to the vectors of the inner product argument
+	LeftGenerators  []*bn256.G1
+	RightGenerators []*bn256.G1
+	// P is the generator used to commit to the inner product
+	P *bn256.G1
+	// BitLength is the number of bits of a token value
+	BitLength int
+}
+
+// SerialNumberParams contains the parameters used to spend tokens by revealing serial numbers
+type SerialNumberParams struct {
+	// Generators are used to commit to the owner and to the serial number nonce of a token
+	Generators []*bn256.G1
+	// Base is the generator used to compute serial numbers
+	Base *bn256.G1
+	// MembershipBase is the generator used by the membership proof
+	MembershipBase *bn256.G1
+	// BitLength is the logarithm of the size of the anonymity set of each spent token
+	BitLength int
+}
+
 func NewPublicParamsFromBytes(raw []byte) (*PublicParams, error) {
 	pp := &PublicParams{}
 	if err := pp.Deserialize(raw); err != nil {
@@ -45,11 +82,18 @@ func NewPublicParamsFromBytes(raw []byte) (*PublicParams, error) {
 }
 
 func (pp *PublicParams) Identifier() string {
+	if pp.GraphHiding() {
+		return DLogGraphHidingPublicParameters
+	}
 	return DLogPublicParameters
 }
 
 func (pp *PublicParams) CertificationDriver() string {
-	return DLogPublicParameters
+	return pp.Identifier()
+}
+
+func (pp *PublicParams) Authority() []byte {
+	return pp.AuthorityID
 }
 
 func (pp *PublicParams) TokenDataHiding() bool {
@@ -57,11 +101,66 @@ func (pp *PublicParams) TokenDataHiding() bool {
 }
 
 func (pp *PublicParams) GraphHiding() bool {
-	return false
+	return pp.SerialNumberParams != nil
 }
 
+// MaxTokenValue returns the largest value covered by the range proofs
 func (pp *PublicParams) MaxTokenValue() uint64 {
-	return uint64(len(pp.RangeProofParams.SignedValues)) - 1
+	if pp.UseBulletproofs() {
+		if pp.BulletproofParams.BitLength >= 64 {
+			return math2.MaxUint64
+		}
+		return 1<<uint(pp.BulletproofParams.BitLength) - 1
+	}
+	max := pp.maxRangeProofValue()
+	if !max.IsUint64() {
+		return math2.MaxUint64
+	}
+	return max.Uint64()
+}
+
+// Precision returns the number of bits of token quantities.
+// It is bounded by the range proofs, and it is never larger than 64.
+// Not all the quantities of that many bits are covered by the range proofs, see ToValue.
+func (pp *PublicParams) Precision() uint64 {
+	if pp.UseBulletproofs() {
+		return uint64(pp.BulletproofParams.BitLength)
+	}
+	bits := uint64(pp.maxRangeProofValue().BitLen())
+	if bits > 64 {
+		return 64
+	}
+	return bits
+}
+
+// ToValue returns the passed quantity as a token value, if the range proofs cover it
+func (pp *PublicParams) ToValue(quantity string) (uint64, error) {
+	q, err := token2.ToQuantity(quantity, pp.Precision())
+	if err != nil {
+		return 0, err
+	}
+	v := q.ToBigInt()
+	if !v.IsUint64() || v.Uint64() > pp.MaxTokenValue() {
+		return 0, errors.Errorf("quantity [%s] exceeds the maximum token value [%d]", quantity, pp.MaxTokenValue())
+	}
+	return v.Uint64(), nil
+}
+
+// maxRangeProofValue returns the largest value covered by the range proofs without bulletproofs,
+// that show that a value has Exponent digits in base len(SignedValues)
+func (pp *PublicParams) maxRangeProofValue() *big.Int {
+	base := big.NewInt(int64(len(pp.RangeProofParams.SignedValues)))
+	max := new(big.Int).Exp(base, big.NewInt(int64(pp.RangeProofParams.Exponent)), nil)
+	return max.Sub(max, big.NewInt(1))
+}
+
+func (pp *PublicParams) TokenTypes() token2.TypeRegistry {
+	return pp.TypeRegistry
+}
+
+// UseBulletproofs returns true if range proofs are bulletproofs
+func (pp *PublicParams) UseBulletproofs() bool {
+	return pp.BulletproofParams != nil
 }
 
 func (pp *PublicParams) Bytes() ([]byte, error) {
@@ -74,7 +173,7 @@ func (pp *PublicParams) Serialize() ([]byte, error) {
 		return nil, err
 	}
 	return json.Marshal(&driver.SerializedPublicParameters{
-		Identifier: DLogPublicParameters,
+		Identifier: pp.Identifier(),
 		Raw:        raw,
 	})
 }
@@ -84,7 +183,7 @@ func (pp *PublicParams) Deserialize(raw []byte) error {
 	if err := json.Unmarshal(raw, publicParams); err != nil {
 		return err
 	}
-	if publicParams.Identifier != DLogPublicParameters {
+	if publicParams.Identifier != DLogPublicParameters && publicParams.Identifier != DLogGraphHidingPublicParameters {
 		return errors.Errorf("invalid identifier, expecting 'dlog', got [%s]", publicParams.Identifier)
 	}
 	// logger.Debugf("unmarshall zkatdlog public params [%s]", string(publicParams.Raw))
@@ -105,6 +204,47 @@ func (pp *PublicParams) GeneratePedersenParameters() error {
 	return nil
 }
 
+func (pp *PublicParams) GenerateSerialNumberParameters(bitLength int) error {
+	if bitLength <= 0 {
+		return errors.Errorf("invalid bit length for the anonymity set [%d]", bitLength)
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return errors.Errorf("failed to get RNG")
+	}
+	pp.SerialNumberParams = &SerialNumberParams{
+		Generators:     make([]*bn256.G1, 2),
+		Base:           bn256.G1Gen().Mul(bn256.RandModOrder(rand)),
+		MembershipBase: bn256.G1Gen().Mul(bn256.RandModOrder(rand)),
+		BitLength:      bitLength,
+	}
+	for i := 0; i < len(pp.SerialNumberParams.Generators); i++ {
+		pp.SerialNumberParams.Generators[i] = bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+	}
+	return nil
+}
+
+func (pp *PublicParams) GenerateBulletproofParameters(bitLength int) error {
+	if bitLength <= 0 || bitLength > 64 || bitLength&(bitLength-1) != 0 {
+		return errors.Errorf("invalid bit length for range proofs [%d], expected a power of 2 not larger than 64", bitLength)
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return errors.Errorf("failed to get RNG")
+	}
+	pp.BulletproofParams = &BulletproofParams{
+		LeftGenerators:  make([]*bn256.G1, bitLength),
+		RightGenerators: make([]*bn256.G1, bitLength),
+		P:               bn256.G1Gen().Mul(bn256.RandModOrder(rand)),
+		BitLength:       bitLength,
+	}
+	for i := 0; i < bitLength; i++ {
+		pp.BulletproofParams.LeftGenerators[i] = bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+		pp.BulletproofParams.RightGenerators[i] = bn256.G1Gen().Mul(bn256.RandModOrder(rand))
+	}
+	return nil
+}
+
 func (pp *PublicParams) GenerateRangeProofParameters(signer *pssign.Signer, maxValue int64) error {
 	pp.RangeProofParams = &RangeProofParams{Q: signer.Q, SignPK: signer.PK}
 
@@ -192,3 +332,38 @@ func Setup(base int64, exponent int, nymPK []byte) (*PublicParams, error) {
 	// max value of any given token is max = base^exponent - 1
 	return pp, nil
 }
+
+// SetupWithBulletproofs generates public parameters whose range proofs are bulletproofs.
+// The max value of any given token is 2^bitLength - 1.
+func SetupWithBulletproofs(bitLength int, nymPK []byte) (*PublicParams, error) {
+	pp := &PublicParams{}
+	err := pp.GeneratePedersenParameters()
+	if err != nil {
+		return nil, err
+	}
+	err = pp.GenerateBulletproofParameters(bitLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed generating bulletproof parameters")
+	}
+	// empty issuing policy
+	ip := &IssuingPolicy{}
+	pp.IssuingPolicy, err = ip.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	pp.IdemixPK = nymPK
+	return pp, nil
+}
+
+// SetupGraphHiding generates public parameters whose transfers hide the transaction graph.
+// Each spent token is hidden in an anonymity set of 2^bitLength token commitments.
+func SetupGraphHiding(base int64, exponent int, nymPK []byte, bitLength int) (*PublicParams, error) {
+	pp, err := Setup(base, exponent, nymPK)
+	if err != nil {
+		return nil, err
+	}
+	if err := pp.GenerateSerialNumberParameters(bitLength); err != nil {
+		return nil, errors.Wrap(err, "failed generating serial number parameters")
+	}
+	return pp, nil
+}
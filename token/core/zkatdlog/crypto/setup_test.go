@@ -7,6 +7,7 @@ package crypto
 
 import (
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -15,8 +16,39 @@ import (
 
 func TestSetup(t *testing.T) {
 	s := time.Now()
-	_, err := Setup(100, 2, nil)
+	pp, err := Setup(100, 2, nil)
 	e := time.Now()
 	fmt.Printf("elapsed %d", e.Sub(s).Milliseconds())
 	assert.NoError(t, err)
+	// values up to 100^2-1 = 9999 fit 14 bits
+	assert.Equal(t, uint64(14), pp.Precision())
+	assert.Equal(t, uint64(9999), pp.MaxTokenValue())
+
+	v, err := pp.ToValue("9999")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9999), v)
+	// 10000 fits the precision but not the range proofs
+	_, err = pp.ToValue("10000")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum token value [9999]")
+	_, err = pp.ToValue("16384")
+	assert.Error(t, err)
+}
+
+func TestSetupWithBulletproofs(t *testing.T) {
+	pp, err := SetupWithBulletproofs(64, nil)
+	assert.NoError(t, err)
+	assert.True(t, pp.UseBulletproofs())
+	assert.Equal(t, uint64(math.MaxUint64), pp.MaxTokenValue())
+	assert.Equal(t, uint64(64), pp.Precision())
+
+	raw, err := pp.Serialize()
+	assert.NoError(t, err)
+	pp2, err := NewPublicParamsFromBytes(raw)
+	assert.NoError(t, err)
+	assert.True(t, pp2.UseBulletproofs())
+	assert.Len(t, pp2.BulletproofParams.LeftGenerators, 64)
+
+	_, err = SetupWithBulletproofs(63, nil)
+	assert.Error(t, err)
 }
@@ -0,0 +1,353 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sn
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Output is a token created by an issue or a transfer
+type Output struct {
+	// Token is stored on the ledger, it is nil when the output is redeemed
+	Token *Token
+	// Data is the commitment to type and value checked by the issue or transfer proof
+	Data *bn256.G1
+	// SerialNumberKey is the key handed out by the owner, it is nil when the output is redeemed
+	SerialNumberKey *bn256.G1 `json:",omitempty"`
+	// Proof shows that Token extends Data with an owner, the serial number key and a nonce
+	Proof []byte
+}
+
+// NewOutput returns an output owned by the passed owner, whose serial number is derived from the passed
+// serial number key of the owner and from a fresh nonce. The nonce is returned as well.
+func NewOutput(data *bn256.G1, owner []byte, key *bn256.G1, pp *crypto.PublicParams) (*Output, *bn256.Zr, error) {
+	if len(owner) == 0 {
+		// redeem
+		return &Output{Data: data}, nil, nil
+	}
+	if key == nil {
+		return nil, nil, errors.New("serial number key of the owner is missing")
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to get random number generator")
+	}
+	nonce := bn256.RandModOrder(rand)
+	tok := &Token{Data: CommitWithKey(data, owner, key, nonce, pp)}
+	proof, err := NewOutputProver(owner, nonce, tok.Data, data, key, pp).Prove()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Output{Token: tok, Data: data, SerialNumberKey: key, Proof: proof}, nonce, nil
+}
+
+func (o *Output) IsRedeem() bool {
+	return o.Token == nil
+}
+
+// Serialize returns the representation of the output stored on the ledger
+func (o *Output) Serialize() ([]byte, error) {
+	if o.IsRedeem() {
+		return (&Token{Data: o.Data}).Serialize()
+	}
+	return o.Token.Serialize()
+}
+
+// Verify checks that the ledger token is consistent with the commitment to type and value
+func (o *Output) Verify(pp *crypto.PublicParams) error {
+	if o.Data == nil {
+		return errors.New("invalid output: missing commitment")
+	}
+	if o.IsRedeem() {
+		return nil
+	}
+	if o.Token.Data == nil {
+		return errors.New("invalid output: missing token")
+	}
+	if o.SerialNumberKey == nil {
+		return errors.New("invalid output: missing serial number key")
+	}
+	return NewOutputVerifier(o.Token.Data, o.Data, o.SerialNumberKey, pp).Verify(o.Proof)
+}
+
+// IssueAction specifies an issue of one or more tokens whose owners are hidden
+type IssueAction struct {
+	Issuer []byte
+	// Outputs are the newly issued tokens
+	Outputs []*Output
+	// ZK proof over the commitments to type and value
+	Proof []byte
+	// flag to indicate type of issue
+	Anonymous bool
+	// NFTs describes the non-fungible tokens created by this action, one for each output
+	NFTs []*token2.NFT `json:",omitempty"`
+	// NFTProof shows that the commitments to type and value open to the non-fungible tokens
+	NFTProof []byte `json:",omitempty"`
+}
+
+// NewIssueAction hides the owners of the outputs of the passed issue action.
+// keys are the serial number keys of the owners, one for each output.
+// The nonces and serial number keys of the new tokens are added to the passed token information.
+func NewIssueAction(action *issue.IssueAction, infos []*token.TokenInformation, keys []*bn256.G1, pp *crypto.PublicParams) (*IssueAction, error) {
+	if len(action.OutputTokens) != len(infos) {
+		return nil, errors.Errorf("number of outputs does not match number of token information")
+	}
+	if len(action.OutputTokens) != len(keys) {
+		return nil, errors.Errorf("number of outputs does not match number of serial number keys")
+	}
+	outputs := make([]*Output, len(action.OutputTokens))
+	for i, t := range action.OutputTokens {
+		if len(t.Owner) == 0 {
+			return nil, errors.Errorf("all recipients should be defined")
+		}
+		var err error
+		outputs[i], infos[i].Nonce, err = NewOutput(t.Data, t.Owner, keys[i], pp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed generating output [%d]", i)
+		}
+		infos[i].SerialNumberKey = keys[i]
+	}
+	return &IssueAction{
+		Issuer:    action.Issuer,
+		Outputs:   outputs,
+		Proof:     action.Proof,
+		Anonymous: action.Anonymous,
+		NFTs:      action.NFTs,
+		NFTProof:  action.NFTProof,
+	}, nil
+}
+
+func (i *IssueAction) GetProof() []byte {
+	return i.Proof
+}
+
+func (i *IssueAction) IsAnonymous() bool {
+	return i.Anonymous
+}
+
+func (i *IssueAction) Serialize() ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (i *IssueAction) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, i)
+}
+
+func (i *IssueAction) NumOutputs() int {
+	return len(i.Outputs)
+}
+
+func (i *IssueAction) GetOutputs() []driver.Output {
+	var res []driver.Output
+	for _, output := range i.Outputs {
+		res = append(res, output)
+	}
+	return res
+}
+
+func (i *IssueAction) GetSerializedOutputs() ([][]byte, error) {
+	var res [][]byte
+	for _, output := range i.Outputs {
+		r, err := output.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (i *IssueAction) GetIssuer() []byte {
+	return i.Issuer
+}
+
+func (i *IssueAction) GetNFTs() []*token2.NFT {
+	return i.NFTs
+}
+
+func (i *IssueAction) GetTokenTypes() []string {
+	return issue.TokenTypes(i.Anonymous, i.Proof)
+}
+
+// GetIssuedQuantities returns nil, quantities are hidden
+func (i *IssueAction) GetIssuedQuantities() (map[string]token2.Quantity, error) {
+	return nil, nil
+}
+
+func (i *IssueAction) GetCommitments() []*bn256.G1 {
+	com := make([]*bn256.G1, len(i.Outputs))
+	for j := 0; j < len(com); j++ {
+		com[j] = i.Outputs[j].Data
+	}
+	return com
+}
+
+// Input is a token spent by revealing its serial number
+type Input struct {
+	// AnonymitySet contains the keys of the ledger tokens among which the spent token is hidden
+	AnonymitySet []string
+	SerialNumber *bn256.G1
+	// Commitment is a re-randomized commitment to the spent token
+	Commitment *bn256.G1
+	// InputCommitment is the commitment to type and value checked by the transfer proof
+	InputCommitment *bn256.G1
+	// OwnerCommitment is a commitment to the owner of the spent token, opened to the auditor only
+	OwnerCommitment *bn256.G1
+	// Proof is a SpendProof bound to the message returned by TransferAction.SpendMessage
+	Proof []byte
+}
+
+// VerifyOwner checks that the owner commitment of the input opens to the passed owner
+func (in *Input) VerifyOwner(opening *OwnerOpening, pp *crypto.PublicParams) error {
+	if in.OwnerCommitment == nil {
+		return errors.New("invalid input: missing owner commitment")
+	}
+	if opening == nil || opening.BlindingFactor == nil {
+		return errors.New("invalid owner opening: missing blinding factor")
+	}
+	com := pp.SerialNumberParams.Generators[0].Mul(bn256.HashModOrder(opening.Owner))
+	com.Add(pp.ZKATPedParams[2].Mul(opening.BlindingFactor))
+	if !com.Equals(in.OwnerCommitment) {
+		return errors.New("owner opening does not match the owner commitment")
+	}
+	return nil
+}
+
+// OwnerOpening opens the owner commitment of an input, the sender hands it out to the auditor
+type OwnerOpening struct {
+	Owner          []byte
+	BlindingFactor *bn256.Zr
+}
+
+func (o *OwnerOpening) Serialize() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func (o *OwnerOpening) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, o)
+}
+
+// TransferAction specifies a transfer of one or more tokens that does not reveal which tokens are spent
+type TransferAction struct {
+	Inputs  []*Input
+	Outputs []*Output
+	// ZK proof of the balance between the input commitments and the outputs
+	Proof []byte
+}
+
+// SpendMessage returns the message the spend proofs of the inputs are bound to.
+// It covers the passed binding, usually the transaction id, and the whole action except the spend proofs.
+func (t *TransferAction) SpendMessage(binding string) ([]byte, error) {
+	inputs := make([]*Input, len(t.Inputs))
+	for i, in := range t.Inputs {
+		inputs[i] = &Input{
+			AnonymitySet:    in.AnonymitySet,
+			SerialNumber:    in.SerialNumber,
+			Commitment:      in.Commitment,
+			InputCommitment: in.InputCommitment,
+			OwnerCommitment: in.OwnerCommitment,
+		}
+	}
+	raw, err := json.Marshal(&TransferAction{Inputs: inputs, Outputs: t.Outputs, Proof: t.Proof})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal transfer action")
+	}
+	return append(raw, []byte(binding)...), nil
+}
+
+// GetInputs returns the keys of the serial numbers of the spent tokens
+func (t *TransferAction) GetInputs() ([]string, error) {
+	var res []string
+	for _, in := range t.Inputs {
+		if in.SerialNumber == nil {
+			return nil, errors.New("invalid input: missing serial number")
+		}
+		key, err := SerialNumberKey(in.SerialNumber)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed creating serial number key")
+		}
+		res = append(res, key)
+	}
+	return res, nil
+}
+
+func (t *TransferAction) NumOutputs() int {
+	return len(t.Outputs)
+}
+
+func (t *TransferAction) GetOutputs() []driver.Output {
+	var res []driver.Output
+	for _, output := range t.Outputs {
+		res = append(res, output)
+	}
+	return res
+}
+
+func (t *TransferAction) IsRedeemAt(index int) bool {
+	return t.Outputs[index].IsRedeem()
+}
+
+func (t *TransferAction) SerializeOutputAt(index int) ([]byte, error) {
+	return t.Outputs[index].Serialize()
+}
+
+func (t *TransferAction) Serialize() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *TransferAction) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, t)
+}
+
+func (t *TransferAction) GetProof() []byte {
+	return t.Proof
+}
+
+func (t *TransferAction) GetSerializedOutputs() ([][]byte, error) {
+	var res [][]byte
+	for _, output := range t.Outputs {
+		r, err := output.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (t *TransferAction) GetInputCommitments() []*bn256.G1 {
+	com := make([]*bn256.G1, len(t.Inputs))
+	for i := 0; i < len(com); i++ {
+		com[i] = t.Inputs[i].InputCommitment
+	}
+	return com
+}
+
+func (t *TransferAction) GetOutputCommitments() []*bn256.G1 {
+	com := make([]*bn256.G1, len(t.Outputs))
+	for i := 0; i < len(com); i++ {
+		com[i] = t.Outputs[i].Data
+	}
+	return com
+}
+
+func (t *TransferAction) IsGraphHiding() bool {
+	return true
+}
+
+// GetNFTs returns nil, non-fungible tokens are not supported with graph hiding
+func (t *TransferAction) GetNFTs() []*token2.NFT {
+	return nil
+}
@@ -0,0 +1,353 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sn
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/o2omp"
+)
+
+// SpendProof shows that a spent token belongs to the anonymity set, that the serial number
+// is derived from the serial number secret of that token, that the input commitment
+// hides the same type and value, and that the owner commitment hides the owner of that token.
+// Knowledge of the serial number secret authorizes the spending, the proof is a signature of
+// knowledge on the message it is bound to.
+type SpendProof struct {
+	Membership          []byte
+	Type                *bn256.Zr
+	Value               *bn256.Zr
+	BlindingFactor      *bn256.Zr
+	Nonce               *bn256.Zr
+	InputBlindingFactor *bn256.Zr
+	Owner               *bn256.Zr
+	OwnerBlindingFactor *bn256.Zr
+	Challenge           *bn256.Zr
+}
+
+func (p *SpendProof) Serialize() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *SpendProof) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, p)
+}
+
+// SpendWitness contains the opening of the spent token
+type SpendWitness struct {
+	// Index of the spent token in the anonymity set
+	Index          int
+	Type           string
+	Value          *bn256.Zr
+	BlindingFactor *bn256.Zr
+	// Owner of the spent token
+	Owner []byte
+	// Nonce is the serial number secret of the spent token
+	Nonce *bn256.Zr
+	// CommitmentBlindingFactor is the blinding factor of the re-randomized token commitment
+	CommitmentBlindingFactor *bn256.Zr
+	// InputBlindingFactor is the blinding factor of the input commitment used in the transfer proof
+	InputBlindingFactor *bn256.Zr
+	// OwnerBlindingFactor is the blinding factor of the owner commitment
+	OwnerBlindingFactor *bn256.Zr
+}
+
+type SpendVerifier struct {
+	AnonymitySet []*bn256.G1
+	SerialNumber *bn256.G1
+	// Commitment is a re-randomized commitment to type, value, owner and serial number secret of the spent token
+	Commitment *bn256.G1
+	// InputCommitment is a commitment to type and value of the spent token
+	InputCommitment *bn256.G1
+	// OwnerCommitment is a commitment to the owner of the spent token
+	OwnerCommitment *bn256.G1
+	// Message the proof is bound to
+	Message []byte
+	PP      *crypto.PublicParams
+}
+
+type SpendProver struct {
+	*SpendVerifier
+	witness *SpendWitness
+}
+
+func NewSpendVerifier(anonymitySet []*bn256.G1, sn, com, inputCom, ownerCom *bn256.G1, message []byte, pp *crypto.PublicParams) *SpendVerifier {
+	return &SpendVerifier{
+		AnonymitySet:    anonymitySet,
+		SerialNumber:    sn,
+		Commitment:      com,
+		InputCommitment: inputCom,
+		OwnerCommitment: ownerCom,
+		Message:         message,
+		PP:              pp,
+	}
+}
+
+func NewSpendProver(witness *SpendWitness, anonymitySet []*bn256.G1, sn, com, inputCom, ownerCom *bn256.G1, message []byte, pp *crypto.PublicParams) *SpendProver {
+	return &SpendProver{
+		SpendVerifier: NewSpendVerifier(anonymitySet, sn, com, inputCom, ownerCom, message, pp),
+		witness:       witness,
+	}
+}
+
+func (p *SpendProver) Prove() ([]byte, error) {
+	if p.witness.Index < 0 || p.witness.Index >= len(p.AnonymitySet) {
+		return nil, errors.Errorf("cannot compute spend proof: invalid index [%d]", p.witness.Index)
+	}
+	// the spent token minus the re-randomized commitment is a commitment to zero
+	randomness := bn256.ModSub(p.witness.BlindingFactor, p.witness.CommitmentBlindingFactor, bn256.Order)
+	membership, err := o2omp.NewProver(
+		p.membershipCommitments(),
+		p.SerialNumber.Bytes(),
+		p.membershipParams(),
+		p.PP.SerialNumberParams.BitLength,
+		p.witness.Index,
+		randomness,
+	).Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate membership proof")
+	}
+
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get random number generator")
+	}
+	r := make([]*bn256.Zr, 7)
+	for i := 0; i < len(r); i++ {
+		r[i] = bn256.RandModOrder(rand)
+	}
+	ped := p.PP.ZKATPedParams
+
+	com, err := common.ComputePedersenCommitment(r[:5], p.commitmentBases())
+	if err != nil {
+		return nil, err
+	}
+	inCom, err := common.ComputePedersenCommitment([]*bn256.Zr{r[0], r[1], r[5]}, ped)
+	if err != nil {
+		return nil, err
+	}
+	ownerCom, err := common.ComputePedersenCommitment([]*bn256.Zr{r[3], r[6]}, p.ownerBases())
+	if err != nil {
+		return nil, err
+	}
+	// the serial number raised to the secret is the base
+	sn := p.SerialNumber.Mul(r[4])
+
+	chal := p.computeChallenge(com, inCom, ownerCom, sn, membership)
+	prover := &common.SchnorrProver{
+		Witness: []*bn256.Zr{
+			bn256.HashModOrder([]byte(p.witness.Type)),
+			p.witness.Value,
+			p.witness.CommitmentBlindingFactor,
+			bn256.HashModOrder(p.witness.Owner),
+			p.witness.Nonce,
+			p.witness.InputBlindingFactor,
+			p.witness.OwnerBlindingFactor,
+		},
+		Randomness: r,
+		Challenge:  chal,
+	}
+	z, err := prover.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate spend proof")
+	}
+
+	proof := &SpendProof{
+		Membership:          membership,
+		Type:                z[0],
+		Value:               z[1],
+		BlindingFactor:      z[2],
+		Owner:               z[3],
+		Nonce:               z[4],
+		InputBlindingFactor: z[5],
+		OwnerBlindingFactor: z[6],
+		Challenge:           chal,
+	}
+	return proof.Serialize()
+}
+
+func (v *SpendVerifier) Verify(raw []byte) error {
+	proof := &SpendProof{}
+	if err := proof.Deserialize(raw); err != nil {
+		return errors.Wrap(err, "invalid spend proof: cannot parse proof")
+	}
+	if proof.Type == nil || proof.Value == nil || proof.BlindingFactor == nil || proof.Owner == nil || proof.Nonce == nil ||
+		proof.InputBlindingFactor == nil || proof.OwnerBlindingFactor == nil || proof.Challenge == nil {
+		return errors.New("invalid spend proof: missing values")
+	}
+
+	err := o2omp.NewVerifier(
+		v.membershipCommitments(),
+		v.SerialNumber.Bytes(),
+		v.membershipParams(),
+		v.PP.SerialNumberParams.BitLength,
+	).Verify(proof.Membership)
+	if err != nil {
+		return errors.Wrap(err, "invalid spend proof: spent token is not in the anonymity set")
+	}
+
+	com := (&common.SchnorrVerifier{PedParams: v.commitmentBases()}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.Commitment,
+		Proof:     []*bn256.Zr{proof.Type, proof.Value, proof.BlindingFactor, proof.Owner, proof.Nonce},
+		Challenge: proof.Challenge,
+	})
+	inCom := (&common.SchnorrVerifier{PedParams: v.PP.ZKATPedParams}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.InputCommitment,
+		Proof:     []*bn256.Zr{proof.Type, proof.Value, proof.InputBlindingFactor},
+		Challenge: proof.Challenge,
+	})
+	ownerCom := (&common.SchnorrVerifier{PedParams: v.ownerBases()}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.OwnerCommitment,
+		Proof:     []*bn256.Zr{proof.Owner, proof.OwnerBlindingFactor},
+		Challenge: proof.Challenge,
+	})
+	sn := (&common.SchnorrVerifier{PedParams: []*bn256.G1{v.SerialNumber}}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.PP.SerialNumberParams.Base,
+		Proof:     []*bn256.Zr{proof.Nonce},
+		Challenge: proof.Challenge,
+	})
+
+	if v.computeChallenge(com, inCom, ownerCom, sn, proof.Membership).Cmp(proof.Challenge) != 0 {
+		return errors.New("invalid spend proof: serial number does not match the spent token")
+	}
+	return nil
+}
+
+// membershipCommitments returns the elements of the anonymity set minus the re-randomized commitment.
+// Only the element at the spent index is a commitment to zero.
+func (v *SpendVerifier) membershipCommitments() []*bn256.G1 {
+	coms := make([]*bn256.G1, len(v.AnonymitySet))
+	for i, t := range v.AnonymitySet {
+		coms[i] = bn256.NewG1().Copy(t)
+		coms[i].Sub(v.Commitment)
+	}
+	return coms
+}
+
+func (v *SpendVerifier) membershipParams() []*bn256.G1 {
+	return []*bn256.G1{v.PP.SerialNumberParams.MembershipBase, v.PP.ZKATPedParams[2]}
+}
+
+// commitmentBases returns the bases of type, value, blinding factor, owner and serial number secret
+// in the re-randomized commitment
+func (v *SpendVerifier) commitmentBases() []*bn256.G1 {
+	ped := v.PP.ZKATPedParams
+	gens := v.PP.SerialNumberParams.Generators
+	return []*bn256.G1{ped[0], ped[1], ped[2], gens[0], gens[1]}
+}
+
+// ownerBases returns the bases of owner and blinding factor in the owner commitment
+func (v *SpendVerifier) ownerBases() []*bn256.G1 {
+	return []*bn256.G1{v.PP.SerialNumberParams.Generators[0], v.PP.ZKATPedParams[2]}
+}
+
+func (v *SpendVerifier) computeChallenge(com, inCom, ownerCom, sn *bn256.G1, membership []byte) *bn256.Zr {
+	array := common.GetG1Array(
+		[]*bn256.G1{com, inCom, ownerCom, sn, v.Commitment, v.InputCommitment, v.OwnerCommitment, v.SerialNumber},
+		v.AnonymitySet,
+	)
+	return bn256.HashModOrder(common.GetBytesArray(array.Bytes(), membership, v.Message))
+}
+
+// OutputProof shows that a token commitment extends the commitment used in the
+// issue or transfer proof with an owner, a serial number key and a nonce
+type OutputProof struct {
+	Owner     *bn256.Zr
+	Nonce     *bn256.Zr
+	Challenge *bn256.Zr
+}
+
+func (p *OutputProof) Serialize() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *OutputProof) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, p)
+}
+
+type OutputVerifier struct {
+	// Token is the commitment stored on the ledger
+	Token *bn256.G1
+	// Data is the commitment to type and value
+	Data *bn256.G1
+	// Key is the serial number key of the owner
+	Key *bn256.G1
+	PP  *crypto.PublicParams
+}
+
+type OutputProver struct {
+	*OutputVerifier
+	owner []byte
+	nonce *bn256.Zr
+}
+
+func NewOutputVerifier(tok, data, key *bn256.G1, pp *crypto.PublicParams) *OutputVerifier {
+	return &OutputVerifier{Token: tok, Data: data, Key: key, PP: pp}
+}
+
+func NewOutputProver(owner []byte, nonce *bn256.Zr, tok, data, key *bn256.G1, pp *crypto.PublicParams) *OutputProver {
+	return &OutputProver{
+		OutputVerifier: NewOutputVerifier(tok, data, key, pp),
+		owner:          owner,
+		nonce:          nonce,
+	}
+}
+
+func (p *OutputProver) Prove() ([]byte, error) {
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get random number generator")
+	}
+	r := []*bn256.Zr{bn256.RandModOrder(rand), bn256.RandModOrder(rand)}
+	com, err := common.ComputePedersenCommitment(r, p.PP.SerialNumberParams.Generators)
+	if err != nil {
+		return nil, err
+	}
+	chal := p.computeChallenge(com)
+	prover := &common.SchnorrProver{
+		Witness:    []*bn256.Zr{bn256.HashModOrder(p.owner), p.nonce},
+		Randomness: r,
+		Challenge:  chal,
+	}
+	z, err := prover.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate output proof")
+	}
+	proof := &OutputProof{Owner: z[0], Nonce: z[1], Challenge: chal}
+	return proof.Serialize()
+}
+
+func (v *OutputVerifier) Verify(raw []byte) error {
+	proof := &OutputProof{}
+	if err := proof.Deserialize(raw); err != nil {
+		return errors.Wrap(err, "invalid output proof: cannot parse proof")
+	}
+	if proof.Owner == nil || proof.Nonce == nil || proof.Challenge == nil {
+		return errors.New("invalid output proof: missing values")
+	}
+	com := (&common.SchnorrVerifier{PedParams: v.PP.SerialNumberParams.Generators}).RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.statement(),
+		Proof:     []*bn256.Zr{proof.Owner, proof.Nonce},
+		Challenge: proof.Challenge,
+	})
+	if v.computeChallenge(com).Cmp(proof.Challenge) != 0 {
+		return errors.New("invalid output proof: token does not match the committed data")
+	}
+	return nil
+}
+
+func (v *OutputVerifier) statement() *bn256.G1 {
+	return bn256.NewG1().Copy(v.Token).Sub(v.Data).Sub(v.Key)
+}
+
+func (v *OutputVerifier) computeChallenge(com *bn256.G1) *bn256.Zr {
+	return common.ComputeChallenge(common.GetG1Array([]*bn256.G1{com, v.Token, v.Data, v.Key}))
+}
@@ -0,0 +1,263 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sn_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+)
+
+var _ = Describe("Spend proof", func() {
+	var (
+		pp       *crypto.PublicParams
+		prover   *sn.SpendProver
+		verifier *sn.SpendVerifier
+		set      []*bn256.G1
+		witness  *sn.SpendWitness
+		serial   *bn256.G1
+		com      *bn256.G1
+		inputCom *bn256.G1
+		ownerCom *bn256.G1
+		message  []byte
+	)
+	BeforeEach(func() {
+		var err error
+		pp, err = crypto.SetupGraphHiding(100, 2, nil, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		rand, err := bn256.GetRand()
+		Expect(err).NotTo(HaveOccurred())
+		witness = &sn.SpendWitness{
+			Index:                    2,
+			Type:                     "ABC",
+			Value:                    bn256.NewZrInt(50),
+			BlindingFactor:           bn256.RandModOrder(rand),
+			Owner:                    []byte("alice"),
+			Nonce:                    bn256.RandModOrder(rand),
+			CommitmentBlindingFactor: bn256.RandModOrder(rand),
+			InputBlindingFactor:      bn256.RandModOrder(rand),
+			OwnerBlindingFactor:      bn256.RandModOrder(rand),
+		}
+		set = prepareAnonymitySet(4, witness, witness.Owner, pp)
+		com, inputCom, ownerCom = prepareSpendCommitments(witness, pp)
+		serial = sn.SerialNumber(witness.Nonce, pp)
+		message = []byte("tx1")
+	})
+	Context("when the spent token is in the anonymity set", func() {
+		BeforeEach(func() {
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("succeeds", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifier.Verify(proof)).NotTo(HaveOccurred())
+		})
+	})
+	Context("when the owner does not match the spent token", func() {
+		BeforeEach(func() {
+			witness.Owner = []byte("bob")
+			com, inputCom, ownerCom = prepareSpendCommitments(witness, pp)
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spent token is not in the anonymity set"))
+		})
+	})
+	Context("when the owner commitment hides another owner", func() {
+		BeforeEach(func() {
+			bob := *witness
+			bob.Owner = []byte("bob")
+			_, _, ownerCom = prepareSpendCommitments(&bob, pp)
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serial number does not match the spent token"))
+		})
+	})
+	Context("when the proof is verified against another message", func() {
+		BeforeEach(func() {
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, []byte("tx2"), pp)
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serial number does not match the spent token"))
+		})
+	})
+	Context("when the serial number is computed like the serial number key", func() {
+		BeforeEach(func() {
+			serial = pp.SerialNumberParams.Base.Mul(witness.Nonce)
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serial number does not match the spent token"))
+		})
+	})
+	Context("when the serial number is not derived from the secret of the spent token", func() {
+		BeforeEach(func() {
+			rand, err := bn256.GetRand()
+			Expect(err).NotTo(HaveOccurred())
+			serial = sn.SerialNumber(bn256.RandModOrder(rand), pp)
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+			verifier = sn.NewSpendVerifier(set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("fails", func() {
+			proof, err := prover.Prove()
+			Expect(err).NotTo(HaveOccurred())
+			err = verifier.Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serial number does not match the spent token"))
+		})
+	})
+	Context("when the index is out of range", func() {
+		BeforeEach(func() {
+			witness.Index = 4
+			prover = sn.NewSpendProver(witness, set, serial, com, inputCom, ownerCom, message, pp)
+		})
+		It("fails", func() {
+			_, err := prover.Prove()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid index [4]"))
+		})
+	})
+})
+
+var _ = Describe("Output proof", func() {
+	var (
+		pp     *crypto.PublicParams
+		data   *bn256.G1
+		key    *bn256.G1
+		secret *bn256.Zr
+	)
+	BeforeEach(func() {
+		var err error
+		pp, err = crypto.SetupGraphHiding(100, 2, nil, 2)
+		Expect(err).NotTo(HaveOccurred())
+		rand, err := bn256.GetRand()
+		Expect(err).NotTo(HaveOccurred())
+		data, err = common.ComputePedersenCommitment(
+			[]*bn256.Zr{bn256.HashModOrder([]byte("ABC")), bn256.NewZrInt(10), bn256.RandModOrder(rand)},
+			pp.ZKATPedParams,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		key, secret, err = sn.NewSerialNumberKey(pp)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	Context("when the token extends the committed data", func() {
+		It("succeeds", func() {
+			output, nonce, err := sn.NewOutput(data, []byte("alice"), key, pp)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nonce).NotTo(BeNil())
+			Expect(output.IsRedeem()).To(BeFalse())
+			Expect(output.Verify(pp)).NotTo(HaveOccurred())
+
+			// only the owner knows the serial number secret of the token
+			Expect(output.Token.Data.Equals(sn.Commit(data, []byte("alice"), sn.SerialNumberSecret(secret, nonce), pp))).To(BeTrue())
+		})
+	})
+	Context("when the serial number key is missing", func() {
+		It("fails", func() {
+			_, _, err := sn.NewOutput(data, []byte("alice"), nil, pp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serial number key of the owner is missing"))
+		})
+	})
+	Context("when the serial number key is replaced", func() {
+		It("fails", func() {
+			output, _, err := sn.NewOutput(data, []byte("alice"), key, pp)
+			Expect(err).NotTo(HaveOccurred())
+			output.SerialNumberKey, _, err = sn.NewSerialNumberKey(pp)
+			Expect(err).NotTo(HaveOccurred())
+			err = output.Verify(pp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("token does not match the committed data"))
+		})
+	})
+	Context("when the output has no owner", func() {
+		It("is a redeem", func() {
+			output, nonce, err := sn.NewOutput(data, nil, nil, pp)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nonce).To(BeNil())
+			Expect(output.IsRedeem()).To(BeTrue())
+			Expect(output.Verify(pp)).NotTo(HaveOccurred())
+		})
+	})
+	Context("when the token does not extend the committed data", func() {
+		It("fails", func() {
+			output, _, err := sn.NewOutput(data, []byte("alice"), key, pp)
+			Expect(err).NotTo(HaveOccurred())
+			output.Data = bn256.NewG1().Copy(data).Add(pp.ZKATPedParams[1])
+			err = output.Verify(pp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("token does not match the committed data"))
+		})
+	})
+})
+
+func prepareAnonymitySet(size int, witness *sn.SpendWitness, owner []byte, pp *crypto.PublicParams) []*bn256.G1 {
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	set := make([]*bn256.G1, size)
+	for i := 0; i < size; i++ {
+		if i == witness.Index {
+			data, err := common.ComputePedersenCommitment(
+				[]*bn256.Zr{bn256.HashModOrder([]byte(witness.Type)), witness.Value, witness.BlindingFactor},
+				pp.ZKATPedParams,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			set[i] = sn.Commit(data, owner, witness.Nonce, pp)
+			continue
+		}
+		data, err := common.ComputePedersenCommitment(
+			[]*bn256.Zr{bn256.HashModOrder([]byte("ABC")), bn256.NewZrInt(20), bn256.RandModOrder(rand)},
+			pp.ZKATPedParams,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		set[i] = sn.Commit(data, []byte("bob"), bn256.RandModOrder(rand), pp)
+	}
+	return set
+}
+
+// prepareSpendCommitments returns the re-randomized commitment, the input commitment and the owner commitment of the witness
+func prepareSpendCommitments(witness *sn.SpendWitness, pp *crypto.PublicParams) (*bn256.G1, *bn256.G1, *bn256.G1) {
+	typ := bn256.HashModOrder([]byte(witness.Type))
+	owner := bn256.HashModOrder(witness.Owner)
+	gens := pp.SerialNumberParams.Generators
+	com, err := common.ComputePedersenCommitment(
+		[]*bn256.Zr{typ, witness.Value, witness.CommitmentBlindingFactor, owner, witness.Nonce},
+		[]*bn256.G1{pp.ZKATPedParams[0], pp.ZKATPedParams[1], pp.ZKATPedParams[2], gens[0], gens[1]},
+	)
+	Expect(err).NotTo(HaveOccurred())
+	inputCom, err := common.ComputePedersenCommitment([]*bn256.Zr{typ, witness.Value, witness.InputBlindingFactor}, pp.ZKATPedParams)
+	Expect(err).NotTo(HaveOccurred())
+	ownerCom, err := common.ComputePedersenCommitment([]*bn256.Zr{owner, witness.OwnerBlindingFactor}, []*bn256.G1{gens[0], pp.ZKATPedParams[2]})
+	Expect(err).NotTo(HaveOccurred())
+	return com, inputCom, ownerCom
+}
@@ -0,0 +1,163 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sn
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+)
+
+// SpentToken contains what the sender needs to know to spend a token
+type SpentToken struct {
+	// AnonymitySet contains the keys of the ledger tokens among which the spent token is hidden
+	AnonymitySet []string
+	// AnonymitySetTokens are the ledger tokens stored under the keys in AnonymitySet
+	AnonymitySetTokens []*Token
+	// Index of the spent token in the anonymity set
+	Index int
+	// Information is the opening of the spent token
+	Information *token.TokenInformation
+	// KeySecret is the secret behind the serial number key in Information, known to the owner only
+	KeySecret *bn256.Zr
+}
+
+type Sender struct {
+	Inputs       []*SpentToken
+	PublicParams *crypto.PublicParams
+}
+
+func NewSender(inputs []*SpentToken, pp *crypto.PublicParams) (*Sender, error) {
+	if !pp.GraphHiding() {
+		return nil, errors.Errorf("public parameters do not support graph hiding")
+	}
+	for i, in := range inputs {
+		if len(in.AnonymitySet) != 1<<uint(pp.SerialNumberParams.BitLength) || len(in.AnonymitySet) != len(in.AnonymitySetTokens) {
+			return nil, errors.Errorf("invalid anonymity set for input [%d]", i)
+		}
+		if in.Information == nil || in.Information.Nonce == nil || in.Information.SerialNumberKey == nil {
+			return nil, errors.Errorf("invalid opening for input [%d]", i)
+		}
+		if in.KeySecret == nil || !pp.SerialNumberParams.Generators[1].Mul(in.KeySecret).Equals(in.Information.SerialNumberKey) {
+			return nil, errors.Errorf("invalid serial number key secret for input [%d]", i)
+		}
+	}
+	return &Sender{Inputs: inputs, PublicParams: pp}, nil
+}
+
+// GenerateZKTransfer returns a transfer of the inputs to the passed owners, whose spend proofs are bound to the passed binding.
+// keys are the serial number keys of the owners, one for each output, nil for the redeemed ones.
+// It returns also the openings of the outputs and the openings of the owner commitments of the inputs.
+func (s *Sender) GenerateZKTransfer(values []uint64, owners [][]byte, keys []*bn256.G1, binding string) (*TransferAction, []*token.TokenInformation, []*OwnerOpening, error) {
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, nil, nil, errors.Errorf("failed to get random number generator")
+	}
+	pp := s.PublicParams
+	ttype := s.Inputs[0].Information.Type
+
+	inputs := make([]*Input, len(s.Inputs))
+	inCom := make([]*bn256.G1, len(s.Inputs))
+	intw := make([]*token.TokenDataWitness, len(s.Inputs))
+	witnesses := make([]*SpendWitness, len(s.Inputs))
+	openings := make([]*OwnerOpening, len(s.Inputs))
+	for i, in := range s.Inputs {
+		inf := in.Information
+		secret := SerialNumberSecret(in.KeySecret, inf.Nonce)
+		w := &SpendWitness{
+			Index:                    in.Index,
+			Type:                     inf.Type,
+			Value:                    inf.Value,
+			BlindingFactor:           inf.BlindingFactor,
+			Owner:                    inf.Owner,
+			Nonce:                    secret,
+			CommitmentBlindingFactor: bn256.RandModOrder(rand),
+			InputBlindingFactor:      bn256.RandModOrder(rand),
+			OwnerBlindingFactor:      bn256.RandModOrder(rand),
+		}
+		typeHash := bn256.HashModOrder([]byte(inf.Type))
+		ownerHash := bn256.HashModOrder(inf.Owner)
+		gens := pp.SerialNumberParams.Generators
+		com, err := common.ComputePedersenCommitment(
+			[]*bn256.Zr{typeHash, inf.Value, w.CommitmentBlindingFactor, ownerHash, secret},
+			[]*bn256.G1{pp.ZKATPedParams[0], pp.ZKATPedParams[1], pp.ZKATPedParams[2], gens[0], gens[1]},
+		)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		inCom[i], err = common.ComputePedersenCommitment([]*bn256.Zr{typeHash, inf.Value, w.InputBlindingFactor}, pp.ZKATPedParams)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ownerCom, err := common.ComputePedersenCommitment([]*bn256.Zr{ownerHash, w.OwnerBlindingFactor}, []*bn256.G1{gens[0], pp.ZKATPedParams[2]})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		inputs[i] = &Input{
+			AnonymitySet:    in.AnonymitySet,
+			SerialNumber:    SerialNumber(secret, pp),
+			Commitment:      com,
+			InputCommitment: inCom[i],
+			OwnerCommitment: ownerCom,
+		}
+		witnesses[i] = w
+		openings[i] = &OwnerOpening{Owner: inf.Owner, BlindingFactor: w.OwnerBlindingFactor}
+		intw[i] = &token.TokenDataWitness{Type: inf.Type, Value: inf.Value, BlindingFactor: w.InputBlindingFactor}
+	}
+
+	out, outtw, err := token.GetTokensWithWitness(values, ttype, pp.ZKATPedParams)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	proof, err := transfer.NewProver(intw, outtw, inCom, out, pp).Prove()
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to generate zero-knowledge proof for transfer request")
+	}
+
+	if len(out) != len(owners) || len(out) != len(keys) {
+		return nil, nil, nil, errors.Errorf("number of owners or serial number keys does not match number of tokens")
+	}
+	outputs := make([]*Output, len(out))
+	inf := make([]*token.TokenInformation, len(out))
+	for i := 0; i < len(out); i++ {
+		var nonce *bn256.Zr
+		outputs[i], nonce, err = NewOutput(out[i], owners[i], keys[i], pp)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "failed to generate output [%d]", i)
+		}
+		inf[i] = &token.TokenInformation{
+			Type:            ttype,
+			Value:           outtw[i].Value,
+			BlindingFactor:  outtw[i].BlindingFactor,
+			Owner:           owners[i],
+			Nonce:           nonce,
+			SerialNumberKey: outputs[i].SerialNumberKey,
+		}
+	}
+
+	action := &TransferAction{Inputs: inputs, Outputs: outputs, Proof: proof}
+	// the spend proofs authorize the spending, they are bound to the rest of the action
+	msg, err := action.SpendMessage(binding)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, in := range inputs {
+		set := make([]*bn256.G1, len(s.Inputs[i].AnonymitySetTokens))
+		for j, t := range s.Inputs[i].AnonymitySetTokens {
+			set[j] = t.Data
+		}
+		in.Proof, err = NewSpendProver(witnesses[i], set, in.SerialNumber, in.Commitment, in.InputCommitment, in.OwnerCommitment, msg, pp).Prove()
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "failed to generate spend proof for input [%d]", i)
+		}
+	}
+
+	return action, inf, openings, nil
+}
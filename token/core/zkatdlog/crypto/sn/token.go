@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sn
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Token is the ledger representation of a token when graph hiding is enabled.
+// The owner is not stored in the clear, it is committed together with type, value and serial number secret.
+type Token struct {
+	Data *bn256.G1 // Commitment to type, value, owner and serial number secret
+}
+
+func (t *Token) Serialize() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *Token) Deserialize(bytes []byte) error {
+	return json.Unmarshal(bytes, t)
+}
+
+func (t *Token) GetCommitment() *bn256.G1 {
+	return t.Data
+}
+
+func (t *Token) GetTokenInTheClear(inf *token.TokenInformation, pp *crypto.PublicParams) (*token2.Token, error) {
+	data, err := common.ComputePedersenCommitment([]*bn256.Zr{bn256.HashModOrder([]byte(inf.Type)), inf.Value, inf.BlindingFactor}, pp.ZKATPedParams)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check token data")
+	}
+	if len(inf.Owner) != 0 {
+		if inf.Nonce == nil || inf.SerialNumberKey == nil {
+			return nil, errors.Errorf("invalid token information, nonce or serial number key is missing")
+		}
+		data = CommitWithKey(data, inf.Owner, inf.SerialNumberKey, inf.Nonce, pp)
+	}
+	if !data.Equals(t.Data) {
+		return nil, errors.Errorf("output does not match provided opening")
+	}
+	return &token2.Token{
+		Type:     inf.Type,
+		Quantity: "0x" + inf.Value.String(),
+		Owner:    &token2.Owner{Raw: inf.Owner},
+	}, nil
+}
+
+// Commit extends the passed commitment to type and value with the owner and the serial number secret
+func Commit(data *bn256.G1, owner []byte, secret *bn256.Zr, pp *crypto.PublicParams) *bn256.G1 {
+	com := bn256.NewG1().Copy(data)
+	com.Add(pp.SerialNumberParams.Generators[0].Mul(bn256.HashModOrder(owner)))
+	com.Add(pp.SerialNumberParams.Generators[1].Mul(secret))
+	return com
+}
+
+// CommitWithKey extends the passed commitment to type and value with the owner, the serial number key
+// of the recipient and the nonce chosen by the sender.
+// The serial number secret of the resulting token is the secret behind the key plus the nonce.
+func CommitWithKey(data *bn256.G1, owner []byte, key *bn256.G1, nonce *bn256.Zr, pp *crypto.PublicParams) *bn256.G1 {
+	com := bn256.NewG1().Copy(data)
+	com.Add(pp.SerialNumberParams.Generators[0].Mul(bn256.HashModOrder(owner)))
+	com.Add(key)
+	com.Add(pp.SerialNumberParams.Generators[1].Mul(nonce))
+	return com
+}
+
+// NewSerialNumberKey returns a fresh serial number key and the secret behind it.
+// A recipient hands the key out to the sender of a token, and keeps the secret to spend it.
+func NewSerialNumberKey(pp *crypto.PublicParams) (*bn256.G1, *bn256.Zr, error) {
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to get random number generator")
+	}
+	secret := bn256.RandModOrder(rand)
+	return pp.SerialNumberParams.Generators[1].Mul(secret), secret, nil
+}
+
+// SerialNumberSecret returns the serial number secret of a token, given the secret behind the serial number key
+// of its owner and the nonce chosen by its sender
+func SerialNumberSecret(keySecret *bn256.Zr, nonce *bn256.Zr) *bn256.Zr {
+	return bn256.ModAdd(keySecret, nonce, bn256.Order)
+}
+
+// SerialNumber returns the serial number revealed when the token with the passed serial number secret is spent.
+// The serial number is the base raised to the inverse of the secret, it cannot be linked to the token
+// without the secret, which the sender of the token does not know.
+func SerialNumber(secret *bn256.Zr, pp *crypto.PublicParams) *bn256.G1 {
+	inv := bn256.NewZrCopy(secret)
+	inv.InvModP(bn256.Order)
+	return pp.SerialNumberParams.Base.Mul(inv)
+}
+
+// SerialNumberKey returns the key under which the passed serial number is stored in the rwset
+func SerialNumberKey(sn *bn256.G1) (string, error) {
+	return keys.CreateSNKey(hex.EncodeToString(sn.Bytes()))
+}
@@ -95,6 +95,11 @@ type TokenInformation struct {
 	BlindingFactor *bn256.Zr
 	Owner          []byte
 	Issuer         []byte
+	// Nonce is the share of the serial number secret chosen by the sender, when graph hiding is enabled
+	Nonce *bn256.Zr `json:",omitempty"`
+	// SerialNumberKey is the key the owner handed out to the sender, when graph hiding is enabled.
+	// The owner alone knows the secret behind it, and therefore the serial number of the token.
+	SerialNumberKey *bn256.G1 `json:",omitempty"`
 }
 
 func (inf *TokenInformation) Deserialize(b []byte) error {
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 //go:generate counterfeiter -o mock/signing_identity.go -fake-name SigningIdentity . SigningIdentity
@@ -93,6 +94,10 @@ type TransferAction struct {
 	OutputTokens []*token.Token
 	// ZK Proof
 	Proof []byte
+	// NFTs describes the non-fungible tokens moved by this action, one for each output
+	NFTs []*token2.NFT `json:",omitempty"`
+	// NFTProof shows that the outputs open to the non-fungible tokens
+	NFTProof []byte `json:",omitempty"`
 }
 
 func NewTransfer(inputs []string, inputCommitments []*bn256.G1, outputs []*bn256.G1, owners [][]byte, proof []byte) (*TransferAction, error) {
@@ -170,6 +175,10 @@ func (t *TransferAction) IsGraphHiding() bool {
 	return false
 }
 
+func (t *TransferAction) GetNFTs() []*token2.NFT {
+	return t.NFTs
+}
+
 func getTokenData(tokens []*token.Token) []*bn256.G1 {
 	tokenData := make([]*bn256.G1, len(tokens))
 	for i := 0; i < len(tokens); i++ {
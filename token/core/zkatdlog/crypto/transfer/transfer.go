@@ -38,7 +38,11 @@ func NewProver(inputwitness, outputwitness []*token.TokenDataWitness, inputs, ou
 
 	p := &Prover{}
 
-	p.RangeCorrectness = rangeproof.NewProver(outputwitness, outputs, pp.RangeProofParams.SignedValues, pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	if pp.UseBulletproofs() {
+		p.RangeCorrectness = rangeproof.NewBulletproofProver(outputwitness, outputs, pp.ZKATPedParams, pp.BulletproofParams.LeftGenerators, pp.BulletproofParams.RightGenerators, pp.BulletproofParams.P, pp.BulletproofParams.BitLength)
+	} else {
+		p.RangeCorrectness = rangeproof.NewProver(outputwitness, outputs, pp.RangeProofParams.SignedValues, pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	}
 	wfw := NewWellFormednessWitness(inputwitness, outputwitness)
 	p.WellFormedness = NewWellFormednessProver(wfw, pp.ZKATPedParams, inputs, outputs)
 	return p
@@ -46,7 +50,11 @@ func NewProver(inputwitness, outputwitness []*token.TokenDataWitness, inputs, ou
 
 func NewVerifier(inputs, outputs []*bn256.G1, pp *crypto.PublicParams) *Verifier {
 	v := &Verifier{}
-	v.RangeCorrectness = rangeproof.NewVerifier(outputs, uint64(len(pp.RangeProofParams.SignedValues)), pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	if pp.UseBulletproofs() {
+		v.RangeCorrectness = rangeproof.NewBulletproofVerifier(outputs, pp.ZKATPedParams, pp.BulletproofParams.LeftGenerators, pp.BulletproofParams.RightGenerators, pp.BulletproofParams.P, pp.BulletproofParams.BitLength)
+	} else {
+		v.RangeCorrectness = rangeproof.NewVerifier(outputs, uint64(len(pp.RangeProofParams.SignedValues)), pp.RangeProofParams.Exponent, pp.ZKATPedParams, pp.RangeProofParams.SignPK, pp.P, pp.RangeProofParams.Q)
+	}
 	v.WellFormedness = NewWellFormednessVerifier(pp.ZKATPedParams, inputs, outputs)
 
 	return v
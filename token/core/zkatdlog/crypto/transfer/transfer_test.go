@@ -56,6 +56,18 @@ var _ = Describe("Transfer", func() {
 				Expect(err.Error()).To(ContainSubstring("can't compute range proof: value of token outside authorized range"))
 			})
 		})
+		Context("range proofs are bulletproofs", func() {
+			BeforeEach(func() {
+				prover, verifier = prepareZKTransferWithBulletproofs()
+			})
+			It("Succeeds", func() {
+				proof, err := prover.Prove()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proof).NotTo(BeNil())
+				err = verifier.Verify(proof)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
 	})
 
 })
@@ -145,6 +157,34 @@ func prepareZKTransferWithInvalidRange() (*transfer.Prover, *transfer.Verifier)
 	return prover, verifier
 }
 
+func prepareZKTransferWithBulletproofs() (*transfer.Prover, *transfer.Verifier) {
+	pp, err := crypto.SetupWithBulletproofs(64, nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	wfw, in, out := prepareInputsForZKTransfer(pp)
+
+	inBF := wfw.GetInBlindingFators()
+	outBF := wfw.GetOutBlindingFators()
+
+	inValues := wfw.GetInValues()
+	outValues := wfw.GetOutValues()
+
+	ttype := "ABC"
+	intw := make([]*token.TokenDataWitness, len(inValues))
+	for i := 0; i < len(intw); i++ {
+		intw[i] = &token.TokenDataWitness{BlindingFactor: inBF[i], Value: inValues[i], Type: ttype}
+	}
+
+	outtw := make([]*token.TokenDataWitness, len(outValues))
+	for i := 0; i < len(outtw); i++ {
+		outtw[i] = &token.TokenDataWitness{BlindingFactor: outBF[i], Value: outValues[i], Type: ttype}
+	}
+
+	prover := transfer.NewProver(intw, outtw, in, out, pp)
+	verifier := transfer.NewVerifier(in, out, pp)
+	return prover, verifier
+}
+
 func prepareInputsForZKTransfer(pp *crypto.PublicParams) (*transfer.WellFormednessWitness, []*bn256.G1, []*bn256.G1) {
 	rand, err := bn256.GetRand()
 	Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,199 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package validator
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/anonym"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+)
+
+// GraphHidingValidator validates token requests whose transfers spend tokens by revealing serial numbers
+type GraphHidingValidator struct {
+	pp   *crypto.PublicParams
+	base *Validator
+}
+
+func NewGraphHiding(pp *crypto.PublicParams) *GraphHidingValidator {
+	return &GraphHidingValidator{pp: pp, base: New(pp)}
+}
+
+func (v *GraphHidingValidator) VerifyTokenRequestFromRaw(ledger driver.Ledger, binding string, raw []byte) ([]interface{}, error) {
+	backend, tr, err := newBackend(v.pp, ledger, binding, raw)
+	if err != nil {
+		return nil, err
+	}
+	return v.VerifyTokenRequest(backend, backend, binding, tr)
+}
+
+func (v *GraphHidingValidator) VerifyTokenRequest(ledger driver.Ledger, signatureProvider driver.SignatureProvider, binding string, tr *driver.TokenRequest) ([]interface{}, error) {
+	if !v.pp.GraphHiding() {
+		return nil, errors.Errorf("public parameters do not support graph hiding [%s]", binding)
+	}
+	if err := v.base.verifyAuditorSignature(signatureProvider); err != nil {
+		return nil, errors.Wrapf(err, "failed to verifier auditor's signature [%s]", binding)
+	}
+	ia := make([]*sn.IssueAction, len(tr.Issues))
+	for i, raw := range tr.Issues {
+		ia[i] = &sn.IssueAction{}
+		if err := ia[i].Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve issue actions [%s]", binding)
+		}
+	}
+	ta := make([]*sn.TransferAction, len(tr.Transfers))
+	for i, raw := range tr.Transfers {
+		ta[i] = &sn.TransferAction{}
+		if err := ta[i].Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve transfer actions [%s]", binding)
+		}
+	}
+	if err := v.verifyIssues(ia, signatureProvider); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify issuers' signatures [%s]", binding)
+	}
+	if err := v.verifyTransfers(ledger, ta, binding); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify transfer actions [%s]", binding)
+	}
+
+	var actions []interface{}
+	for _, action := range ia {
+		actions = append(actions, action)
+	}
+	for _, action := range ta {
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+func (v *GraphHidingValidator) verifyIssues(issues []*sn.IssueAction, signatureProvider driver.SignatureProvider) error {
+	for _, a := range issues {
+		if len(a.Outputs) == 0 {
+			return errors.New("failed to verify issue action: no outputs")
+		}
+		for i, output := range a.Outputs {
+			if output.IsRedeem() {
+				return errors.Errorf("failed to verify issue action: output [%d] has no owner", i)
+			}
+			if err := output.Verify(v.pp); err != nil {
+				return errors.Wrapf(err, "failed to verify issue action output [%d]", i)
+			}
+		}
+		if err := issue2.NewVerifier(a.GetCommitments(), a.IsAnonymous(), v.pp).Verify(a.GetProof()); err != nil {
+			return errors.Wrapf(err, "failed to verify issue action")
+		}
+		// spent tokens are hidden, therefore non-fungible tokens could not be tracked
+		if len(a.NFTs) != 0 {
+			return errors.New("failed to verify issue action: non-fungible tokens are not supported with graph hiding")
+		}
+		if err := issue2.VerifyNFTs(v.pp.TypeRegistry, a.NFTs, a.GetCommitments(), a.IsAnonymous(), a.GetProof(), a.NFTProof, v.pp.ZKATPedParams); err != nil {
+			return errors.Wrapf(err, "failed to verify issue action: invalid non-fungible tokens")
+		}
+		if err := issue2.VerifyTokenType(v.pp.TypeRegistry, a.Issuer, a.NFTs, a.IsAnonymous(), a.GetProof()); err != nil {
+			return errors.Wrapf(err, "failed to verify issue action: invalid token type")
+		}
+
+		var verifier driver.Verifier
+		if a.Anonymous {
+			av := &anonym.Verifier{}
+			ip, err := v.pp.GetIssuingPolicy()
+			if err != nil {
+				return err
+			}
+			if err := av.Deserialize(ip.BitLength, ip.Issuers, v.pp.ZKATPedParams, a.Outputs[0].Data, a.Issuer); err != nil {
+				return err
+			}
+			verifier = av
+		} else {
+			identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+			var err error
+			verifier, err = identityDeserializer.GetVerifier(a.Issuer)
+			if err != nil {
+				return errors.Wrapf(err, "failed getting verifier for [%s]", view.Identity(a.Issuer).String())
+			}
+		}
+		if err := signatureProvider.HasBeenSignedBy(a.Issuer, verifier); err != nil {
+			return errors.Wrapf(err, "failed verifying signature")
+		}
+	}
+	return nil
+}
+
+// verifyTransfers checks the transfer actions. The owners of the spent tokens do not sign the request,
+// the spend proofs show knowledge of the serial number secrets and are bound to the action and to the binding.
+func (v *GraphHidingValidator) verifyTransfers(ledger driver.Ledger, transferActions []*sn.TransferAction, binding string) error {
+	for i, t := range transferActions {
+		if len(t.Inputs) == 0 {
+			return errors.Errorf("invalid transfer action [%d]: no inputs", i)
+		}
+		msg, err := t.SpendMessage(binding)
+		if err != nil {
+			return errors.Wrapf(err, "invalid transfer action [%d]", i)
+		}
+		for j, in := range t.Inputs {
+			if err := v.verifyInput(ledger, in, msg); err != nil {
+				return errors.Wrapf(err, "failed to verify input [%d][%d]", i, j)
+			}
+		}
+		for j, output := range t.Outputs {
+			if err := output.Verify(v.pp); err != nil {
+				return errors.Wrapf(err, "failed to verify output [%d][%d]", i, j)
+			}
+		}
+		if err := transfer.NewVerifier(t.GetInputCommitments(), t.GetOutputCommitments(), v.pp).Verify(t.GetProof()); err != nil {
+			return errors.Wrapf(err, "failed to verify transfer action")
+		}
+	}
+	return nil
+}
+
+func (v *GraphHidingValidator) verifyInput(ledger driver.Ledger, in *sn.Input, msg []byte) error {
+	if in.SerialNumber == nil || in.Commitment == nil || in.InputCommitment == nil || in.OwnerCommitment == nil {
+		return errors.New("invalid input: missing fields")
+	}
+	if len(in.AnonymitySet) != 1<<uint(v.pp.SerialNumberParams.BitLength) {
+		return errors.Errorf("invalid input: anonymity set must contain [%d] tokens, got [%d]", 1<<uint(v.pp.SerialNumberParams.BitLength), len(in.AnonymitySet))
+	}
+	set := make([]*bn256.G1, len(in.AnonymitySet))
+	seen := make(map[string]bool, len(in.AnonymitySet))
+	for i, key := range in.AnonymitySet {
+		// a repeated key shrinks the anonymity set
+		if seen[key] {
+			return errors.Errorf("invalid input: duplicate key in the anonymity set [%s]", key)
+		}
+		seen[key] = true
+		if prefix, _, err := keys.SplitCompositeKey(key); err != nil || prefix != keys.TokenKeyPrefix {
+			return errors.Errorf("invalid key in the anonymity set [%s]", key)
+		}
+		if _, err := keys.GetTokenIdFromKey(key); err != nil {
+			return errors.Wrapf(err, "invalid key in the anonymity set [%s]", key)
+		}
+		raw, err := ledger.GetState(key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to retrieve token in the anonymity set [%s]", key)
+		}
+		if len(raw) == 0 {
+			return errors.Errorf("token in the anonymity set [%s] does not exist", key)
+		}
+		tok := &sn.Token{}
+		if err := tok.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed to deserialize token in the anonymity set [%s]", key)
+		}
+		if tok.Data == nil {
+			return errors.Errorf("invalid token in the anonymity set [%s]", key)
+		}
+		set[i] = tok.Data
+	}
+	return sn.NewSpendVerifier(set, in.SerialNumber, in.Commitment, in.InputCommitment, in.OwnerCommitment, msg, v.pp).Verify(in.Proof)
+}
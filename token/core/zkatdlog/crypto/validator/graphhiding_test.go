@@ -0,0 +1,246 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package validator_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	tokn "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	enginedlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+)
+
+var _ = Describe("graph hiding validator", func() {
+	var (
+		engine   *enginedlog.GraphHidingValidator
+		pp       *crypto.PublicParams
+		ledger   map[string][]byte
+		tr       *driver.TokenRequest
+		ta       *sn.TransferAction
+		sender   *sn.Sender
+		auditor  *audit.Auditor
+		id       []byte
+		key      *bn256.G1
+		openings []*sn.OwnerOpening
+	)
+	BeforeEach(func() {
+		var err error
+		ipk, err := ioutil.ReadFile("./testdata/idemix/msp/IssuerPublicKey")
+		Expect(err).NotTo(HaveOccurred())
+		pp, err = crypto.SetupGraphHiding(100, 2, ipk, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		asigner, _ := prepareECDSASigner()
+		auditor = &audit.Auditor{Signer: asigner, PedersenParams: pp.ZKATPedParams, NYMParams: pp.IdemixPK}
+		pp.Auditor, err = asigner.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+
+		engine = enginedlog.NewGraphHiding(pp)
+
+		id, _, _ = getIdemixInfo("./testdata/idemix")
+		ledger = map[string][]byte{}
+		input := prepareSpentToken(pp, id, 1, ledger)
+		sender, err = sn.NewSender([]*sn.SpentToken{input}, pp)
+		Expect(err).NotTo(HaveOccurred())
+
+		key, _, err = sn.NewSerialNumberKey(pp)
+		Expect(err).NotTo(HaveOccurred())
+		ta, _, openings, err = sender.GenerateZKTransfer([]uint64{60, 40}, [][]byte{id, id}, []*bn256.G1{key, key}, "1")
+		Expect(err).NotTo(HaveOccurred())
+		tr = signGraphHidingTransfer(ta, auditor)
+
+		fakeldger = &mock.Ledger{}
+		fakeldger.GetStateStub = func(key string) ([]byte, error) {
+			return ledger[key], nil
+		}
+	})
+	Context("when the transfer spends a token in the anonymity set", func() {
+		It("succeeds", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(actions)).To(Equal(1))
+
+			inputs, err := actions[0].(*sn.TransferAction).GetInputs()
+			Expect(err).NotTo(HaveOccurred())
+			snKey, err := sn.SerialNumberKey(ta.Inputs[0].SerialNumber)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inputs).To(Equal([]string{snKey}))
+		})
+		It("hides the owner of the spent token from the ledger and opens it to the auditor", func() {
+			raw, err := ta.Serialize()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(raw)).NotTo(ContainSubstring("Owner\""))
+			Expect(ta.Inputs[0].VerifyOwner(openings[0], pp)).NotTo(HaveOccurred())
+			Expect(openings[0].Owner).To(Equal(id))
+
+			other := &sn.OwnerOpening{Owner: []byte("bob"), BlindingFactor: openings[0].BlindingFactor}
+			err = ta.Inputs[0].VerifyOwner(other, pp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("owner opening does not match the owner commitment"))
+		})
+	})
+	Context("when the anonymity set contains the same key twice", func() {
+		BeforeEach(func() {
+			ta.Inputs[0].AnonymitySet[0] = ta.Inputs[0].AnonymitySet[1]
+			tr = signGraphHidingTransfer(ta, auditor)
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("duplicate key in the anonymity set"))
+		})
+	})
+	Context("when the spend proofs are bound to another transaction", func() {
+		BeforeEach(func() {
+			var err error
+			ta, _, _, err = sender.GenerateZKTransfer([]uint64{60, 40}, [][]byte{id, id}, []*bn256.G1{key, key}, "2")
+			Expect(err).NotTo(HaveOccurred())
+			tr = signGraphHidingTransfer(ta, auditor)
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid spend proof"))
+		})
+	})
+	Context("when the outputs are replaced", func() {
+		BeforeEach(func() {
+			other, _, _, err := sender.GenerateZKTransfer([]uint64{60, 40}, [][]byte{id, id}, []*bn256.G1{key, key}, "1")
+			Expect(err).NotTo(HaveOccurred())
+			ta.Outputs = other.Outputs
+			ta.Proof = other.Proof
+			tr = signGraphHidingTransfer(ta, auditor)
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid spend proof"))
+		})
+	})
+	Context("when a token in the anonymity set does not exist", func() {
+		BeforeEach(func() {
+			delete(ledger, ta.Inputs[0].AnonymitySet[0])
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not exist"))
+		})
+	})
+	Context("when the anonymity set is replaced", func() {
+		BeforeEach(func() {
+			key, err := keys.CreateTokenKey("other", 0)
+			Expect(err).NotTo(HaveOccurred())
+			ledger[key] = ledger[ta.Inputs[0].AnonymitySet[0]]
+			ta.Inputs[0].AnonymitySet[1] = key
+			tr = signGraphHidingTransfer(ta, auditor)
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spent token is not in the anonymity set"))
+		})
+	})
+	Context("when the sender does not know the secret behind the serial number key", func() {
+		It("cannot spend the token", func() {
+			input := prepareSpentToken(pp, id, 1, map[string][]byte{})
+			rand, err := bn256.GetRand()
+			Expect(err).NotTo(HaveOccurred())
+			input.KeySecret = bn256.RandModOrder(rand)
+			_, err = sn.NewSender([]*sn.SpentToken{input}, pp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid serial number key secret for input [0]"))
+		})
+	})
+	Context("when the serial number is tampered with", func() {
+		BeforeEach(func() {
+			ta.Inputs[0].SerialNumber = bn256.NewG1().Copy(ta.Inputs[0].SerialNumber).Add(pp.SerialNumberParams.Base)
+			tr = signGraphHidingTransfer(ta, auditor)
+		})
+		It("fails", func() {
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid spend proof"))
+		})
+	})
+})
+
+// prepareSpentToken stores an anonymity set of 4 tokens on the ledger and returns the opening of the one at index
+func prepareSpentToken(pp *crypto.PublicParams, owner []byte, index int, ledger map[string][]byte) *sn.SpentToken {
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+
+	size := 1 << uint(pp.SerialNumberParams.BitLength)
+	input := &sn.SpentToken{
+		AnonymitySet:       make([]string, size),
+		AnonymitySetTokens: make([]*sn.Token, size),
+		Index:              index,
+	}
+	for i := 0; i < size; i++ {
+		snKey, secret, err := sn.NewSerialNumberKey(pp)
+		Expect(err).NotTo(HaveOccurred())
+		inf := &tokn.TokenInformation{
+			Type:            "ABC",
+			Value:           bn256.NewZrInt(100),
+			BlindingFactor:  bn256.RandModOrder(rand),
+			Owner:           owner,
+			Nonce:           bn256.RandModOrder(rand),
+			SerialNumberKey: snKey,
+		}
+		data, err := common.ComputePedersenCommitment([]*bn256.Zr{bn256.HashModOrder([]byte(inf.Type)), inf.Value, inf.BlindingFactor}, pp.ZKATPedParams)
+		Expect(err).NotTo(HaveOccurred())
+		tok := &sn.Token{Data: sn.CommitWithKey(data, owner, snKey, inf.Nonce, pp)}
+
+		key, err := keys.CreateTokenKey("tx", i)
+		Expect(err).NotTo(HaveOccurred())
+		ledger[key], err = tok.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+
+		input.AnonymitySet[i] = key
+		input.AnonymitySetTokens[i] = tok
+		if i == index {
+			input.Information = inf
+			input.KeySecret = secret
+		}
+	}
+	return input
+}
+
+// signGraphHidingTransfer returns a request with the passed action signed by the auditor,
+// the owners of the spent tokens do not sign
+func signGraphHidingTransfer(ta *sn.TransferAction, auditor *audit.Auditor) *driver.TokenRequest {
+	raw, err := ta.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+	tr := &driver.TokenRequest{Transfers: [][]byte{raw}}
+	tr.AuditorSignature, err = auditor.Endorse(tr, "1")
+	Expect(err).NotTo(HaveOccurred())
+	return tr
+}
@@ -7,15 +7,19 @@ package validator
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/pkg/errors"
 
 	idemix2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/msp/idemix"
+	driver2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/driver"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
@@ -23,6 +27,8 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.zkatdlog")
@@ -35,40 +41,10 @@ func New(pp *crypto.PublicParams) *Validator {
 	return &Validator{pp: pp}
 }
 
-func (v *Validator) VerifyTokenRequestFromRaw(getState driver.GetStateFnc, binding string, raw []byte) ([]interface{}, error) {
-	if len(raw) == 0 {
-		return nil, errors.New("empty token request")
-	}
-	tr := &driver.TokenRequest{}
-	err := json.Unmarshal(raw, tr)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal token request")
-	}
-
-	// Prepare message expected to be signed
-	// TODO: encapsulate this somewhere
-	req := &driver.TokenRequest{}
-	req.Transfers = tr.Transfers
-	req.Issues = tr.Issues
-	bytes, err := json.Marshal(req)
+func (v *Validator) VerifyTokenRequestFromRaw(ledger driver.Ledger, binding string, raw []byte) ([]interface{}, error) {
+	backend, tr, err := newBackend(v.pp, ledger, binding, raw)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal signed token request"+err.Error())
-	}
-
-	logger.Debugf("cc tx-id [%s][%s]", hash.Hashable(bytes).String(), binding)
-	signed := append(bytes, []byte(binding)...)
-	var signatures [][]byte
-	if len(v.pp.Auditor) != 0 {
-		signatures = append(signatures, tr.AuditorSignature)
-		signatures = append(signatures, tr.Signatures...)
-	} else {
-		signatures = tr.Signatures
-	}
-
-	backend := &backend{
-		getState:   getState,
-		message:    signed,
-		signatures: signatures,
+		return nil, err
 	}
 	return v.VerifyTokenRequest(backend, backend, binding, tr)
 }
@@ -179,10 +155,19 @@ func (v *Validator) verifyIssues(issues []driver.IssueAction, signatureProvider
 }
 
 func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []driver.TransferAction, signatureProvider driver.SignatureProvider) error {
-	identityDeserializer, err := idemix2.NewDeserializer(v.pp.IdemixPK)
+	deserializer, err := idemix2.NewDeserializer(v.pp.IdemixPK)
 	if err != nil {
 		return errors.Wrap(err, "failed instantiating deserializer")
 	}
+	// scripts are checked against the time of the transaction
+	now, err := driver.GetTxTimestamp(ledger)
+	if err != nil {
+		return errors.Wrap(err, "failed getting the timestamp of the transaction")
+	}
+	identityDeserializer := htlc.NewDeserializer(
+		multisig.NewDeserializer(&idemixDeserializer{deserializer: deserializer}),
+		func() time.Time { return now },
+	)
 
 	logger.Debugf("check sender start...")
 	defer logger.Debugf("check sender finished.")
@@ -207,8 +192,11 @@ func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []driv
 			if err != nil {
 				return errors.Wrapf(err, "failed to deserialize input to spend [%s]", in)
 			}
+			if err := translator.CheckNotFrozen(ledger.GetState, in, tok.Owner); err != nil {
+				return errors.Wrapf(err, "input to spend [%s] cannot be spent", in)
+			}
 			logger.Debugf("check sender [%d][%s]", i, view.Identity(tok.Owner).UniqueID())
-			verifier, err := identityDeserializer.DeserializeVerifier(tok.Owner)
+			verifier, err := identityDeserializer.GetVerifier(tok.Owner)
 			if err != nil {
 				return errors.Wrapf(err, "failed deserializing owner [%d][%s][%s]", i, in, view.Identity(tok.Owner).UniqueID())
 			}
@@ -217,7 +205,10 @@ func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []driv
 				return errors.Wrapf(err, "failed signature verification [%d][%s][%s]", i, in, view.Identity(tok.Owner).UniqueID())
 			}
 		}
-		if err := v.verifyTransfer(inputTokens, t); err != nil {
+		if err := v.verifyNFTTransfer(ledger, inputs, t); err != nil {
+			return errors.Wrapf(err, "failed to verify transfer action")
+		}
+		if err := v.verifyTransfer(inputTokens, t, now); err != nil {
 			return errors.Wrapf(err, "failed to verify transfer action")
 		}
 	}
@@ -227,13 +218,19 @@ func (v *Validator) verifyTransfers(ledger driver.Ledger, transferActions []driv
 func (v *Validator) verifyIssue(issue driver.IssueAction) error {
 	action := issue.(*issue2.IssueAction)
 
+	if err := issue2.VerifyNFTs(v.pp.TypeRegistry, action.NFTs, action.GetCommitments(), action.IsAnonymous(), action.GetProof(), action.NFTProof, v.pp.ZKATPedParams); err != nil {
+		return errors.Wrap(err, "invalid non-fungible tokens")
+	}
+	if err := issue2.VerifyTokenType(v.pp.TypeRegistry, action.Issuer, action.NFTs, action.IsAnonymous(), action.GetProof()); err != nil {
+		return errors.Wrap(err, "invalid token type")
+	}
 	return issue2.NewVerifier(
 		action.GetCommitments(),
 		action.IsAnonymous(),
 		v.pp).Verify(action.GetProof())
 }
 
-func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferAction) error {
+func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferAction, now time.Time) error {
 	action := tr.(*transfer.TransferAction)
 
 	in := make([]*bn256.G1, len(inputTokens))
@@ -244,6 +241,14 @@ func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferActio
 		}
 		in[i] = tok.GetCommitment()
 	}
+	for i, out := range action.OutputTokens {
+		if err := htlc.ValidateOwner(out.Owner, now); err != nil {
+			return errors.Wrapf(err, "invalid transfer: invalid output [%d]", i)
+		}
+		if err := multisig.ValidateOwner(out.Owner); err != nil {
+			return errors.Wrapf(err, "invalid transfer: invalid output [%d]", i)
+		}
+	}
 
 	return transfer.NewVerifier(
 		in,
@@ -251,8 +256,97 @@ func (v *Validator) verifyTransfer(inputTokens [][]byte, tr driver.TransferActio
 		v.pp).Verify(action.GetProof())
 }
 
+// verifyNFTTransfer checks that a spent non-fungible token is moved whole to exactly one output.
+// Types are hidden, therefore the spent non-fungible tokens are recognized by the marks the translator
+// stores next to the tokens that hold them, and the action must prove the type and value of its output.
+func (v *Validator) verifyNFTTransfer(ledger driver.Ledger, inputs []string, tr driver.TransferAction) error {
+	action := tr.(*transfer.TransferAction)
+
+	var ids []string
+	for _, in := range inputs {
+		id, err := keys.GetTokenIdFromKey(in)
+		if err != nil {
+			// only the tokens stored under a token key can be marked
+			continue
+		}
+		key, err := keys.CreateNFTTokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return errors.Wrapf(err, "failed creating nft token key for [%s]", in)
+		}
+		raw, err := ledger.GetState(key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to retrieve nft mark of [%s]", in)
+		}
+		if len(raw) != 0 {
+			ids = append(ids, string(raw))
+		}
+	}
+	if len(ids) == 0 {
+		if len(action.NFTs) != 0 {
+			return errors.New("invalid transfer: non-fungible tokens are declared but none is spent")
+		}
+		return nil
+	}
+	if len(inputs) != 1 || len(action.OutputTokens) != 1 {
+		return errors.Errorf("invalid transfer: nft [%s] must be moved whole, from one input to one output", ids[0])
+	}
+	if len(action.NFTs) != 1 || action.NFTs[0] == nil || action.NFTs[0].ID != ids[0] {
+		return errors.Errorf("invalid transfer: nft [%s] is spent but not declared", ids[0])
+	}
+	return issue2.NewNFTVerifier(action.GetOutputCommitments(), action.NFTs, v.pp.ZKATPedParams).Verify(action.NFTProof)
+}
+
+// idemixDeserializer returns the verifiers of Idemix owners
+type idemixDeserializer struct {
+	deserializer interface {
+		DeserializeVerifier(raw []byte) (driver2.Verifier, error)
+	}
+}
+
+func (d *idemixDeserializer) GetVerifier(id view.Identity) (driver.Verifier, error) {
+	return d.deserializer.DeserializeVerifier(id)
+}
+
+// newBackend unmarshals the passed token request and prepares the message its signatures are expected to be on
+func newBackend(pp *crypto.PublicParams, ledger driver.Ledger, binding string, raw []byte) (*backend, *driver.TokenRequest, error) {
+	if len(raw) == 0 {
+		return nil, nil, errors.New("empty token request")
+	}
+	tr := &driver.TokenRequest{}
+	err := json.Unmarshal(raw, tr)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal token request")
+	}
+
+	// Prepare message expected to be signed
+	// TODO: encapsulate this somewhere
+	req := &driver.TokenRequest{}
+	req.Transfers = tr.Transfers
+	req.Issues = tr.Issues
+	bytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal signed token request"+err.Error())
+	}
+
+	logger.Debugf("cc tx-id [%s][%s]", hash.Hashable(bytes).String(), binding)
+	signed := append(bytes, []byte(binding)...)
+	var signatures [][]byte
+	if len(pp.Auditor) != 0 {
+		signatures = append(signatures, tr.AuditorSignature)
+		signatures = append(signatures, tr.Signatures...)
+	} else {
+		signatures = tr.Signatures
+	}
+
+	return &backend{
+		ledger:     ledger,
+		message:    signed,
+		signatures: signatures,
+	}, tr, nil
+}
+
 type backend struct {
-	getState   driver.GetStateFnc
+	ledger     driver.Ledger
 	message    []byte
 	index      int
 	signatures [][]byte
@@ -269,5 +363,9 @@ func (b *backend) HasBeenSignedBy(id view.Identity, verifier driver.Verifier) er
 }
 
 func (b *backend) GetState(key string) ([]byte, error) {
-	return b.getState(key)
+	return b.ledger.GetState(key)
+}
+
+func (b *backend) TxTimestamp() (time.Time, error) {
+	return driver.GetTxTimestamp(b.ledger)
 }
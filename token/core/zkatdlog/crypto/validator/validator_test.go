@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package validator_test
 
 import (
+	crypto2 "crypto"
+	"crypto/sha256"
 	"encoding/json"
 	"io/ioutil"
 	"time"
@@ -21,6 +23,7 @@ import (
 	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
@@ -33,6 +36,8 @@ import (
 	enginedlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator/mock"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var fakeldger *mock.Ledger
@@ -65,6 +70,8 @@ var _ = Describe("validator", func() {
 		Expect(err).NotTo(HaveOccurred())
 		pp, err = crypto.Setup(100, 2, ipk)
 		Expect(err).NotTo(HaveOccurred())
+		// issuing is denied by default
+		pp.TypeRegistry = pp.TypeRegistry.Register(&token2.TypeInfo{Type: "ABC"})
 
 		//prepare issuers' public keys
 		sk, pk, err := anonym.GenerateKeyPair("ABC", pp)
@@ -148,7 +155,7 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger, "1", raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -164,7 +171,7 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger, "1", raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -199,7 +206,7 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -234,7 +241,7 @@ var _ = Describe("validator", func() {
 
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -271,7 +278,7 @@ var _ = Describe("validator", func() {
 
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "2", raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(2))
 			})
@@ -286,7 +293,7 @@ var _ = Describe("validator", func() {
 					Expect(err).NotTo(HaveOccurred())
 				})
 				It("fails", func() {
-					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+					_, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "2", raw)
 					Expect(err.Error()).To(ContainSubstring("failed to verify issuers' signatures"))
 				})
 			})
@@ -305,7 +312,7 @@ var _ = Describe("validator", func() {
 
 				})
 				It("fails", func() {
-					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+					_, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "2", raw)
 					Expect(err.Error()).To(ContainSubstring("pseudonym signature invalid"))
 
 				})
@@ -314,6 +321,325 @@ var _ = Describe("validator", func() {
 	})
 })
 
+var _ = Describe("validator with bulletproofs", func() {
+	var (
+		engine *enginedlog.Validator
+		pp     *crypto.PublicParams
+		tr     *driver.TokenRequest
+		inputs []*tokn.Token
+	)
+	BeforeEach(func() {
+		fakeldger = &mock.Ledger{}
+		ipk, err := ioutil.ReadFile("./testdata/idemix/msp/IssuerPublicKey")
+		Expect(err).NotTo(HaveOccurred())
+		pp, err = crypto.SetupWithBulletproofs(64, ipk)
+		Expect(err).NotTo(HaveOccurred())
+
+		asigner, _ := prepareECDSASigner()
+		auditor := &audit.Auditor{Signer: asigner, PedersenParams: pp.ZKATPedParams, NYMParams: pp.IdemixPK}
+		pp.Auditor, err = asigner.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+
+		engine = enginedlog.New(pp)
+		_, tr, _, inputs = prepareTransferRequest(pp, auditor)
+	})
+	Context("validator is called correctly with a transfer action", func() {
+		var raw []byte
+		BeforeEach(func() {
+			for i := 0; i < 4; i++ {
+				raw, err := inputs[i%2].Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				fakeldger.GetStateReturnsOnCall(i, raw, nil)
+			}
+			fakeldger.GetStateReturnsOnCall(4, nil, nil)
+			fakeldger.GetStateReturnsOnCall(5, nil, nil)
+
+			var err error
+			raw, err = json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("succeeds", func() {
+			actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(actions)).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("validator with non-fungible tokens", func() {
+	var (
+		engine  *enginedlog.Validator
+		pp      *crypto.PublicParams
+		auditor *audit.Auditor
+		input   *tokn.Token
+		inKey   string
+	)
+	BeforeEach(func() {
+		fakeldger = &mock.Ledger{}
+		ipk, err := ioutil.ReadFile("./testdata/idemix/msp/IssuerPublicKey")
+		Expect(err).NotTo(HaveOccurred())
+		pp, err = crypto.Setup(100, 2, ipk)
+		Expect(err).NotTo(HaveOccurred())
+
+		asigner, _ := prepareECDSASigner()
+		auditor = &audit.Auditor{Signer: asigner, PedersenParams: pp.ZKATPedParams, NYMParams: pp.IdemixPK}
+		pp.Auditor, err = asigner.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+
+		engine = enginedlog.New(pp)
+		inKey, err = keys.CreateTokenKey("tx0", 0)
+		Expect(err).NotTo(HaveOccurred())
+		mark, err := keys.CreateNFTTokenKey("tx0", 0)
+		Expect(err).NotTo(HaveOccurred())
+		fakeldger.GetStateStub = func(key string) ([]byte, error) {
+			switch key {
+			case inKey:
+				return input.Serialize()
+			case mark:
+				return []byte("painting"), nil
+			}
+			return nil, nil
+		}
+	})
+	Context("the non-fungible token is moved to one output with a proof", func() {
+		It("succeeds", func() {
+			var tr *driver.TokenRequest
+			input, tr = prepareNFTTransfer(pp, auditor, inKey, 1, true)
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			actions, err := engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(actions)).To(Equal(1))
+		})
+	})
+	Context("the non-fungible token is not declared", func() {
+		It("fails", func() {
+			var tr *driver.TokenRequest
+			input, tr = prepareNFTTransfer(pp, auditor, inKey, 1, false)
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("nft [painting] is spent but not declared"))
+		})
+	})
+	Context("the non-fungible token is split", func() {
+		It("fails", func() {
+			var tr *driver.TokenRequest
+			input, tr = prepareNFTTransfer(pp, auditor, inKey, 2, true)
+			raw, err := json.Marshal(tr)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = engine.VerifyTokenRequestFromRaw(&mock.Ledger{GetStateStub: getState}, "1", raw)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("nft [painting] must be moved whole, from one input to one output"))
+		})
+	})
+})
+
+// timedLedger is a ledger that knows the timestamp of the transaction being validated
+type timedLedger struct {
+	*mock.Ledger
+	timestamp time.Time
+}
+
+func (l *timedLedger) TxTimestamp() (time.Time, error) {
+	return l.timestamp, nil
+}
+
+var _ = Describe("validator with hash time-locked scripts", func() {
+	var (
+		engine   *enginedlog.Validator
+		pp       *crypto.PublicParams
+		id       view.Identity
+		signer   driver.SigningIdentity
+		script   *htlc.Script
+		preimage []byte
+		input    *tokn.Token
+		ledger   *timedLedger
+	)
+	BeforeEach(func() {
+		ipk, err := ioutil.ReadFile("./testdata/idemix/msp/IssuerPublicKey")
+		Expect(err).NotTo(HaveOccurred())
+		pp, err = crypto.Setup(100, 2, ipk)
+		Expect(err).NotTo(HaveOccurred())
+		engine = enginedlog.New(pp)
+
+		id, _, signer = getIdemixInfo("./testdata/idemix")
+		preimage = []byte("a secret preimage")
+		hash := sha256.Sum256(preimage)
+		script = &htlc.Script{
+			Sender:    id,
+			Recipient: id,
+			Deadline:  time.Now().Add(time.Hour),
+			HashInfo:  htlc.HashInfo{Hash: hash[:], HashFunc: crypto2.SHA256},
+		}
+		ledger = &timedLedger{
+			Ledger: &mock.Ledger{GetStateStub: func(key string) ([]byte, error) {
+				if key == "0" {
+					return input.Serialize()
+				}
+				return nil, nil
+			}},
+			timestamp: time.Now(),
+		}
+	})
+	claimer := func() *htlc.Signer {
+		s := htlc.NewSigner(script)
+		s.Now = time.Now
+		Expect(s.SetRecipient(signer, preimage)).To(Succeed())
+		return s
+	}
+	reclaimer := func() *htlc.Signer {
+		s := htlc.NewSigner(script)
+		s.Now = func() time.Time { return script.Deadline }
+		s.SetSender(signer)
+		return s
+	}
+
+	It("accepts a claim before the deadline of the transaction", func() {
+		var raw []byte
+		input, raw = prepareScriptTransfer(pp, script, claimer(), id)
+		actions, err := engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(1))
+	})
+	It("rejects a claim once the transaction is past the deadline", func() {
+		var raw []byte
+		input, raw = prepareScriptTransfer(pp, script, claimer(), id)
+		ledger.timestamp = script.Deadline
+		_, err := engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cannot claim: the deadline has expired"))
+	})
+	It("accepts a reclaim only once the transaction is past the deadline", func() {
+		var raw []byte
+		input, raw = prepareScriptTransfer(pp, script, reclaimer(), id)
+		_, err := engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cannot reclaim: the deadline has not expired yet"))
+
+		ledger.timestamp = script.Deadline.Add(time.Second)
+		actions, err := engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actions).To(HaveLen(1))
+	})
+	It("rejects the spending of a script when the time of the transaction is unknown", func() {
+		var raw []byte
+		input, raw = prepareScriptTransfer(pp, script, claimer(), id)
+		_, err := engine.VerifyTokenRequestFromRaw(ledger.Ledger, "1", raw)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("the time of the transaction is unknown"))
+	})
+	It("rejects an output owned by a script that has expired at the time of the transaction", func() {
+		expired := *script
+		expired.Deadline = ledger.timestamp.Add(time.Minute)
+		owner, err := expired.Identity()
+		Expect(err).NotTo(HaveOccurred())
+		var raw []byte
+		input, raw = prepareScriptTransfer(pp, script, claimer(), owner)
+		_, err = engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).NotTo(HaveOccurred())
+
+		ledger.timestamp = ledger.timestamp.Add(2 * time.Minute)
+		_, err = engine.VerifyTokenRequestFromRaw(ledger, "1", raw)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("the deadline has already expired"))
+	})
+})
+
+// prepareScriptTransfer returns a token owned by the passed script, stored under the key `0`,
+// and the request, signed by the passed script signer, transferring it to the passed owner
+func prepareScriptTransfer(pp *crypto.PublicParams, script *htlc.Script, signer *htlc.Signer, owner view.Identity) (*tokn.Token, []byte) {
+	scriptOwner, err := script.Identity()
+	Expect(err).NotTo(HaveOccurred())
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	bf := bn256.RandModOrder(rand)
+	input := &tokn.Token{Data: prepareToken(bn256.NewZrInt(10), bf, "ABC", pp.ZKATPedParams), Owner: scriptOwner}
+	inf := &tokn.TokenInformation{Type: "ABC", Value: bn256.NewZrInt(10), BlindingFactor: bf}
+	sender, err := transfer.NewSender([]driver.Signer{signer}, []*tokn.Token{input}, []string{"0"}, []*tokn.TokenInformation{inf}, pp)
+	Expect(err).NotTo(HaveOccurred())
+	action, _, err := sender.GenerateZKTransfer([]uint64{10}, [][]byte{owner})
+	Expect(err).NotTo(HaveOccurred())
+
+	raw, err := action.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+	tr := &driver.TokenRequest{Transfers: [][]byte{raw}}
+	raw, err = json.Marshal(tr)
+	Expect(err).NotTo(HaveOccurred())
+	tr.Signatures, err = sender.SignTokenActions(raw, "1")
+	Expect(err).NotTo(HaveOccurred())
+	raw, err = json.Marshal(tr)
+	Expect(err).NotTo(HaveOccurred())
+	return input, raw
+}
+
+// prepareNFTTransfer returns a token holding the non-fungible token `painting`, stored under the passed key,
+// and the request transferring it to the passed number of outputs, declaring the nft if requested
+func prepareNFTTransfer(pp *crypto.PublicParams, auditor *audit.Auditor, key string, outputs int, declare bool) (*tokn.Token, *driver.TokenRequest) {
+	id, auditInfo, signer := getIdemixInfo("./testdata/idemix")
+	ttype := token2.NFTTypePrefix + "painting"
+
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	bf := bn256.RandModOrder(rand)
+	input := &tokn.Token{Data: prepareToken(bn256.NewZrInt(1), bf, ttype, pp.ZKATPedParams), Owner: id}
+	inf := &tokn.TokenInformation{Type: ttype, Value: bn256.NewZrInt(1), BlindingFactor: bf}
+	sender, err := transfer.NewSender([]driver.Signer{signer}, []*tokn.Token{input}, []string{key}, []*tokn.TokenInformation{inf}, pp)
+	Expect(err).NotTo(HaveOccurred())
+
+	values := make([]uint64, outputs)
+	owners := make([][]byte, outputs)
+	for i := 0; i < outputs; i++ {
+		owners[i] = id
+	}
+	values[0] = 1
+	action, infos, err := sender.GenerateZKTransfer(values, owners)
+	Expect(err).NotTo(HaveOccurred())
+	if declare {
+		nfts := make([]*token2.NFT, outputs)
+		bfs := make([]*bn256.Zr, outputs)
+		for i := 0; i < outputs; i++ {
+			nfts[i] = &token2.NFT{ID: "painting"}
+			bfs[i] = infos[i].BlindingFactor
+		}
+		action.NFTs = nfts
+		action.NFTProof, err = issue2.NewNFTProver(bfs, action.GetOutputCommitments(), nfts, pp.ZKATPedParams).Prove()
+		Expect(err).NotTo(HaveOccurred())
+	}
+	raw, err := action.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+	tr := &driver.TokenRequest{Transfers: [][]byte{raw}}
+	raw, err = json.Marshal(tr)
+	Expect(err).NotTo(HaveOccurred())
+
+	metadata := driver.TransferMetadata{}
+	metadata.SenderAuditInfos = [][]byte{nil}
+	metadata.SenderAuditInfos[0], err = auditInfo.Bytes()
+	Expect(err).NotTo(HaveOccurred())
+	for i := 0; i < outputs; i++ {
+		info, err := json.Marshal(infos[i])
+		Expect(err).NotTo(HaveOccurred())
+		metadata.TokenInfo = append(metadata.TokenInfo, info)
+		out, err := json.Marshal(action.OutputTokens[i].Data)
+		Expect(err).NotTo(HaveOccurred())
+		metadata.Outputs = append(metadata.Outputs, out)
+		ai, err := auditInfo.Bytes()
+		Expect(err).NotTo(HaveOccurred())
+		metadata.ReceiverAuditInfos = append(metadata.ReceiverAuditInfos, ai)
+	}
+	err = auditor.Check(tr, &driver.TokenRequestMetadata{Transfers: []driver.TransferMetadata{metadata}}, [][]*tokn.Token{{input}}, "1")
+	Expect(err).NotTo(HaveOccurred())
+	tr.AuditorSignature, err = auditor.Endorse(tr, "1")
+	Expect(err).NotTo(HaveOccurred())
+
+	signatures, err := sender.SignTokenActions(raw, "1")
+	Expect(err).NotTo(HaveOccurred())
+	tr.Signatures = append(tr.Signatures, signatures...)
+
+	return input, tr
+}
+
 func prepareECDSASigner() (*ecdsa.ECDSASigner, *ecdsa.ECDSAVerifier) {
 	signer, err := ecdsa.NewECDSASigner()
 	Expect(err).NotTo(HaveOccurred())
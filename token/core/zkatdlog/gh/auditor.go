@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package gh
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// AuditorCheck opens the owners of the inputs and outputs using the token request metadata
+// and then runs the same checks performed by the auditor of zkatdlog without graph hiding.
+func (s *service) AuditorCheck(tokenRequest *api3.TokenRequest, tokenRequestMetadata *api3.TokenRequestMetadata, txID string) error {
+	logger.Debugf("check token request validity...")
+	if len(tokenRequest.Issues) != len(tokenRequestMetadata.Issues) || len(tokenRequest.Transfers) != len(tokenRequestMetadata.Transfers) {
+		return errors.Errorf("number of actions does not match the number of provided metadata")
+	}
+	pp := s.PublicParams()
+
+	opened := &api3.TokenRequest{}
+	for i, raw := range tokenRequest.Issues {
+		ia := &sn.IssueAction{}
+		if err := ia.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed deserializing issue action [%d]", i)
+		}
+		tokens, err := openOutputs(pp, ia.Outputs, tokenRequestMetadata.Issues[i].TokenInfo)
+		if err != nil {
+			return errors.WithMessagef(err, "failed opening issue action [%d]", i)
+		}
+		raw, err := (&issue.IssueAction{Issuer: ia.Issuer, OutputTokens: tokens, Proof: ia.Proof, Anonymous: ia.Anonymous}).Serialize()
+		if err != nil {
+			return err
+		}
+		opened.Issues = append(opened.Issues, raw)
+	}
+
+	var inputTokens [][]*token.Token
+	for i, raw := range tokenRequest.Transfers {
+		ta := &sn.TransferAction{}
+		if err := ta.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed deserializing transfer action [%d]", i)
+		}
+		tokens, err := openOutputs(pp, ta.Outputs, tokenRequestMetadata.Transfers[i].TokenInfo)
+		if err != nil {
+			return errors.WithMessagef(err, "failed opening transfer action [%d]", i)
+		}
+		raw, err := (&transfer.TransferAction{InputCommitments: ta.GetInputCommitments(), OutputTokens: tokens, Proof: ta.Proof}).Serialize()
+		if err != nil {
+			return err
+		}
+		opened.Transfers = append(opened.Transfers, raw)
+
+		inputs, err := openInputs(pp, ta.Inputs, tokenRequestMetadata.Transfers[i])
+		if err != nil {
+			return errors.WithMessagef(err, "failed opening inputs of transfer action [%d]", i)
+		}
+		inputTokens = append(inputTokens, inputs)
+	}
+
+	if err := audit.NewAuditor(pp.ZKATPedParams, pp.IdemixPK, nil).Check(
+		opened,
+		tokenRequestMetadata,
+		inputTokens,
+		txID,
+	); err != nil {
+		return errors.WithMessagef(err, "failed checking transaction")
+	}
+	return nil
+}
+
+// openInputs returns the passed inputs with the owner in the clear, after checking that
+// the owner commitments open to the senders in the metadata
+func openInputs(pp *crypto.PublicParams, inputs []*sn.Input, metadata api3.TransferMetadata) ([]*token.Token, error) {
+	if len(inputs) != len(metadata.Senders) || len(inputs) != len(metadata.SenderOpenings) {
+		return nil, errors.Errorf("number of inputs does not match the number of senders and owner openings")
+	}
+	tokens := make([]*token.Token, len(inputs))
+	for i, in := range inputs {
+		opening := &sn.OwnerOpening{}
+		if err := opening.Deserialize(metadata.SenderOpenings[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing owner opening [%d]", i)
+		}
+		if !metadata.Senders[i].Equal(opening.Owner) {
+			return nil, errors.Errorf("owner opening [%d] does not match the sender", i)
+		}
+		if err := in.VerifyOwner(opening, pp); err != nil {
+			return nil, errors.Wrapf(err, "invalid input [%d]", i)
+		}
+		tokens[i] = &token.Token{Owner: opening.Owner, Data: in.InputCommitment}
+	}
+	return tokens, nil
+}
+
+// openOutputs returns the passed outputs with the owner in the clear, after checking that
+// the ledger tokens match the provided openings
+func openOutputs(pp *crypto.PublicParams, outputs []*sn.Output, infos [][]byte) ([]*token.Token, error) {
+	if len(outputs) != len(infos) {
+		return nil, errors.Errorf("number of outputs does not match the number of token information")
+	}
+	tokens := make([]*token.Token, len(outputs))
+	for i, output := range outputs {
+		ti := &token.TokenInformation{}
+		if err := ti.Deserialize(infos[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing token information [%d]", i)
+		}
+		if output.IsRedeem() != (len(ti.Owner) == 0) {
+			return nil, errors.Errorf("owner of output [%d] does not match the provided opening", i)
+		}
+		if err := output.Verify(pp); err != nil {
+			return nil, errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		if !output.IsRedeem() {
+			if _, err := output.Token.GetTokenInTheClear(ti, pp); err != nil {
+				return nil, errors.Wrapf(err, "output [%d] does not match the provided opening", i)
+			}
+		}
+		tokens[i] = &token.Token{Owner: ti.Owner, Data: output.Data}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package driver
+
+import (
+	fabric2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ppm"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
+	zkatdlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/gh"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/nogh"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault"
+)
+
+type Driver struct {
+}
+
+func (d *Driver) PublicParametersFromBytes(params []byte) (driver.PublicParameters, error) {
+	pp, err := crypto.NewPublicParamsFromBytes(params)
+	if err != nil {
+		return nil, err
+	}
+	if !pp.GraphHiding() {
+		return nil, errors.Errorf("invalid public parameters, graph hiding is not supported")
+	}
+	return pp, nil
+}
+
+func (d *Driver) NewTokenService(sp view2.ServiceProvider, publicParamsFetcher driver.PublicParamsFetcher, network string, channel driver.Channel, namespace string) (driver.TokenManagerService, error) {
+	nodeIdentity := view2.GetIdentityProvider(sp).DefaultIdentity()
+	return zkatdlog.NewTokenService(
+		channel,
+		namespace,
+		sp,
+		publicParamsFetcher,
+		&nogh.VaultTokenCommitmentLoader{TokenVault: vault.NewVault(sp, network, channel, namespace).QueryEngine()},
+		vault.NewVault(sp, network, channel, namespace).QueryEngine(),
+		identity.NewProvider(
+			sp,
+			map[driver.IdentityUsage]identity.Mapper{
+				driver.IssuerRole:    fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+				driver.AuditorRole:   fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+				driver.CertifierRole: fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+				driver.OwnerRole:     fabric.NewMapper(fabric.IdemixMSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+			},
+		),
+	)
+}
+
+func (d *Driver) NewValidator(params driver.PublicParameters) (driver.Validator, error) {
+	return validator.NewGraphHiding(params.(*crypto.PublicParams)), nil
+}
+
+func (d *Driver) NewPublicParametersManager(params driver.PublicParameters) (driver.PublicParamsManager, error) {
+	return ppm.New(params.(*crypto.PublicParams)), nil
+}
+
+func init() {
+	core.Register(crypto.DLogGraphHidingPublicParameters, &Driver{})
+}
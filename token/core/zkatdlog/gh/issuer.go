@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package gh
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func (s *service) Issue(issuerIdentity view.Identity, typ string, values []token2.Quantity, owners [][]byte) (api3.IssueAction, [][]byte, view.Identity, error) {
+	ia, infoRaws, fid, err := s.TokenManagerService.Issue(issuerIdentity, typ, values, owners)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return s.hideOwners(ia, infoRaws, fid)
+}
+
+// IssueNFT returns an error, transfers hide the spent tokens, therefore non-fungible tokens could not be moved whole
+func (s *service) IssueNFT(issuerIdentity view.Identity, nft *token2.NFT, owner []byte) (api3.IssueAction, [][]byte, view.Identity, error) {
+	return nil, nil, nil, errors.New("non-fungible tokens are not supported with graph hiding")
+}
+
+// hideOwners turns an issue action of zkatdlog without graph hiding into one whose outputs hide their owners
+func (s *service) hideOwners(ia api3.IssueAction, infoRaws [][]byte, fid view.Identity) (api3.IssueAction, [][]byte, view.Identity, error) {
+	action, ok := ia.(*issue.IssueAction)
+	if !ok {
+		return nil, nil, nil, errors.Errorf("expected *issue.IssueAction, got [%T]", ia)
+	}
+
+	infos := make([]*token.TokenInformation, len(infoRaws))
+	for i, raw := range infoRaws {
+		infos[i] = &token.TokenInformation{}
+		if err := infos[i].Deserialize(raw); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed deserializing token info")
+		}
+	}
+	owners := make([][]byte, len(action.OutputTokens))
+	for i, t := range action.OutputTokens {
+		owners[i] = t.Owner
+	}
+	keys, err := s.serialNumberKeys(owners)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// hide the owners
+	ghAction, err := sn.NewIssueAction(action, infos, keys, s.PublicParams())
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "failed hiding owners of issued tokens")
+	}
+	for i, information := range infos {
+		infoRaws[i], err = information.Serialize()
+		if err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "failed serializing token info")
+		}
+	}
+	return ghAction, infoRaws, fid, nil
+}
+
+func (s *service) VerifyIssue(ia api3.IssueAction, tokenInfos [][]byte) error {
+	action, ok := ia.(*sn.IssueAction)
+	if !ok {
+		return errors.Errorf("expected *sn.IssueAction, got [%T]", ia)
+	}
+	if len(tokenInfos) != len(action.Outputs) {
+		return errors.Errorf("number of token information does not match number of outputs")
+	}
+	if len(action.NFTs) != 0 {
+		return errors.New("non-fungible tokens are not supported with graph hiding")
+	}
+
+	pp := s.PublicParams()
+	for i, output := range action.Outputs {
+		if output.IsRedeem() {
+			return errors.Errorf("invalid output [%d], issued tokens must have an owner", i)
+		}
+		if err := output.Verify(pp); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		ti := &token.TokenInformation{}
+		if err := ti.Deserialize(tokenInfos[i]); err != nil {
+			return errors.Wrapf(err, "failed unmarshalling token information")
+		}
+		if _, err := output.Token.GetTokenInTheClear(ti, pp); err != nil {
+			return errors.Wrapf(err, "failed getting token in the clear [%d]", i)
+		}
+	}
+	if err := issue.VerifyNFTs(pp.TypeRegistry, action.NFTs, action.GetCommitments(), action.IsAnonymous(), action.GetProof(), action.NFTProof, pp.ZKATPedParams); err != nil {
+		return errors.Wrap(err, "invalid non-fungible tokens")
+	}
+	return issue.NewVerifier(action.GetCommitments(), action.IsAnonymous(), pp).Verify(action.GetProof())
+}
+
+func (s *service) DeserializeIssueAction(raw []byte) (api3.IssueAction, error) {
+	issue := &sn.IssueAction{}
+	if err := issue.Deserialize(raw); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package gh
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// ownerWallet hands out a fresh serial number key as token metadata,
+// so that the sender of a token cannot derive its serial number
+type ownerWallet struct {
+	api3.OwnerWallet
+	service *service
+}
+
+func (w *ownerWallet) GetTokenMetadata(id view.Identity) ([]byte, error) {
+	key, err := w.service.newSerialNumberKey()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed generating serial number key for [%s]", id)
+	}
+	return json.Marshal(key)
+}
+
+func (s *service) OwnerWallet(walletID string) api3.OwnerWallet {
+	w := s.TokenManagerService.OwnerWallet(walletID)
+	if w == nil {
+		return nil
+	}
+	return &ownerWallet{OwnerWallet: w, service: s}
+}
+
+func (s *service) OwnerWalletByIdentity(identity view.Identity) api3.OwnerWallet {
+	w := s.TokenManagerService.OwnerWalletByIdentity(identity)
+	if w == nil {
+		return nil
+	}
+	return &ownerWallet{OwnerWallet: w, service: s}
+}
+
+// RegisterRecipientIdentity registers the passed recipient identity and the serial number key in its metadata
+func (s *service) RegisterRecipientIdentity(id view.Identity, auditInfo []byte, metadata []byte) error {
+	if err := s.TokenManagerService.RegisterRecipientIdentity(id, auditInfo, metadata); err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	key := &bn256.G1{}
+	if err := json.Unmarshal(metadata, key); err != nil {
+		return errors.Wrapf(err, "invalid serial number key for recipient [%s]", id)
+	}
+	return kvs.GetService(s.sp).Put(s.recipientKeyID(id), key)
+}
+
+// serialNumberKeys returns the serial number keys of the passed owners.
+// The key of a remote owner is the one registered with its identity,
+// a local owner gets a fresh key. Redeemed outputs have no key.
+func (s *service) serialNumberKeys(owners [][]byte) ([]*bn256.G1, error) {
+	keys := make([]*bn256.G1, len(owners))
+	for i, owner := range owners {
+		if len(owner) == 0 {
+			continue
+		}
+		k := s.recipientKeyID(owner)
+		if kvs.GetService(s.sp).Exists(k) {
+			keys[i] = &bn256.G1{}
+			if err := kvs.GetService(s.sp).Get(k, keys[i]); err != nil {
+				return nil, errors.WithMessagef(err, "failed getting serial number key of [%s]", view.Identity(owner))
+			}
+			continue
+		}
+		if s.TokenManagerService.OwnerWalletByIdentity(owner) == nil {
+			return nil, errors.Errorf("no serial number key for recipient [%s], it must be exchanged as token metadata", view.Identity(owner))
+		}
+		var err error
+		keys[i], err = s.newSerialNumberKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// newSerialNumberKey returns a fresh serial number key and stores the secret behind it
+func (s *service) newSerialNumberKey() (*bn256.G1, error) {
+	key, secret, err := sn.NewSerialNumberKey(s.PublicParams())
+	if err != nil {
+		return nil, err
+	}
+	if err := kvs.GetService(s.sp).Put(s.keySecretID(key), secret); err != nil {
+		return nil, errors.WithMessage(err, "failed storing serial number key secret")
+	}
+	return key, nil
+}
+
+// keySecret returns the secret behind the passed serial number key, if it was generated by this node
+func (s *service) keySecret(key *bn256.G1) (*bn256.Zr, error) {
+	k := s.keySecretID(key)
+	if !kvs.GetService(s.sp).Exists(k) {
+		return nil, errors.Errorf("serial number key [%s] was not generated by this node", hex.EncodeToString(key.Bytes()))
+	}
+	secret := &bn256.Zr{}
+	if err := kvs.GetService(s.sp).Get(k, secret); err != nil {
+		return nil, errors.WithMessage(err, "failed getting serial number key secret")
+	}
+	return secret, nil
+}
+
+func (s *service) recipientKeyID(id view.Identity) string {
+	return kvs.CreateCompositeKeyOrPanic(
+		"token-sdk.zkatdlog.gh.recipient-key",
+		[]string{s.channel.Name(), s.namespace, id.UniqueID()},
+	)
+}
+
+func (s *service) keySecretID(key *bn256.G1) string {
+	return kvs.CreateCompositeKeyOrPanic(
+		"token-sdk.zkatdlog.gh.key-secret",
+		[]string{s.channel.Name(), s.namespace, hex.EncodeToString(key.Bytes())},
+	)
+}
@@ -0,0 +1,340 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package gh
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func (s *service) Transfer(txID string, wallet driver.OwnerWallet, ids []*token3.Id, outputTokens ...*token3.Token) (driver.TransferAction, *driver.TransferMetadata, error) {
+	logger.Debugf("Prepare Transfer Action [%s,%v]", txID, ids)
+
+	qe, err := s.channel.Vault().NewQueryExecutor()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer qe.Done()
+
+	pp := s.PublicParams()
+	candidates, err := s.anonymitySetCandidates(qe)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed listing tokens for the anonymity sets")
+	}
+
+	var inputs []*sn.SpentToken
+	var senders []view.Identity
+	for _, id := range ids {
+		// Token Info
+		outputID, err := keys.CreateFabtokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error creating output ID: %v", id)
+		}
+		meta, _, _, err := qe.GetStateMetadata(s.namespace, outputID)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed getting metadata for id [%v]", id)
+		}
+		ti := &token.TokenInformation{}
+		if err := ti.Deserialize(meta[keys.Info]); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed deserializeing token info for id [%v]", id)
+		}
+
+		// Token
+		tokenKey, err := keys.CreateTokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error creating output ID: %v", id)
+		}
+		tok, err := s.loadToken(qe, tokenKey)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed loading token for id [%v]", id)
+		}
+		if _, err := tok.GetTokenInTheClear(ti, pp); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid token, cannot get it in clear [%v]", id)
+		}
+
+		// Anonymity Set
+		input, err := s.anonymitySet(qe, candidates, tokenKey, tok)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed preparing anonymity set for id [%v]", id)
+		}
+		input.Information = ti
+		if ti.SerialNumberKey == nil {
+			return nil, nil, errors.Errorf("invalid token information for id [%v], serial number key is missing", id)
+		}
+		input.KeySecret, err = s.keySecret(ti.SerialNumberKey)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "cannot spend token [%v]", id)
+		}
+
+		inputs = append(inputs, input)
+		senders = append(senders, ti.Owner)
+	}
+
+	// the spend proofs authorize the transfer, the owners of the inputs do not sign it
+	sender, err := sn.NewSender(inputs, pp)
+	if err != nil {
+		return nil, nil, err
+	}
+	var values []uint64
+	var owners [][]byte
+	var ownerIdentities []view.Identity
+	for _, output := range outputTokens {
+		v, err := pp.ToValue(output.Quantity)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid quantity [%s]", output.Quantity)
+		}
+		values = append(values, v)
+		owners = append(owners, output.Owner.Raw)
+
+		// add owner identity if not present already
+		found := false
+		for _, identity := range ownerIdentities {
+			if identity.Equal(output.Owner.Raw) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ownerIdentities = append(ownerIdentities, output.Owner.Raw)
+		}
+	}
+	keys, err := s.serialNumberKeys(owners)
+	if err != nil {
+		return nil, nil, err
+	}
+	transfer, infos, openings, err := sender.GenerateZKTransfer(values, owners, keys, txID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed generating zkat proof for txid [%s]", txID)
+	}
+
+	// Prepare metadata
+	infoRaws := [][]byte{}
+	for _, information := range infos {
+		raw, err := information.Serialize()
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed serializing token info")
+		}
+		infoRaws = append(infoRaws, raw)
+	}
+
+	var receiverAuditInfos [][]byte
+	for _, output := range outputTokens {
+		auditInfo, err := view2.GetSigService(s.sp).GetAuditInfo(output.Owner.Raw)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed getting audit info for recipient identity [%s]", view.Identity(output.Owner.Raw).String())
+		}
+		receiverAuditInfos = append(receiverAuditInfos, auditInfo)
+	}
+
+	var senderOpenings [][]byte
+	for _, opening := range openings {
+		raw, err := opening.Serialize()
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed serializing owner opening")
+		}
+		senderOpenings = append(senderOpenings, raw)
+	}
+
+	var senderAuditInfos [][]byte
+	for _, id := range senders {
+		auditInfo, err := view2.GetSigService(s.sp).GetAuditInfo(id)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed getting audit info for sender identity [%s]", id.String())
+		}
+		senderAuditInfos = append(senderAuditInfos, auditInfo)
+	}
+
+	outputs, err := transfer.GetSerializedOutputs()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed getting serialized outputs")
+	}
+
+	receiverIsSender := make([]bool, len(ownerIdentities))
+	for i, receiver := range ownerIdentities {
+		receiverIsSender[i] = s.OwnerWalletByIdentity(receiver) != nil
+	}
+
+	metadata := &driver.TransferMetadata{
+		Outputs:            outputs,
+		Senders:            senders,
+		SenderAuditInfos:   senderAuditInfos,
+		SenderOpenings:     senderOpenings,
+		UnsignedSenders:    true,
+		TokenIDs:           ids,
+		TokenInfo:          infoRaws,
+		Receivers:          ownerIdentities,
+		ReceiverAuditInfos: receiverAuditInfos,
+		ReceiverIsSender:   receiverIsSender,
+	}
+
+	return transfer, metadata, nil
+}
+
+func (s *service) VerifyTransfer(action driver.TransferAction, tokenInfos [][]byte) error {
+	tr, ok := action.(*sn.TransferAction)
+	if !ok {
+		return errors.Errorf("expected *sn.TransferAction")
+	}
+	if len(tokenInfos) != len(tr.Outputs) {
+		return errors.Errorf("number of token information does not match number of outputs")
+	}
+
+	pp := s.PublicParams()
+	for i, output := range tr.Outputs {
+		if err := output.Verify(pp); err != nil {
+			return errors.Wrapf(err, "invalid output [%d]", i)
+		}
+		ti := &token.TokenInformation{}
+		if err := ti.Deserialize(tokenInfos[i]); err != nil {
+			return errors.Wrapf(err, "failed unmarshalling token information")
+		}
+		raw, err := output.Serialize()
+		if err != nil {
+			return errors.Wrapf(err, "failed serializing output [%d]", i)
+		}
+		tok := &sn.Token{}
+		if err := tok.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed deserializing output [%d]", i)
+		}
+		clear, err := tok.GetTokenInTheClear(ti, pp)
+		if err != nil {
+			return errors.Wrapf(err, "failed getting token in the clear")
+		}
+		logger.Debugf("transfer output [%s,%s,%s]", clear.Type, clear.Quantity, view.Identity(clear.Owner.Raw))
+	}
+	return transfer.NewVerifier(tr.GetInputCommitments(), tr.GetOutputCommitments(), pp).Verify(tr.Proof)
+}
+
+func (s *service) DeserializeTransferAction(raw []byte) (driver.TransferAction, error) {
+	transfer := &sn.TransferAction{}
+	if err := transfer.Deserialize(raw); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (s *service) loadToken(qe *fabric.QueryExecutor, key string) (*sn.Token, error) {
+	raw, err := qe.GetState(s.namespace, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting state [%s]", key)
+	}
+	if len(raw) == 0 {
+		return nil, errors.Errorf("token [%s] does not exist", key)
+	}
+	tok := &sn.Token{}
+	if err := tok.Deserialize(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling token [%s]", key)
+	}
+	return tok, nil
+}
+
+// anonymitySetCandidates returns the keys of the ledger tokens known to the vault.
+// Spent tokens are never removed from the ledger, therefore any of them can be used to hide a spent token.
+func (s *service) anonymitySetCandidates(qe *fabric.QueryExecutor) ([]string, error) {
+	startKey, err := keys.CreateCompositeKey(keys.TokenKeyPrefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	endKey := startKey + string(keys.MaxUnicodeRuneValue)
+	iterator, err := qe.GetStateRangeScanIterator(s.namespace, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	var res []string
+	for {
+		next, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return res, nil
+		}
+		if len(next.Raw) == 0 {
+			continue
+		}
+		_, components, err := keys.SplitCompositeKey(next.Key)
+		if err != nil || len(components) != 2 {
+			continue
+		}
+		switch components[0] {
+		case keys.SerialNumber, keys.TokenSetupKeyPrefix, keys.TokenRequestKeyPrefix, keys.NFT, keys.Supply:
+			continue
+		}
+		if _, err := strconv.Atoi(components[1]); err != nil {
+			continue
+		}
+		res = append(res, next.Key)
+	}
+}
+
+// anonymitySet hides the passed token among distinct tokens randomly chosen from the passed candidates.
+// It fails when there are not enough candidates to fill the anonymity set.
+func (s *service) anonymitySet(qe *fabric.QueryExecutor, candidates []string, tokenKey string, tok *sn.Token) (*sn.SpentToken, error) {
+	var others []string
+	for _, c := range candidates {
+		if c != tokenKey {
+			others = append(others, c)
+		}
+	}
+
+	size := 1 << uint(s.PublicParams().SerialNumberParams.BitLength)
+	if len(others) < size-1 {
+		return nil, errors.Errorf("not enough tokens on the ledger to hide the spent token, [%d] needed, [%d] available", size-1, len(others))
+	}
+	index, err := randomInt(size)
+	if err != nil {
+		return nil, err
+	}
+	res := &sn.SpentToken{
+		AnonymitySet:       make([]string, size),
+		AnonymitySetTokens: make([]*sn.Token, size),
+		Index:              index,
+	}
+	pool := others
+	for i := 0; i < size; i++ {
+		if i == index {
+			res.AnonymitySet[i] = tokenKey
+			res.AnonymitySetTokens[i] = tok
+			continue
+		}
+		// sample without replacement
+		j, err := randomInt(len(pool))
+		if err != nil {
+			return nil, err
+		}
+		res.AnonymitySet[i] = pool[j]
+		res.AnonymitySetTokens[i], err = s.loadToken(qe, pool[j])
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool[:j], pool[j+1:]...)
+	}
+	return res, nil
+}
+
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed getting random number")
+	}
+	return int(i.Int64()), nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package gh
+
+import (
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ppm"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sn"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/nogh"
+	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type PublicParamsProvider interface {
+	PublicParams() *crypto.PublicParams
+}
+
+// service is the token manager service of zkatdlog with graph hiding.
+// Wallets and identities are managed as in zkatdlog without graph hiding,
+// while issues and transfers hide the owners and spend tokens by revealing serial numbers.
+type service struct {
+	api3.TokenManagerService
+	ppProvider PublicParamsProvider
+
+	channel   nogh.Channel
+	namespace string
+	sp        view2.ServiceProvider
+}
+
+func NewTokenService(
+	channel nogh.Channel,
+	namespace string,
+	sp view2.ServiceProvider,
+	publicParamsFetcher api3.PublicParamsFetcher,
+	tokenCommitmentLoader nogh.TokenCommitmentLoader,
+	queryEngine nogh.QueryEngine,
+	identityProvider api3.IdentityProvider,
+) (*service, error) {
+	tms, err := nogh.NewTokenService(channel, namespace, sp, publicParamsFetcher, tokenCommitmentLoader, queryEngine, identityProvider)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		TokenManagerService: tms,
+		ppProvider:          tms,
+		channel:             channel,
+		namespace:           namespace,
+		sp:                  sp,
+	}, nil
+}
+
+func (s *service) DeserializeToken(tok []byte, infoRaw []byte) (*token3.Token, view.Identity, error) {
+	output := &sn.Token{}
+	if err := output.Deserialize(tok); err != nil {
+		return nil, nil, err
+	}
+
+	ti := &token.TokenInformation{}
+	if err := ti.Deserialize(infoRaw); err != nil {
+		return nil, nil, err
+	}
+
+	to, err := output.GetTokenInTheClear(ti, s.PublicParams())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return to, ti.Issuer, nil
+}
+
+func (s *service) Validator() api3.Validator {
+	return validator.NewGraphHiding(s.PublicParams())
+}
+
+func (s *service) PublicParamsManager() api3.PublicParamsManager {
+	return ppm.New(s.PublicParams())
+}
+
+func (s *service) PublicParams() *crypto.PublicParams {
+	pp := s.ppProvider.PublicParams()
+	if pp != nil && !pp.GraphHiding() {
+		panic(errors.Errorf("public parameters [%s] do not support graph hiding", pp.Identifier()))
+	}
+	return pp
+}
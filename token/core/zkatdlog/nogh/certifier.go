@@ -6,18 +6,75 @@ SPDX-License-Identifier: Apache-2.0
 package nogh
 
 import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
 	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
+// NewCertificationRequest returns a request to certify the passed tokens, as stored in the vault
 func (s *service) NewCertificationRequest(ids []*token3.Id) ([]byte, error) {
-	return nil, nil
+	tokens, err := s.loadTokenCommitments(ids)
+	if err != nil {
+		return nil, err
+	}
+	cr, err := certification.NewCertificationRequest(ids, tokens)
+	if err != nil {
+		return nil, err
+	}
+	return cr.Serialize()
 }
 
+// Certify checks that the tokens in the request are the passed tokens, as stored on the ledger,
+// and signs the certifications with the certifier identity of the passed wallet
 func (s *service) Certify(wallet api3.CertifierWallet, ids []*token3.Id, tokens [][]byte, request []byte) ([][]byte, error) {
-	return nil, nil
+	if wallet == nil {
+		return nil, errors.New("certifier wallet is not set")
+	}
+	id, err := wallet.GetCertifierIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting certifier identity")
+	}
+	if len(s.PublicParams().Certifier) != 0 && !id.Equal(s.PublicParams().Certifier) {
+		return nil, errors.Errorf("certifier identity does not match the certifier in the public parameters")
+	}
+	signer, err := wallet.GetSigner(id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting certifier signer")
+	}
+	return certification.NewCertifier(signer).Certify(ids, tokens, request)
 }
 
+// VerifyCertifications checks the passed certifications against the tokens stored in the vault
+// and the certifier in the public parameters
 func (s *service) VerifyCertifications(ids []*token3.Id, certifications [][]byte) error {
-	return nil
+	certifier := s.PublicParams().Certifier
+	if len(certifier) == 0 {
+		return errors.New("no certifier set in the public parameters")
+	}
+	verifier, err := (&fabric.MSPX509IdentityDeserializer{}).GetVerifier(certifier)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve certifier's identity")
+	}
+	tokens, err := s.loadTokenCommitments(ids)
+	if err != nil {
+		return err
+	}
+	return certification.NewCertificationVerifier(verifier).Verify(ids, tokens, certifications)
+}
+
+func (s *service) loadTokenCommitments(ids []*token3.Id) ([][]byte, error) {
+	var tokens [][]byte
+	if err := s.qe.GetTokenCommitments(ids, func(id *token3.Id, raw []byte) error {
+		if len(raw) == 0 {
+			return errors.Errorf("token %s not found in the vault", id)
+		}
+		tokens = append(tokens, raw)
+		return nil
+	}); err != nil {
+		return nil, errors.WithMessagef(err, "failed loading tokens [%v]", ids)
+	}
+	return tokens, nil
 }
@@ -7,20 +7,33 @@ package nogh
 
 import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/nonanonym"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"github.com/pkg/errors"
 )
 
-func (s *service) Issue(issuerIdentity view.Identity, typ string, values []uint64, owners [][]byte) (api3.IssueAction, [][]byte, view.Identity, error) {
+func (s *service) Issue(issuerIdentity view.Identity, typ string, values []token2.Quantity, owners [][]byte) (api3.IssueAction, [][]byte, view.Identity, error) {
 	for _, owner := range owners {
 		if len(owner) == 0 {
 			return nil, nil, nil, errors.Errorf("all recipients should be defined")
 		}
 	}
 
+	// the range proofs bound the quantities
+	vs := make([]uint64, len(values))
+	for i, v := range values {
+		var err error
+		vs[i], err = s.PublicParams().ToValue(v.Decimal())
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "invalid quantity [%s]", v.Decimal())
+		}
+	}
+
 	signer, err := s.IssuerWalletByIdentity(issuerIdentity).GetSigner(issuerIdentity)
 	if err != nil {
 		return nil, nil, nil, err
@@ -32,7 +45,7 @@ func (s *service) Issue(issuerIdentity view.Identity, typ string, values []uint6
 		Signer:   signer,
 	}, s.PublicParams())
 
-	issue, infos, err := issuer.GenerateZKIssue(values, owners)
+	issue, infos, err := issuer.GenerateZKIssue(vs, owners)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -58,9 +71,41 @@ func (s *service) Issue(issuerIdentity view.Identity, typ string, values []uint6
 	return issue, infoRaws, fid, err
 }
 
+func (s *service) IssueNFT(issuerIdentity view.Identity, nft *token2.NFT, owner []byte) (api3.IssueAction, [][]byte, view.Identity, error) {
+	if err := nft.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	ia, infoRaws, fid, err := s.Issue(issuerIdentity, nft.Type(), []token2.Quantity{token2.NewQuantityFromUInt64(token2.NFTQuantity)}, [][]byte{owner})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	action := ia.(*issue.IssueAction)
+
+	ti := &token.TokenInformation{}
+	if err := ti.Deserialize(infoRaws[0]); err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "failed deserializing token info")
+	}
+	nfts := []*token2.NFT{nft}
+	action.NFTProof, err = issue.NewNFTProver(
+		[]*bn256.Zr{ti.BlindingFactor},
+		action.GetCommitments(),
+		nfts,
+		s.PublicParams().ZKATPedParams,
+	).Prove()
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "failed generating nft proof")
+	}
+	action.NFTs = nfts
+
+	return action, infoRaws, fid, nil
+}
+
 func (s *service) VerifyIssue(ia api3.IssueAction, tokenInfos [][]byte) error {
 	action := ia.(*issue.IssueAction)
 
+	if err := issue.VerifyNFTs(s.PublicParams().TypeRegistry, action.NFTs, action.GetCommitments(), action.IsAnonymous(), action.GetProof(), action.NFTProof, s.PublicParams().ZKATPedParams); err != nil {
+		return errors.Wrap(err, "invalid non-fungible tokens")
+	}
 	return issue.NewVerifier(
 		action.GetCommitments(),
 		action.IsAnonymous(),
@@ -6,8 +6,6 @@ SPDX-License-Identifier: Apache-2.0
 package nogh
 
 import (
-	"strconv"
-
 	"github.com/pkg/errors"
 
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -15,6 +13,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -97,13 +96,9 @@ func (s *service) Transfer(txID string, wallet driver.OwnerWallet, ids []*token3
 	var owners [][]byte
 	var ownerIdentities []view.Identity
 	for _, output := range outputTokens {
-		q, err := token3.ToQuantity(output.Quantity, keys.Precision)
-		if err != nil {
-			return nil, nil, err
-		}
-		v, err := strconv.ParseUint(q.Decimal(), 10, 64)
+		v, err := pp.ToValue(output.Quantity)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, errors.Wrapf(err, "invalid quantity [%s]", output.Quantity)
 		}
 		values = append(values, v)
 		owners = append(owners, output.Owner.Raw)
@@ -124,6 +119,11 @@ func (s *service) Transfer(txID string, wallet driver.OwnerWallet, ids []*token3
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "failed generating zkat proof for txid [%s]", txID)
 	}
+	if len(inputInf) != 0 && token3.IsNFTType(inputInf[0].Type) {
+		if err := s.proveNFTs(transfer, inputInf[0].Type, infos); err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed generating nft proof for txid [%s]", txID)
+		}
+	}
 
 	// Prepare metadata
 	infoRaws := [][]byte{}
@@ -200,9 +200,35 @@ func (s *service) VerifyTransfer(action driver.TransferAction, tokenInfos [][]by
 		}
 		logger.Debugf("transfer output [%s,%s,%s]", tok.Type, tok.Quantity, view.Identity(tok.Owner.Raw))
 	}
+	if len(tr.NFTs) != 0 {
+		if err := issue.NewNFTVerifier(com, tr.NFTs, pp.ZKATPedParams).Verify(tr.NFTProof); err != nil {
+			return errors.Wrap(err, "invalid non-fungible tokens")
+		}
+	}
 	return transfer.NewVerifier(tr.InputCommitments, com, pp).Verify(tr.Proof)
 }
 
+// proveNFTs declares that each output of the passed transfer holds the non-fungible token of the passed type,
+// and proves it. The validator requires the proof when a non-fungible token is spent.
+func (s *service) proveNFTs(tr *transfer.TransferAction, typ string, infos []*token.TokenInformation) error {
+	id, err := token3.NFTIDFromType(typ)
+	if err != nil {
+		return err
+	}
+	nfts := make([]*token3.NFT, len(infos))
+	bfs := make([]*bn256.Zr, len(infos))
+	for i, inf := range infos {
+		nfts[i] = &token3.NFT{ID: id}
+		bfs[i] = inf.BlindingFactor
+	}
+	tr.NFTProof, err = issue.NewNFTProver(bfs, tr.GetOutputCommitments(), nfts, s.PublicParams().ZKATPedParams).Prove()
+	if err != nil {
+		return err
+	}
+	tr.NFTs = nfts
+	return nil
+}
+
 func (s *service) DeserializeTransferAction(raw []byte) (driver.TransferAction, error) {
 	transfer := &transfer.TransferAction{}
 	err := transfer.Deserialize(raw)
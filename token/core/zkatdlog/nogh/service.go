@@ -39,8 +39,12 @@ type TokenCommitmentLoader interface {
 type QueryEngine interface {
 	IsMine(id *token3.Id) (bool, error)
 	ListUnspentTokens() (*token3.UnspentTokens, error)
+	ListUnspentTokensOf(wallet string, typ string) (*token3.UnspentTokens, error)
+	UnspentTokensIterator(filter *api3.TokenFilter, cursor string) (api3.UnspentTokensIterator, error)
 	ListAuditTokens(ids ...*token3.Id) ([]*token3.Token, error)
 	ListHistoryIssuedTokens() (*token3.IssuedTokens, error)
+	GetNFT(id string) (*token3.NFT, error)
+	GetTokenCommitments(ids []*token3.Id, callback api3.QueryCallbackFunc) error
 }
 
 type service struct {
@@ -64,6 +68,7 @@ type service struct {
 	ownerWallets     []*wallet
 	issuerWallets    []*issuerWallet
 	auditorWallets   []*auditorWallet
+	certifierWallets []*certifierWallet
 	walletsLock      sync.Mutex
 }
 
@@ -242,10 +242,39 @@ func (s *service) auditorWallet(id interface{}) api2.AuditorWallet {
 }
 
 func (s *service) CertifierWallet(id string) api2.CertifierWallet {
-	return nil
+	return s.certifierWallet(id)
 }
 
 func (s *service) CertifierWalletByIdentity(id view.Identity) api2.CertifierWallet {
+	return s.certifierWallet(id)
+}
+
+func (s *service) certifierWallet(id interface{}) api2.CertifierWallet {
+	s.walletsLock.Lock()
+	defer s.walletsLock.Unlock()
+
+	// check if there is already a wallet
+	identity, walletID := s.identityProvider.LookupIdentifier(api2.CertifierRole, id)
+	for _, w := range s.certifierWallets {
+		if w.Contains(identity) || w.ID() == walletID {
+			logger.Debugf("found certifier wallet [%s:%s]", identity, walletID)
+			return w
+		}
+	}
+
+	// Create the wallet
+	if idInfo := s.identityProvider.GetIdentityInfo(api2.CertifierRole, walletID); idInfo != nil {
+		id, err := idInfo.GetIdentity()
+		if err != nil {
+			panic(err)
+		}
+		w := newCertifierWallet(s, idInfo.ID, id)
+		s.certifierWallets = append(s.certifierWallets, w)
+		logger.Debugf("created certifier wallet [%s:%s]", identity, walletID)
+		return w
+	}
+
+	logger.Debugf("no certifier wallet found for [%s:%s]", identity, walletID)
 	return nil
 }
 
@@ -306,29 +335,63 @@ func (w *wallet) GetSigner(identity view.Identity) (api2.Signer, error) {
 
 func (w *wallet) ListTokens(opts *api2.ListTokensOptions) (*token2.UnspentTokens, error) {
 	logger.Debugf("wallet: list tokens, type [%s]", opts.TokenType)
-	source, err := w.tokenService.qe.ListUnspentTokens()
+	unspentTokens, err := w.tokenService.qe.ListUnspentTokensOf(w.ID(), opts.TokenType)
 	if err != nil {
 		return nil, errors.Wrap(err, "token selection failed")
 	}
+	logger.Debugf("wallet: list tokens done, found [%d] unspent tokens", len(unspentTokens.Tokens))
 
-	unspentTokens := &token2.UnspentTokens{}
-	for _, t := range source.Tokens {
-		if len(opts.TokenType) != 0 && t.Type != opts.TokenType {
-			logger.Debugf("wallet: discarding token of type [%s]!=[%s]", t.Type, opts.TokenType)
+	return unspentTokens, nil
+}
+
+// UnspentTokensIterator returns an iterator over the unspent tokens of this wallet that match the passed filter
+func (w *wallet) UnspentTokensIterator(filter *api2.TokenFilter, cursor string) (api2.UnspentTokensIterator, error) {
+	f := &api2.TokenFilter{}
+	if filter != nil {
+		*f = *filter
+	}
+	f.Wallet = w.ID()
+	it, err := w.tokenService.qe.UnspentTokensIterator(f, cursor)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed iterating over unspent tokens")
+	}
+	return it, nil
+}
+
+func (w *wallet) ListNFTs() ([]*token2.NFT, error) {
+	logger.Debugf("wallet: list nfts")
+	unspentTokens, err := w.ListTokens(&api2.ListTokensOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	one := token2.NewQuantityFromUInt64(token2.NFTQuantity)
+	var nfts []*token2.NFT
+	for _, t := range unspentTokens.Tokens {
+		if !token2.IsNFTType(t.Type) {
 			continue
 		}
-
-		if !w.Contains(t.Owner.Raw) {
-			logger.Debugf("wallet: discarding token, owner does not belong to this wallet")
+		q, err := token2.ToQuantity(t.Quantity, token2.MaxPrecision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s] for token [%s]", t.Quantity, t.Id)
+		}
+		if q.Cmp(one) != 0 {
+			logger.Debugf("wallet: discarding token of type [%s], quantity [%s] is not one", t.Type, t.Quantity)
 			continue
 		}
-
-		logger.Debugf("wallet: adding token of type [%s], quantity [%s]", t.Type, t.Quantity)
-		unspentTokens.Tokens = append(unspentTokens.Tokens, t)
+		id, err := token2.NFTIDFromType(t.Type)
+		if err != nil {
+			return nil, err
+		}
+		nft, err := w.tokenService.qe.GetNFT(id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting nft [%s]", id)
+		}
+		nfts = append(nfts, nft)
 	}
-	logger.Debugf("wallet: list tokens done, found [%d] unspent tokens", len(unspentTokens.Tokens))
+	logger.Debugf("wallet: list nfts done, found [%d] nfts", len(nfts))
 
-	return unspentTokens, nil
+	return nfts, nil
 }
 
 func (w *wallet) existsRecipientIdentity(id view.Identity) bool {
@@ -469,3 +532,41 @@ func (w *auditorWallet) GetSigner(id view.Identity) (api2.Signer, error) {
 	}
 	return si, err
 }
+
+type certifierWallet struct {
+	tokenService *service
+	id           string
+	identity     view.Identity
+}
+
+func newCertifierWallet(tokenService *service, id string, identity view.Identity) *certifierWallet {
+	return &certifierWallet{
+		tokenService: tokenService,
+		id:           id,
+		identity:     identity,
+	}
+}
+
+func (w *certifierWallet) ID() string {
+	return w.id
+}
+
+func (w *certifierWallet) Contains(identity view.Identity) bool {
+	return w.identity.Equal(identity)
+}
+
+func (w *certifierWallet) GetCertifierIdentity() (view.Identity, error) {
+	return w.identity, nil
+}
+
+func (w *certifierWallet) GetSigner(id view.Identity) (api2.Signer, error) {
+	if !w.Contains(id) {
+		return nil, errors.Errorf("identity [%s] does not belong to this wallet [%s]", id, w.ID())
+	}
+
+	si, err := w.tokenService.identityProvider.GetSigner(w.identity)
+	if err != nil {
+		return nil, err
+	}
+	return si, err
+}
@@ -5,6 +5,8 @@ SPDX-License-Identifier: Apache-2.0
 */
 package driver
 
+import token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+
 type SetupAction interface {
 	GetSetupParameters() ([]byte, error)
 }
@@ -16,6 +18,8 @@ type IssueAction interface {
 	GetOutputs() []Output
 	IsAnonymous() bool
 	GetIssuer() []byte
+	// GetNFTs returns the non-fungible tokens created by this action, if any
+	GetNFTs() []*token2.NFT
 }
 
 type Output interface {
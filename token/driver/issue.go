@@ -5,10 +5,17 @@ SPDX-License-Identifier: Apache-2.0
 */
 package driver
 
-import "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 type IssueService interface {
-	Issue(id view.Identity, typ string, values []uint64, owners [][]byte) (IssueAction, [][]byte, view.Identity, error)
+	Issue(id view.Identity, typ string, values []token2.Quantity, owners [][]byte) (IssueAction, [][]byte, view.Identity, error)
+
+	// IssueNFT issues to the passed owner a token of quantity one whose type is derived from the unique identifier of the passed nft
+	IssueNFT(id view.Identity, nft *token2.NFT, owner []byte) (IssueAction, [][]byte, view.Identity, error)
 
 	VerifyIssue(tr IssueAction, tokenInfos [][]byte) error
 
@@ -5,7 +5,11 @@ SPDX-License-Identifier: Apache-2.0
 */
 package driver
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 type SerializedPublicParameters struct {
 	Identifier string
@@ -28,7 +32,13 @@ type PublicParameters interface {
 	TokenDataHiding() bool
 	GraphHiding() bool
 	MaxTokenValue() uint64
+	// Precision returns the number of bits used to represent token quantities
+	Precision() uint64
+	// TokenTypes returns the token types registered in the public parameters
+	TokenTypes() token2.TypeRegistry
 	CertificationDriver() string
+	// Authority returns the identity of the authority that can freeze and seize tokens, if any
+	Authority() []byte
 	Bytes() ([]byte, error)
 }
 
@@ -41,6 +51,14 @@ type PublicParamsManager interface {
 
 	SetCertifier(certifier []byte) ([]byte, error)
 
+	// SetAuthority sets the identity of the authority that can freeze and seize tokens.
+	// It returns the serialized public parameters.
+	SetAuthority(authority []byte) ([]byte, error)
+
+	// RegisterTokenType registers the passed token type, replacing any previous registration of the same type.
+	// It returns the serialized public parameters.
+	RegisterTokenType(info *token2.TypeInfo) ([]byte, error)
+
 	NewCertifierKeyPair() ([]byte, []byte, error)
 
 	ForceFetch() error
@@ -47,6 +47,12 @@ type TransferMetadata struct {
 	Receivers          []view.Identity
 	ReceiverIsSender   []bool
 	ReceiverAuditInfos [][]byte
+	// SenderOpenings open the commitments to the senders carried by the inputs, one for each sender.
+	// They are set by the drivers that hide the senders on the ledger and are checked by the auditor.
+	SenderOpenings [][]byte `json:",omitempty"`
+	// UnsignedSenders is true when the action alone authorizes the spending of the inputs,
+	// the senders do not sign the request
+	UnsignedSenders bool `json:",omitempty"`
 }
 
 type TokenRequestMetadata struct {
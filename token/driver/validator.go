@@ -5,7 +5,11 @@ SPDX-License-Identifier: Apache-2.0
 */
 package driver
 
-import "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+)
 
 type GetStateFnc = func(key string) ([]byte, error)
 
@@ -13,6 +17,23 @@ type Ledger interface {
 	GetState(key string) ([]byte, error)
 }
 
+// TimeProvider is implemented by the ledgers that know the timestamp of the transaction being validated.
+// The validators check the deadlines of the time-locked owners against it.
+type TimeProvider interface {
+	// TxTimestamp returns the timestamp of the transaction being validated
+	TxTimestamp() (time.Time, error)
+}
+
+// GetTxTimestamp returns the timestamp of the transaction being validated against the passed ledger,
+// or the zero time if the ledger does not know it
+func GetTxTimestamp(ledger Ledger) (time.Time, error) {
+	tp, ok := ledger.(TimeProvider)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return tp.TxTimestamp()
+}
+
 type SignatureProvider interface {
 	HasBeenSignedBy(id view.Identity, verifier Verifier) error
 }
@@ -20,5 +41,5 @@ type SignatureProvider interface {
 type Validator interface {
 	VerifyTokenRequest(ledger Ledger, signatureProvider SignatureProvider, binding string, tr *TokenRequest) ([]interface{}, error)
 
-	VerifyTokenRequestFromRaw(getState GetStateFnc, binding string, raw []byte) ([]interface{}, error)
+	VerifyTokenRequestFromRaw(ledger Ledger, binding string, raw []byte) ([]interface{}, error)
 }
@@ -6,11 +6,49 @@ SPDX-License-Identifier: Apache-2.0
 package driver
 
 import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type QueryCallbackFunc func(*token.Id, []byte) error
 
+// TokenFilter selects tokens. Empty fields match all tokens.
+type TokenFilter struct {
+	// Wallet is the identifier of the owner wallet the tokens must belong to
+	Wallet string
+	// Owner is the identity that must own the tokens
+	Owner view.Identity
+	// Type is the type of the tokens
+	Type string
+	// MinQuantity is the minimum quantity of the tokens, inclusive
+	MinQuantity token.Quantity
+	// MaxQuantity is the maximum quantity of the tokens, inclusive
+	MaxQuantity token.Quantity
+	// TxID is the identifier of the transaction that created the tokens
+	TxID string
+}
+
+// UnspentTokensIterator iterates over unspent tokens
+type UnspentTokensIterator interface {
+	// Next returns the next unspent token, nil when there are no more tokens
+	Next() (*token.UnspentToken, error)
+	// Cursor returns an opaque cursor that resumes the iteration after the last token returned by Next
+	Cursor() string
+	// Close releases the resources held by the iterator
+	Close()
+}
+
+// IssuedTokensIterator iterates over issued tokens
+type IssuedTokensIterator interface {
+	// Next returns the next issued token, nil when there are no more tokens
+	Next() (*token.IssuedToken, error)
+	// Cursor returns an opaque cursor that resumes the iteration after the last token returned by Next
+	Cursor() string
+	// Close releases the resources held by the iterator
+	Close()
+}
+
 type Vault interface {
 	QueryEngine() QueryEngine
 }
@@ -23,10 +61,25 @@ type CertificationStorage interface {
 type QueryEngine interface {
 	IsMine(id *token.Id) (bool, error)
 	ListUnspentTokens() (*token.UnspentTokens, error)
+	// ListUnspentTokensOf returns the unspent tokens owned by the passed wallet and of the passed type.
+	// The empty type matches all types.
+	ListUnspentTokensOf(wallet string, typ string) (*token.UnspentTokens, error)
+	// Balance returns the sum of the unspent tokens owned by the passed wallet and of the passed type
+	Balance(wallet string, typ string) (token.Quantity, error)
+	// Balances returns the balance of each token type owned by the passed wallet
+	Balances(wallet string) (map[string]token.Quantity, error)
 	ListAuditTokens(ids ...*token.Id) ([]*token.Token, error)
+	// UnspentTokensIterator returns an iterator over the unspent tokens that match the passed filter.
+	// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+	UnspentTokensIterator(filter *TokenFilter, cursor string) (UnspentTokensIterator, error)
 	ListHistoryIssuedTokens() (*token.IssuedTokens, error)
+	// IssuedTokensIterator returns an iterator over the issued tokens that match the passed filter.
+	// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+	IssuedTokensIterator(filter *TokenFilter, cursor string) (IssuedTokensIterator, error)
 	PublicParams() ([]byte, error)
 	GetTokenInfos(ids []*token.Id, callback QueryCallbackFunc) error
 	GetTokenCommitments(ids []*token.Id, callback QueryCallbackFunc) error
 	GetTokens(inputs ...*token.Id) ([]*token.Token, error)
+	// GetNFT returns the non-fungible token with the passed unique identifier
+	GetNFT(id string) (*token.NFT, error)
 }
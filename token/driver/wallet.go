@@ -44,6 +44,14 @@ type OwnerWallet interface {
 	// ListTokens returns the list of unspent tokens owned by this wallet filtered using the passed options.
 	ListTokens(opts *ListTokensOptions) (*token2.UnspentTokens, error)
 
+	// UnspentTokensIterator returns an iterator over the unspent tokens owned by this wallet that match the passed filter.
+	// The wallet field of the filter is ignored. If the cursor is not empty, the iteration resumes after the token
+	// the cursor was taken at.
+	UnspentTokensIterator(filter *TokenFilter, cursor string) (UnspentTokensIterator, error)
+
+	// ListNFTs returns the list of non-fungible tokens owned by this wallet
+	ListNFTs() ([]*token2.NFT, error)
+
 	// GetTokenMetadata returns any information needed to implement the transfer
 	GetTokenMetadata(id view.Identity) ([]byte, error)
 }
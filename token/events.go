@@ -0,0 +1,200 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"sync"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// EventType is the type of token event
+type EventType string
+
+const (
+	// TokenReceived is emitted when a token owned by a local owner wallet is committed
+	TokenReceived EventType = "TokenReceived"
+	// TokenSpent is emitted when a token owned by a local owner wallet is spent by a committed transaction
+	TokenSpent EventType = "TokenSpent"
+	// TransactionConfirmed is emitted, for each local owner wallet and token type involved, when a transaction is committed
+	TransactionConfirmed EventType = "TransactionConfirmed"
+	// TransactionInvalidated is emitted, for each local owner wallet and token type involved, when a transaction
+	// submitted by this node is not committed
+	TransactionInvalidated EventType = "TransactionInvalidated"
+)
+
+// DefaultEventBufferSize is the number of events a subscription buffers when no buffer size is passed
+const DefaultEventBufferSize = 100
+
+// Event describes what a transaction did to a local owner wallet
+type Event struct {
+	Type EventType
+	// TxID is the identifier of the transaction
+	TxID string
+	// Wallet is the identifier of the owner wallet, the empty string if the owner of the token does not belong
+	// to a single local wallet, as for scripts and multisig identities
+	Wallet string
+	// TokenType is the type of the tokens
+	TokenType string
+	// Token is the token received or spent, nil for transaction events
+	Token *token2.UnspentToken
+}
+
+// EventFilter selects events. Empty fields match all events.
+type EventFilter struct {
+	// Types are the event types to select
+	Types []EventType
+	// Wallet is the identifier of the owner wallet
+	Wallet string
+	// TokenType is the type of the tokens
+	TokenType string
+}
+
+// Match returns true if the passed event is selected by this filter
+func (f *EventFilter) Match(e *Event) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Wallet) != 0 && f.Wallet != e.Wallet {
+		return false
+	}
+	if len(f.TokenType) != 0 && f.TokenType != e.TokenType {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription delivers the events selected by its filter until it is cancelled
+type Subscription struct {
+	hub    *EventHub
+	id     uint64
+	filter *EventFilter
+	ch     chan *Event
+}
+
+// Events returns the channel the events are delivered on. The channel is closed when the subscription is cancelled.
+func (s *Subscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Unsubscribe cancels the subscription
+func (s *Subscription) Unsubscribe() {
+	s.hub.unsubscribe(s.id)
+}
+
+// EventHub dispatches the token events of a token management service to its subscriptions.
+// Publishing never blocks: when the buffer of a subscription is full, its events are dropped.
+type EventHub struct {
+	lock          sync.RWMutex
+	nextID        uint64
+	subscriptions map[uint64]*Subscription
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{subscriptions: map[uint64]*Subscription{}}
+}
+
+// Subscribe returns a subscription to the events selected by the passed filter, buffering up to bufferSize events.
+// If bufferSize is not positive, DefaultEventBufferSize is used.
+func (h *EventHub) Subscribe(filter *EventFilter, bufferSize int) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = DefaultEventBufferSize
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.nextID++
+	s := &Subscription{
+		hub:    h,
+		id:     h.nextID,
+		filter: filter,
+		ch:     make(chan *Event, bufferSize),
+	}
+	h.subscriptions[s.id] = s
+	return s
+}
+
+// Publish delivers the passed events to the subscriptions that select them
+func (h *EventHub) Publish(events ...*Event) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	for _, e := range events {
+		for _, s := range h.subscriptions {
+			if !s.filter.Match(e) {
+				continue
+			}
+			select {
+			case s.ch <- e:
+			default:
+				logger.Warnf("subscription [%d] is full, dropping event [%s] of [%s]", s.id, e.Type, e.TxID)
+			}
+		}
+	}
+}
+
+func (h *EventHub) unsubscribe(id uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	s, ok := h.subscriptions[id]
+	if !ok {
+		return
+	}
+	delete(h.subscriptions, id)
+	close(s.ch)
+}
+
+// EventHubProvider holds the event hub of each token management service
+type EventHubProvider struct {
+	lock sync.Mutex
+	hubs map[string]*EventHub
+}
+
+func NewEventHubProvider() *EventHubProvider {
+	return &EventHubProvider{hubs: map[string]*EventHub{}}
+}
+
+// Hub returns the event hub of the token management service with the passed network, channel and namespace
+func (p *EventHubProvider) Hub(network string, channel string, namespace string) *EventHub {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := network + ":" + channel + ":" + namespace
+	h, ok := p.hubs[key]
+	if !ok {
+		h = NewEventHub()
+		p.hubs[key] = h
+	}
+	return h
+}
+
+// GetEventHubProvider returns the event hub provider registered in the passed service provider, nil if none is registered
+func GetEventHubProvider(sp ServiceProvider) *EventHubProvider {
+	s, err := sp.GetService(&EventHubProvider{})
+	if err != nil {
+		logger.Debugf("no event hub provider registered [%s]", err)
+		return nil
+	}
+	return s.(*EventHubProvider)
+}
+
+// GetEventHub returns the event hub of the passed token management service, nil if no event hub provider is registered
+func GetEventHub(sp ServiceProvider, tms *ManagementService) *EventHub {
+	p := GetEventHubProvider(sp)
+	if p == nil {
+		return nil
+	}
+	return p.Hub(tms.Network(), tms.Channel(), tms.Namespace())
+}
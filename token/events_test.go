@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHub(t *testing.T) {
+	hub := NewEventHub()
+	all := hub.Subscribe(nil, 0)
+	alice := hub.Subscribe(&EventFilter{Wallet: "alice", TokenType: "USD"}, 0)
+	spent := hub.Subscribe(&EventFilter{Types: []EventType{TokenSpent}}, 1)
+
+	hub.Publish(
+		&Event{Type: TokenReceived, TxID: "tx1", Wallet: "alice", TokenType: "USD"},
+		&Event{Type: TokenReceived, TxID: "tx1", Wallet: "alice", TokenType: "EUR"},
+		&Event{Type: TokenSpent, TxID: "tx1", Wallet: "bob", TokenType: "USD"},
+		&Event{Type: TokenSpent, TxID: "tx2", Wallet: "bob", TokenType: "USD"},
+	)
+
+	assert.Len(t, all.Events(), 4)
+	assert.Len(t, alice.Events(), 1)
+	e := <-alice.Events()
+	assert.Equal(t, "tx1", e.TxID)
+	assert.Equal(t, "USD", e.TokenType)
+	// the buffer of the subscription is full, the second event is dropped
+	assert.Len(t, spent.Events(), 1)
+	e = <-spent.Events()
+	assert.Equal(t, "tx1", e.TxID)
+
+	alice.Unsubscribe()
+	_, ok := <-alice.Events()
+	assert.False(t, ok)
+	alice.Unsubscribe()
+	hub.Publish(&Event{Type: TokenReceived, TxID: "tx3", Wallet: "alice", TokenType: "USD"})
+	assert.Len(t, all.Events(), 5)
+}
+
+func TestEventHubProvider(t *testing.T) {
+	p := NewEventHubProvider()
+	assert.True(t, p.Hub("n", "c", "ns") == p.Hub("n", "c", "ns"))
+	assert.False(t, p.Hub("n", "c", "ns") == p.Hub("n", "c", "ns2"))
+}
@@ -44,3 +44,28 @@ func (m *Metadata) SpentTokenID() []*token2.Id {
 	}
 	return res
 }
+
+// Issues returns the issuers and receivers of the issue actions
+func (m *Metadata) Issues() []*Issue {
+	var issues []*Issue
+	for _, issue := range m.tokenRequestMetadata.Issues {
+		issues = append(issues, &Issue{
+			Issuer:    issue.Issuer,
+			Receivers: issue.Receivers,
+		})
+	}
+	return issues
+}
+
+// Transfers returns the senders and receivers of the transfer actions
+func (m *Metadata) Transfers() []*Transfer {
+	var transfers []*Transfer
+	for _, transfer := range m.tokenRequestMetadata.Transfers {
+		transfers = append(transfers, &Transfer{
+			Senders:         transfer.Senders,
+			Receivers:       transfer.Receivers,
+			UnsignedSenders: transfer.UnsignedSenders,
+		})
+	}
+	return transfers
+}
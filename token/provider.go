@@ -6,7 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"time"
+
 	tokenapi "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type Normalizer interface {
@@ -18,8 +21,26 @@ type VaultProvider interface {
 }
 
 type SelectorManager interface {
+	// NewSelector returns a selector for the passed transaction id that uses the strategy configured for the TMS
 	NewSelector(id string) (Selector, error)
+	// NewSelectorWithStrategy returns a selector for the passed transaction id that uses the passed strategy
+	NewSelectorWithStrategy(id string, strategy SelectionStrategy) (Selector, error)
 	Unlock(txID string) error
+	// Reserve locks tokens of the passed type, owned by the passed filter, for the hold with the passed identifier,
+	// until the passed expiry. The locked tokens cover at least the passed quantity.
+	Reserve(holdID string, ownerFilter OwnerFilter, q, tokenType string, expiry time.Time) (*Hold, error)
+	// NewHoldSelector returns a selector for the passed transaction id that draws down the passed hold
+	NewHoldSelector(txID string, holdID string) (Selector, error)
+	// Release releases the hold with the passed identifier and unlocks its tokens
+	Release(holdID string) error
+	// Holds returns the active holds
+	Holds() ([]*Hold, error)
+	// Locks returns the locks currently held on the tokens
+	Locks() ([]*TokenLock, error)
+	// UnlockIDs releases the locks held on the passed tokens, whatever transaction holds them
+	UnlockIDs(ids ...*token2.Id) error
+	// Reclaims returns the outcomes of the latest attempts to reclaim locked tokens
+	Reclaims() ([]*ReclaimReport, error)
 }
 
 type SelectorManagerProvider interface {
@@ -5,7 +5,12 @@ SPDX-License-Identifier: Apache-2.0
 */
 package token
 
-import tokenapi "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	tokenapi "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 type PublicParamsFetcher interface {
 	Fetch() ([]byte, error)
@@ -23,10 +28,35 @@ func (c *PublicParametersManager) SetCertifier(certifier []byte) ([]byte, error)
 	return c.ppm.SetCertifier(certifier)
 }
 
+// SetAuthority sets the identity of the authority that can freeze and seize tokens, and returns the serialized public parameters
+func (c *PublicParametersManager) SetAuthority(authority []byte) ([]byte, error) {
+	return c.ppm.SetAuthority(authority)
+}
+
+// Authority returns the identity of the authority that can freeze and seize tokens, or nil if not set
+func (c *PublicParametersManager) Authority() view.Identity {
+	return c.ppm.PublicParameters().Authority()
+}
+
 func (c *PublicParametersManager) AddIssuer(bytes []byte) ([]byte, error) {
 	return c.ppm.AddIssuer(bytes)
 }
 
+// RegisterTokenType registers the passed token type in the public parameters and returns them serialized
+func (c *PublicParametersManager) RegisterTokenType(info *token2.TypeInfo) ([]byte, error) {
+	return c.ppm.RegisterTokenType(info)
+}
+
+// TokenTypes returns the token types registered in the public parameters
+func (c *PublicParametersManager) TokenTypes() token2.TypeRegistry {
+	return c.ppm.PublicParameters().TokenTypes()
+}
+
+// TokenType returns the information about the passed token type, or nil if the type is not registered
+func (c *PublicParametersManager) TokenType(typ string) *token2.TypeInfo {
+	return c.ppm.PublicParameters().TokenTypes().Lookup(typ)
+}
+
 func (c *PublicParametersManager) CertificationDriver() string {
 	return c.ppm.PublicParameters().CertificationDriver()
 }
@@ -43,6 +73,11 @@ func (c *PublicParametersManager) MaxTokenValue() uint64 {
 	return c.ppm.PublicParameters().MaxTokenValue()
 }
 
+// Precision returns the number of bits used to represent token quantities in this token management service
+func (c *PublicParametersManager) Precision() uint64 {
+	return c.ppm.PublicParameters().Precision()
+}
+
 func (c *PublicParametersManager) Bytes() ([]byte, error) {
 	return c.ppm.PublicParameters().Bytes()
 }
@@ -6,7 +6,9 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"context"
 	"encoding/json"
+	"math/big"
 
 	"github.com/pkg/errors"
 
@@ -18,8 +20,11 @@ import (
 )
 
 type TransferOptions struct {
-	Selector Selector
-	TokenIDs []*token2.Id
+	Selector          Selector
+	SelectionStrategy SelectionStrategy
+	SelectionContext  context.Context
+	SelectOptions     []SelectOption
+	TokenIDs          []*token2.Id
 }
 
 func compileTransferOptions(opts ...TransferOption) (*TransferOptions, error) {
@@ -41,6 +46,25 @@ func WithTokenSelector(selector Selector) TransferOption {
 	}
 }
 
+// WithSelectionStrategy tells the default selector to use the passed strategy, instead of the one configured for the TMS.
+// It is ignored if a selector is passed with WithTokenSelector.
+func WithSelectionStrategy(strategy SelectionStrategy) TransferOption {
+	return func(o *TransferOptions) error {
+		o.SelectionStrategy = strategy
+		return nil
+	}
+}
+
+// WithSelectionContext bounds the token selection by the passed context, if the selector is a ContextSelector.
+// The select options are passed to the selector.
+func WithSelectionContext(ctx context.Context, opts ...SelectOption) TransferOption {
+	return func(o *TransferOptions) error {
+		o.SelectionContext = ctx
+		o.SelectOptions = opts
+		return nil
+	}
+}
+
 func WithTokenIDs(ids ...*token2.Id) TransferOption {
 	return func(o *TransferOptions) error {
 		o.TokenIDs = ids
@@ -49,9 +73,12 @@ func WithTokenIDs(ids ...*token2.Id) TransferOption {
 }
 
 type AuditRecord struct {
-	TxID   string
-	Inputs *InputStream
-	Ouputs *OutputStream
+	TxID string
+	// Network and Channel identify the channel the transaction is submitted to
+	Network string
+	Channel string
+	Inputs  *InputStream
+	Ouputs  *OutputStream
 }
 
 type Issue struct {
@@ -62,6 +89,8 @@ type Issue struct {
 type Transfer struct {
 	Senders   []view.Identity
 	Receivers []view.Identity
+	// UnsignedSenders is true when the senders do not sign the request
+	UnsignedSenders bool
 }
 
 type Request struct {
@@ -103,7 +132,9 @@ func (t *Request) ID() string {
 	return t.TxID
 }
 
-func (t *Request) Issue(wallet *IssuerWallet, receiver view.Identity, typ string, q uint64) (*IssueAction, error) {
+// Issue appends an issue action that creates a token of the passed type and quantity, owned by the passed receiver.
+// The quantity must fit the precision declared by the public parameters.
+func (t *Request) Issue(wallet *IssuerWallet, receiver view.Identity, typ string, q token2.Quantity) (*IssueAction, error) {
 	if receiver.IsNone() {
 		return nil, errors.Errorf("all recipients should be defined")
 	}
@@ -113,12 +144,52 @@ func (t *Request) Issue(wallet *IssuerWallet, receiver view.Identity, typ string
 		return nil, errors.WithMessagef(err, "failed getting issuer identity for type [%s]", typ)
 	}
 
+	// Check the token type registry, the validator enforces it as well
+	info, err := t.TokenService.PublicParametersManager().TokenTypes().CheckIssue(typ, id)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		if err := info.CheckSupply(q); err != nil {
+			return nil, err
+		}
+	}
+
 	// Compute Issue
-	issue, tokenInfos, issuer, err := t.TokenService.tms.Issue(id, typ, []uint64{q}, [][]byte{receiver})
+	issue, tokenInfos, issuer, err := t.TokenService.tms.Issue(id, typ, []token2.Quantity{q}, [][]byte{receiver})
 	if err != nil {
 		return nil, err
 	}
 
+	return t.appendIssue(issue, tokenInfos, issuer, receiver)
+}
+
+// IssueNFT appends an issue action that creates a non-fungible token, owned by the passed receiver.
+// The token has quantity one and its type is derived from the unique identifier of the passed nft.
+// Issuing twice the same unique identifier makes the transaction invalid.
+func (t *Request) IssueNFT(wallet *IssuerWallet, receiver view.Identity, nft *token2.NFT) (*IssueAction, error) {
+	if receiver.IsNone() {
+		return nil, errors.Errorf("all recipients should be defined")
+	}
+	if err := nft.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := wallet.GetIssuerIdentity(nft.Type())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting issuer identity for type [%s]", nft.Type())
+	}
+
+	// Compute Issue
+	issue, tokenInfos, issuer, err := t.TokenService.tms.IssueNFT(id, nft, receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.appendIssue(issue, tokenInfos, issuer, receiver)
+}
+
+func (t *Request) appendIssue(issue api2.IssueAction, tokenInfos [][]byte, issuer view.Identity, receiver view.Identity) (*IssueAction, error) {
 	// Append
 	raw, err := issue.Serialize()
 	if err != nil {
@@ -149,7 +220,9 @@ func (t *Request) Issue(wallet *IssuerWallet, receiver view.Identity, typ string
 	return &IssueAction{a: issue}, nil
 }
 
-func (t *Request) Transfer(wallet *OwnerWallet, typ string, values []uint64, owners []view.Identity, opts ...TransferOption) (*TransferAction, error) {
+// Transfer appends a transfer action that moves the passed quantities of the passed type to the passed owners.
+// Each quantity must fit the precision declared by the public parameters.
+func (t *Request) Transfer(wallet *OwnerWallet, typ string, values []token2.Quantity, owners []view.Identity, opts ...TransferOption) (*TransferAction, error) {
 	tokenIDs, outputTokens, err := t.prepareTransfer(false, wallet, typ, values, owners, opts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed preparing transfer")
@@ -180,8 +253,19 @@ func (t *Request) Transfer(wallet *OwnerWallet, typ string, values []uint64, own
 	return &TransferAction{a: transfer}, nil
 }
 
-func (t *Request) Redeem(wallet *OwnerWallet, typ string, value uint64, opts ...TransferOption) error {
-	tokenIDs, outputTokens, err := t.prepareTransfer(true, wallet, typ, []uint64{value}, []view.Identity{nil}, opts...)
+// TransferNFT appends a transfer action that moves the whole non-fungible token with the passed unique identifier
+// to the passed receiver
+func (t *Request) TransferNFT(wallet *OwnerWallet, id string, receiver view.Identity, opts ...TransferOption) (*TransferAction, error) {
+	nft := &token2.NFT{ID: id}
+	if err := nft.Validate(); err != nil {
+		return nil, err
+	}
+	return t.Transfer(wallet, nft.Type(), []token2.Quantity{token2.NewQuantityFromUInt64(token2.NFTQuantity)}, []view.Identity{receiver}, opts...)
+}
+
+// Redeem appends a transfer action that destroys the passed quantity of the passed type
+func (t *Request) Redeem(wallet *OwnerWallet, typ string, value token2.Quantity, opts ...TransferOption) error {
+	tokenIDs, outputTokens, err := t.prepareTransfer(true, wallet, typ, []token2.Quantity{value}, []view.Identity{nil}, opts...)
 	if err != nil {
 		return errors.Wrap(err, "failed preparing transfer")
 	}
@@ -234,6 +318,7 @@ func (t *Request) Outputs() (*OutputStream, error) {
 			}
 
 			outputs = append(outputs, &Output{
+				ActionType:   IssueActionType,
 				ActionIndex:  i,
 				Owner:        tok.Owner.Raw,
 				EnrollmentID: eID,
@@ -265,6 +350,7 @@ func (t *Request) Outputs() (*OutputStream, error) {
 			}
 
 			outputs = append(outputs, &Output{
+				ActionType:   TransferActionType,
 				ActionIndex:  i,
 				Owner:        tok.Owner.Raw,
 				EnrollmentID: eID,
@@ -433,8 +519,9 @@ func (t *Request) Transfers() []*Transfer {
 	var transfers []*Transfer
 	for _, transfer := range t.Metadata.Transfers {
 		transfers = append(transfers, &Transfer{
-			Senders:   transfer.Senders,
-			Receivers: transfer.Receivers,
+			Senders:         transfer.Senders,
+			Receivers:       transfer.Receivers,
+			UnsignedSenders: transfer.UnsignedSenders,
 		})
 	}
 	return transfers
@@ -477,9 +564,11 @@ func (t *Request) AuditRecord() (*AuditRecord, error) {
 		return nil, errors.WithMessagef(err, "failed getting audit outputs")
 	}
 	return &AuditRecord{
-		TxID:   t.TxID,
-		Inputs: inputs,
-		Ouputs: outputs,
+		TxID:    t.TxID,
+		Network: t.TokenService.Network(),
+		Channel: t.TokenService.Channel(),
+		Inputs:  inputs,
+		Ouputs:  outputs,
 	}, nil
 }
 
@@ -535,7 +624,7 @@ func (t *Request) parseInputIDs(inputs []*token2.Id) ([]*token2.Id, token2.Quant
 		return nil, nil, "", errors.WithMessagef(err, "failed querying tokens ids")
 	}
 	var typ string
-	sum := token2.NewQuantityFromUInt64(0)
+	sum := token2.NewZeroQuantity(token2.MaxPrecision)
 	for _, tok := range inputTokens {
 		if len(typ) == 0 {
 			typ = tok.Type
@@ -543,7 +632,7 @@ func (t *Request) parseInputIDs(inputs []*token2.Id) ([]*token2.Id, token2.Quant
 		if typ != tok.Type {
 			return nil, nil, "", errors.WithMessagef(err, "tokens must have the same type [%s]!=[%s]", typ, tok.Type)
 		}
-		q, err := token2.ToQuantity(tok.Quantity, 65)
+		q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
 		if err != nil {
 			return nil, nil, "", errors.WithMessagef(err, "failed unmarshalling token quantity [%s]", tok.Quantity)
 		}
@@ -553,7 +642,7 @@ func (t *Request) parseInputIDs(inputs []*token2.Id) ([]*token2.Id, token2.Quant
 	return inputs, sum, typ, nil
 }
 
-func (t *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, typ string, values []uint64, owners []view.Identity, opts ...TransferOption) ([]*token2.Id, []*token2.Token, error) {
+func (t *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, typ string, values []token2.Quantity, owners []view.Identity, opts ...TransferOption) ([]*token2.Id, []*token2.Token, error) {
 	// compile options
 	transferOpts, err := compileTransferOptions(opts...)
 	if err != nil {
@@ -586,29 +675,46 @@ func (t *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, typ string,
 	}
 
 	// Compute output tokens
-	outputSum := uint64(0)
+	precision := t.TokenService.PublicParametersManager().Precision()
+	outputSum := big.NewInt(0)
 	var outputTokens []*token2.Token
 	for i, value := range values {
-		outputSum += value
+		q, err := token2.ToQuantity(value.Decimal(), precision)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid quantity [%s]", value.Decimal())
+		}
+		outputSum.Add(outputSum, q.ToBigInt())
 		outputTokens = append(outputTokens, &token2.Token{
 			Owner:    &token2.Owner{Raw: owners[i]},
 			Type:     typ,
-			Quantity: token2.NewQuantityFromUInt64(value).Decimal(),
+			Quantity: q.Decimal(),
 		})
 	}
-	qOutputSum := token2.NewQuantityFromUInt64(outputSum)
+	// each output fits the precision, their sum might not
+	qOutputSum, err := token2.ToQuantity(outputSum.String(), token2.MaxPrecision)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid sum of the outputs [%s]", outputSum)
+	}
 
 	// Select input tokens, if not passed as opt
 	if len(transferOpts.TokenIDs) == 0 {
 		selector := transferOpts.Selector
 		if selector == nil {
-			// resort to default strategy
-			selector, err = t.TokenService.SelectorManager().NewSelector(t.TxID)
+			// resort to default selector
+			if transferOpts.SelectionStrategy != nil {
+				selector, err = t.TokenService.SelectorManager().NewSelectorWithStrategy(t.TxID, transferOpts.SelectionStrategy)
+			} else {
+				selector, err = t.TokenService.SelectorManager().NewSelector(t.TxID)
+			}
 			if err != nil {
 				return nil, nil, errors.Wrapf(err, "failed getting default selector")
 			}
 		}
-		tokenIDs, inputSum, err = selector.Select(wallet, token2.NewQuantityFromUInt64(outputSum).Decimal(), typ)
+		if cs, ok := selector.(ContextSelector); ok && transferOpts.SelectionContext != nil {
+			tokenIDs, inputSum, err = cs.SelectWithContext(transferOpts.SelectionContext, wallet, qOutputSum.Decimal(), typ, transferOpts.SelectOptions...)
+		} else {
+			tokenIDs, inputSum, err = selector.Select(wallet, qOutputSum.Decimal(), typ)
+		}
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "failed selecting tokens")
 		}
@@ -618,6 +724,9 @@ func (t *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, typ string,
 	if inputSum.Cmp(qOutputSum) == 1 {
 		diff := inputSum.Sub(qOutputSum)
 		logger.Debugf("reassign rest [%s] to sender", diff.Decimal())
+		if _, err := token2.ToQuantity(diff.Decimal(), precision); err != nil {
+			return nil, nil, errors.Wrapf(err, "the rest [%s] exceeds the precision [%d], transfer less or consolidate first", diff.Decimal(), precision)
+		}
 
 		pseudonym, err := wallet.GetRecipientIdentity()
 		if err != nil {
@@ -0,0 +1,15 @@
+//go:build cgo
+// +build cgo
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	// the sql audit db driver links sqlite, which needs cgo
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/db/sql"
+)
@@ -6,21 +6,52 @@ SPDX-License-Identifier: Apache-2.0
 package fabric
 
 import (
+	"database/sql"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db"
+	_ "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/badger"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/inmemory"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/persistent"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.fabric")
+
+const (
+	// DefaultLeaseExpiry is the default lease of the locks kept by a persistent locker
+	DefaultLeaseExpiry = 10 * time.Minute
 )
 
 type GetFabricNetworkServiceFunc func(network string) fabric.NetworkService
 
+type LockerPersistenceOpts struct {
+	// Path is the folder of the persistence, for the persistence drivers of the Fabric Smart Client
+	Path string
+	// Driver is the name of the database/sql driver, for the sql type
+	Driver string
+	// DataSource is the driver-specific data source name, for the sql type
+	DataSource string
+}
+
+// LockerProvider returns the lockers of the token selectors. The in-memory locker is used, unless
+// `token.selector.locker.persistence.type` is `sql` or names a persistence driver, like `badger`. In that case,
+// the locks are stored in that persistence, and they expire after `token.selector.locker.leaseExpiry`.
+// The replicas of a node share their locks by using the same sql database.
 type LockerProvider struct {
 	sp                           view.ServiceProvider
 	sleepTimeout                 time.Duration
 	validTxEvictionTimeoutMillis int64
+
+	lock        sync.Mutex
+	persistence persistent.Persistence
 }
 
 func NewLockerProvider(sp view.ServiceProvider, sleepTimeout time.Duration, validTxEvictionTimeoutMillis int64) *LockerProvider {
@@ -32,5 +63,85 @@ func (s *LockerProvider) New(network string, channel string, namespace string) s
 	if err != nil {
 		panic(err)
 	}
-	return inmemory.NewLocker(ch, s.sleepTimeout, s.validTxEvictionTimeoutMillis)
+
+	cs := view.GetConfigService(s.sp)
+	driverName := cs.GetString("token.selector.locker.persistence.type")
+	if len(driverName) == 0 || driverName == "memory" {
+		return inmemory.NewLocker(ch, s.sleepTimeout, s.validTxEvictionTimeoutMillis)
+	}
+
+	persistence, err := s.openPersistence(driverName)
+	if err != nil {
+		panic(err)
+	}
+	leaseExpiry := DefaultLeaseExpiry
+	if cs.IsSet("token.selector.locker.leaseExpiry") {
+		leaseExpiry = cs.GetDuration("token.selector.locker.leaseExpiry")
+	}
+	return persistent.NewLocker(
+		ch,
+		persistence,
+		network+"."+channel+"."+namespace,
+		leaseExpiry,
+		s.sleepTimeout,
+		s.validTxEvictionTimeoutMillis,
+	)
+}
+
+// openPersistence opens the persistence shared by the lockers of all the TMSs, once
+func (s *LockerProvider) openPersistence(driverName string) (persistent.Persistence, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.persistence != nil {
+		return s.persistence, nil
+	}
+	opts := &LockerPersistenceOpts{}
+	if err := view.GetConfigService(s.sp).UnmarshalKey("token.selector.locker.persistence.opts", opts); err != nil {
+		return nil, errors.Wrapf(err, "failed getting opts for locker")
+	}
+	if driverName == "sql" {
+		persistence, err := openSQLPersistence(opts)
+		if err != nil {
+			return nil, err
+		}
+		s.persistence = persistence
+		return persistence, nil
+	}
+	if len(opts.Path) != 0 {
+		if err := os.MkdirAll(opts.Path, 0755); err != nil {
+			return nil, errors.Wrapf(err, "failed creating folders for locker [%s]", opts.Path)
+		}
+	}
+	persistence, err := db.Open(driverName, opts.Path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed opening locker persistence [%s]", driverName)
+	}
+	s.persistence = persistent.NewPersistence(persistence)
+	return s.persistence, nil
+}
+
+func openSQLPersistence(opts *LockerPersistenceOpts) (persistent.Persistence, error) {
+	if len(opts.Driver) == 0 || len(opts.DataSource) == 0 {
+		return nil, errors.New("the sql locker persistence needs a driver and a data source")
+	}
+	registered := false
+	for _, name := range sql.Drivers() {
+		registered = registered || name == opts.Driver
+	}
+	if !registered {
+		return nil, errors.Errorf("sql driver [%s] not registered, the application must import it", opts.Driver)
+	}
+	sqlDB, err := sql.Open(opts.Driver, opts.DataSource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening locker persistence with driver [%s]", opts.Driver)
+	}
+	persistence, err := persistent.NewSQLPersistence(sqlDB)
+	if err != nil {
+		if err1 := sqlDB.Close(); err1 != nil {
+			logger.Errorf("failed closing locker persistence [%s]", err1)
+		}
+		return nil, err
+	}
+	return persistence, nil
 }
@@ -23,6 +23,7 @@ func NewVaultProvider(sp view.ServiceProvider) *VaultProvider {
 func (v *VaultProvider) Vault(network string, channel string, namespace string) driver.Vault {
 	return vault.NewVault(
 		v.sp,
+		network,
 		fabric.GetChannel(v.sp, network, channel),
 		namespace,
 	)
@@ -19,16 +19,22 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/core/fabtoken/driver"
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/gh/driver"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/nogh/driver"
 	fabric2 "github.com/hyperledger-labs/fabric-token-sdk/token/sdk/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/sdk/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/db/badger"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/db/memory"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/dummy"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/interactive"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/db/badger"
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/db/memory"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/query"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/processor"
 )
 
@@ -60,9 +66,26 @@ func (p *SDK) Install() error {
 	}
 	logger.Infof("Token platform enabled, installing...")
 
+	// Token selection, selections bounded by a context with a deadline ignore the number of retries
+	numRetry := 2
+	if configProvider.IsSet("token.selector.numRetry") {
+		if err := configProvider.UnmarshalKey("token.selector.numRetry", &numRetry); err != nil {
+			return errors.Wrapf(err, "failed getting the number of retries of the token selector")
+		}
+	}
+	retryTimeout := 5 * time.Second
+	if configProvider.IsSet("token.selector.retryTimeout") {
+		retryTimeout = configProvider.GetDuration("token.selector.retryTimeout")
+	}
+
 	logger.Infof("Set Token Service")
 	fabricNetwork := fabric.GetDefaultNetwork(p.registry)
 
+	// Unspent tokens index, shared by the vaults and the transaction processors
+	assert.NoError(p.registry.RegisterService(index.NewProvider(p.registry)))
+	// Token events, published by the transaction processors and the finality views
+	assert.NoError(p.registry.RegisterService(token.NewEventHubProvider()))
+
 	tmsProvider := core.NewTMSProvider(fabricNetwork, p.registry,
 		func(network, channel, namespace string) error {
 			n := fabric.GetFabricNetworkService(p.registry, network)
@@ -87,7 +110,7 @@ func (p *SDK) Install() error {
 			p.registry,
 			2*time.Second,
 			(5*time.Minute).Milliseconds(),
-		), 2, 5*time.Second),
+		), numRetry, retryTimeout),
 		view.NewSigServiceWrapper(view2.GetSigService(p.registry)),
 	)))
 
@@ -97,13 +120,54 @@ func (p *SDK) Install() error {
 		driverName = "memory"
 	}
 	assert.NoError(p.registry.RegisterService(auditdb.NewManager(p.registry, driverName)))
+	// Audit policies, evaluated by the auditor before signing
+	assert.NoError(p.registry.RegisterService(auditor.NewPolicyProvider(p.registry)))
+
+	// TransactionDB, the transaction history of the owner wallets
+	txDriverName := view2.GetConfigService(p.registry).GetString("token.owner.txdb.persistence.type")
+	if len(txDriverName) == 0 {
+		txDriverName = "memory"
+	}
+	assert.NoError(p.registry.RegisterService(txdb.NewManager(p.registry, txDriverName)))
 
 	logger.Infof("Install View Handlers")
 	query.InstallQueryViewFactories(p.registry)
+	query.InstallLockViewFactories(p.registry)
 
 	return nil
 }
 
 func (p *SDK) Start(ctx context.Context) error {
+	if !view2.GetConfigService(p.registry).GetBool("token.enabled") {
+		return nil
+	}
+
+	// Settle the audit records left pending by a previous run.
+	// Only the nodes with an auditor wallet have an audit db.
+	var tmsConfigs []*token.TMS
+	if err := view2.GetConfigService(p.registry).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	if !hasAuditorWallet(tmsConfigs) {
+		logger.Debugf("no auditor wallet configured, no audit records to recover")
+		return nil
+	}
+	s, err := p.registry.GetService(&auditdb.Manager{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting audit db manager")
+	}
+	if err := s.(*auditdb.Manager).Recover(); err != nil {
+		return errors.WithMessagef(err, "failed recovering pending audit records")
+	}
 	return nil
 }
+
+// hasAuditorWallet returns true if any of the passed TMSs configures an auditor wallet
+func hasAuditorWallet(tmsConfigs []*token.TMS) bool {
+	for _, tms := range tmsConfigs {
+		if tms.Auditor != nil && len(tms.Auditor.Wallet) != 0 {
+			return true
+		}
+	}
+	return false
+}
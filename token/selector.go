@@ -6,6 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -27,6 +31,46 @@ var (
 	SelectorSufficientFundsButConcurrencyIssue = errors.New("sufficient funds but concurrency issue")
 )
 
+// SelectionError describes a failed token selection. Its cause is one of the Selector* errors above,
+// so that errors.Cause can be used to tell why the selection failed.
+type SelectionError struct {
+	// Reason is the Selector* error that tells why the selection failed
+	Reason error
+	// Type is the requested token type
+	Type string
+	// Requested is the requested quantity
+	Requested token2.Quantity
+	// Available is the quantity of the tokens that could be selected
+	Available token2.Quantity
+	// Locked is the quantity of the tokens locked by other transactions
+	Locked token2.Quantity
+	// NotCertified is the quantity of the tokens that were not certified
+	NotCertified token2.Quantity
+	// Interrupted is the error of the context that ended the selection, if any
+	Interrupted error
+}
+
+func (e *SelectionError) Error() string {
+	msg := fmt.Sprintf(
+		"token selection failed: requested [%s] tokens of type [%s], available [%s], locked [%s], not certified [%s]",
+		e.Requested.Decimal(), e.Type, e.Available.Decimal(), e.Locked.Decimal(), e.NotCertified.Decimal(),
+	)
+	if e.Interrupted != nil {
+		msg = fmt.Sprintf("%s, interrupted [%s]", msg, e.Interrupted)
+	}
+	return fmt.Sprintf("%s: %s", msg, e.Reason)
+}
+
+// Cause returns the reason of the failure
+func (e *SelectionError) Cause() error {
+	return e.Reason
+}
+
+// Unwrap returns the reason of the failure
+func (e *SelectionError) Unwrap() error {
+	return e.Reason
+}
+
 // OwnerFilter tells if a passed identity is recognized
 type OwnerFilter interface {
 	// Contains returns true if the passed identity is recognized, false otherwise.
@@ -44,3 +88,96 @@ type Selector interface {
 	// stored in each token.
 	Select(ownerFilter OwnerFilter, q, tokenType string) ([]*token2.Id, token2.Quantity, error)
 }
+
+// SelectionStrategy decides the order in which a selector considers the unspent tokens that match a request.
+// The selector walks the ordered tokens, skipping the ones it cannot use, until the requested quantity is covered.
+type SelectionStrategy interface {
+	// Order returns the passed tokens in the order they should be selected to cover the target quantity.
+	// The tokens all have the requested type and owner, precision is the one of the token quantities.
+	Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error)
+}
+
+// SelectOptions tunes a selection bounded by a context
+type SelectOptions struct {
+	// WaitForFunds tells the selector to retry as soon as it is notified that locked tokens have been released
+	// or new tokens have been received, instead of waiting for the next retry.
+	WaitForFunds bool
+}
+
+type SelectOption func(*SelectOptions) error
+
+// WithWaitForFunds tells the selector to retry as soon as tokens might have become available
+func WithWaitForFunds() SelectOption {
+	return func(o *SelectOptions) error {
+		o.WaitForFunds = true
+		return nil
+	}
+}
+
+// CompileSelectOptions returns the select options resulting from the passed ones
+func CompileSelectOptions(opts ...SelectOption) (*SelectOptions, error) {
+	options := &SelectOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+// ContextSelector is a selector whose selections can be bounded by a context
+type ContextSelector interface {
+	Selector
+
+	// SelectWithContext selects tokens like Select does, retrying until the passed context is done.
+	// If the context has no deadline, the selector gives up after its configured number of retries.
+	// When the selection fails, the returned error is a *SelectionError.
+	SelectWithContext(ctx context.Context, ownerFilter OwnerFilter, q, tokenType string, opts ...SelectOption) ([]*token2.Id, token2.Quantity, error)
+}
+
+// Hold is a reservation of an amount of a token type. The tokens of a hold stay locked until transactions draw them down,
+// the hold is released, or it expires.
+type Hold struct {
+	// ID identifies the hold
+	ID string
+	// Type is the reserved token type
+	Type string
+	// Amount is the reserved quantity
+	Amount token2.Quantity
+	// Remaining is the quantity that can still be drawn down
+	Remaining token2.Quantity
+	// Tokens are the tokens currently locked by the hold
+	Tokens []*token2.Id
+	// Expiry is the time after which the hold is released
+	Expiry time.Time
+}
+
+// TokenLock describes the lock a transaction holds on a token
+type TokenLock struct {
+	// ID is the locked token
+	ID *token2.Id
+	// TxID is the transaction holding the lock
+	TxID string
+	// Created is when the lock was taken
+	Created time.Time
+	// LastAccess is when another transaction last tried to lock the token
+	LastAccess time.Time
+	// Expiry is when the lock expires, zero if the lock does not expire
+	Expiry time.Time
+}
+
+// ReclaimReport is the outcome of the attempt of a transaction to reclaim a token locked by another transaction
+type ReclaimReport struct {
+	// ID is the locked token
+	ID *token2.Id
+	// TxID is the transaction holding the lock
+	TxID string
+	// Requester is the transaction that tried to reclaim the token
+	Requester string
+	// Status is the status of TxID in the vault
+	Status string
+	// Reclaimed is true if the lock was reclaimed
+	Reclaimed bool
+	// Time is when the reclaim happened
+	Time time.Time
+}
@@ -9,7 +9,9 @@ import (
 	"math/big"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
@@ -64,6 +66,7 @@ type Status string
 const (
 	Pending Status = "Pending"
 	Valid   Status = "Confirmed"
+	Deleted Status = "Deleted"
 )
 
 type QueryExecutor struct {
@@ -122,71 +125,186 @@ func (db *AuditDB) Append(record *token.AuditRecord) error {
 		return errors.WithMessagef(err, "begin update for txid '%s' failed", record.TxID)
 	}
 
+	for _, r := range ActionRecords(record, time.Now()) {
+		if err := db.db.AddRecord(r); err != nil {
+			if err1 := db.db.Discard(); err1 != nil {
+				logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+			}
+			return err
+		}
+	}
+
+	if err := db.db.Commit(); err != nil {
+		return errors.WithMessagef(err, "committing tx for txid '%s' failed", record.TxID)
+	}
+
+	logger.Debugf("Appending new completed without errors")
+	return nil
+}
+
+// ActionRecords returns, for each action of the passed audit record, the net movement of each enrollment ID and token type.
+// The outputs of a transfer action without owner are redeemed, and are recorded apart from the transferred ones.
+// The sum of the records of an enrollment ID is the net movement of the enrollment ID in the transaction.
+// These are the records Append stores, the quantities of the audit record must be valid.
+func ActionRecords(record *token.AuditRecord, now time.Time) []*driver.Record {
 	inputs := record.Inputs
 	outputs := record.Ouputs
 
-	// compute the payment done in the transaction
-	eIDs := outputs.EnrollmentIDs()
-	tokenTypes := outputs.TokenTypes()
-	for _, eID := range eIDs {
-		for _, tokenType := range tokenTypes {
-			sent := inputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
-			received := outputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
-			diff := sent.Sub(sent, received)
-			if diff.Cmp(big.NewInt(0)) <= 0 {
-				continue
-			}
-
-			if err := db.db.AddRecord(&driver.Record{
-				TxID:         record.TxID,
-				ActionIndex:  0,
-				EnrollmentID: eID,
-				Amount:       diff.Neg(diff),
-				Type:         tokenType,
-				Status:       driver.Pending,
-			}); err != nil {
-				if err1 := db.db.Discard(); err1 != nil {
-					logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
-				}
-				return err
-			}
+	type action struct {
+		typ   token.ActionType
+		index int
+	}
+	var actions []action
+	seen := map[action]bool{}
+	for _, output := range outputs.Outputs() {
+		a := action{typ: output.ActionType, index: output.ActionIndex}
+		if !seen[a] {
+			seen[a] = true
+			actions = append(actions, a)
+		}
+	}
+	for i := 0; i < inputs.Count(); i++ {
+		a := action{typ: token.TransferActionType, index: inputs.At(i).ActionIndex}
+		if !seen[a] {
+			seen[a] = true
+			actions = append(actions, a)
 		}
 	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].typ != actions[j].typ {
+			return actions[i].typ < actions[j].typ
+		}
+		return actions[i].index < actions[j].index
+	})
+
+	var res []*driver.Record
+	for _, a := range actions {
+		actionOutputs := outputs.Filter(func(t *token.Output) bool {
+			return t.ActionType == a.typ && t.ActionIndex == a.index
+		})
+		actionInputs := inputs.Filter(func(t *token.Input) bool {
+			return a.typ == token.TransferActionType && t.ActionIndex == a.index
+		})
+		actionType := driver.Issue
+		if a.typ == token.TransferActionType {
+			actionType = driver.Transfer
+		}
+		// the outputs without owner are redeemed
+		redeemed := actionOutputs.Filter(func(t *token.Output) bool {
+			return a.typ == token.TransferActionType && len(t.Owner) == 0
+		})
+
+		for _, tokenType := range UnionStrings(actionInputs.TokenTypes(), actionOutputs.TokenTypes()) {
+			// the net movement of each enrollment ID, redeemed outputs have no enrollment ID
+			nets := map[string]*big.Int{}
+			var senders, recipients []string
+			for _, eID := range UnionStrings(actionInputs.EnrollmentIDs(), actionOutputs.EnrollmentIDs()) {
+				if len(eID) == 0 {
+					continue
+				}
+				received := actionOutputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
+				sent := actionInputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
+				net := received.Sub(received, sent)
+				switch net.Sign() {
+				case -1:
+					senders = append(senders, eID)
+				case 1:
+					recipients = append(recipients, eID)
+				default:
+					continue
+				}
+				nets[eID] = net
+			}
+			sort.Strings(senders)
+			sort.Strings(recipients)
 
-	// compute what received in the transaction
-	eIDs = outputs.EnrollmentIDs()
-	tokenTypes = outputs.TokenTypes()
-	for _, eID := range eIDs {
-		for _, tokenType := range tokenTypes {
-			received := outputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
-			sent := inputs.ByEnrollmentID(eID).ByType(tokenType).Sum().ToBigInt()
-			diff := received.Sub(received, sent)
-			if diff.Cmp(big.NewInt(0)) <= 0 {
-				// Nothing received
+			amount := redeemed.ByType(tokenType).Sum().ToBigInt()
+			if len(senders) == 1 && amount.Sign() > 0 {
+				// the redeemed amount is charged to the only sender, the rest of what it sent is transferred to the recipients
+				res = append(res, transferRecords(record, actionType, a.index, tokenType, senders, recipients, nets, now, amount)...)
 				continue
 			}
-
-			if err := db.db.AddRecord(&driver.Record{
-				TxID:         record.TxID,
-				ActionIndex:  0,
-				EnrollmentID: eID,
-				Amount:       diff,
-				Type:         tokenType, Status: driver.Pending,
-			}); err != nil {
-				if err1 := db.db.Discard(); err1 != nil {
-					logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
-				}
-				return err
+			res = append(res, transferRecords(record, actionType, a.index, tokenType, senders, recipients, nets, now, nil)...)
+			if amount.Sign() > 0 {
+				// with several senders, who redeemed what is not known: the redeem is recorded once, without
+				// enrollment ID, and the Transfer records of the senders include what they redeemed
+				res = append(res, &driver.Record{
+					TxID:        record.TxID,
+					Network:     record.Network,
+					Channel:     record.Channel,
+					ActionType:  driver.Redeem,
+					ActionIndex: uint32(a.index),
+					Type:        tokenType,
+					Amount:      amount.Neg(amount),
+					Senders:     senders,
+					Status:      driver.Pending,
+					CreatedAt:   now,
+				})
 			}
 		}
 	}
+	return res
+}
 
-	if err := db.db.Commit(); err != nil {
-		return errors.WithMessagef(err, "committing tx for txid '%s' failed", record.TxID)
+// transferRecords returns the records of the senders and the recipients of the passed token type in an action.
+// If redeemed is not nil, it is charged to the only sender with a Redeem record, the Transfer record of the sender
+// holds the rest of what it sent.
+func transferRecords(record *token.AuditRecord, actionType driver.ActionType, index int, tokenType string, senders []string, recipients []string, nets map[string]*big.Int, now time.Time, redeemed *big.Int) []*driver.Record {
+	var res []*driver.Record
+	transferSenders := senders
+	if redeemed != nil {
+		sender := senders[0]
+		rest := new(big.Int).Add(nets[sender], redeemed)
+		nets[sender] = rest
+		if rest.Sign() == 0 {
+			transferSenders = nil
+		}
+	}
+	for _, eID := range append(append([]string{}, transferSenders...), recipients...) {
+		res = append(res, &driver.Record{
+			TxID:         record.TxID,
+			Network:      record.Network,
+			Channel:      record.Channel,
+			ActionType:   actionType,
+			ActionIndex:  uint32(index),
+			EnrollmentID: eID,
+			Type:         tokenType,
+			Amount:       nets[eID],
+			Senders:      transferSenders,
+			Recipients:   recipients,
+			Status:       driver.Pending,
+			CreatedAt:    now,
+		})
+	}
+	if redeemed != nil {
+		res = append(res, &driver.Record{
+			TxID:         record.TxID,
+			Network:      record.Network,
+			Channel:      record.Channel,
+			ActionType:   driver.Redeem,
+			ActionIndex:  uint32(index),
+			EnrollmentID: senders[0],
+			Type:         tokenType,
+			Amount:       new(big.Int).Neg(redeemed),
+			Senders:      senders,
+			Status:       driver.Pending,
+			CreatedAt:    now,
+		})
 	}
+	return res
+}
 
-	logger.Debugf("Appending new completed without errors")
-	return nil
+// UnionStrings returns the strings in either of the passed lists, in order of appearance and without duplicates
+func UnionStrings(a []string, b []string) []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	return res
 }
 
 func (db *AuditDB) NewQueryExecutor() *QueryExecutor {
@@ -196,6 +314,38 @@ func (db *AuditDB) NewQueryExecutor() *QueryExecutor {
 	return &QueryExecutor{db: db}
 }
 
+// ChannelID identifies a channel of a network
+type ChannelID struct {
+	Network string
+	Channel string
+}
+
+// PendingTxIDs returns the identifiers of the transactions with pending records, grouped by channel, in order of addition.
+// The transactions of the records stored without channel are grouped under the empty ChannelID.
+func (db *AuditDB) PendingTxIDs() (map[ChannelID][]string, error) {
+	db.storeLock.RLock()
+	defer db.storeLock.RUnlock()
+
+	records, err := db.db.Query(&driver.QueryParams{
+		Statuses:  []driver.Status{driver.Pending},
+		Value:     driver.All,
+		Direction: driver.FromBeginning,
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying pending records")
+	}
+	res := map[ChannelID][]string{}
+	seen := map[string]bool{}
+	for _, r := range records {
+		if !seen[r.TxID] {
+			seen[r.TxID] = true
+			ch := ChannelID{Network: r.Network, Channel: r.Channel}
+			res[ch] = append(res[ch], r.TxID)
+		}
+	}
+	return res, nil
+}
+
 func (db *AuditDB) SetStatus(txID string, status Status) error {
 	logger.Debugf("Set status [%s][%s]...[%d]", txID, status, db.counter)
 	db.storeLock.Lock()
@@ -221,36 +371,105 @@ func (db *AuditDB) SetStatus(txID string, status Status) error {
 	return nil
 }
 
+// Manager handles the audit db of the node, shared by its auditor wallets, and the finality trackers of its records
 type Manager struct {
 	sp         view2.ServiceProvider
 	driver     string
 	mutex      sync.Mutex
-	committers map[string]*AuditDB
+	db         *AuditDB
+	finalities map[ChannelID]*Finality
 }
 
 func NewManager(sp view2.ServiceProvider, driver string) *Manager {
 	return &Manager{
 		sp:         sp,
 		driver:     driver,
-		committers: map[string]*AuditDB{},
+		finalities: map[ChannelID]*Finality{},
 	}
 }
 
+// AuditDB returns the audit db of the passed wallet
 func (cm *Manager) AuditDB(w *token.AuditorWallet) (*AuditDB, error) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	id := w.ID()
-	c, ok := cm.committers[id]
+	return cm.auditDB()
+}
+
+// Finality returns the finality tracker of the audit records of the transactions of the passed channel
+func (cm *Manager) Finality(network string, channel string) (*Finality, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	return cm.finality(ChannelID{Network: network, Channel: channel})
+}
+
+// Recover settles the audit records left pending by a previous run, channel by channel.
+// The transactions the ledger has not decided on yet are tracked, and checked again periodically.
+// The records stored without channel are recovered on the default channel of the default network.
+func (cm *Manager) Recover() error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	db, err := cm.auditDB()
+	if err != nil {
+		return err
+	}
+	pending, err := db.PendingTxIDs()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting pending transactions")
+	}
+	for ch, txIDs := range pending {
+		if len(ch.Network) == 0 && len(ch.Channel) == 0 {
+			n := fabric.GetDefaultNetwork(cm.sp)
+			if n == nil {
+				logger.Warnf("no default network, cannot recover [%d] pending transactions without channel", len(txIDs))
+				continue
+			}
+			ch = ChannelID{Network: n.Name(), Channel: n.DefaultChannel()}
+		}
+		f, err := cm.finality(ch)
+		if err != nil {
+			logger.Errorf("failed recovering [%d] pending transactions of [%s:%s] [%s]", len(txIDs), ch.Network, ch.Channel, err)
+			continue
+		}
+		f.Recover(txIDs)
+	}
+	return nil
+}
+
+func (cm *Manager) finality(ch ChannelID) (*Finality, error) {
+	f, ok := cm.finalities[ch]
 	if !ok {
-		driver, err := drivers[cm.driver].Open(cm.sp, "")
+		db, err := cm.auditDB()
+		if err != nil {
+			return nil, err
+		}
+		ledger, err := NewLedger(cm.sp, ch.Network, ch.Channel)
+		if err != nil {
+			return nil, err
+		}
+		f = newFinality(db, ledger)
+		cm.finalities[ch] = f
+	}
+	return f, nil
+}
+
+func (cm *Manager) auditDB() (*AuditDB, error) {
+	if cm.db == nil {
+		driversMu.RLock()
+		d, ok := drivers[cm.driver]
+		driversMu.RUnlock()
+		if !ok {
+			return nil, errors.Errorf("audit db driver [%s] not registered, the application must import its package", cm.driver)
+		}
+		driver, err := d.Open(cm.sp, "")
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed instantiating audit db driver")
 		}
-		c = newAuditDB(driver)
-		cm.committers[id] = c
+		cm.db = newAuditDB(driver)
 	}
-	return c, nil
+	return cm.db, nil
 }
 
 func GetAuditDB(sp view2.ServiceProvider, w *token.AuditorWallet) *AuditDB {
@@ -264,3 +483,16 @@ func GetAuditDB(sp view2.ServiceProvider, w *token.AuditorWallet) *AuditDB {
 	}
 	return c
 }
+
+// GetFinality returns the finality tracker of the audit records of the transactions of the passed channel
+func GetFinality(sp view2.ServiceProvider, network string, channel string) *Finality {
+	s, err := sp.GetService(&Manager{})
+	if err != nil {
+		panic(err)
+	}
+	f, err := s.(*Manager).Finality(network, channel)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
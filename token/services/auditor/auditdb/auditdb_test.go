@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+func TestActionRecords(t *testing.T) {
+	// an issue to alice, alice pays bob and charlie with change, bob redeems part of his tokens
+	inputs := token.NewInputStream(nil, []*token.Input{
+		{ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "10"},
+		{ActionIndex: 1, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "5"},
+	})
+	outputs := token.NewOutputStream([]*token.Output{
+		{ActionType: token.IssueActionType, ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "100"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "6"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("charlie"), EnrollmentID: "charlie", Type: "USD", Quantity: "3"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "1"},
+		{ActionType: token.TransferActionType, ActionIndex: 1, Type: "USD", Quantity: "4"},
+		{ActionType: token.TransferActionType, ActionIndex: 1, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "1"},
+	})
+	now := time.Now()
+	records := ActionRecords(&token.AuditRecord{TxID: "tx1", Inputs: inputs, Ouputs: outputs}, now)
+	assert.Len(t, records, 5)
+
+	assert.Equal(t, driver.Issue, records[0].ActionType)
+	assert.Equal(t, "alice", records[0].EnrollmentID)
+	assert.Equal(t, int64(100), records[0].Amount.Int64())
+	assert.Empty(t, records[0].Senders)
+	assert.Equal(t, []string{"alice"}, records[0].Recipients)
+
+	for i, expected := range []struct {
+		eID    string
+		amount int64
+	}{{"alice", -9}, {"bob", 6}, {"charlie", 3}} {
+		r := records[1+i]
+		assert.Equal(t, driver.Transfer, r.ActionType)
+		assert.Equal(t, uint32(0), r.ActionIndex)
+		assert.Equal(t, expected.eID, r.EnrollmentID)
+		assert.Equal(t, expected.amount, r.Amount.Int64())
+		assert.Equal(t, []string{"alice"}, r.Senders)
+		assert.Equal(t, []string{"bob", "charlie"}, r.Recipients)
+		assert.Equal(t, driver.Pending, r.Status)
+		assert.Equal(t, now, r.CreatedAt)
+	}
+
+	assert.Equal(t, driver.Redeem, records[4].ActionType)
+	assert.Equal(t, uint32(1), records[4].ActionIndex)
+	assert.Equal(t, "bob", records[4].EnrollmentID)
+	assert.Equal(t, int64(-4), records[4].Amount.Int64())
+	assert.Equal(t, []string{"bob"}, records[4].Senders)
+	assert.Empty(t, records[4].Recipients)
+
+	// the net movement of the transaction is the sum of the records
+	net := map[string]int64{}
+	for _, r := range records {
+		net[r.EnrollmentID] += r.Amount.Int64()
+	}
+	assert.Equal(t, map[string]int64{"alice": 91, "bob": 2, "charlie": 3}, net)
+}
+
+func TestActionRecordsRedeemWithTransfer(t *testing.T) {
+	// alice pays bob and redeems part of her tokens in the same action, with change
+	inputs := token.NewInputStream(nil, []*token.Input{
+		{ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "10"},
+	})
+	outputs := token.NewOutputStream([]*token.Output{
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "6"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Type: "USD", Quantity: "3"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "1"},
+	})
+	records := ActionRecords(&token.AuditRecord{TxID: "tx1", Inputs: inputs, Ouputs: outputs}, time.Now())
+	assert.Len(t, records, 3)
+
+	for i, expected := range []struct {
+		actionType driver.ActionType
+		eID        string
+		amount     int64
+		senders    []string
+		recipients []string
+	}{
+		{driver.Transfer, "alice", -6, []string{"alice"}, []string{"bob"}},
+		{driver.Transfer, "bob", 6, []string{"alice"}, []string{"bob"}},
+		{driver.Redeem, "alice", -3, []string{"alice"}, nil},
+	} {
+		r := records[i]
+		assert.Equal(t, expected.actionType, r.ActionType)
+		assert.Equal(t, uint32(0), r.ActionIndex)
+		assert.Equal(t, expected.eID, r.EnrollmentID)
+		assert.Equal(t, expected.amount, r.Amount.Int64())
+		assert.Equal(t, expected.senders, r.Senders)
+		assert.Equal(t, expected.recipients, r.Recipients)
+	}
+}
+
+func TestActionRecordsRedeemWithSeveralSenders(t *testing.T) {
+	// alice and bob pay charlie and redeem part of their tokens in the same action, alice gets change
+	inputs := token.NewInputStream(nil, []*token.Input{
+		{ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "10"},
+		{ActionIndex: 0, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "5"},
+	})
+	outputs := token.NewOutputStream([]*token.Output{
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("charlie"), EnrollmentID: "charlie", Type: "USD", Quantity: "8"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Type: "USD", Quantity: "4"},
+		{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "3"},
+	})
+	records := ActionRecords(&token.AuditRecord{TxID: "tx1", Inputs: inputs, Ouputs: outputs}, time.Now())
+	assert.Len(t, records, 4)
+
+	// who redeemed what is not known, the redeem is not split among the senders
+	for i, expected := range []struct {
+		actionType driver.ActionType
+		eID        string
+		amount     int64
+		recipients []string
+	}{
+		{driver.Transfer, "alice", -7, []string{"charlie"}},
+		{driver.Transfer, "bob", -5, []string{"charlie"}},
+		{driver.Transfer, "charlie", 8, []string{"charlie"}},
+		{driver.Redeem, "", -4, nil},
+	} {
+		r := records[i]
+		assert.Equal(t, expected.actionType, r.ActionType)
+		assert.Equal(t, expected.eID, r.EnrollmentID)
+		assert.Equal(t, expected.amount, r.Amount.Int64())
+		assert.Equal(t, []string{"alice", "bob"}, r.Senders)
+		assert.Equal(t, expected.recipients, r.Recipients)
+	}
+}
@@ -9,8 +9,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/db/badger/keys"
@@ -107,44 +107,78 @@ func (db *Persistence) Discard() error {
 }
 
 func (db *Persistence) AddRecord(record *driver.Record) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
 	next, err := db.seq.Next()
 	if err != nil {
 		return errors.Wrapf(err, "failed getting next index")
 	}
-	dbKey := dbKey("default", fmt.Sprintf("%d", next))
+	return db.set(&Record{Id: next, Record: record})
+}
 
-	value := &Record{
-		Id:     next,
-		Record: record,
+func (db *Persistence) SetStatus(txID string, status driver.Status) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
 	}
-
-	bytes, err := json.Marshal(value)
+	records, err := db.scan(db.txn, func(record *driver.Record) bool {
+		return record.TxID == txID
+	})
 	if err != nil {
-		return errors.Wrapf(err, "could not marshal record for key %s", dbKey)
+		return err
 	}
+	now := time.Now()
+	for _, record := range records {
+		if status == driver.Confirmed && record.Record.Status != driver.Confirmed {
+			record.Record.ConfirmedAt = now
+		}
+		record.Record.Status = status
+		if err := db.set(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	err = db.txn.Set([]byte(dbKey), bytes)
+func (db *Persistence) Query(params *driver.QueryParams) ([]*driver.Record, error) {
+	txn := db.db.NewTransaction(false)
+	defer txn.Discard()
+
+	records, err := db.scan(txn, params.Match)
 	if err != nil {
-		return errors.Wrapf(err, "could not set value for key %s", dbKey)
+		return nil, err
 	}
 
-	return nil
-}
+	// Sort
+	switch params.Direction {
+	case driver.FromBeginning:
+		sort.Sort(records)
+	case driver.FromLast:
+		sort.Sort(sort.Reverse(records))
+	}
 
-func (db *Persistence) SetStatus(txID string, status driver.Status) error {
-	panic("implement me")
+	if params.NumRecords > 0 && len(records) > params.NumRecords {
+		records = records[:params.NumRecords]
+	}
+
+	var res []*driver.Record
+	for _, record := range records {
+		res = append(res, record.Record)
+	}
+
+	return res, nil
 }
 
-func (db *Persistence) Query(ids []string, types []string, status []driver.Status, direction driver.Direction, value driver.Value, numRecords int) ([]*driver.Record, error) {
-	txn := db.db.NewTransaction(false)
-	it := txn.NewIterator(badger.DefaultIteratorOptions)
-	var records RecordSlice
+// scan returns the records, visible to the passed badger transaction, that satisfy the passed filter
+func (db *Persistence) scan(txn *badger.Txn, filter func(record *driver.Record) bool) (RecordSlice, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(dbKey("default", ""))
+	it := txn.NewIterator(opts)
+	defer it.Close()
 
+	var records RecordSlice
 	for it.Rewind(); it.Valid(); it.Next() {
 		item := it.Item()
-		if !strings.HasPrefix(string(item.Key()), "default") {
-			continue
-		}
 		record := &Record{}
 		err := item.Value(func(val []byte) error {
 			if err := json.Unmarshal(val, record); err != nil {
@@ -155,79 +189,26 @@ func (db *Persistence) Query(ids []string, types []string, status []driver.Statu
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not get value for key %s", string(item.Key()))
 		}
-
-		// filter
-		if len(ids) != 0 {
-			found := false
-			for _, id := range ids {
-				if record.Record.EnrollmentID == id {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if len(types) != 0 {
-			found := false
-			for _, typ := range types {
-				if record.Record.Type == typ {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if len(status) != 0 {
-			found := false
-			for _, st := range status {
-				if record.Record.Status == st {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		} else {
-			// exclude the deleted
-			if record.Record.Status == driver.Deleted {
-				continue
-			}
+		if filter(record.Record) {
+			records = append(records, record)
 		}
-
-		if value == driver.Sent && record.Record.Amount.Sign() > 0 {
-			continue
-		}
-
-		if value == driver.Received && record.Record.Amount.Sign() < 0 {
-			continue
-		}
-
-		records = append(records, record)
-	}
-
-	// Sort
-	switch direction {
-	case driver.FromBeginning:
-		sort.Sort(records)
-	case driver.FromLast:
-		sort.Sort(sort.Reverse(records))
 	}
+	return records, nil
+}
 
-	if numRecords > 0 && len(records) > numRecords {
-		records = records[:numRecords]
+func (db *Persistence) set(record *Record) error {
+	dbKey := dbKey("default", fmt.Sprintf("%d", record.Id))
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal record for key %s", dbKey)
 	}
 
-	var res []*driver.Record
-	for _, record := range records {
-		res = append(res, record.Record)
+	err = db.txn.Set([]byte(dbKey), bytes)
+	if err != nil {
+		return errors.Wrapf(err, "could not set value for key %s", dbKey)
 	}
 
-	return res, nil
+	return nil
 }
 
 func dbKey(namespace, key string) string {
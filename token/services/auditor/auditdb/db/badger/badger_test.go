@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -56,11 +57,53 @@ func TestDB(t *testing.T) {
 	assert.NoError(t, err)
 	db.Commit()
 
-	records, err := db.Query(nil, nil, nil, driver.FromLast, driver.Received, 2)
+	records, err := db.Query(&driver.QueryParams{Direction: driver.FromLast, Value: driver.Received, NumRecords: 2})
 	assert.NoError(t, err)
 	assert.Len(t, records, 2)
 }
 
+func TestTimeRange(t *testing.T) {
+	db, err := OpenDB(filepath.Join(tempDir, "DB-TestTimeRange"))
+	assert.NoError(t, err)
+	defer db.Close()
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, db.BeginUpdate())
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, db.AddRecord(&driver.Record{
+			TxID:         fmt.Sprintf("%d", i),
+			EnrollmentID: "alice",
+			Amount:       big.NewInt(10),
+			Type:         "EUR",
+			Status:       driver.Pending,
+			CreatedAt:    day.Add(time.Duration(i) * 24 * time.Hour),
+		}))
+	}
+	assert.NoError(t, db.Commit())
+
+	records, err := db.Query(&driver.QueryParams{From: day.Add(time.Hour), Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+	assert.True(t, day.Add(24*time.Hour).Equal(records[0].CreatedAt))
+	records, err = db.Query(&driver.QueryParams{From: day, To: day.Add(48 * time.Hour), Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+
+	assert.NoError(t, db.BeginUpdate())
+	assert.NoError(t, db.SetStatus("1", driver.Confirmed))
+	assert.NoError(t, db.Commit())
+	records, err = db.Query(&driver.QueryParams{Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "1", records[0].TxID)
+	assert.False(t, records[0].ConfirmedAt.IsZero())
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.True(t, records[0].ConfirmedAt.IsZero())
+}
+
 var tempDir string
 
 func TestMain(m *testing.M) {
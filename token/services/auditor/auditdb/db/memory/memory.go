@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package memory
 
 import (
+	"time"
+
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
@@ -15,11 +17,11 @@ type Persistence struct {
 	records []*driver.Record
 }
 
-func (p *Persistence) Query(ids []string, types []string, status []driver.Status, direction driver.Direction, value driver.Value, numRecords int) ([]*driver.Record, error) {
+func (p *Persistence) Query(params *driver.QueryParams) ([]*driver.Record, error) {
 	var res []*driver.Record
 
 	var cursor int
-	switch direction {
+	switch params.Direction {
 	case driver.FromBeginning:
 		cursor = -1
 	case driver.FromLast:
@@ -27,9 +29,8 @@ func (p *Persistence) Query(ids []string, types []string, status []driver.Status
 	default:
 		panic("direction not valid")
 	}
-	counter := 0
 	for {
-		switch direction {
+		switch params.Direction {
 		case driver.FromBeginning:
 			cursor++
 		case driver.FromLast:
@@ -38,62 +39,14 @@ func (p *Persistence) Query(ids []string, types []string, status []driver.Status
 		if cursor < 0 || cursor >= len(p.records) {
 			break
 		}
-
-		record := p.records[cursor]
-		if len(ids) != 0 {
-			found := false
-			for _, id := range ids {
-				if record.EnrollmentID == id {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if len(types) != 0 {
-			found := false
-			for _, typ := range types {
-				if record.Type == typ {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if len(status) != 0 {
-			found := false
-			for _, st := range status {
-				if record.Status == st {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		} else {
-			// exclude the deleted
-			if record.Status == driver.Deleted {
-				continue
-			}
-		}
-
-		if numRecords != 0 && counter+1 > numRecords {
+		if params.NumRecords > 0 && len(res) >= params.NumRecords {
 			break
 		}
 
-		if value == driver.Sent && record.Amount.Sign() > 0 {
-			continue
-		}
-		if value == driver.Received && record.Amount.Sign() < 0 {
+		record := p.records[cursor]
+		if !params.Match(record) {
 			continue
 		}
-
-		counter++
 		res = append(res, record)
 	}
 
@@ -109,6 +62,9 @@ func (p *Persistence) AddRecord(record *driver.Record) error {
 func (p *Persistence) SetStatus(txID string, status driver.Status) error {
 	for _, record := range p.records {
 		if record.TxID == txID {
+			if status == driver.Confirmed && record.Status != driver.Confirmed {
+				record.ConfirmedAt = time.Now()
+			}
 			record.Status = status
 		}
 	}
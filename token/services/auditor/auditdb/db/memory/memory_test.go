@@ -6,8 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 package memory
 
 import (
+	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
 	"github.com/stretchr/testify/assert"
@@ -43,26 +45,56 @@ func Test(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
-	records, err := db.Query([]string{"alice"}, []string{"EUR"}, nil, driver.FromBeginning, driver.Sent, 0)
+	records, err := db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"EUR"}, Direction: driver.FromBeginning, Value: driver.Sent})
 	assert.NoError(t, err)
 	assert.Len(t, records, 1)
-	records, err = db.Query([]string{"alice"}, []string{"EUR"}, nil, driver.FromLast, driver.Sent, 0)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"EUR"}, Direction: driver.FromLast, Value: driver.Sent})
 	assert.NoError(t, err)
 	assert.Len(t, records, 1)
-	records, err = db.Query([]string{"alice"}, []string{"EUR"}, nil, driver.FromLast, driver.Received, 0)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"EUR"}, Direction: driver.FromLast, Value: driver.Received})
 	assert.NoError(t, err)
 	assert.Len(t, records, 2)
-	records, err = db.Query([]string{"alice"}, []string{"EUR"}, nil, driver.FromLast, driver.Received, 1)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"EUR"}, Direction: driver.FromLast, Value: driver.Received, NumRecords: 1})
 	assert.NoError(t, err)
 	assert.Len(t, records, 1)
 
-	records, err = db.Query([]string{"bob"}, []string{"EUR"}, nil, driver.FromBeginning, driver.Sent, 0)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"bob"}, Types: []string{"EUR"}, Direction: driver.FromBeginning, Value: driver.Sent})
 	assert.NoError(t, err)
 	assert.Len(t, records, 0)
-	records, err = db.Query([]string{"alice"}, []string{"USD"}, nil, driver.FromBeginning, driver.Sent, 0)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"USD"}, Direction: driver.FromBeginning, Value: driver.Sent})
 	assert.NoError(t, err)
 	assert.Len(t, records, 0)
-	records, err = db.Query([]string{"alice"}, []string{"EUR"}, []driver.Status{driver.Confirmed}, driver.FromBeginning, driver.Sent, 0)
+	records, err = db.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"EUR"}, Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromBeginning, Value: driver.Sent})
 	assert.NoError(t, err)
 	assert.Len(t, records, 0)
 }
+
+func TestTimeRange(t *testing.T) {
+	db := &Persistence{}
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, db.AddRecord(&driver.Record{
+			TxID:         fmt.Sprintf("%d", i),
+			EnrollmentID: "alice",
+			Amount:       big.NewInt(10),
+			Type:         "EUR",
+			Status:       driver.Pending,
+			CreatedAt:    day.Add(time.Duration(i) * 24 * time.Hour),
+		}))
+	}
+
+	records, err := db.Query(&driver.QueryParams{From: day.Add(time.Hour), Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+	records, err = db.Query(&driver.QueryParams{From: day, To: day.Add(48 * time.Hour), Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+
+	assert.NoError(t, db.SetStatus("1", driver.Confirmed))
+	records, err = db.Query(&driver.QueryParams{Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.False(t, records[0].ConfirmedAt.IsZero())
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.zkat.auditdb.sql")
+
+const (
+	// SQLite is the name of the sqlite database/sql driver, the default
+	SQLite = "sqlite3"
+	// Postgres is the name of the postgres database/sql driver.
+	// The driver is not linked by default, the application must import it.
+	Postgres = "postgres"
+
+	// sqliteBusyTimeout is the time, in milliseconds, sqlite waits for a lock held by another connection
+	sqliteBusyTimeout = 5000
+)
+
+type Opts struct {
+	// Driver is the name of the database/sql driver, sqlite3 by default
+	Driver string
+	// DataSource is the driver-specific data source name.
+	// For sqlite, if empty, the database is the file audit.db in Path, in WAL mode and with a busy timeout.
+	DataSource string
+	// Path is the folder of the sqlite database, used if DataSource is empty
+	Path string
+	// MaxOpenConns is the maximum number of open connections to the database, unlimited if not positive
+	MaxOpenConns int
+}
+
+type Driver struct {
+}
+
+func (d Driver) Open(sp view2.ServiceProvider, name string) (driver.AuditDB, error) {
+	opts := &Opts{}
+	err := view2.GetConfigService(sp).UnmarshalKey("token.auditor.auditdb.persistence.opts", opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting opts for the audit db")
+	}
+	if len(opts.Driver) == 0 {
+		opts.Driver = SQLite
+	}
+	if len(opts.DataSource) == 0 {
+		if opts.Driver != SQLite {
+			return nil, errors.Errorf("no data source configured for driver [%s]", opts.Driver)
+		}
+		path := filepath.Join(opts.Path, name)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return nil, errors.Wrapf(err, "failed creating folders for the audit db [%s]", path)
+		}
+		// WAL lets the queries run while a transaction is stored, the busy timeout makes the writers wait for each other
+		opts.DataSource = fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL", filepath.Join(path, "audit.db"), sqliteBusyTimeout)
+	}
+	logger.Debugf("open audit db with driver [%s]", opts.Driver)
+
+	db, err := sql.Open(opts.Driver, opts.DataSource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening audit db with driver [%s]", opts.Driver)
+	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	persistence, err := NewPersistence(db, opts.Driver)
+	if err != nil {
+		if err1 := db.Close(); err1 != nil {
+			logger.Errorf("failed closing audit db [%s]", err1)
+		}
+		return nil, err
+	}
+	return persistence, nil
+}
+
+func init() {
+	auditdb.Register("sql", &Driver{})
+}
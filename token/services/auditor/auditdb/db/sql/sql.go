@@ -0,0 +1,327 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+// The schema is portable across sqlite and postgres, only the auto-increment primary key differs.
+// Amounts are stored as decimal strings to not lose precision, cast them to NUMERIC in reports.
+// The sign of each amount, -1, 0 or 1, is stored in amount_sign to select the sent and received amounts.
+// Timestamps are stored in UTC. Senders and recipients are stored as JSON arrays.
+const (
+	createTable = `CREATE TABLE IF NOT EXISTS audit_records (
+	id %s,
+	tx_id TEXT NOT NULL,
+	network TEXT NOT NULL,
+	channel TEXT NOT NULL,
+	action_type TEXT NOT NULL,
+	action_index INTEGER NOT NULL,
+	enrollment_id TEXT NOT NULL,
+	token_type TEXT NOT NULL,
+	amount TEXT NOT NULL,
+	amount_sign INTEGER NOT NULL,
+	senders TEXT NOT NULL,
+	recipients TEXT NOT NULL,
+	status TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	confirmed_at TIMESTAMP
+)`
+	sqliteID   = "INTEGER PRIMARY KEY AUTOINCREMENT"
+	postgresID = "BIGSERIAL PRIMARY KEY"
+)
+
+var createIndices = []string{
+	"CREATE INDEX IF NOT EXISTS idx_audit_records_tx_id ON audit_records (tx_id)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_records_enrollment_id ON audit_records (enrollment_id)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_records_token_type ON audit_records (token_type)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_records_status ON audit_records (status)",
+	"CREATE INDEX IF NOT EXISTS idx_audit_records_created_at ON audit_records (created_at)",
+}
+
+// Persistence stores the audit records in a SQL database
+type Persistence struct {
+	db *sql.DB
+
+	txn     *sql.Tx
+	txnLock sync.Mutex
+}
+
+// NewPersistence returns a new Persistence on the passed database, opened with the passed database/sql driver.
+// The schema is created if it does not exist.
+func NewPersistence(db *sql.DB, driverName string) (*Persistence, error) {
+	id := sqliteID
+	if driverName != SQLite {
+		id = postgresID
+	}
+	if _, err := db.Exec(fmt.Sprintf(createTable, id)); err != nil {
+		return nil, errors.Wrap(err, "failed creating table audit_records")
+	}
+	for _, stmt := range createIndices {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, errors.Wrapf(err, "failed creating index [%s]", stmt)
+		}
+	}
+	return &Persistence{db: db}, nil
+}
+
+func (db *Persistence) Close() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn != nil {
+		if err := db.txn.Rollback(); err != nil {
+			logger.Errorf("failed discarding pending transaction [%s]", err)
+		}
+		db.txn = nil
+	}
+	if err := db.db.Close(); err != nil {
+		return errors.Wrap(err, "could not close DB")
+	}
+	return nil
+}
+
+func (db *Persistence) BeginUpdate() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn != nil {
+		return errors.New("previous commit in progress")
+	}
+	txn, err := db.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	db.txn = txn
+	return nil
+}
+
+func (db *Persistence) Commit() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	err := db.txn.Commit()
+	db.txn = nil
+	if err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (db *Persistence) Discard() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	err := db.txn.Rollback()
+	db.txn = nil
+	if err != nil {
+		return errors.Wrap(err, "could not discard transaction")
+	}
+	return nil
+}
+
+func (db *Persistence) AddRecord(record *driver.Record) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	amount, sign := "0", 0
+	if record.Amount != nil {
+		amount, sign = record.Amount.String(), record.Amount.Sign()
+	}
+	senders, err := marshalList(record.Senders)
+	if err != nil {
+		return err
+	}
+	recipients, err := marshalList(record.Recipients)
+	if err != nil {
+		return err
+	}
+	_, err = db.txn.Exec(
+		"INSERT INTO audit_records (tx_id, network, channel, action_type, action_index, enrollment_id, token_type, amount, amount_sign, senders, recipients, status, created_at, confirmed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		record.TxID, record.Network, record.Channel, string(record.ActionType), record.ActionIndex, record.EnrollmentID, record.Type, amount, sign, senders, recipients,
+		string(record.Status), record.CreatedAt.UTC(), nullTime(record.ConfirmedAt),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "could not insert record for [%s]", record.TxID)
+	}
+	return nil
+}
+
+func (db *Persistence) SetStatus(txID string, status driver.Status) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	if status == driver.Confirmed {
+		_, err := db.txn.Exec(
+			"UPDATE audit_records SET confirmed_at = $1 WHERE tx_id = $2 AND status <> $3",
+			time.Now().UTC(), txID, string(driver.Confirmed),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "could not set confirmation time of [%s]", txID)
+		}
+	}
+	if _, err := db.txn.Exec("UPDATE audit_records SET status = $1 WHERE tx_id = $2", string(status), txID); err != nil {
+		return errors.Wrapf(err, "could not set status of [%s]", txID)
+	}
+	return nil
+}
+
+func (db *Persistence) Query(params *driver.QueryParams) ([]*driver.Record, error) {
+	q := &query{}
+	q.in("enrollment_id", params.IDs)
+	q.in("token_type", params.Types)
+	if len(params.Statuses) != 0 {
+		var statuses []string
+		for _, st := range params.Statuses {
+			statuses = append(statuses, string(st))
+		}
+		q.in("status", statuses)
+	} else {
+		// exclude the deleted
+		q.where("status <> " + q.arg(string(driver.Deleted)))
+	}
+	// as in driver.QueryParams.Match, a zero amount is both sent and received
+	switch params.Value {
+	case driver.Sent:
+		q.where("amount_sign <= 0")
+	case driver.Received:
+		q.where("amount_sign >= 0")
+	}
+	if !params.From.IsZero() {
+		q.where("created_at >= " + q.arg(params.From.UTC()))
+	}
+	if !params.To.IsZero() {
+		q.where("created_at < " + q.arg(params.To.UTC()))
+	}
+
+	stmt := "SELECT tx_id, network, channel, action_type, action_index, enrollment_id, token_type, amount, senders, recipients, status, created_at, confirmed_at FROM audit_records" + q.clause()
+	switch params.Direction {
+	case driver.FromBeginning:
+		stmt += " ORDER BY id ASC"
+	case driver.FromLast:
+		stmt += " ORDER BY id DESC"
+	default:
+		return nil, errors.Errorf("direction not valid [%d]", params.Direction)
+	}
+	if params.NumRecords > 0 {
+		stmt += " LIMIT " + q.arg(params.NumRecords)
+	}
+
+	rows, err := db.db.Query(stmt, q.args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed querying audit records")
+	}
+	defer rows.Close()
+
+	var res []*driver.Record
+	for rows.Next() {
+		record := &driver.Record{}
+		var actionType, amount, senders, recipients, st string
+		var confirmedAt sql.NullTime
+		if err := rows.Scan(&record.TxID, &record.Network, &record.Channel, &actionType, &record.ActionIndex, &record.EnrollmentID, &record.Type, &amount, &senders, &recipients, &st, &record.CreatedAt, &confirmedAt); err != nil {
+			return nil, errors.Wrap(err, "failed reading audit record")
+		}
+		record.ActionType = driver.ActionType(actionType)
+		if err := json.Unmarshal([]byte(senders), &record.Senders); err != nil {
+			return nil, errors.Wrapf(err, "invalid senders [%s] for [%s]", senders, record.TxID)
+		}
+		if err := json.Unmarshal([]byte(recipients), &record.Recipients); err != nil {
+			return nil, errors.Wrapf(err, "invalid recipients [%s] for [%s]", recipients, record.TxID)
+		}
+		if len(record.Senders) == 0 {
+			record.Senders = nil
+		}
+		if len(record.Recipients) == 0 {
+			record.Recipients = nil
+		}
+		var ok bool
+		record.Amount, ok = new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, errors.Errorf("invalid amount [%s] for [%s]", amount, record.TxID)
+		}
+		record.Status = driver.Status(st)
+		if confirmedAt.Valid {
+			record.ConfirmedAt = confirmedAt.Time
+		}
+		res = append(res, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed reading audit records")
+	}
+	return res, nil
+}
+
+// marshalList returns the passed list as a JSON array, the empty array if the list is nil
+func marshalList(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return "", errors.Wrap(err, "failed marshalling list")
+	}
+	return string(raw), nil
+}
+
+// nullTime returns the passed time in UTC, nil if it is zero
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// query builds the conditions of a statement with numbered placeholders, understood by both sqlite and postgres
+type query struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds the passed argument and returns its placeholder
+func (q *query) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *query) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// in adds the condition that the passed column has one of the passed values, if any is passed
+func (q *query) in(column string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	var placeholders []string
+	for _, v := range values {
+		placeholders = append(placeholders, q.arg(v))
+	}
+	q.conditions = append(q.conditions, column+" IN ("+strings.Join(placeholders, ", ")+")")
+}
+
+func (q *query) clause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
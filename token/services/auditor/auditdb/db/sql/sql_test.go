@@ -0,0 +1,202 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+func TestDB(t *testing.T) {
+	db, err := sql.Open(SQLite, filepath.Join(tempDir, "DB-TestQueries.db"))
+	assert.NoError(t, err)
+	p, err := NewPersistence(db, SQLite)
+	assert.NoError(t, err)
+	defer p.Close()
+
+	assert.Error(t, p.AddRecord(&driver.Record{TxID: "0"}))
+
+	assert.NoError(t, p.BeginUpdate())
+	for i, r := range []struct {
+		txID   string
+		eID    string
+		typ    string
+		amount int64
+	}{
+		{"0", "alice", "magic", 10},
+		{"1", "alice", "magic", -20},
+		{"1", "bob", "magic", 20},
+		{"2", "bob", "spell", 30},
+	} {
+		err = p.AddRecord(&driver.Record{
+			TxID:         r.txID,
+			Network:      "n1",
+			Channel:      "ch1",
+			ActionIndex:  uint32(i),
+			EnrollmentID: r.eID,
+			Type:         r.typ,
+			Amount:       big.NewInt(r.amount),
+			Status:       driver.Pending,
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, p.Commit())
+
+	records, err := p.Query(&driver.QueryParams{Direction: driver.FromLast, Value: driver.Received, NumRecords: 2})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "2", records[0].TxID)
+	assert.Equal(t, "1", records[1].TxID)
+	assert.Equal(t, int64(20), records[1].Amount.Int64())
+
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"alice"}, Types: []string{"magic"}, Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "0", records[0].TxID)
+	assert.Equal(t, "n1", records[0].Network)
+	assert.Equal(t, "ch1", records[0].Channel)
+
+	records, err = p.Query(&driver.QueryParams{Direction: driver.FromBeginning, Value: driver.Sent})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "alice", records[0].EnrollmentID)
+	assert.Equal(t, int64(-20), records[0].Amount.Int64())
+
+	// the action and the counterparties are stored
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.AddRecord(&driver.Record{
+		TxID:         "4",
+		ActionType:   driver.Transfer,
+		ActionIndex:  1,
+		EnrollmentID: "dave",
+		Type:         "magic",
+		Amount:       big.NewInt(-7),
+		Senders:      []string{"dave"},
+		Recipients:   []string{"alice", "bob"},
+		Status:       driver.Pending,
+	}))
+	assert.NoError(t, p.Commit())
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"dave"}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, driver.Transfer, records[0].ActionType)
+	assert.Equal(t, uint32(1), records[0].ActionIndex)
+	assert.Equal(t, []string{"dave"}, records[0].Senders)
+	assert.Equal(t, []string{"alice", "bob"}, records[0].Recipients)
+
+	// a large amount does not lose precision
+	large, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.True(t, ok)
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.AddRecord(&driver.Record{TxID: "3", EnrollmentID: "charlie", Type: "magic", Amount: large, Status: driver.Pending}))
+	assert.NoError(t, p.Commit())
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"charlie"}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, 0, large.Cmp(records[0].Amount))
+
+	// the amounts are selected by sign, a zero amount is both sent and received
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.AddRecord(&driver.Record{TxID: "5", EnrollmentID: "erin", Type: "magic", Amount: big.NewInt(0), Status: driver.Pending}))
+	assert.NoError(t, p.Commit())
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"erin"}, Direction: driver.FromLast, Value: driver.Sent})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"erin"}, Direction: driver.FromLast, Value: driver.Received})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// discarded updates are not stored
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.SetStatus("1", driver.Confirmed))
+	assert.NoError(t, p.Discard())
+	records, err = p.Query(&driver.QueryParams{Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.SetStatus("1", driver.Confirmed))
+	assert.NoError(t, p.SetStatus("2", driver.Deleted))
+	assert.NoError(t, p.Commit())
+	records, err = p.Query(&driver.QueryParams{Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	records, err = p.Query(&driver.QueryParams{Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 6)
+
+	// the schema is created only once
+	_, err = NewPersistence(db, SQLite)
+	assert.NoError(t, err)
+}
+
+func TestTimeRange(t *testing.T) {
+	db, err := sql.Open(SQLite, filepath.Join(tempDir, "DB-TestTimeRange.db"))
+	assert.NoError(t, err)
+	p, err := NewPersistence(db, SQLite)
+	assert.NoError(t, err)
+	defer p.Close()
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, p.BeginUpdate())
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, p.AddRecord(&driver.Record{
+			TxID:         fmt.Sprintf("%d", i),
+			EnrollmentID: "alice",
+			Amount:       big.NewInt(10),
+			Type:         "EUR",
+			Status:       driver.Pending,
+			CreatedAt:    day.Add(time.Duration(i) * 24 * time.Hour),
+		}))
+	}
+	assert.NoError(t, p.Commit())
+
+	records, err := p.Query(&driver.QueryParams{From: day.Add(time.Hour), Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+	assert.True(t, day.Add(24*time.Hour).Equal(records[0].CreatedAt))
+	records, err = p.Query(&driver.QueryParams{From: day, To: day.Add(48 * time.Hour), Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "1", records[0].TxID)
+
+	assert.NoError(t, p.BeginUpdate())
+	assert.NoError(t, p.SetStatus("1", driver.Confirmed))
+	assert.NoError(t, p.Commit())
+	records, err = p.Query(&driver.QueryParams{Statuses: []driver.Status{driver.Confirmed}, Direction: driver.FromLast, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "1", records[0].TxID)
+	assert.False(t, records[0].ConfirmedAt.IsZero())
+	records, err = p.Query(&driver.QueryParams{IDs: []string{"alice"}, Direction: driver.FromBeginning, Value: driver.All})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.True(t, records[0].ConfirmedAt.IsZero())
+}
+
+var tempDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	tempDir, err = ioutil.TempDir("", "sql-auditdb-test")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temporary directory: %v", err)
+		os.Exit(-1)
+	}
+	defer os.RemoveAll(tempDir)
+
+	m.Run()
+}
@@ -7,6 +7,7 @@ package driver
 
 import (
 	"math/big"
+	"time"
 
 	view "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 )
@@ -34,14 +35,97 @@ const (
 	Deleted   Status = "Deleted"
 )
 
+// ActionType is the kind of action a record refers to
+type ActionType string
+
+const (
+	Issue    ActionType = "Issue"
+	Transfer ActionType = "Transfer"
+	// Redeem is a transfer with redeemed outputs
+	Redeem ActionType = "Redeem"
+)
+
+// Record is the net movement of an enrollment ID, for a token type, in an action of a transaction
 type Record struct {
-	TxID         string
+	TxID string
+	// Network and Channel identify the channel of the transaction, they are empty for the records stored without them
+	Network string
+	Channel string
+	// ActionType is the kind of the action
+	ActionType ActionType
+	// ActionIndex is the index of the action among the actions of its kind, issues or transfers. Redeems are transfers.
 	ActionIndex  uint32
 	EnrollmentID string
 	Type         string
 	// Positive is money received. Negative is money sent
 	Amount *big.Int
-	Status Status
+	// Senders are the enrollment IDs that sent tokens of this type in the action, empty for issues
+	Senders []string
+	// Recipients are the enrollment IDs that received tokens of this type in the action
+	Recipients []string
+	Status     Status
+	// CreatedAt is the time the record was added
+	CreatedAt time.Time
+	// ConfirmedAt is the time the record was confirmed, zero if it is not
+	ConfirmedAt time.Time
+}
+
+// QueryParams selects records. Empty fields match all records.
+type QueryParams struct {
+	// IDs are the enrollment IDs
+	IDs []string
+	// Types are the token types
+	Types []string
+	// Statuses are the statuses, all but Deleted if empty
+	Statuses []Status
+	// Value selects the records of money sent, received or both. Notice that the zero value is Sent.
+	Value Value
+	// From selects the records created at or after this time, if not zero
+	From time.Time
+	// To selects the records created before this time, if not zero
+	To time.Time
+	// Direction is the order of the records, by time of addition
+	Direction Direction
+	// NumRecords is the maximum number of records to return, unlimited if not positive
+	NumRecords int
+}
+
+// Match returns true if the passed record is selected by these params, regardless of the number of records
+func (p *QueryParams) Match(record *Record) bool {
+	if len(p.IDs) != 0 && !containsString(p.IDs, record.EnrollmentID) {
+		return false
+	}
+	if len(p.Types) != 0 && !containsString(p.Types, record.Type) {
+		return false
+	}
+	if len(p.Statuses) != 0 {
+		found := false
+		for _, st := range p.Statuses {
+			if record.Status == st {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	} else if record.Status == Deleted {
+		// exclude the deleted
+		return false
+	}
+	if p.Value == Sent && record.Amount.Sign() > 0 {
+		return false
+	}
+	if p.Value == Received && record.Amount.Sign() < 0 {
+		return false
+	}
+	if !p.From.IsZero() && record.CreatedAt.Before(p.From) {
+		return false
+	}
+	if !p.To.IsZero() && !record.CreatedAt.Before(p.To) {
+		return false
+	}
+	return true
 }
 
 type AuditDB interface {
@@ -50,10 +134,22 @@ type AuditDB interface {
 	Commit() error
 	Discard() error
 	AddRecord(record *Record) error
+	// SetStatus sets the status of the records of the passed transaction.
+	// The records that become Confirmed get the current time as confirmation time.
 	SetStatus(txID string, status Status) error
-	Query(ids []string, types []string, status []Status, direction Direction, value Value, numRecords int) ([]*Record, error)
+	// Query returns the records selected by the passed params
+	Query(params *QueryParams) ([]*Record, error)
 }
 
 type Driver interface {
 	Open(sp view.ServiceProvider, name string) (AuditDB, error)
 }
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
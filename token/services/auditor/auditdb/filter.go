@@ -7,6 +7,8 @@ package auditdb
 
 import (
 	"math/big"
+	"sort"
+	"time"
 
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 
@@ -19,6 +21,9 @@ type PaymentsFilter struct {
 	EnrollmentIds  []string
 	Types          []string
 	LastNumRecords int
+	FromTime       time.Time
+	ToTime         time.Time
+	Grouping       Grouping
 
 	records []*driver.Record
 }
@@ -38,8 +43,46 @@ func (f *PaymentsFilter) Last(num int) *PaymentsFilter {
 	return f
 }
 
+// From selects the payments recorded at or after the passed time
+func (f *PaymentsFilter) From(t time.Time) *PaymentsFilter {
+	f.FromTime = t
+	return f
+}
+
+// To selects the payments recorded before the passed time
+func (f *PaymentsFilter) To(t time.Time) *PaymentsFilter {
+	f.ToTime = t
+	return f
+}
+
+// GroupByDay groups the payments by the UTC day they were recorded on
+func (f *PaymentsFilter) GroupByDay() *PaymentsFilter {
+	f.Grouping.Day = true
+	return f
+}
+
+// GroupByType groups the payments by token type
+func (f *PaymentsFilter) GroupByType() *PaymentsFilter {
+	f.Grouping.Type = true
+	return f
+}
+
+// GroupByEnrollmentId groups the payments by enrollment ID
+func (f *PaymentsFilter) GroupByEnrollmentId() *PaymentsFilter {
+	f.Grouping.EnrollmentID = true
+	return f
+}
+
 func (f *PaymentsFilter) Execute() (*PaymentsFilter, error) {
-	records, err := f.db.db.Query(f.EnrollmentIds, f.Types, nil, driver.FromLast, driver.Sent, f.LastNumRecords)
+	records, err := f.db.db.Query(&driver.QueryParams{
+		IDs:        f.EnrollmentIds,
+		Types:      f.Types,
+		Value:      driver.Sent,
+		From:       f.FromTime,
+		To:         f.ToTime,
+		Direction:  driver.FromLast,
+		NumRecords: f.LastNumRecords,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +99,25 @@ func (f *PaymentsFilter) Sum() token2.Quantity {
 	return token2.NewQuantityFromBig64(sum)
 }
 
+// Records returns the payment records, one for each action and enrollment ID that sent tokens.
+// The amounts are negative.
+func (f *PaymentsFilter) Records() []*driver.Record {
+	return f.records
+}
+
+// Groups returns the sum of the payments of each group, the amounts sent are positive
+func (f *PaymentsFilter) Groups() []*Group {
+	return f.Grouping.groups(f.records, true)
+}
+
 type HoldingsFilter struct {
 	db *AuditDB
 
 	EnrollmentIds []string
 	Types         []string
+	FromTime      time.Time
+	ToTime        time.Time
+	Grouping      Grouping
 
 	records []*driver.Record
 }
@@ -75,8 +132,45 @@ func (f *HoldingsFilter) ByType(tokenType string) *HoldingsFilter {
 	return f
 }
 
+// From selects the movements recorded at or after the passed time
+func (f *HoldingsFilter) From(t time.Time) *HoldingsFilter {
+	f.FromTime = t
+	return f
+}
+
+// To selects the movements recorded before the passed time
+func (f *HoldingsFilter) To(t time.Time) *HoldingsFilter {
+	f.ToTime = t
+	return f
+}
+
+// GroupByDay groups the movements by the UTC day they were recorded on
+func (f *HoldingsFilter) GroupByDay() *HoldingsFilter {
+	f.Grouping.Day = true
+	return f
+}
+
+// GroupByType groups the movements by token type
+func (f *HoldingsFilter) GroupByType() *HoldingsFilter {
+	f.Grouping.Type = true
+	return f
+}
+
+// GroupByEnrollmentId groups the movements by enrollment ID
+func (f *HoldingsFilter) GroupByEnrollmentId() *HoldingsFilter {
+	f.Grouping.EnrollmentID = true
+	return f
+}
+
 func (f *HoldingsFilter) Execute() (*HoldingsFilter, error) {
-	records, err := f.db.db.Query(f.EnrollmentIds, f.Types, nil, driver.FromBeginning, driver.All, 0)
+	records, err := f.db.db.Query(&driver.QueryParams{
+		IDs:       f.EnrollmentIds,
+		Types:     f.Types,
+		Value:     driver.All,
+		From:      f.FromTime,
+		To:        f.ToTime,
+		Direction: driver.FromBeginning,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -91,3 +185,97 @@ func (f *HoldingsFilter) Sum() token2.Quantity {
 	}
 	return token2.NewQuantityFromBig64(sum)
 }
+
+// Records returns the records of the movements, one for each action and enrollment ID that sent or received tokens
+func (f *HoldingsFilter) Records() []*driver.Record {
+	return f.records
+}
+
+// Groups returns the net movement of each group, the amounts received are positive and the amounts sent negative
+func (f *HoldingsFilter) Groups() []*Group {
+	return f.Grouping.groups(f.records, false)
+}
+
+// Grouping tells how records are grouped. Records are grouped together if they agree on all the selected criteria.
+type Grouping struct {
+	// Day groups the records by the UTC day they were recorded on
+	Day bool
+	// Type groups the records by token type
+	Type bool
+	// EnrollmentID groups the records by enrollment ID
+	EnrollmentID bool
+}
+
+// Group is the sum of the amounts of a group of records
+type Group struct {
+	// Day is the start of the UTC day of the records, zero if the records are not grouped by day
+	Day time.Time
+	// Type is the token type of the records, empty if the records are not grouped by type
+	Type string
+	// EnrollmentID is the enrollment ID of the records, empty if the records are not grouped by enrollment ID
+	EnrollmentID string
+	// Sum is the sum of the amounts of the records
+	Sum token2.Quantity
+	// Count is the number of records
+	Count int
+}
+
+type groupKey struct {
+	day          time.Time
+	typ          string
+	enrollmentID string
+}
+
+// groups returns the groups of the passed records, sorted by day, enrollment ID and type.
+// If negate is true, the sums are negated.
+func (g Grouping) groups(records []*driver.Record, negate bool) []*Group {
+	sums := map[groupKey]*big.Int{}
+	counts := map[groupKey]int{}
+	var keys []groupKey
+	for _, record := range records {
+		k := groupKey{}
+		if g.Day {
+			t := record.CreatedAt.UTC()
+			k.day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if g.Type {
+			k.typ = record.Type
+		}
+		if g.EnrollmentID {
+			k.enrollmentID = record.EnrollmentID
+		}
+		sum, ok := sums[k]
+		if !ok {
+			sum = big.NewInt(0)
+			sums[k] = sum
+			keys = append(keys, k)
+		}
+		sum.Add(sum, record.Amount)
+		counts[k]++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].day.Equal(keys[j].day) {
+			return keys[i].day.Before(keys[j].day)
+		}
+		if keys[i].enrollmentID != keys[j].enrollmentID {
+			return keys[i].enrollmentID < keys[j].enrollmentID
+		}
+		return keys[i].typ < keys[j].typ
+	})
+
+	res := make([]*Group, 0, len(keys))
+	for _, k := range keys {
+		sum := sums[k]
+		if negate {
+			sum.Neg(sum)
+		}
+		res = append(res, &Group{
+			Day:          k.day,
+			Type:         k.typ,
+			EnrollmentID: k.enrollmentID,
+			Sum:          token2.NewQuantityFromBig64(sum),
+			Count:        counts[k],
+		})
+	}
+	return res
+}
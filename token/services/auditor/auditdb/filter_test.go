@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditdb
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+func TestGrouping(t *testing.T) {
+	day := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := []*driver.Record{
+		{EnrollmentID: "alice", Type: "EUR", Amount: big.NewInt(-10), CreatedAt: day.Add(time.Hour)},
+		{EnrollmentID: "alice", Type: "USD", Amount: big.NewInt(-5), CreatedAt: day.Add(2 * time.Hour)},
+		{EnrollmentID: "bob", Type: "EUR", Amount: big.NewInt(-1), CreatedAt: day.Add(3 * time.Hour)},
+		{EnrollmentID: "alice", Type: "EUR", Amount: big.NewInt(-20), CreatedAt: day.Add(25 * time.Hour)},
+	}
+
+	groups := Grouping{Day: true, EnrollmentID: true}.groups(records, true)
+	assert.Len(t, groups, 3)
+	assert.True(t, day.Equal(groups[0].Day))
+	assert.Equal(t, "alice", groups[0].EnrollmentID)
+	assert.Equal(t, "", groups[0].Type)
+	assert.Equal(t, "15", groups[0].Sum.Decimal())
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Equal(t, "bob", groups[1].EnrollmentID)
+	assert.Equal(t, "1", groups[1].Sum.Decimal())
+	assert.True(t, day.Add(24*time.Hour).Equal(groups[2].Day))
+	assert.Equal(t, "20", groups[2].Sum.Decimal())
+
+	groups = Grouping{Type: true}.groups(records, true)
+	assert.Len(t, groups, 2)
+	assert.True(t, groups[0].Day.IsZero())
+	assert.Equal(t, "EUR", groups[0].Type)
+	assert.Equal(t, "31", groups[0].Sum.Decimal())
+	assert.Equal(t, "USD", groups[1].Type)
+	assert.Equal(t, "5", groups[1].Sum.Decimal())
+
+	groups = Grouping{}.groups(records, true)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, 4, groups[0].Count)
+	assert.Equal(t, "36", groups[0].Sum.Decimal())
+}
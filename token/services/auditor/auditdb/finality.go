@@ -0,0 +1,204 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/pkg/errors"
+)
+
+// Ledger gives the status of the transactions of a channel
+type Ledger interface {
+	// IsFinal waits for the passed transaction to be committed, it returns an error if the transaction
+	// is not valid or the wait fails
+	IsFinal(txID string) error
+	// Status returns the status of the passed transaction in the vault
+	Status(txID string) (fabric.ValidationCode, error)
+}
+
+type channelLedger struct {
+	ch *fabric.Channel
+}
+
+// NewLedger returns the ledger of the passed channel
+func NewLedger(sp view2.ServiceProvider, network string, channel string) (Ledger, error) {
+	ch, err := fabric.GetFabricNetworkService(sp, network).Channel(channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting channel [%s:%s]", network, channel)
+	}
+	return &channelLedger{ch: ch}, nil
+}
+
+func (l *channelLedger) IsFinal(txID string) error {
+	return l.ch.Finality().IsFinal(txID)
+}
+
+func (l *channelLedger) Status(txID string) (fabric.ValidationCode, error) {
+	code, _, err := l.ch.Vault().Status(txID)
+	return code, err
+}
+
+// retryInterval is the time between two checks of the status of the transactions the ledger has not decided on yet
+const retryInterval = time.Minute
+
+// Finality moves the audit records of a transaction to Confirmed or Deleted once the ledger decides on the transaction
+type Finality struct {
+	db            *AuditDB
+	ledger        Ledger
+	retryInterval time.Duration
+
+	lock     sync.Mutex
+	tracking map[string]bool
+	// undecided are the transactions whose status is checked again every retryInterval
+	undecided map[string]bool
+	retrying  bool
+}
+
+func newFinality(db *AuditDB, ledger Ledger) *Finality {
+	return &Finality{
+		db:            db,
+		ledger:        ledger,
+		retryInterval: retryInterval,
+		tracking:      map[string]bool{},
+		undecided:     map[string]bool{},
+	}
+}
+
+// Track waits, in the background, for the finality of the passed transaction and updates the status of its records.
+// Tracking a transaction already tracked has no effect.
+func (f *Finality) Track(txID string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.tracking[txID] {
+		return
+	}
+	f.tracking[txID] = true
+	go f.wait(txID)
+}
+
+// Recover settles the pending records of the passed transactions whose status is known to the vault,
+// and tracks the others. Use it to recover the records left pending by a restart.
+func (f *Finality) Recover(txIDs []string) {
+	logger.Debugf("recovering [%d] pending transactions", len(txIDs))
+	for _, txID := range txIDs {
+		code, err := f.ledger.Status(txID)
+		if err != nil {
+			logger.Warnf("failed getting the status of [%s], track it [%s]", txID, err)
+			f.Track(txID)
+			continue
+		}
+		if !f.settle(txID, code) {
+			f.Track(txID)
+		}
+	}
+}
+
+func (f *Finality) wait(txID string) {
+	defer func() {
+		f.lock.Lock()
+		delete(f.tracking, txID)
+		f.lock.Unlock()
+	}()
+
+	err := f.ledger.IsFinal(txID)
+	if err == nil {
+		if !f.settle(txID, fabric.Valid) {
+			f.retryLater(txID)
+		}
+		return
+	}
+	// the wait might have failed for reasons other than the validity of the transaction, ask the vault
+	code, err2 := f.ledger.Status(txID)
+	if err2 != nil {
+		logger.Warnf("transaction [%s] is not final [%s] and its status is not available [%s], retry later", txID, err, err2)
+		f.retryLater(txID)
+		return
+	}
+	if !f.settle(txID, code) {
+		logger.Warnf("transaction [%s] is not final [%s], retry later", txID, err)
+		f.retryLater(txID)
+	}
+}
+
+// retryLater checks the status of the passed transaction every retryInterval, until the ledger decides on it
+func (f *Finality) retryLater(txID string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.undecided[txID] = true
+	if !f.retrying {
+		f.retrying = true
+		go f.retry()
+	}
+}
+
+func (f *Finality) retry() {
+	for {
+		time.Sleep(f.retryInterval)
+		if !f.retryUndecided() {
+			return
+		}
+	}
+}
+
+// retryUndecided settles the undecided transactions the vault knows the status of.
+// It returns false, and stops the retries, if no transaction is left undecided.
+func (f *Finality) retryUndecided() bool {
+	f.lock.Lock()
+	var txIDs []string
+	for txID := range f.undecided {
+		txIDs = append(txIDs, txID)
+	}
+	f.lock.Unlock()
+
+	var settled []string
+	for _, txID := range txIDs {
+		code, err := f.ledger.Status(txID)
+		if err != nil {
+			logger.Debugf("status of [%s] not available yet [%s]", txID, err)
+			continue
+		}
+		if f.settle(txID, code) {
+			settled = append(settled, txID)
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, txID := range settled {
+		delete(f.undecided, txID)
+	}
+	if len(f.undecided) == 0 {
+		f.retrying = false
+		return false
+	}
+	return true
+}
+
+// settle sets the status of the records of the passed transaction according to the passed validation code.
+// It returns false if the validation code does not decide on the transaction or the status is not stored,
+// the transaction is then retried.
+func (f *Finality) settle(txID string, code fabric.ValidationCode) bool {
+	var status Status
+	switch code {
+	case fabric.Valid:
+		status = Valid
+	case fabric.Invalid:
+		status = Deleted
+	default:
+		return false
+	}
+	if err := f.db.SetStatus(txID, status); err != nil {
+		logger.Errorf("failed setting status of [%s] to [%s] [%s]", txID, status, err)
+		return false
+	}
+	return true
+}
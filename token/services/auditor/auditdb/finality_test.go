@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditdb
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/driver"
+)
+
+func TestFinalityTrack(t *testing.T) {
+	db := newAuditDB(&fakeDB{})
+	addPending(t, db, "valid", "invalid", "timeout")
+	ledger := &fakeLedger{
+		final: map[string]error{
+			"valid":   nil,
+			"invalid": errors.New("transaction [invalid] is not valid"),
+			"timeout": errors.New("timeout waiting for event"),
+		},
+		codes: map[string]fabric.ValidationCode{
+			"valid":   fabric.Valid,
+			"invalid": fabric.Invalid,
+			"timeout": fabric.Unknown,
+		},
+	}
+	f := newFinality(db, ledger)
+	f.Track("valid")
+	f.Track("invalid")
+	f.Track("timeout")
+
+	assert.Eventually(t, func() bool {
+		return statusOf(t, db, "valid") == driver.Confirmed && statusOf(t, db, "invalid") == driver.Deleted
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return !f.isTracking("timeout") }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, driver.Pending, statusOf(t, db, "timeout"))
+	// the transaction the ledger has not decided on is checked again later
+	assert.True(t, f.isUndecided("timeout"))
+
+	pending, err := db.PendingTxIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[ChannelID][]string{{}: {"timeout"}}, pending)
+}
+
+func TestFinalityRetry(t *testing.T) {
+	db := newAuditDB(&fakeDB{})
+	addPending(t, db, "late", "lost")
+	ledger := &fakeLedger{
+		codes: map[string]fabric.ValidationCode{
+			"late": fabric.Unknown,
+			"lost": fabric.Unknown,
+		},
+	}
+	f := newFinality(db, ledger)
+	// the retries are run by the test
+	f.retryInterval = time.Hour
+	f.retryLater("late")
+	f.retryLater("lost")
+
+	assert.True(t, f.retryUndecided())
+	assert.Equal(t, driver.Pending, statusOf(t, db, "late"))
+
+	ledger.setCode("late", fabric.Valid)
+	assert.True(t, f.retryUndecided())
+	assert.Equal(t, driver.Confirmed, statusOf(t, db, "late"))
+	assert.False(t, f.isUndecided("late"))
+
+	// the retries stop once the ledger decides on all the transactions
+	ledger.setCode("lost", fabric.Invalid)
+	assert.False(t, f.retryUndecided())
+	assert.Equal(t, driver.Deleted, statusOf(t, db, "lost"))
+}
+
+func TestFinalitySettleFailure(t *testing.T) {
+	fdb := &fakeDB{}
+	db := newAuditDB(fdb)
+	addPending(t, db, "valid")
+	fdb.failSetStatus(true)
+	ledger := &fakeLedger{
+		codes: map[string]fabric.ValidationCode{"valid": fabric.Valid},
+	}
+	f := newFinality(db, ledger)
+	// the retries are run by the test
+	f.retryInterval = time.Hour
+	f.Track("valid")
+
+	// the status is not stored, the transaction is retried
+	assert.Eventually(t, func() bool { return f.isUndecided("valid") }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, driver.Pending, statusOf(t, db, "valid"))
+
+	fdb.failSetStatus(false)
+	assert.False(t, f.retryUndecided())
+	assert.Equal(t, driver.Confirmed, statusOf(t, db, "valid"))
+}
+
+func TestManagerUnknownDriver(t *testing.T) {
+	err := NewManager(nil, "unknown").Recover()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "audit db driver [unknown] not registered")
+}
+
+func TestPendingTxIDsByChannel(t *testing.T) {
+	db := newAuditDB(&fakeDB{})
+	assert.NoError(t, db.db.BeginUpdate())
+	for _, r := range []*driver.Record{
+		{TxID: "tx1", Network: "n1", Channel: "ch1"},
+		{TxID: "tx2", Network: "n1", Channel: "ch2"},
+		{TxID: "tx1", Network: "n1", Channel: "ch1"},
+		{TxID: "tx3"},
+		{TxID: "tx4", Network: "n1", Channel: "ch1"},
+	} {
+		r.Amount = big.NewInt(1)
+		r.Status = driver.Pending
+		assert.NoError(t, db.db.AddRecord(r))
+	}
+	assert.NoError(t, db.db.Commit())
+
+	pending, err := db.PendingTxIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[ChannelID][]string{
+		{Network: "n1", Channel: "ch1"}: {"tx1", "tx4"},
+		{Network: "n1", Channel: "ch2"}: {"tx2"},
+		{}:                              {"tx3"},
+	}, pending)
+}
+
+func TestFinalityRecover(t *testing.T) {
+	db := newAuditDB(&fakeDB{})
+	addPending(t, db, "valid", "invalid", "busy")
+	release := make(chan struct{})
+	ledger := &fakeLedger{
+		final: map[string]error{"busy": nil},
+		codes: map[string]fabric.ValidationCode{
+			"valid":   fabric.Valid,
+			"invalid": fabric.Invalid,
+			"busy":    fabric.Busy,
+		},
+		release: release,
+	}
+	f := newFinality(db, ledger)
+	pending, err := db.PendingTxIDs()
+	assert.NoError(t, err)
+	f.Recover(pending[ChannelID{}])
+
+	// the transactions known to the vault are settled right away, the others are tracked
+	assert.Equal(t, driver.Confirmed, statusOf(t, db, "valid"))
+	assert.Equal(t, driver.Deleted, statusOf(t, db, "invalid"))
+	assert.Equal(t, driver.Pending, statusOf(t, db, "busy"))
+	assert.True(t, f.isTracking("busy"))
+
+	close(release)
+	assert.Eventually(t, func() bool { return statusOf(t, db, "busy") == driver.Confirmed }, 5*time.Second, 10*time.Millisecond)
+}
+
+func addPending(t *testing.T, db *AuditDB, txIDs ...string) {
+	assert.NoError(t, db.db.BeginUpdate())
+	for _, txID := range txIDs {
+		assert.NoError(t, db.db.AddRecord(&driver.Record{
+			TxID:         txID,
+			EnrollmentID: "alice",
+			Type:         "USD",
+			Amount:       big.NewInt(10),
+			Status:       driver.Pending,
+			CreatedAt:    time.Now(),
+		}))
+	}
+	assert.NoError(t, db.db.Commit())
+}
+
+func statusOf(t *testing.T, db *AuditDB, txID string) driver.Status {
+	db.storeLock.RLock()
+	defer db.storeLock.RUnlock()
+
+	records, err := db.db.Query(&driver.QueryParams{
+		Statuses:  []driver.Status{driver.Pending, driver.Confirmed, driver.Deleted},
+		Value:     driver.All,
+		Direction: driver.FromBeginning,
+	})
+	assert.NoError(t, err)
+	for _, r := range records {
+		if r.TxID == txID {
+			return r.Status
+		}
+	}
+	t.Fatalf("no record for [%s]", txID)
+	return ""
+}
+
+func (f *Finality) isTracking(txID string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.tracking[txID]
+}
+
+func (f *Finality) isUndecided(txID string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.undecided[txID]
+}
+
+type fakeLedger struct {
+	lock  sync.Mutex
+	final map[string]error
+	codes map[string]fabric.ValidationCode
+	// release, if not nil, blocks IsFinal until it is closed
+	release chan struct{}
+}
+
+func (l *fakeLedger) IsFinal(txID string) error {
+	if l.release != nil {
+		<-l.release
+	}
+	return l.final[txID]
+}
+
+func (l *fakeLedger) Status(txID string) (fabric.ValidationCode, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.codes[txID], nil
+}
+
+func (l *fakeLedger) setCode(txID string, code fabric.ValidationCode) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.codes[txID] = code
+}
+
+type fakeDB struct {
+	lock    sync.Mutex
+	records []*driver.Record
+	// failing makes SetStatus fail
+	failing bool
+}
+
+func (d *fakeDB) failSetStatus(failing bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.failing = failing
+}
+
+func (d *fakeDB) Close() error       { return nil }
+func (d *fakeDB) BeginUpdate() error { return nil }
+func (d *fakeDB) Commit() error      { return nil }
+func (d *fakeDB) Discard() error     { return nil }
+
+func (d *fakeDB) AddRecord(record *driver.Record) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.records = append(d.records, record)
+	return nil
+}
+
+func (d *fakeDB) SetStatus(txID string, status driver.Status) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.failing {
+		return errors.New("store unavailable")
+	}
+	for _, r := range d.records {
+		if r.TxID == txID {
+			r.Status = status
+		}
+	}
+	return nil
+}
+
+func (d *fakeDB) Query(params *driver.QueryParams) ([]*driver.Record, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var res []*driver.Record
+	for _, r := range d.records {
+		if params.Match(r) {
+			c := *r
+			res = append(res, &c)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,355 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditor
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.auditor")
+
+// Violation is the error returned when a transaction does not comply with the audit policy
+type Violation struct {
+	TxID string
+	// Reasons describe why the transaction is rejected
+	Reasons []string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("transaction [%s] rejected by the audit policy: %s", v.TxID, strings.Join(v.Reasons, "; "))
+}
+
+// History gives the amounts the enrollment IDs sent in the past
+type History interface {
+	// Sent returns the amount of the passed token type the passed enrollment ID sent in [from, to)
+	Sent(enrollmentID string, tokenType string, from time.Time, to time.Time) (*big.Int, error)
+}
+
+type limit struct {
+	perTransaction *big.Int
+	daily          *big.Int
+	rolling        *big.Int
+	window         time.Duration
+}
+
+// Policy decides whether the auditor signs a transaction.
+// It checks the token types, the blocked enrollment IDs, and the amounts each enrollment ID sends,
+// per transaction and, using the audit history, per UTC day and per rolling window.
+// Pending transactions count towards the daily and rolling limits.
+type Policy struct {
+	allowedTypes map[string]bool
+	blockedIDs   map[string]bool
+	limits       map[string]*limit
+
+	// lock serializes the admissions, so that concurrent transactions cannot exceed the limits together
+	lock sync.Mutex
+}
+
+// NewPolicy returns the policy described by the passed configuration
+func NewPolicy(conf *token.AuditPolicy) (*Policy, error) {
+	p := &Policy{
+		allowedTypes: map[string]bool{},
+		blockedIDs:   map[string]bool{},
+		limits:       map[string]*limit{},
+	}
+	for _, t := range conf.AllowedTypes {
+		p.allowedTypes[t] = true
+	}
+	for _, id := range conf.BlockedIDs {
+		p.blockedIDs[id] = true
+	}
+	for _, l := range conf.Limits {
+		if len(l.Type) == 0 {
+			return nil, errors.New("limit without token type")
+		}
+		if _, ok := p.limits[l.Type]; ok {
+			return nil, errors.Errorf("more than one limit for token type [%s]", l.Type)
+		}
+		var err error
+		parsed := &limit{window: l.Window}
+		if parsed.perTransaction, err = parseAmount(l.PerTransaction); err != nil {
+			return nil, errors.WithMessagef(err, "invalid per transaction limit for [%s]", l.Type)
+		}
+		if parsed.daily, err = parseAmount(l.Daily); err != nil {
+			return nil, errors.WithMessagef(err, "invalid daily limit for [%s]", l.Type)
+		}
+		if parsed.rolling, err = parseAmount(l.Rolling); err != nil {
+			return nil, errors.WithMessagef(err, "invalid rolling limit for [%s]", l.Type)
+		}
+		if parsed.rolling != nil && parsed.window <= 0 {
+			return nil, errors.Errorf("rolling limit for [%s] without window", l.Type)
+		}
+		p.limits[l.Type] = parsed
+	}
+	return p, nil
+}
+
+// Evaluate returns a Violation if the passed audit record does not comply with the policy at the passed time.
+// It returns another error, and the record is rejected as well, if the record cannot be evaluated.
+func (p *Policy) Evaluate(record *token.AuditRecord, history History, now time.Time) error {
+	var reasons []string
+
+	inputs, outputs := record.Inputs, record.Ouputs
+	types := sortedUnion(inputs.TokenTypes(), outputs.TokenTypes())
+	if len(p.allowedTypes) != 0 {
+		for _, t := range types {
+			if !p.allowedTypes[t] {
+				reasons = append(reasons, fmt.Sprintf("token type [%s] is not allowed", t))
+			}
+		}
+	}
+	eIDs := sortedUnion(inputs.EnrollmentIDs(), outputs.EnrollmentIDs())
+	for _, eID := range eIDs {
+		if p.blockedIDs[eID] {
+			reasons = append(reasons, fmt.Sprintf("enrollment ID [%s] is blocked", eID))
+		}
+	}
+
+	if err := validateQuantities(record); err != nil {
+		return err
+	}
+	amounts := sentAmounts(record, now)
+	for _, t := range types {
+		l, ok := p.limits[t]
+		if !ok {
+			continue
+		}
+		for _, eID := range eIDs {
+			sent, ok := amounts[t][eID]
+			if !ok {
+				continue
+			}
+			if l.perTransaction != nil && sent.Cmp(l.perTransaction) > 0 {
+				reasons = append(reasons, fmt.Sprintf("[%s] sends [%s] of [%s], above the per transaction limit [%s]", eID, sent, t, l.perTransaction))
+			}
+			if l.daily != nil {
+				day := now.UTC().Truncate(24 * time.Hour)
+				reason, err := checkWindow(history, eID, t, sent, l.daily, day, now, "daily")
+				if err != nil {
+					return err
+				}
+				if len(reason) != 0 {
+					reasons = append(reasons, reason)
+				}
+			}
+			if l.rolling != nil {
+				reason, err := checkWindow(history, eID, t, sent, l.rolling, now.Add(-l.window), now, fmt.Sprintf("rolling [%s]", l.window))
+				if err != nil {
+					return err
+				}
+				if len(reason) != 0 {
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+	}
+
+	if len(reasons) != 0 {
+		return &Violation{TxID: record.TxID, Reasons: reasons}
+	}
+	return nil
+}
+
+// Admit appends the passed audit record to the passed audit db if it complies with the policy.
+// A nil policy admits all records.
+func (p *Policy) Admit(record *token.AuditRecord, db *auditdb.AuditDB) error {
+	if p == nil {
+		return db.Append(record)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.Evaluate(record, &dbHistory{db: db}, time.Now()); err != nil {
+		return err
+	}
+	return db.Append(record)
+}
+
+// checkWindow returns the reason of the violation if the passed amount, added to what the enrollment ID sent
+// in [from, to), exceeds the passed limit. It returns the empty string otherwise.
+func checkWindow(history History, eID string, tokenType string, sent *big.Int, max *big.Int, from time.Time, to time.Time, name string) (string, error) {
+	past, err := history.Sent(eID, tokenType, from, to)
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed getting the amount of [%s] sent by [%s]", tokenType, eID)
+	}
+	total := new(big.Int).Add(past, sent)
+	if total.Cmp(max) <= 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("[%s] sends [%s] of [%s], [%s] in total, above the %s limit [%s]", eID, sent, tokenType, total, name, max), nil
+}
+
+// dbHistory is the history kept in an audit db
+type dbHistory struct {
+	db *auditdb.AuditDB
+}
+
+func (h *dbHistory) Sent(enrollmentID string, tokenType string, from time.Time, to time.Time) (*big.Int, error) {
+	qe := h.db.NewQueryExecutor()
+	defer qe.Done()
+
+	filter, err := qe.NewPaymentsFilter().ByEnrollmentId(enrollmentID).ByType(tokenType).From(from).To(to).Execute()
+	if err != nil {
+		return nil, err
+	}
+	sum := big.NewInt(0)
+	for _, r := range filter.Records() {
+		sum.Sub(sum, r.Amount)
+	}
+	return sum, nil
+}
+
+// PolicyProvider holds the audit policy of each token management service, as configured in `token.tms`
+type PolicyProvider struct {
+	sp       view2.ServiceProvider
+	lock     sync.Mutex
+	policies map[string]*Policy
+}
+
+func NewPolicyProvider(sp view2.ServiceProvider) *PolicyProvider {
+	return &PolicyProvider{sp: sp, policies: map[string]*Policy{}}
+}
+
+// Policy returns the audit policy of the token management service with the passed network, channel and namespace,
+// nil if none is configured
+func (p *PolicyProvider) Policy(network string, channel string, namespace string) (*Policy, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := network + ":" + channel + ":" + namespace
+	if policy, ok := p.policies[key]; ok {
+		return policy, nil
+	}
+	var tmsConfigs []*token.TMS
+	if err := view2.GetConfigService(p.sp).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	var policy *Policy
+	for _, tms := range tmsConfigs {
+		if len(tms.Network) != 0 && tms.Network != network {
+			continue
+		}
+		if tms.Channel == channel && tms.Namespace == namespace {
+			if tms.Auditor == nil || tms.Auditor.Policy == nil {
+				break
+			}
+			var err error
+			policy, err = NewPolicy(tms.Auditor.Policy)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "invalid audit policy for [%s]", key)
+			}
+			break
+		}
+	}
+	p.policies[key] = policy
+	return policy, nil
+}
+
+// GetPolicyProvider returns the policy provider registered in the passed service provider, nil if none is registered
+func GetPolicyProvider(sp view2.ServiceProvider) *PolicyProvider {
+	s, err := sp.GetService(&PolicyProvider{})
+	if err != nil {
+		logger.Debugf("no audit policy provider registered [%s]", err)
+		return nil
+	}
+	return s.(*PolicyProvider)
+}
+
+// Admit appends the passed audit record to the passed audit db if it complies with the audit policy
+// of the passed token management service. A Violation is returned otherwise.
+func Admit(sp view2.ServiceProvider, tms *token.ManagementService, db *auditdb.AuditDB, record *token.AuditRecord) error {
+	var policy *Policy
+	if provider := GetPolicyProvider(sp); provider != nil {
+		var err error
+		policy, err = provider.Policy(tms.Network(), tms.Channel(), tms.Namespace())
+		if err != nil {
+			return err
+		}
+	}
+	return policy.Admit(record, db)
+}
+
+// parseAmount parses the passed decimal amount, nil if it is empty
+func parseAmount(s string) (*big.Int, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, errors.Errorf("invalid amount [%s]", s)
+	}
+	return v, nil
+}
+
+// sentAmounts returns, for each token type and enrollment ID, the amount sent in the passed audit record.
+// It is the sum of what the enrollment ID sends in each action, as recorded by the audit db, so that it adds up
+// with the amounts sent in the past.
+func sentAmounts(record *token.AuditRecord, now time.Time) map[string]map[string]*big.Int {
+	res := map[string]map[string]*big.Int{}
+	for _, r := range auditdb.ActionRecords(record, now) {
+		// a redeem of several senders is not charged to any of them, their transfer records include it
+		if len(r.EnrollmentID) == 0 || r.Amount.Sign() >= 0 {
+			continue
+		}
+		if res[r.Type] == nil {
+			res[r.Type] = map[string]*big.Int{}
+		}
+		sum, ok := res[r.Type][r.EnrollmentID]
+		if !ok {
+			sum = big.NewInt(0)
+			res[r.Type][r.EnrollmentID] = sum
+		}
+		sum.Sub(sum, r.Amount)
+	}
+	return res
+}
+
+// validateQuantities returns an error if a quantity of the passed audit record cannot be parsed
+func validateQuantities(record *token.AuditRecord) error {
+	for i := 0; i < record.Inputs.Count(); i++ {
+		in := record.Inputs.At(i)
+		if _, err := parseQuantity(in.Quantity); err != nil {
+			return errors.WithMessagef(err, "invalid input [%d] of [%s] of [%s]", i, in.EnrollmentID, in.Type)
+		}
+	}
+	for i, o := range record.Ouputs.Outputs() {
+		if _, err := parseQuantity(o.Quantity); err != nil {
+			return errors.WithMessagef(err, "invalid output [%d] of [%s] of [%s]", i, o.EnrollmentID, o.Type)
+		}
+	}
+	return nil
+}
+
+// parseQuantity parses a quantity of the audit record, in any base understood by big.Int,
+// with the precision the audit db sums it at
+func parseQuantity(q string) (token2.Quantity, error) {
+	v, err := token2.ToQuantity(q, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid quantity [%s]", q)
+	}
+	return v, nil
+}
+
+// sortedUnion returns the sorted strings in either of the passed lists, without duplicates
+func sortedUnion(a []string, b []string) []string {
+	res := auditdb.UnionStrings(a, b)
+	sort.Strings(res)
+	return res
+}
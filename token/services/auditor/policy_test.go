@@ -0,0 +1,184 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package auditor
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+func TestPolicy(t *testing.T) {
+	policy, err := NewPolicy(&token.AuditPolicy{
+		AllowedTypes: []string{"USD", "EUR"},
+		BlockedIDs:   []string{"mallory"},
+		Limits: []*token.AuditLimit{
+			{Type: "USD", PerTransaction: "100", Daily: "150", Rolling: "300", Window: 7 * 24 * time.Hour},
+		},
+	})
+	assert.NoError(t, err)
+	now := time.Date(2021, 6, 10, 15, 0, 0, 0, time.UTC)
+
+	// alice pays 60 to bob and gets 40 back as change
+	history := &fakeHistory{}
+	assert.NoError(t, policy.Evaluate(transfer("alice", "bob", "USD", 100, 60), history, now))
+	// what was sent today and in the last week counts towards the daily and rolling limits
+	assert.Equal(t, []window{
+		{eID: "alice", tokenType: "USD", from: time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC), to: now},
+		{eID: "alice", tokenType: "USD", from: now.Add(-7 * 24 * time.Hour), to: now},
+	}, history.windows)
+
+	// per transaction limit
+	err = policy.Evaluate(transfer("alice", "bob", "USD", 120, 101), &fakeHistory{}, now)
+	assert.Equal(t, []string{"[alice] sends [101] of [USD], above the per transaction limit [100]"}, err.(*Violation).Reasons)
+
+	// daily limit, 100 already sent today
+	err = policy.Evaluate(transfer("alice", "bob", "USD", 60, 60), &fakeHistory{sent: 100}, now)
+	assert.Equal(t, []string{
+		"[alice] sends [60] of [USD], [160] in total, above the daily limit [150]",
+	}, err.(*Violation).Reasons)
+
+	// rolling limit, 250 already sent in the week, the same for today
+	err = policy.Evaluate(transfer("alice", "bob", "USD", 60, 60), &fakeHistory{sent: 250, daily: map[string]int64{"alice": 0}}, now)
+	assert.Equal(t, []string{
+		"[alice] sends [60] of [USD], [310] in total, above the rolling [168h0m0s] limit [300]",
+	}, err.(*Violation).Reasons)
+
+	// token types and blocked enrollment IDs, the recipient of the payment is not limited
+	err = policy.Evaluate(transfer("alice", "mallory", "GBP", 10, 10), &fakeHistory{}, now)
+	assert.Equal(t, []string{
+		"token type [GBP] is not allowed",
+		"enrollment ID [mallory] is blocked",
+	}, err.(*Violation).Reasons)
+	assert.Contains(t, err.Error(), "transaction [tx] rejected by the audit policy")
+
+	// types without limits
+	assert.NoError(t, policy.Evaluate(transfer("alice", "bob", "EUR", 1000, 1000), &fakeHistory{sent: 1000}, now))
+}
+
+func TestPolicyInvalidQuantity(t *testing.T) {
+	policy, err := NewPolicy(&token.AuditPolicy{
+		Limits: []*token.AuditLimit{{Type: "USD", PerTransaction: "100"}},
+	})
+	assert.NoError(t, err)
+
+	// a quantity that cannot be parsed rejects the record, it does not count as zero
+	record := transfer("alice", "bob", "USD", 1000, 1000)
+	record.Inputs.At(0).Quantity = "a lot"
+	err = policy.Evaluate(record, &fakeHistory{}, time.Now())
+	assert.EqualError(t, err, "invalid input [0] of [alice] of [USD]: invalid quantity [a lot]")
+
+	record = transfer("alice", "bob", "USD", 1000, 10)
+	record.Ouputs.Outputs()[1].Quantity = "-990"
+	err = policy.Evaluate(record, &fakeHistory{}, time.Now())
+	assert.EqualError(t, err, "invalid output [1] of [alice] of [USD]: invalid quantity [-990]")
+}
+
+func TestPolicyPerAction(t *testing.T) {
+	policy, err := NewPolicy(&token.AuditPolicy{
+		Limits: []*token.AuditLimit{{Type: "USD", PerTransaction: "50"}},
+	})
+	assert.NoError(t, err)
+	now := time.Now()
+
+	// alice pays 60 to bob in one action and gets 60 back from bob in another one, she sends 60 as the audit db records
+	record := &token.AuditRecord{
+		TxID: "tx",
+		Inputs: token.NewInputStream(nil, []*token.Input{
+			{ActionIndex: 0, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "60"},
+			{ActionIndex: 1, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "60"},
+		}),
+		Ouputs: token.NewOutputStream([]*token.Output{
+			{ActionType: token.TransferActionType, ActionIndex: 0, Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "60"},
+			{ActionType: token.TransferActionType, ActionIndex: 1, Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "60"},
+		}),
+	}
+	err = policy.Evaluate(record, &fakeHistory{}, now)
+	assert.Equal(t, []string{
+		"[alice] sends [60] of [USD], above the per transaction limit [50]",
+		"[bob] sends [60] of [USD], above the per transaction limit [50]",
+	}, err.(*Violation).Reasons)
+
+	// what the only sender redeems counts as sent
+	record = transfer("alice", "bob", "USD", 100, 30)
+	record.Ouputs.Outputs()[1].Owner = nil
+	record.Ouputs.Outputs()[1].EnrollmentID = ""
+	err = policy.Evaluate(record, &fakeHistory{}, now)
+	assert.Equal(t, []string{"[alice] sends [100] of [USD], above the per transaction limit [50]"}, err.(*Violation).Reasons)
+
+	// with several senders, the redeem is in what they send
+	record = &token.AuditRecord{
+		TxID: "tx",
+		Inputs: token.NewInputStream(nil, []*token.Input{
+			{Owner: []byte("alice"), EnrollmentID: "alice", Type: "USD", Quantity: "40"},
+			{Owner: []byte("bob"), EnrollmentID: "bob", Type: "USD", Quantity: "60"},
+		}),
+		Ouputs: token.NewOutputStream([]*token.Output{
+			{ActionType: token.TransferActionType, Owner: []byte("charlie"), EnrollmentID: "charlie", Type: "USD", Quantity: "30"},
+			{ActionType: token.TransferActionType, Type: "USD", Quantity: "70"},
+		}),
+	}
+	err = policy.Evaluate(record, &fakeHistory{}, now)
+	assert.Equal(t, []string{"[bob] sends [60] of [USD], above the per transaction limit [50]"}, err.(*Violation).Reasons)
+}
+
+func TestNewPolicy(t *testing.T) {
+	_, err := NewPolicy(&token.AuditPolicy{Limits: []*token.AuditLimit{{PerTransaction: "10"}}})
+	assert.EqualError(t, err, "limit without token type")
+	_, err = NewPolicy(&token.AuditPolicy{Limits: []*token.AuditLimit{{Type: "USD"}, {Type: "USD"}}})
+	assert.EqualError(t, err, "more than one limit for token type [USD]")
+	_, err = NewPolicy(&token.AuditPolicy{Limits: []*token.AuditLimit{{Type: "USD", Daily: "ten"}}})
+	assert.EqualError(t, err, "invalid daily limit for [USD]: invalid amount [ten]")
+	_, err = NewPolicy(&token.AuditPolicy{Limits: []*token.AuditLimit{{Type: "USD", Rolling: "10"}}})
+	assert.EqualError(t, err, "rolling limit for [USD] without window")
+}
+
+// transfer returns the audit record of a transfer of the passed amount from sender to recipient,
+// spending a token of the passed value and giving the rest back to the sender
+func transfer(sender string, recipient string, tokenType string, value int64, amount int64) *token.AuditRecord {
+	outputs := []*token.Output{
+		{ActionType: token.TransferActionType, Owner: []byte(recipient), EnrollmentID: recipient, Type: tokenType, Quantity: big.NewInt(amount).String()},
+	}
+	if value > amount {
+		outputs = append(outputs, &token.Output{
+			ActionType: token.TransferActionType, Owner: []byte(sender), EnrollmentID: sender, Type: tokenType, Quantity: big.NewInt(value - amount).String(),
+		})
+	}
+	return &token.AuditRecord{
+		TxID: "tx",
+		Inputs: token.NewInputStream(nil, []*token.Input{
+			{Owner: []byte(sender), EnrollmentID: sender, Type: tokenType, Quantity: big.NewInt(value).String()},
+		}),
+		Ouputs: token.NewOutputStream(outputs),
+	}
+}
+
+type window struct {
+	eID       string
+	tokenType string
+	from      time.Time
+	to        time.Time
+}
+
+// fakeHistory returns the same amount for every query, but for the enrollment IDs in daily when the window
+// starts at midnight
+type fakeHistory struct {
+	sent    int64
+	daily   map[string]int64
+	windows []window
+}
+
+func (h *fakeHistory) Sent(enrollmentID string, tokenType string, from time.Time, to time.Time) (*big.Int, error) {
+	h.windows = append(h.windows, window{eID: enrollmentID, tokenType: tokenType, from: from, to: to})
+	if v, ok := h.daily[enrollmentID]; ok && from.Equal(from.Truncate(24*time.Hour)) {
+		return big.NewInt(v), nil
+	}
+	return big.NewInt(h.sent), nil
+}
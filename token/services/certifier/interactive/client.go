@@ -12,6 +12,8 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -42,11 +44,17 @@ type Resolver interface {
 	ResolveIdentities(endpoints ...string) []view.Identity
 }
 
+// scanInterval is the interval at which the certification client checks whether the last transaction in the vault changed
+const scanInterval = 2 * time.Second
+
 // CertificationClient scans the vault for tokens not yet certified and asks the certification.
+// The vault is scanned again each time a token is received, and when the last transaction in the vault changes.
+// The latter catches the tokens whose events were dropped, or all the tokens if no events are available.
 type CertificationClient struct {
 	ctx                  context.Context
 	channel, namespace   string
 	vault                Vault
+	events               <-chan *token.Event
 	queryEngine          QueryEngine
 	certificationStorage CertificationStorage
 	viewManager          ViewManager
@@ -105,6 +113,11 @@ func (d *CertificationClient) RequestCertification(ids ...*token2.Id) error {
 	return nil
 }
 
+// SetEvents sets the channel of the token received events that trigger a new scan of the vault
+func (d *CertificationClient) SetEvents(events <-chan *token.Event) {
+	d.events = events
+}
+
 func (d *CertificationClient) Start() error {
 	go d.Scan()
 	return nil
@@ -138,25 +151,52 @@ func (d *CertificationClient) Scan() {
 			logger.Debugf("request certification of [%v] satisfied with no error", toBeCertified)
 		}
 
-		done := false
-		for {
-			select {
-			case <-d.ctx.Done():
-				return
-			case <-time.After(2 * time.Second):
-				txid, err := d.vault.GetLastTxID()
-				if err != nil {
-					continue
-				}
-				if txid != lastTXID {
-					lastTXID = txid
-					done = true
-					break
-				}
+		if !d.wait(&lastTXID) {
+			return
+		}
+	}
+}
+
+// wait waits for a token received event, or for the last transaction in the vault to change.
+// It returns false if the client is stopped.
+func (d *CertificationClient) wait(lastTXID *string) bool {
+	for {
+		// receiving from a nil channel of events blocks, the client then relies on the vault only
+		select {
+		case <-d.ctx.Done():
+			return false
+		case _, ok := <-d.events:
+			if !ok {
+				// no more events, fall back to polling
+				d.events = nil
+				continue
+			}
+			d.drainEvents()
+			if txid, err := d.vault.GetLastTxID(); err == nil {
+				*lastTXID = txid
+			}
+			return true
+		case <-time.After(scanInterval):
+			txid, err := d.vault.GetLastTxID()
+			if err != nil || txid == *lastTXID {
+				continue
 			}
-			if done {
-				break
+			*lastTXID = txid
+			return true
+		}
+	}
+}
+
+// drainEvents discards the events already received, a single scan covers them all
+func (d *CertificationClient) drainEvents() {
+	for d.events != nil {
+		select {
+		case _, ok := <-d.events:
+			if !ok {
+				d.events = nil
 			}
+		default:
+			return
 		}
 	}
 }
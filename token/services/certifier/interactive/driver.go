@@ -18,6 +18,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type Driver struct {
@@ -42,7 +43,7 @@ func (d *Driver) NewCertificationClient(sp view2.ServiceProvider, network, chann
 	if !ok {
 		ch := fabric.GetChannel(sp, network, channel)
 		fabricVault := ch.Vault()
-		tokenVault := vault.NewVault(sp, ch, namespace)
+		tokenVault := vault.NewVault(sp, network, ch, namespace)
 
 		// Load certifier identities
 		var tmsConfigs []*token.TMS
@@ -64,16 +65,30 @@ func (d *Driver) NewCertificationClient(sp view2.ServiceProvider, network, chann
 			return nil, errors.Errorf("no certifier id configured")
 		}
 
+		// certifications are verified before being stored
+		certificationStorage := tokenVault.CertificationStorage()
+		certificationStorage.SetVerifier(&certificationVerifier{
+			sp:        sp,
+			network:   network,
+			channel:   channel,
+			namespace: namespace,
+		})
+
 		inst := NewCertificationClient(
 			context.Background(),
 			channel,
 			namespace,
 			fabricVault,
 			tokenVault.QueryEngine(),
-			tokenVault.CertificationStorage(),
+			certificationStorage,
 			view2.GetManager(sp),
 			certifiers,
 		)
+		if hubs := token.GetEventHubProvider(sp); hubs != nil {
+			inst.SetEvents(hubs.Hub(network, channel, namespace).Subscribe(
+				&token.EventFilter{Types: []token.EventType{token.TokenReceived}}, 0,
+			).Events())
+		}
 		inst.Start()
 
 		d.cms[k] = inst
@@ -93,3 +108,22 @@ func (d *Driver) NewCertificationService(sp view2.ServiceProvider, network, chan
 
 	return d.certifier, nil
 }
+
+// certificationVerifier verifies certifications with the certification manager of the token management service
+type certificationVerifier struct {
+	sp                          view2.ServiceProvider
+	network, channel, namespace string
+}
+
+func (c *certificationVerifier) VerifyCertifications(ids []*token2.Id, certifications [][]byte) error {
+	tms := token.GetManagementService(
+		c.sp,
+		token.WithNetwork(c.network),
+		token.WithChannel(c.channel),
+		token.WithNamespace(c.namespace),
+	)
+	if tms == nil {
+		return errors.Errorf("token management service not found for [%s:%s:%s]", c.network, c.channel, c.namespace)
+	}
+	return tms.CertificationManager().VerifyCertifications(ids, certifications)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package consolidation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.consolidation")
+
+// Service merges the small tokens of the passed wallets, periodically or on demand.
+// Each consolidation runs the ttxcc.ConsolidateView.
+type Service struct {
+	sp             view2.ServiceProvider
+	interval       time.Duration
+	consolidations []*ttxcc.Consolidation
+
+	// lock makes sure that only one round of consolidations runs at a time
+	lock sync.Mutex
+}
+
+func NewService(sp view2.ServiceProvider, interval time.Duration, consolidations ...*ttxcc.Consolidation) *Service {
+	return &Service{sp: sp, interval: interval, consolidations: consolidations}
+}
+
+// Start runs a round of consolidations every interval, until the passed context is done
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debugf("consolidation service stopped [%s]", ctx.Err())
+				return
+			case <-ticker.C:
+				if _, err := s.Consolidate(); err != nil {
+					logger.Errorf("failed consolidating tokens: [%s]", err)
+				}
+			}
+		}
+	}()
+}
+
+// Consolidate runs a round of consolidations now, and returns the identifiers of the committed transactions.
+// A failed consolidation does not stop the others, the first error is returned.
+func (s *Service) Consolidate() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var txIDs []string
+	var firstErr error
+	for _, c := range s.consolidations {
+		res, err := view2.GetManager(s.sp).InitiateView(ttxcc.NewConsolidateView(c))
+		if ids, ok := res.([]string); ok {
+			txIDs = append(txIDs, ids...)
+		}
+		if err != nil {
+			logger.Errorf("failed consolidating tokens of type [%s] in wallet [%s]: [%s]", c.Type, c.Wallet, err)
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "failed consolidating tokens of type [%s] in wallet [%s]", c.Type, c.Wallet)
+			}
+		}
+	}
+	return txIDs, firstErr
+}
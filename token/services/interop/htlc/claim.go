@@ -0,0 +1,130 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Claim contains the input information to claim locked tokens
+type Claim struct {
+	// Wallet is the identifier of the wallet of the recipient of the script. The claimed tokens go to a fresh
+	// identity of this wallet.
+	Wallet string
+	// TokenID is the identifier of the locked token. If nil, the first locked token whose hash lock
+	// matches the preimage is claimed.
+	TokenID *token2.Id
+	// Preimage is the preimage of the hash lock
+	Preimage []byte
+	// TxOptions are the options used to create the transaction
+	TxOptions []ttxcc.TxOption
+}
+
+// ClaimView spends a locked token by revealing the preimage of its hash lock. It must run before the deadline.
+// The transaction is distributed to the sender of the script as well, so that the sender can learn the preimage.
+type ClaimView struct {
+	*Claim
+}
+
+func NewClaimView(claim *Claim) *ClaimView {
+	return &ClaimView{Claim: claim}
+}
+
+func (c *ClaimView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.NewAnonymousTransaction(context, c.TxOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating transaction")
+	}
+	tms := tx.TokenService()
+	wallet := tms.WalletManager().OwnerWallet(c.Wallet)
+	if wallet == nil {
+		return nil, errors.Errorf("wallet [%s] not found", c.Wallet)
+	}
+
+	lt, err := lookup(tms, c.TokenID, func(lt *LockedToken) bool {
+		return wallet.Contains(lt.Script.Recipient) &&
+			!lt.Script.Expired(time.Now()) &&
+			lt.Script.HashInfo.Match(c.Preimage) == nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot claim")
+	}
+
+	owner, err := lt.Script.Identity()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := getScriptSigner(context, tms, owner, lt.Script)
+	if err != nil {
+		return nil, err
+	}
+	recipientSigner, err := wallet.GetSigner(lt.Script.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting signer of the recipient")
+	}
+	if err := signer.SetRecipient(recipientSigner, c.Preimage); err != nil {
+		return nil, err
+	}
+	auditInfo, err := wallet.GetAuditInfo(lt.Script.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting audit info of the recipient")
+	}
+	if err := registerScriptAuditInfo(context, owner, &htlc2.ScriptInfo{Recipient: auditInfo}); err != nil {
+		return nil, err
+	}
+
+	return spend(context, tx, wallet, lt)
+}
+
+// ClaimResponderView runs at the sender of a script when the recipient claims the tokens.
+// It accepts the transaction and returns the revealed preimage.
+type ClaimResponderView struct{}
+
+func NewClaimResponderView() *ClaimResponderView {
+	return &ClaimResponderView{}
+}
+
+func (c *ClaimResponderView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed receiving transaction")
+	}
+
+	var preimage []byte
+	for _, transfer := range tx.TokenRequest.Metadata.Transfers {
+		for _, sender := range transfer.Senders {
+			if !htlc2.IsScript(sender) {
+				continue
+			}
+			script, err := htlc2.ScriptFromIdentity(sender)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed unmarshalling script")
+			}
+			preimage, err = GetPreimage(tx, &script.HashInfo)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(preimage) == 0 {
+		return nil, errors.Errorf("transaction [%s] does not claim locked tokens", tx.ID())
+	}
+
+	if _, err := context.RunView(ttxcc.NewAcceptView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed accepting transaction")
+	}
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed waiting for finality")
+	}
+	return preimage, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"crypto"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Lock contains the input information to lock tokens
+type Lock struct {
+	// Wallet is the identifier of the wallet that owns the tokens to lock
+	Wallet string
+	// Type of tokens to lock
+	Type string
+	// Quantity to lock
+	Quantity token2.Quantity
+	// Recipient is the identity of the recipient's FSC node
+	Recipient view.Identity
+	// Deadline is the time after which the sender can reclaim the tokens
+	Deadline time.Time
+	// Hash is the hash lock. If empty, a fresh preimage is generated and its hash is used.
+	Hash []byte
+	// HashFunc is the hash function of the hash lock, DefaultHashFunc if not set
+	HashFunc crypto.Hash
+	// TxOptions are the options used to create the transaction
+	TxOptions []ttxcc.TxOption
+}
+
+// LockInfo is the result of the lock view
+type LockInfo struct {
+	// TxID is the identifier of the transaction that locked the tokens
+	TxID string
+	// Script is the script that locks the tokens
+	Script *htlc2.Script
+	// Preimage is the generated preimage, if the lock did not specify a hash
+	Preimage []byte
+}
+
+// LockView transfers tokens to a script owner. The recipient claims them by revealing the preimage of the hash
+// before the deadline, otherwise the sender reclaims them.
+type LockView struct {
+	*Lock
+}
+
+func NewLockView(lock *Lock) *LockView {
+	return &LockView{Lock: lock}
+}
+
+func (l *LockView) Call(context view.Context) (interface{}, error) {
+	if !l.Deadline.After(time.Now()) {
+		return nil, errors.New("the deadline must be in the future")
+	}
+	hashInfo, preimage, err := newHashInfo(l.Hash, l.HashFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := ttxcc.RequestRecipientIdentity(context, l.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting recipient identity")
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(context, l.TxOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating transaction")
+	}
+	tms := tx.TokenService()
+	wallet := tms.WalletManager().OwnerWallet(l.Wallet)
+	if wallet == nil {
+		return nil, errors.Errorf("wallet [%s] not found", l.Wallet)
+	}
+	sender, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting sender identity")
+	}
+
+	script := &htlc2.Script{
+		Sender:    sender,
+		Recipient: recipient,
+		Deadline:  l.Deadline,
+		HashInfo:  *hashInfo,
+	}
+	if err := script.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid script")
+	}
+	owner, err := script.Identity()
+	if err != nil {
+		return nil, err
+	}
+
+	// Register the script, so that the sender can reclaim the tokens and the drivers can audit the transfer
+	signer, err := getScriptSigner(context, tms, owner, script)
+	if err != nil {
+		return nil, err
+	}
+	senderSigner, err := wallet.GetSigner(sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting signer of the sender")
+	}
+	signer.SetSender(senderSigner)
+	senderAuditInfo, err := wallet.GetAuditInfo(sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting audit info of the sender")
+	}
+	recipientAuditInfo, err := view2.GetSigService(context).GetAuditInfo(recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting audit info of the recipient")
+	}
+	if err := registerScriptAuditInfo(context, owner, &htlc2.ScriptInfo{Sender: senderAuditInfo, Recipient: recipientAuditInfo}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Transfer(wallet, l.Type, []token2.Quantity{l.Quantity}, []view.Identity{owner}); err != nil {
+		return nil, errors.Wrap(err, "failed locking tokens")
+	}
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingAndFinalityView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed ordering transaction")
+	}
+
+	return &LockInfo{TxID: tx.ID(), Script: script, Preimage: preimage}, nil
+}
+
+// LockResponderView runs at the recipient of a lock. It provides the recipient identity, from the passed wallet,
+// and accepts the transaction once it has checked that it locks tokens for that identity.
+type LockResponderView struct {
+	Wallet string
+}
+
+func NewLockResponderView(wallet string) *LockResponderView {
+	return &LockResponderView{Wallet: wallet}
+}
+
+func (l *LockResponderView) Call(context view.Context) (interface{}, error) {
+	recipientBoxed, err := context.RunView(&ttxcc.RespondRequestRecipientIdentityView{Wallet: l.Wallet})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed responding with recipient identity")
+	}
+	recipient := recipientBoxed.(view.Identity)
+
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed receiving transaction")
+	}
+	found := false
+	for _, transfer := range tx.TokenRequest.Metadata.Transfers {
+		for _, receiver := range transfer.Receivers {
+			if !htlc2.IsScript(receiver) {
+				continue
+			}
+			script, err := htlc2.ScriptFromIdentity(receiver)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed unmarshalling script")
+			}
+			if !script.Recipient.Equal(recipient) {
+				continue
+			}
+			if err := htlc2.ValidateOwner(receiver, time.Now()); err != nil {
+				return nil, err
+			}
+			// The claim is distributed to the sender, bind it to the FSC node that locked the tokens
+			if err := view2.GetEndpointService(context).Bind(context.Session().Info().Caller, script.Sender); err != nil {
+				return nil, errors.Wrap(err, "failed binding the sender of the script")
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("transaction [%s] does not lock tokens for the recipient", tx.ID())
+	}
+
+	if _, err := context.RunView(ttxcc.NewAcceptView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed accepting transaction")
+	}
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed waiting for finality")
+	}
+	return tx, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Reclaim contains the input information to reclaim locked tokens
+type Reclaim struct {
+	// Wallet is the identifier of the wallet of the sender of the script. The reclaimed tokens go to a fresh
+	// identity of this wallet.
+	Wallet string
+	// TokenID is the identifier of the locked token. If nil, the first expired locked token of the wallet is reclaimed.
+	TokenID *token2.Id
+	// TxOptions are the options used to create the transaction
+	TxOptions []ttxcc.TxOption
+}
+
+// ReclaimView spends a locked token on behalf of the sender of its script. It must run after the deadline.
+// The recipient of the script is not involved.
+type ReclaimView struct {
+	*Reclaim
+}
+
+func NewReclaimView(reclaim *Reclaim) *ReclaimView {
+	return &ReclaimView{Reclaim: reclaim}
+}
+
+func (r *ReclaimView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.NewAnonymousTransaction(context, r.TxOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating transaction")
+	}
+	tms := tx.TokenService()
+	wallet := tms.WalletManager().OwnerWallet(r.Wallet)
+	if wallet == nil {
+		return nil, errors.Errorf("wallet [%s] not found", r.Wallet)
+	}
+
+	lt, err := lookup(tms, r.TokenID, func(lt *LockedToken) bool {
+		return wallet.Contains(lt.Script.Sender) && lt.Script.Expired(time.Now())
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot reclaim")
+	}
+
+	owner, err := lt.Script.Identity()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := getScriptSigner(context, tms, owner, lt.Script)
+	if err != nil {
+		return nil, err
+	}
+	senderSigner, err := wallet.GetSigner(lt.Script.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting signer of the sender")
+	}
+	signer.SetSender(senderSigner)
+	auditInfo, err := wallet.GetAuditInfo(lt.Script.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting audit info of the sender")
+	}
+	if err := registerScriptAuditInfo(context, owner, &htlc2.ScriptInfo{Sender: auditInfo}); err != nil {
+		return nil, err
+	}
+
+	return spend(context, tx, wallet, lt)
+}
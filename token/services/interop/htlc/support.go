@@ -0,0 +1,191 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/json"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.htlc")
+
+// DefaultHashFunc is the hash function used when none is specified
+const DefaultHashFunc = crypto.SHA256
+
+// PreimageSize is the size in bytes of the preimages generated by the lock view
+const PreimageSize = 32
+
+// LockedToken is an unspent token locked by a script
+type LockedToken struct {
+	Id       *token2.Id
+	Type     string
+	Quantity string
+	Script   *htlc2.Script
+}
+
+// ListLockedTokens returns the unspent tokens, stored in the vault of the passed TMS, that are locked by a script.
+// The filter, if not nil, selects the tokens to return.
+func ListLockedTokens(tms *token.ManagementService, filter func(*LockedToken) bool) ([]*LockedToken, error) {
+	unspent, err := tms.Vault().NewQueryEngine().ListUnspentTokens()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed listing unspent tokens")
+	}
+	var res []*LockedToken
+	for _, tok := range unspent.Tokens {
+		if tok.Owner == nil || !htlc2.IsScript(tok.Owner.Raw) {
+			continue
+		}
+		script, err := htlc2.ScriptFromIdentity(tok.Owner.Raw)
+		if err != nil {
+			logger.Warnf("skipping token [%s], invalid script [%s]", tok.Id, err)
+			continue
+		}
+		lt := &LockedToken{Id: tok.Id, Type: tok.Type, Quantity: tok.Quantity, Script: script}
+		if filter == nil || filter(lt) {
+			res = append(res, lt)
+		}
+	}
+	return res, nil
+}
+
+// GetPreimage returns the preimage, revealed by a claim in the passed transaction, that unlocks the passed hash lock.
+// The party that locked the tokens uses it to learn the preimage from the transaction of the recipient.
+func GetPreimage(tx *ttxcc.Transaction, info *htlc2.HashInfo) ([]byte, error) {
+	for _, sigma := range tx.TokenRequest.Actions.Signatures {
+		sig := &htlc2.Signature{}
+		if err := json.Unmarshal(sigma, sig); err != nil || len(sig.Preimage) == 0 {
+			continue
+		}
+		if info.Match(sig.Preimage) == nil {
+			return sig.Preimage, nil
+		}
+	}
+	return nil, errors.Errorf("no preimage found in transaction [%s]", tx.ID())
+}
+
+// newHashInfo returns the hash lock for the passed hash. If the hash is empty, a fresh preimage is generated and returned.
+func newHashInfo(hash []byte, hashFunc crypto.Hash) (*htlc2.HashInfo, []byte, error) {
+	if hashFunc == 0 {
+		hashFunc = DefaultHashFunc
+	}
+	info := &htlc2.HashInfo{Hash: hash, HashFunc: hashFunc}
+	if len(hash) != 0 {
+		return info, nil, nil
+	}
+	preimage := make([]byte, PreimageSize)
+	if _, err := rand.Read(preimage); err != nil {
+		return nil, nil, errors.Wrap(err, "failed generating preimage")
+	}
+	image, err := info.Image(preimage)
+	if err != nil {
+		return nil, nil, err
+	}
+	info.Hash = image
+	return info, preimage, nil
+}
+
+// getScriptSigner returns the signer registered for the passed script, registering a new one if needed.
+// The signer and the verifier of a script are registered in the signature service, so that the token drivers
+// and the endorsement views can use them as for any other owner.
+func getScriptSigner(context view.Context, tms *token.ManagementService, id view.Identity, script *htlc2.Script) (*htlc2.Signer, error) {
+	sigService := view2.GetSigService(context)
+	if signer, err := sigService.GetSigner(id); err == nil {
+		if s, ok := signer.(*htlc2.Signer); ok {
+			return s, nil
+		}
+		return nil, errors.Errorf("another signer is registered for script [%s]", id)
+	}
+
+	sender, err := tms.SigService().GetVerifier(script.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting verifier of the sender")
+	}
+	recipient, err := tms.SigService().GetVerifier(script.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting verifier of the recipient")
+	}
+	// the client sets the timestamp of the transactions it creates, therefore its clock is the best guess
+	// of the time the validators will check the script against
+	signer := htlc2.NewSigner(script)
+	signer.Now = time.Now
+	verifier := &htlc2.Verifier{Script: script, Sender: sender, Recipient: recipient, Now: time.Now}
+	if err := sigService.RegisterSigner(id, signer, verifier); err != nil {
+		return nil, errors.Wrapf(err, "failed registering signer for script [%s]", id)
+	}
+	return signer, nil
+}
+
+// registerScriptAuditInfo registers the audit information of the script, unless already present
+func registerScriptAuditInfo(context view.Context, id view.Identity, info *htlc2.ScriptInfo) error {
+	sigService := view2.GetSigService(context)
+	current, err := sigService.GetAuditInfo(id)
+	if err != nil {
+		return errors.Wrapf(err, "failed getting audit info for script [%s]", id)
+	}
+	if len(current) != 0 {
+		return nil
+	}
+	raw, err := info.Bytes()
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling script audit info")
+	}
+	return sigService.RegisterAuditInfo(id, raw)
+}
+
+// spend assembles, endorses, and commits a transaction that moves the passed locked token to a fresh
+// identity of the passed wallet
+func spend(context view.Context, tx *ttxcc.Transaction, wallet *token.OwnerWallet, lt *LockedToken) (*ttxcc.Transaction, error) {
+	recipient, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting recipient identity")
+	}
+	q, err := token2.ToQuantity(lt.Quantity, tx.TokenService().PublicParametersManager().Precision())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid quantity for token [%s]", lt.Id)
+	}
+	if err := tx.Transfer(wallet, lt.Type, []token2.Quantity{q}, []view.Identity{recipient}, token.WithTokenIDs(lt.Id)); err != nil {
+		return nil, errors.Wrapf(err, "failed spending token [%s]", lt.Id)
+	}
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingAndFinalityView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed ordering transaction")
+	}
+	return tx, nil
+}
+
+// lookup returns the locked token with the passed id, if any, or the first locked token selected by the filter
+func lookup(tms *token.ManagementService, id *token2.Id, filter func(*LockedToken) bool) (*LockedToken, error) {
+	tokens, err := ListLockedTokens(tms, func(lt *LockedToken) bool {
+		if id != nil && (lt.Id.TxId != id.TxId || lt.Id.Index != id.Index) {
+			return false
+		}
+		return filter(lt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		if id != nil {
+			return nil, errors.Errorf("token %s is not locked or cannot be spent", id)
+		}
+		return nil, errors.New("no locked token can be spent")
+	}
+	return tokens[0], nil
+}
@@ -0,0 +1,39 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package owner
+
+import (
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+)
+
+type QueryExecutor struct {
+	*txdb.QueryExecutor
+}
+
+// Transactions returns a filter over the transaction history of the local owner wallets
+func (a *QueryExecutor) Transactions() *txdb.TransactionsFilter {
+	return a.QueryExecutor.NewTransactionsFilter()
+}
+
+func (a *QueryExecutor) Done() {
+	a.QueryExecutor.Done()
+}
+
+// Owner gives access to the transaction history of the local owner wallets of a token management service
+type Owner struct {
+	db *txdb.TransactionDB
+}
+
+func New(sp view2.ServiceProvider, tms *token.ManagementService) *Owner {
+	return &Owner{db: txdb.GetTransactionDB(sp, tms)}
+}
+
+func (a *Owner) NewQueryExecutor() *QueryExecutor {
+	return &QueryExecutor{QueryExecutor: a.db.NewQueryExecutor()}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+const (
+	recordsNamespace   = "default"
+	namespaceSeparator = "\u0000"
+)
+
+type Record struct {
+	Id     uint64
+	Record *driver.TransactionRecord
+}
+
+type Persistence struct {
+	db *badger.DB
+
+	seq     *badger.Sequence
+	txn     *badger.Txn
+	txnLock sync.Mutex
+}
+
+func OpenDB(path string) (*Persistence, error) {
+	db, err := badger.Open(badger.DefaultOptions(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open DB at '%s'", path)
+	}
+	seq, err := db.GetSequence([]byte("idseq"), 1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting sequence for DB at '%s'", path)
+	}
+
+	return &Persistence{db: db, seq: seq}, nil
+}
+
+func (db *Persistence) Close() error {
+	db.txnLock.Lock()
+	if db.txn != nil {
+		db.txn.Discard()
+		db.txn = nil
+	}
+	db.txnLock.Unlock()
+
+	if err := db.seq.Release(); err != nil {
+		logger.Errorf("failed closing seq [%s]", err)
+	}
+
+	err := db.db.Close()
+	if err != nil {
+		return errors.Wrap(err, "could not close DB")
+	}
+
+	return nil
+}
+
+func (db *Persistence) BeginUpdate() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn != nil {
+		return errors.New("previous commit in progress")
+	}
+
+	db.txn = db.db.NewTransaction(true)
+
+	return nil
+}
+
+func (db *Persistence) Commit() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	err := db.txn.Commit()
+	if err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+
+	db.txn = nil
+
+	return nil
+}
+
+func (db *Persistence) Discard() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	db.txn.Discard()
+
+	db.txn = nil
+
+	return nil
+}
+
+func (db *Persistence) AddRecord(record *driver.TransactionRecord) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	next, err := db.seq.Next()
+	if err != nil {
+		return errors.Wrapf(err, "failed getting next index")
+	}
+	return db.set(&Record{Id: next, Record: record})
+}
+
+func (db *Persistence) SetStatus(txID string, status driver.Status) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+	records, err := db.scan(db.txn, func(record *driver.TransactionRecord) bool {
+		return record.TxID == txID
+	})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		record.Record.Status = status
+		if err := db.set(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Persistence) Query(params *driver.QueryTransactionsParams) ([]*driver.TransactionRecord, error) {
+	txn := db.db.NewTransaction(false)
+	defer txn.Discard()
+
+	records, err := db.scan(txn, params.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort
+	switch params.Order {
+	case driver.FromBeginning:
+		sort.Sort(records)
+	case driver.FromLast:
+		sort.Sort(sort.Reverse(records))
+	}
+
+	if params.NumRecords > 0 && len(records) > params.NumRecords {
+		records = records[:params.NumRecords]
+	}
+
+	var res []*driver.TransactionRecord
+	for _, record := range records {
+		res = append(res, record.Record)
+	}
+
+	return res, nil
+}
+
+// scan returns the records, visible to the passed badger transaction, that satisfy the passed filter
+func (db *Persistence) scan(txn *badger.Txn, filter func(record *driver.TransactionRecord) bool) (RecordSlice, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(recordsNamespace + namespaceSeparator)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var records RecordSlice
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		record := &Record{}
+		err := item.Value(func(val []byte) error {
+			if err := json.Unmarshal(val, record); err != nil {
+				return errors.Wrapf(err, "could not unmarshal key %s", string(item.Key()))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get value for key %s", string(item.Key()))
+		}
+		if filter(record.Record) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (db *Persistence) set(record *Record) error {
+	dbKey := dbKey(recordsNamespace, fmt.Sprintf("%d", record.Id))
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal record for key %s", dbKey)
+	}
+
+	err = db.txn.Set([]byte(dbKey), bytes)
+	if err != nil {
+		return errors.Wrapf(err, "could not set value for key %s", dbKey)
+	}
+	return nil
+}
+
+func dbKey(namespace, key string) string {
+	return namespace + namespaceSeparator + key
+}
+
+type RecordSlice []*Record
+
+func (p RecordSlice) Len() int           { return len(p) }
+func (p RecordSlice) Less(i, j int) bool { return p[i].Id < p[j].Id }
+func (p RecordSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+func TestDB(t *testing.T) {
+	dbpath := filepath.Join(tempDir, "DB-TestTransactions")
+	db, err := OpenDB(dbpath)
+	assert.NoError(t, err)
+	assert.NotNil(t, db)
+	defer db.Close()
+
+	assert.NoError(t, db.BeginUpdate())
+	for i, wallet := range []string{"alice", "alice", "bob"} {
+		err = db.AddRecord(&driver.TransactionRecord{
+			TxID:      fmt.Sprintf("%d", i),
+			Wallet:    wallet,
+			Type:      "magic",
+			Amount:    big.NewInt(int64(10 * (i + 1))),
+			Direction: driver.Received,
+			Status:    driver.Pending,
+			Timestamp: time.Now(),
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.Commit())
+
+	records, err := db.Query(&driver.QueryTransactionsParams{Order: driver.FromLast, NumRecords: 2})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "2", records[0].TxID)
+	records, err = db.Query(&driver.QueryTransactionsParams{Wallets: []string{"alice"}, Order: driver.FromBeginning})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "0", records[0].TxID)
+	assert.Equal(t, int64(10), records[0].Amount.Int64())
+
+	assert.NoError(t, db.BeginUpdate())
+	assert.NoError(t, db.SetStatus("1", driver.Confirmed))
+	assert.NoError(t, db.Commit())
+	records, err = db.Query(&driver.QueryTransactionsParams{Statuses: []driver.Status{driver.Confirmed}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "1", records[0].TxID)
+
+	assert.Error(t, db.SetStatus("1", driver.Deleted))
+}
+
+var tempDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	tempDir, err = ioutil.TempDir("", "badger-txdb-test")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temporary directory: %v", err)
+		os.Exit(-1)
+	}
+	defer os.RemoveAll(tempDir)
+
+	m.Run()
+}
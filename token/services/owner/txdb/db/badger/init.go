@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"os"
+	"path/filepath"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.owner.txdb.badger")
+
+type Opts struct {
+	Path string
+}
+
+type Driver struct {
+}
+
+func (d Driver) Open(sp view2.ServiceProvider, name string) (driver.TransactionDB, error) {
+	opts := &Opts{}
+	err := view2.GetConfigService(sp).UnmarshalKey("token.owner.txdb.persistence.opts", opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting opts for transaction db")
+	}
+	opts.Path = filepath.Join(opts.Path, name)
+	logger.Debugf("init transaction db with badger at [%s]", opts.Path)
+
+	err = os.MkdirAll(opts.Path, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating folders for transaction db [%s]", opts.Path)
+	}
+	persistence, err := OpenDB(opts.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening transaction db [%s]", opts.Path)
+	}
+	return persistence, nil
+}
+
+func init() {
+	txdb.Register("badger", &Driver{})
+}
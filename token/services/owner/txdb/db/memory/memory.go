@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package memory
+
+import (
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+type Persistence struct {
+	records []*driver.TransactionRecord
+}
+
+func (p *Persistence) Query(params *driver.QueryTransactionsParams) ([]*driver.TransactionRecord, error) {
+	var res []*driver.TransactionRecord
+
+	var cursor int
+	switch params.Order {
+	case driver.FromBeginning:
+		cursor = -1
+	case driver.FromLast:
+		cursor = len(p.records)
+	default:
+		panic("order not valid")
+	}
+	for {
+		switch params.Order {
+		case driver.FromBeginning:
+			cursor++
+		case driver.FromLast:
+			cursor--
+		}
+		if cursor < 0 || cursor >= len(p.records) {
+			break
+		}
+		if params.NumRecords != 0 && len(res) >= params.NumRecords {
+			break
+		}
+
+		record := p.records[cursor]
+		if !params.Match(record) {
+			continue
+		}
+		// return a copy, the status of the stored record might change
+		r := *record
+		res = append(res, &r)
+	}
+
+	return res, nil
+}
+
+func (p *Persistence) AddRecord(record *driver.TransactionRecord) error {
+	p.records = append(p.records, record)
+
+	return nil
+}
+
+func (p *Persistence) SetStatus(txID string, status driver.Status) error {
+	for _, record := range p.records {
+		if record.TxID == txID {
+			record.Status = status
+		}
+	}
+	return nil
+}
+
+func (p *Persistence) Close() error {
+	return nil
+}
+
+func (p *Persistence) BeginUpdate() error {
+	return nil
+}
+
+func (p *Persistence) Commit() error {
+	return nil
+}
+
+func (p *Persistence) Discard() error {
+	return nil
+}
+
+type Driver struct {
+}
+
+func (d Driver) Open(sp view2.ServiceProvider, name string) (driver.TransactionDB, error) {
+	return &Persistence{}, nil
+}
+
+func init() {
+	txdb.Register("memory", &Driver{})
+}
@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package memory
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+func Test(t *testing.T) {
+	db := &Persistence{}
+	now := time.Now()
+	assert.NoError(t, db.AddRecord(&driver.TransactionRecord{
+		TxID:      "0",
+		Wallet:    "alice",
+		Type:      "EUR",
+		Amount:    big.NewInt(10),
+		Direction: driver.Received,
+		Status:    driver.Confirmed,
+		Timestamp: now.Add(-48 * time.Hour),
+	}))
+	assert.NoError(t, db.AddRecord(&driver.TransactionRecord{
+		TxID:      "1",
+		Wallet:    "alice",
+		Type:      "EUR",
+		Amount:    big.NewInt(5),
+		Direction: driver.Sent,
+		Status:    driver.Pending,
+		Timestamp: now.Add(-time.Hour),
+	}))
+	assert.NoError(t, db.AddRecord(&driver.TransactionRecord{
+		TxID:      "1",
+		Wallet:    "bob",
+		Type:      "EUR",
+		Amount:    big.NewInt(5),
+		Direction: driver.Received,
+		Status:    driver.Pending,
+		Timestamp: now.Add(-time.Hour),
+	}))
+
+	records, err := db.Query(&driver.QueryTransactionsParams{Wallets: []string{"alice"}, Order: driver.FromBeginning})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "0", records[0].TxID)
+	records, err = db.Query(&driver.QueryTransactionsParams{Wallets: []string{"alice"}, Order: driver.FromLast, NumRecords: 1})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "1", records[0].TxID)
+	records, err = db.Query(&driver.QueryTransactionsParams{Wallets: []string{"alice"}, Directions: []driver.Direction{driver.Sent}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = db.Query(&driver.QueryTransactionsParams{From: now.Add(-24 * time.Hour), To: now})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	records, err = db.Query(&driver.QueryTransactionsParams{Types: []string{"USD"}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	assert.NoError(t, db.SetStatus("1", driver.Deleted))
+	records, err = db.Query(&driver.QueryTransactionsParams{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = db.Query(&driver.QueryTransactionsParams{Statuses: []driver.Status{driver.Deleted}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package driver
+
+import (
+	"math/big"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+)
+
+// Direction tells if a wallet sent or received tokens in a transaction
+type Direction int
+
+const (
+	Sent Direction = iota
+	Received
+)
+
+// Order is the order in which records are returned
+type Order int
+
+const (
+	FromLast Order = iota
+	FromBeginning
+)
+
+type Status string
+
+const (
+	Pending   Status = "Pending"
+	Confirmed Status = "Confirmed"
+	Deleted   Status = "Deleted"
+)
+
+// TransactionRecord tells how many tokens of a type a wallet sent or received in a transaction
+type TransactionRecord struct {
+	TxID string
+	// Wallet is the identifier of the owner wallet
+	Wallet string
+	// Counterparties are the recipients of the tokens sent, or the senders and issuers of the tokens received
+	Counterparties []view.Identity
+	Type           string
+	// Amount is the net amount sent or received, it is always positive
+	Amount    *big.Int
+	Direction Direction
+	Status    Status
+	// Timestamp is the time the record was created
+	Timestamp time.Time
+}
+
+// QueryTransactionsParams selects transaction records. Empty fields match all records.
+type QueryTransactionsParams struct {
+	TxID       string
+	Wallets    []string
+	Types      []string
+	Directions []Direction
+	// Statuses are the statuses of the records, if empty all records but the deleted ones are selected
+	Statuses []Status
+	// From selects the records with a timestamp not before it, if not zero
+	From time.Time
+	// To selects the records with a timestamp before it, if not zero
+	To    time.Time
+	Order Order
+	// NumRecords is the maximum number of records returned, unbounded if zero
+	NumRecords int
+}
+
+type TransactionDB interface {
+	Close() error
+	BeginUpdate() error
+	Commit() error
+	Discard() error
+	AddRecord(record *TransactionRecord) error
+	SetStatus(txID string, status Status) error
+	Query(params *QueryTransactionsParams) ([]*TransactionRecord, error)
+}
+
+type Driver interface {
+	Open(sp view2.ServiceProvider, name string) (TransactionDB, error)
+}
+
+// Match returns true if the passed record is selected by these parameters, NumRecords and Order aside
+func (p *QueryTransactionsParams) Match(record *TransactionRecord) bool {
+	if len(p.TxID) != 0 && record.TxID != p.TxID {
+		return false
+	}
+	if len(p.Wallets) != 0 && !containsString(p.Wallets, record.Wallet) {
+		return false
+	}
+	if len(p.Types) != 0 && !containsString(p.Types, record.Type) {
+		return false
+	}
+	if len(p.Directions) != 0 {
+		found := false
+		for _, d := range p.Directions {
+			if record.Direction == d {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(p.Statuses) != 0 {
+		found := false
+		for _, st := range p.Statuses {
+			if record.Status == st {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	} else if record.Status == Deleted {
+		// exclude the deleted
+		return false
+	}
+	if !p.From.IsZero() && record.Timestamp.Before(p.From) {
+		return false
+	}
+	if !p.To.IsZero() && !record.Timestamp.Before(p.To) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
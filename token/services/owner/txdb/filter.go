@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package txdb
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+type TransactionsFilter struct {
+	db *TransactionDB
+
+	Wallets    []string
+	Types      []string
+	Directions []driver.Direction
+	Statuses   []Status
+	FromTime   time.Time
+	ToTime     time.Time
+	// Oldest first, if true. Newest first otherwise.
+	Ascending  bool
+	NumRecords int
+
+	records []*driver.TransactionRecord
+}
+
+func (f *TransactionsFilter) ByWallet(id string) *TransactionsFilter {
+	f.Wallets = append(f.Wallets, id)
+	return f
+}
+
+func (f *TransactionsFilter) ByType(tokenType string) *TransactionsFilter {
+	f.Types = append(f.Types, tokenType)
+	return f
+}
+
+// Sent selects the records of tokens sent
+func (f *TransactionsFilter) Sent() *TransactionsFilter {
+	f.Directions = append(f.Directions, driver.Sent)
+	return f
+}
+
+// Received selects the records of tokens received
+func (f *TransactionsFilter) Received() *TransactionsFilter {
+	f.Directions = append(f.Directions, driver.Received)
+	return f
+}
+
+// ByStatus selects the records with the passed status. By default, all records but the deleted ones are selected.
+func (f *TransactionsFilter) ByStatus(status Status) *TransactionsFilter {
+	f.Statuses = append(f.Statuses, status)
+	return f
+}
+
+// From selects the records created at or after the passed time
+func (f *TransactionsFilter) From(t time.Time) *TransactionsFilter {
+	f.FromTime = t
+	return f
+}
+
+// To selects the records created before the passed time
+func (f *TransactionsFilter) To(t time.Time) *TransactionsFilter {
+	f.ToTime = t
+	return f
+}
+
+// First selects the oldest num records
+func (f *TransactionsFilter) First(num int) *TransactionsFilter {
+	f.Ascending = true
+	f.NumRecords = num
+	return f
+}
+
+// Last selects the newest num records
+func (f *TransactionsFilter) Last(num int) *TransactionsFilter {
+	f.Ascending = false
+	f.NumRecords = num
+	return f
+}
+
+func (f *TransactionsFilter) Execute() (*TransactionsFilter, error) {
+	params := &driver.QueryTransactionsParams{
+		Wallets:    f.Wallets,
+		Types:      f.Types,
+		Directions: f.Directions,
+		From:       f.FromTime,
+		To:         f.ToTime,
+		Order:      driver.FromLast,
+		NumRecords: f.NumRecords,
+	}
+	if f.Ascending {
+		params.Order = driver.FromBeginning
+	}
+	for _, status := range f.Statuses {
+		params.Statuses = append(params.Statuses, driver.Status(status))
+	}
+	records, err := f.db.db.Query(params)
+	if err != nil {
+		return nil, err
+	}
+	f.records = records
+	return f, nil
+}
+
+// Records returns the records selected by the last execution
+func (f *TransactionsFilter) Records() []*driver.TransactionRecord {
+	return f.records
+}
@@ -0,0 +1,223 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package txdb
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type movement struct {
+	wallet   string
+	owner    view.Identity
+	typ      string
+	quantity *big.Int
+}
+
+// Movements collects the tokens spent and created by a transaction, to compute what each local owner wallet
+// sent and received.
+type Movements struct {
+	inputs  []*movement
+	outputs []*movement
+	issuers []view.Identity
+}
+
+func NewMovements() *Movements {
+	return &Movements{}
+}
+
+// AddInput adds a token spent by the transaction.
+// The wallet is the local owner wallet the owner belongs to, the empty string if none does.
+// The type and quantity are needed only for the tokens of the local wallets, the quantity is nil if unknown.
+func (m *Movements) AddInput(wallet string, owner view.Identity, typ string, q token2.Quantity) {
+	m.inputs = append(m.inputs, newMovement(wallet, owner, typ, q))
+}
+
+// AddOutput adds a token created by the transaction.
+// The wallet is the local owner wallet the owner belongs to, the empty string if none does.
+func (m *Movements) AddOutput(wallet string, owner view.Identity, typ string, q token2.Quantity) {
+	m.outputs = append(m.outputs, newMovement(wallet, owner, typ, q))
+}
+
+// AddIssuer adds the issuer of tokens created by the transaction
+func (m *Movements) AddIssuer(issuer view.Identity) {
+	m.issuers = append(m.issuers, issuer)
+}
+
+// Records returns, for each local wallet and token type, the net amount sent or received in the transaction.
+// The counterparties of a wallet that sent tokens are the owners of the outputs of that type outside the wallet.
+// The counterparties of a wallet that received tokens are the owners of the inputs outside the wallet, and the issuers.
+func (m *Movements) Records(txID string, status driver.Status, timestamp time.Time) []*driver.TransactionRecord {
+	nets := map[string]map[string]*big.Int{}
+	add := func(mv *movement, sign int) {
+		if len(mv.wallet) == 0 || mv.quantity == nil {
+			return
+		}
+		types, ok := nets[mv.wallet]
+		if !ok {
+			types = map[string]*big.Int{}
+			nets[mv.wallet] = types
+		}
+		net, ok := types[mv.typ]
+		if !ok {
+			net = big.NewInt(0)
+			types[mv.typ] = net
+		}
+		if sign < 0 {
+			net.Sub(net, mv.quantity)
+		} else {
+			net.Add(net, mv.quantity)
+		}
+	}
+	for _, mv := range m.inputs {
+		add(mv, -1)
+	}
+	for _, mv := range m.outputs {
+		add(mv, 1)
+	}
+
+	var res []*driver.TransactionRecord
+	for _, wallet := range sortedKeys(nets) {
+		types := nets[wallet]
+		var typs []string
+		for typ := range types {
+			typs = append(typs, typ)
+		}
+		sort.Strings(typs)
+		for _, typ := range typs {
+			net := types[typ]
+			record := &driver.TransactionRecord{
+				TxID:      txID,
+				Wallet:    wallet,
+				Type:      typ,
+				Status:    status,
+				Timestamp: timestamp,
+			}
+			switch net.Sign() {
+			case 0:
+				continue
+			case -1:
+				record.Direction = driver.Sent
+				record.Amount = new(big.Int).Neg(net)
+				record.Counterparties = counterparties(wallet, m.outputs, typ, nil)
+			default:
+				record.Direction = driver.Received
+				record.Amount = new(big.Int).Set(net)
+				record.Counterparties = counterparties(wallet, m.inputs, "", m.issuers)
+			}
+			res = append(res, record)
+		}
+	}
+	return res
+}
+
+// MovementsOf returns the movements of the passed token request, as seen by the local owner wallets
+func MovementsOf(request *token.Request) (*Movements, error) {
+	tms := request.TokenService
+	m := NewMovements()
+
+	outputs, err := request.Outputs()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting outputs of [%s]", request.ID())
+	}
+	for _, output := range outputs.Outputs() {
+		q, err := token2.ToQuantity(output.Quantity, token2.MaxPrecision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s]", output.Quantity)
+		}
+		m.AddOutput(walletOf(tms, output.Owner), output.Owner, output.Type, q)
+	}
+
+	inputs, err := request.Inputs()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting inputs of [%s]", request.ID())
+	}
+	qe := tms.Vault().NewQueryEngine()
+	for i := 0; i < inputs.Count(); i++ {
+		input := inputs.At(i)
+		wallet := walletOf(tms, input.Owner)
+		if len(wallet) == 0 {
+			m.AddInput("", input.Owner, "", nil)
+			continue
+		}
+		// the input belongs to a local wallet, it is still in the vault
+		toks, err := qe.GetTokens(input.Id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting input [%s]", input.Id)
+		}
+		q, err := token2.ToQuantity(toks[0].Quantity, token2.MaxPrecision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s] for input [%s]", toks[0].Quantity, input.Id)
+		}
+		m.AddInput(wallet, input.Owner, toks[0].Type, q)
+	}
+
+	for _, issue := range request.Issues() {
+		m.AddIssuer(issue.Issuer)
+	}
+	return m, nil
+}
+
+func newMovement(wallet string, owner view.Identity, typ string, q token2.Quantity) *movement {
+	mv := &movement{wallet: wallet, owner: owner, typ: typ}
+	if q != nil {
+		mv.quantity = q.ToBigInt()
+	}
+	return mv
+}
+
+// counterparties returns the distinct owners of the passed movements, of the passed type if not empty,
+// outside the passed wallet, followed by the passed identities
+func counterparties(wallet string, movements []*movement, typ string, others []view.Identity) []view.Identity {
+	var res []view.Identity
+	seen := map[string]bool{}
+	appendID := func(id view.Identity) {
+		if len(id) == 0 || seen[string(id)] {
+			return
+		}
+		seen[string(id)] = true
+		res = append(res, id)
+	}
+	for _, mv := range movements {
+		if mv.wallet == wallet || (len(typ) != 0 && mv.typ != typ) {
+			continue
+		}
+		appendID(mv.owner)
+	}
+	for _, id := range others {
+		appendID(id)
+	}
+	return res
+}
+
+// walletOf returns the identifier of the local owner wallet the passed identity belongs to, the empty string if none does
+func walletOf(tms *token.ManagementService, id view.Identity) string {
+	if len(id) == 0 {
+		return ""
+	}
+	w := tms.WalletManager().OwnerWalletByIdentity(id)
+	if w == nil {
+		return ""
+	}
+	return w.ID()
+}
+
+func sortedKeys(m map[string]map[string]*big.Int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package txdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func TestMovements(t *testing.T) {
+	q := func(v uint64) token2.Quantity {
+		return token2.NewQuantityFromUInt64(v)
+	}
+	alice := view.Identity("alice")
+	alice2 := view.Identity("alice2")
+	bob := view.Identity("bob")
+	charlie := view.Identity("charlie")
+
+	// alice sends 7 EUR to bob and 1 EUR to charlie, 2 EUR go back to her as change
+	m := NewMovements()
+	m.AddInput("alice", alice, "EUR", q(10))
+	m.AddInput("alice", alice, "", nil)
+	m.AddOutput("bob", bob, "EUR", q(7))
+	m.AddOutput("", charlie, "EUR", q(1))
+	m.AddOutput("alice", alice2, "EUR", q(2))
+
+	records := m.Records("tx1", driver.Pending, time.Now())
+	assert.Len(t, records, 2)
+
+	assert.Equal(t, "alice", records[0].Wallet)
+	assert.Equal(t, driver.Sent, records[0].Direction)
+	assert.Equal(t, int64(8), records[0].Amount.Int64())
+	assert.Equal(t, []view.Identity{bob, charlie}, records[0].Counterparties)
+
+	assert.Equal(t, "bob", records[1].Wallet)
+	assert.Equal(t, driver.Received, records[1].Direction)
+	assert.Equal(t, int64(7), records[1].Amount.Int64())
+	assert.Equal(t, []view.Identity{alice}, records[1].Counterparties)
+
+	// a transfer to the same wallet moves nothing
+	m = NewMovements()
+	m.AddInput("alice", alice, "EUR", q(10))
+	m.AddOutput("alice", alice2, "EUR", q(10))
+	assert.Empty(t, m.Records("tx2", driver.Pending, time.Now()))
+
+	// an issue is received from the issuer
+	m = NewMovements()
+	m.AddOutput("bob", bob, "USD", q(5))
+	m.AddIssuer(view.Identity("issuer"))
+	records = m.Records("tx3", driver.Confirmed, time.Now())
+	assert.Len(t, records, 1)
+	assert.Equal(t, driver.Received, records[0].Direction)
+	assert.Equal(t, []view.Identity{view.Identity("issuer")}, records[0].Counterparties)
+}
@@ -0,0 +1,261 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package txdb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.owner.txdb")
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]driver.Driver)
+)
+
+// Register makes a TransactionDB driver available by the provided name.
+// If Register is called twice with the same name or if driver is nil,
+// it panics.
+func Register(name string, driver driver.Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if driver == nil {
+		panic("txdb: Register driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("txdb: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns a sorted list of the names of the registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	list := make([]string, 0, len(drivers))
+	for name := range drivers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+type Status string
+
+const (
+	Pending   Status = "Pending"
+	Confirmed Status = "Confirmed"
+	Deleted   Status = "Deleted"
+)
+
+type QueryExecutor struct {
+	db     *TransactionDB
+	closed bool
+}
+
+func (qe *QueryExecutor) NewTransactionsFilter() *TransactionsFilter {
+	return &TransactionsFilter{
+		db: qe.db,
+	}
+}
+
+func (qe *QueryExecutor) Done() {
+	if qe.closed {
+		return
+	}
+	qe.db.storeLock.RUnlock()
+	qe.closed = true
+}
+
+// TransactionDB keeps the history of the transactions of the local owner wallets of a token management service.
+// Each transaction is recorded, for each wallet and token type, as the net amount the wallet sent or received.
+type TransactionDB struct {
+	db        driver.TransactionDB
+	storeLock sync.RWMutex
+}
+
+func newTransactionDB(p driver.TransactionDB) *TransactionDB {
+	return &TransactionDB{db: p}
+}
+
+// AppendPending records the passed movements of the passed transaction as pending,
+// unless the transaction is already recorded
+func (db *TransactionDB) AppendPending(txID string, movements *Movements) error {
+	return db.append(txID, movements, driver.Pending, false)
+}
+
+// Confirm marks the records of the passed transaction as confirmed.
+// If the transaction is not recorded yet, the passed movements are recorded as confirmed.
+func (db *TransactionDB) Confirm(txID string, movements *Movements) error {
+	return db.append(txID, movements, driver.Confirmed, true)
+}
+
+func (db *TransactionDB) append(txID string, movements *Movements, status driver.Status, setStatus bool) error {
+	logger.Debugf("Appending records of [%s] as [%s]...", txID, status)
+	db.storeLock.Lock()
+	defer db.storeLock.Unlock()
+
+	existing, err := db.db.Query(&driver.QueryTransactionsParams{
+		TxID:     txID,
+		Statuses: []driver.Status{driver.Pending, driver.Confirmed, driver.Deleted},
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed looking up records of [%s]", txID)
+	}
+	if len(existing) != 0 && !setStatus {
+		logger.Debugf("[%s] already recorded, skipping", txID)
+		return nil
+	}
+	var records []*driver.TransactionRecord
+	if len(existing) == 0 {
+		records = movements.Records(txID, status, time.Now())
+		if len(records) == 0 {
+			logger.Debugf("[%s] does not move tokens of local wallets, skipping", txID)
+			return nil
+		}
+	}
+
+	if err := db.db.BeginUpdate(); err != nil {
+		return errors.WithMessagef(err, "begin update for txid '%s' failed", txID)
+	}
+	if len(existing) != 0 {
+		err = db.db.SetStatus(txID, status)
+	}
+	for _, record := range records {
+		if err = db.db.AddRecord(record); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		if err1 := db.db.Discard(); err1 != nil {
+			logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+		}
+		return errors.WithMessagef(err, "failed recording [%s]", txID)
+	}
+	if err := db.db.Commit(); err != nil {
+		return errors.WithMessagef(err, "committing tx for txid '%s' failed", txID)
+	}
+
+	logger.Debugf("Appending records of [%s] as [%s] done, [%d] new records", txID, status, len(records))
+	return nil
+}
+
+func (db *TransactionDB) NewQueryExecutor() *QueryExecutor {
+	db.storeLock.RLock()
+
+	return &QueryExecutor{db: db}
+}
+
+// SetStatus sets the status of the records of the passed transaction.
+// A confirmed transaction is committed on the ledger, it keeps its status.
+func (db *TransactionDB) SetStatus(txID string, status Status) error {
+	logger.Debugf("Set status [%s][%s]...", txID, status)
+	db.storeLock.Lock()
+	defer db.storeLock.Unlock()
+
+	if status != Confirmed {
+		confirmed, err := db.db.Query(&driver.QueryTransactionsParams{
+			TxID:     txID,
+			Statuses: []driver.Status{driver.Confirmed},
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "failed looking up records of [%s]", txID)
+		}
+		if len(confirmed) != 0 {
+			logger.Debugf("[%s] is confirmed, keep its status instead of [%s]", txID, status)
+			return nil
+		}
+	}
+
+	if err := db.db.BeginUpdate(); err != nil {
+		return errors.WithMessagef(err, "begin update for txid '%s' failed", txID)
+	}
+
+	if err := db.db.SetStatus(txID, driver.Status(status)); err != nil {
+		if err1 := db.db.Discard(); err1 != nil {
+			logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+		}
+		return errors.Wrapf(err, "failed setting status [%s][%s]", txID, status)
+	}
+
+	if err := db.db.Commit(); err != nil {
+		return errors.WithMessagef(err, "committing tx for txid '%s' failed", txID)
+	}
+
+	logger.Debugf("Set status [%s][%s]... done without errors", txID, status)
+	return nil
+}
+
+type Manager struct {
+	sp     view2.ServiceProvider
+	driver string
+	mutex  sync.Mutex
+	dbs    map[string]*TransactionDB
+}
+
+func NewManager(sp view2.ServiceProvider, driver string) *Manager {
+	return &Manager{
+		sp:     sp,
+		driver: driver,
+		dbs:    map[string]*TransactionDB{},
+	}
+}
+
+// TransactionDB returns the transaction db of the passed token management service
+func (m *Manager) TransactionDB(tms *token.ManagementService) (*TransactionDB, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	id := fmt.Sprintf("%s-%s-%s", tms.Network(), tms.Channel(), tms.Namespace())
+	c, ok := m.dbs[id]
+	if !ok {
+		driversMu.RLock()
+		d, ok := drivers[m.driver]
+		driversMu.RUnlock()
+		if !ok {
+			return nil, errors.Errorf("transaction db driver [%s] not found", m.driver)
+		}
+		p, err := d.Open(m.sp, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed instantiating transaction db driver")
+		}
+		c = newTransactionDB(p)
+		m.dbs[id] = c
+	}
+	return c, nil
+}
+
+// GetManager returns the manager registered in the passed service provider, nil if none is registered
+func GetManager(sp view2.ServiceProvider) *Manager {
+	s, err := sp.GetService(&Manager{})
+	if err != nil {
+		logger.Debugf("no transaction db manager registered [%s]", err)
+		return nil
+	}
+	return s.(*Manager)
+}
+
+func GetTransactionDB(sp view2.ServiceProvider, tms *token.ManagementService) *TransactionDB {
+	s, err := sp.GetService(&Manager{})
+	if err != nil {
+		panic(err)
+	}
+	c, err := s.(*Manager).TransactionDB(tms)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package txdb
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// records is a transaction db that keeps its records in memory
+type records struct {
+	records []*driver.TransactionRecord
+}
+
+func (r *records) Close() error       { return nil }
+func (r *records) BeginUpdate() error { return nil }
+func (r *records) Commit() error      { return nil }
+func (r *records) Discard() error     { return nil }
+
+func (r *records) AddRecord(record *driver.TransactionRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *records) SetStatus(txID string, status driver.Status) error {
+	for _, record := range r.records {
+		if record.TxID == txID {
+			record.Status = status
+		}
+	}
+	return nil
+}
+
+func (r *records) Query(params *driver.QueryTransactionsParams) ([]*driver.TransactionRecord, error) {
+	var res []*driver.TransactionRecord
+	for _, record := range r.records {
+		if params.Match(record) {
+			res = append(res, record)
+		}
+	}
+	return res, nil
+}
+
+func TestSetStatusKeepsConfirmed(t *testing.T) {
+	p := &records{}
+	db := newTransactionDB(p)
+	m := NewMovements()
+	m.AddOutput("alice", view.Identity("alice"), "EUR", token2.NewQuantityFromUInt64(10))
+
+	assert.NoError(t, db.AppendPending("tx1", m))
+	assert.NoError(t, db.AppendPending("tx2", m))
+	assert.NoError(t, db.Confirm("tx1", m))
+
+	// the confirmed transaction is not downgraded
+	assert.NoError(t, db.SetStatus("tx1", Deleted))
+	assert.NoError(t, db.SetStatus("tx2", Deleted))
+	for _, record := range p.records {
+		switch record.TxID {
+		case "tx1":
+			assert.Equal(t, driver.Confirmed, record.Status)
+		case "tx2":
+			assert.Equal(t, driver.Deleted, record.Status)
+		}
+	}
+
+	// a transaction deleted too early is confirmed by the ledger
+	assert.NoError(t, db.Confirm("tx2", m))
+	assert.Equal(t, driver.Confirmed, p.records[1].Status)
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-smart-client/integration/nwo/common"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type Client interface {
@@ -59,3 +62,32 @@ func (c *viewClient) AllMyBalances() ([]Balance, error) {
 	}
 	return bal.Balances, err
 }
+
+func (c *viewClient) Locks(txID string) ([]Lock, error) {
+	res, err := c.vClient.CallView("zkat.selector.locks", common.JSONMarshall(&LocksQuery{TxID: txID}))
+	if err != nil {
+		return nil, err
+	}
+	locks := Locks{}
+	if err := json.Unmarshal(res.([]byte), &locks); err != nil {
+		return nil, errors.Errorf("could not retrieve locks")
+	}
+	return locks.Locks, nil
+}
+
+func (c *viewClient) Unlock(txID string, ids ...*token2.Id) error {
+	_, err := c.vClient.CallView("zkat.selector.unlock", common.JSONMarshall(&UnlockRequest{TxID: txID, TokenIDs: ids}))
+	return err
+}
+
+func (c *viewClient) Reclaims() ([]*token.ReclaimReport, error) {
+	res, err := c.vClient.CallView("zkat.selector.reclaims", common.JSONMarshall(&ReclaimsQuery{}))
+	if err != nil {
+		return nil, err
+	}
+	reclaims := Reclaims{}
+	if err := json.Unmarshal(res.([]byte), &reclaims); err != nil {
+		return nil, errors.Errorf("could not retrieve reclaims")
+	}
+	return reclaims.Reports, nil
+}
@@ -13,3 +13,17 @@ func InstallQueryViewFactories(sp view.ServiceProvider) {
 	view.GetRegistry(sp).RegisterFactory("zkat.balance.query", &BalanceViewFactory{})
 	view.GetRegistry(sp).RegisterFactory("zkat.all.balance.query", &AllMyBalanceViewFactory{})
 }
+
+// InstallLockViewFactories installs the views to inspect the locks of the token selectors
+func InstallLockViewFactories(sp view.ServiceProvider) {
+	view.GetRegistry(sp).RegisterFactory("zkat.selector.locks", &LocksViewFactory{})
+	view.GetRegistry(sp).RegisterFactory("zkat.selector.reclaims", &ReclaimsViewFactory{})
+}
+
+// InstallUnlockViewFactory installs the view to force-release the locks of the token selectors.
+// The view does not authorize its caller, anyone who can invoke views on the node can release the locks of
+// in-flight transactions. Therefore, it is not installed by default: install it only on nodes whose view
+// invocations are restricted to administrators.
+func InstallUnlockViewFactory(sp view.ServiceProvider) {
+	view.GetRegistry(sp).RegisterFactory("zkat.selector.unlock", &UnlockViewFactory{})
+}
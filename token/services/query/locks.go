@@ -0,0 +1,158 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.query")
+
+type LocksQuery struct {
+	Channel string
+	// TxID, if set, selects the locks held by this transaction only
+	TxID string
+}
+
+type Lock struct {
+	TokenID    *token2.Id
+	TxID       string
+	Created    time.Time
+	Age        time.Duration
+	LastAccess time.Time
+	// Expiry is zero if the lock does not expire
+	Expiry time.Time
+}
+
+type Locks struct {
+	Locks []Lock
+}
+
+// LocksView lists the locks held on the tokens, oldest first
+type LocksView struct {
+	*LocksQuery
+}
+
+func (l *LocksView) Call(context view.Context) (interface{}, error) {
+	tms := token.GetManagementService(context, token.WithChannel(l.Channel))
+	locks, err := tms.SelectorManager().Locks()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed listing locks")
+	}
+	now := time.Now()
+	res := Locks{}
+	for _, lock := range locks {
+		if len(l.TxID) != 0 && lock.TxID != l.TxID {
+			continue
+		}
+		res.Locks = append(res.Locks, Lock{
+			TokenID:    lock.ID,
+			TxID:       lock.TxID,
+			Created:    lock.Created,
+			Age:        now.Sub(lock.Created),
+			LastAccess: lock.LastAccess,
+			Expiry:     lock.Expiry,
+		})
+	}
+	sort.Slice(res.Locks, func(i, j int) bool {
+		return res.Locks[i].Created.Before(res.Locks[j].Created)
+	})
+	return res, nil
+}
+
+type LocksViewFactory struct{}
+
+func (l *LocksViewFactory) NewView(in []byte) (view.View, error) {
+	f := &LocksView{LocksQuery: &LocksQuery{}}
+	if err := json.Unmarshal(in, f.LocksQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type UnlockRequest struct {
+	Channel string
+	// TxID, if set, releases all the locks held by this transaction
+	TxID string
+	// TokenIDs, if set, releases the locks held on these tokens
+	TokenIDs []*token2.Id
+}
+
+// UnlockView force-releases locks, by transaction or by token
+type UnlockView struct {
+	*UnlockRequest
+}
+
+func (u *UnlockView) Call(context view.Context) (interface{}, error) {
+	if len(u.TxID) == 0 && len(u.TokenIDs) == 0 {
+		return nil, errors.New("no transaction or token to unlock")
+	}
+	tms := token.GetManagementService(context, token.WithChannel(u.Channel))
+	if len(u.TxID) != 0 {
+		logger.Infof("force-release the locks held by [%s]", u.TxID)
+		if err := tms.SelectorManager().Unlock(u.TxID); err != nil {
+			return nil, errors.WithMessagef(err, "failed releasing the locks held by [%s]", u.TxID)
+		}
+	}
+	if len(u.TokenIDs) != 0 {
+		logger.Infof("force-release the locks held on [%v]", u.TokenIDs)
+		if err := tms.SelectorManager().UnlockIDs(u.TokenIDs...); err != nil {
+			return nil, errors.WithMessagef(err, "failed releasing the locks held on [%v]", u.TokenIDs)
+		}
+	}
+	return nil, nil
+}
+
+type UnlockViewFactory struct{}
+
+func (u *UnlockViewFactory) NewView(in []byte) (view.View, error) {
+	f := &UnlockView{UnlockRequest: &UnlockRequest{}}
+	if err := json.Unmarshal(in, f.UnlockRequest); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type ReclaimsQuery struct {
+	Channel string
+}
+
+type Reclaims struct {
+	Reports []*token.ReclaimReport
+}
+
+// ReclaimsView lists the outcomes of the latest attempts to reclaim locked tokens, oldest first
+type ReclaimsView struct {
+	*ReclaimsQuery
+}
+
+func (r *ReclaimsView) Call(context view.Context) (interface{}, error) {
+	tms := token.GetManagementService(context, token.WithChannel(r.Channel))
+	reports, err := tms.SelectorManager().Reclaims()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed listing reclaims")
+	}
+	return Reclaims{Reports: reports}, nil
+}
+
+type ReclaimsViewFactory struct{}
+
+func (r *ReclaimsViewFactory) NewView(in []byte) (view.View, error) {
+	f := &ReclaimsView{ReclaimsQuery: &ReclaimsQuery{}}
+	if err := json.Unmarshal(in, f.ReclaimsQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
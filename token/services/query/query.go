@@ -14,7 +14,6 @@ import (
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 )
 
 type BalanceQuery struct {
@@ -26,6 +25,18 @@ type BalanceQuery struct {
 type Balance struct {
 	Type     string
 	Quantity string
+	// Display is the quantity in the display unit of the type, followed by its symbol.
+	// It is set only when the type is registered in the public parameters.
+	Display string `json:",omitempty"`
+}
+
+// newBalance returns the balance of the passed type, displayed according to the token type registry
+func newBalance(tms *token.ManagementService, typ string, q token2.Quantity) Balance {
+	b := Balance{Type: typ, Quantity: q.Decimal()}
+	if info := tms.PublicParametersManager().TokenType(typ); info != nil {
+		b.Display = info.Format(q)
+	}
+	return b
 }
 
 type BalanceView struct {
@@ -33,23 +44,21 @@ type BalanceView struct {
 }
 
 func (b *BalanceView) Call(context view.Context) (interface{}, error) {
-	wallet := token.GetManagementService(context, token.WithChannel(b.Channel)).WalletManager().OwnerWallet(b.Wallet)
+	tms := token.GetManagementService(context, token.WithChannel(b.Channel))
+	wallet := tms.WalletManager().OwnerWallet(b.Wallet)
 	if wallet == nil {
 		return nil, fmt.Errorf("wallet %s not found", b.Wallet)
 	}
-	unspentTokens, err := wallet.ListUnspentTokens(token.WithType(b.Type))
+	balance, err := tms.Vault().NewQueryEngine().Balance(wallet.ID(), b.Type)
 	if err != nil {
 		return nil, err
 	}
-	sum := token2.NewZeroQuantity(keys.Precision)
-	for _, tok := range unspentTokens.Tokens {
-		q, err := token2.ToQuantity(tok.Quantity, keys.Precision)
-		if err != nil {
-			return nil, err
-		}
-		sum = sum.Add(q)
+	// a balance sums several tokens, it might not fit the precision of a single token
+	sum, err := token2.ToQuantity(balance.Decimal(), token2.MaxPrecision)
+	if err != nil {
+		return nil, err
 	}
-	return Balance{Quantity: sum.Decimal(), Type: b.Type}, nil
+	return newBalance(tms, b.Type, sum), nil
 }
 
 type BalanceViewFactory struct{}
@@ -76,30 +85,22 @@ type AllMyBalanceView struct {
 }
 
 func (b *AllMyBalanceView) Call(context view.Context) (interface{}, error) {
-	balances := make(map[string]token2.Quantity)
-	wallet := token.GetManagementService(context, token.WithChannel(b.Channel)).WalletManager().OwnerWallet(b.Wallet)
+	tms := token.GetManagementService(context, token.WithChannel(b.Channel))
+	wallet := tms.WalletManager().OwnerWallet(b.Wallet)
 	if wallet == nil {
 		return nil, fmt.Errorf("wallet %s not found", b.Wallet)
 	}
-	unspentTokens, err := wallet.ListUnspentTokens()
+	balances, err := tms.Vault().NewQueryEngine().Balances(wallet.ID())
 	if err != nil {
 		return nil, err
 	}
-	for _, tok := range unspentTokens.Tokens {
-		fmt.Printf("\n am I here? \n")
-		_, exists := balances[tok.Type]
-		if !exists {
-			balances[tok.Type] = token2.NewZeroQuantity(keys.Precision)
-		}
-		q, err := token2.ToQuantity(tok.Quantity, keys.Precision)
+	var mybalance []Balance
+	for k, balance := range balances {
+		q, err := token2.ToQuantity(balance.Decimal(), token2.MaxPrecision)
 		if err != nil {
 			return nil, err
 		}
-		balances[tok.Type] = balances[tok.Type].Add(q)
-	}
-	var mybalance []Balance
-	for k := range balances {
-		mybalance = append(mybalance, Balance{Type: k, Quantity: balances[k].Decimal()})
+		mybalance = append(mybalance, newBalance(tms, k, q))
 	}
 	return AllMyBalances{mybalance}, nil
 }
@@ -0,0 +1,351 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+const holdLockPrefix = "hold:"
+
+// Vault gives the status of the transactions that draw down the holds
+type Vault interface {
+	Status(txID string) (fabric.ValidationCode, []string, error)
+}
+
+type heldToken struct {
+	id       *token2.Id
+	quantity token2.Quantity
+}
+
+// draw is the part of a hold drawn down by a transaction
+type draw struct {
+	quantity token2.Quantity
+	tokens   []*heldToken
+}
+
+type hold struct {
+	id          string
+	ownerFilter token.OwnerFilter
+	typ         string
+	amount      token2.Quantity
+	remaining   token2.Quantity
+	tokens      []*heldToken
+	expiry      time.Time
+	// draws are the draws of the transactions that might still be released, by transaction id
+	draws map[string]*draw
+}
+
+func (h *hold) toHold() *token.Hold {
+	var ids []*token2.Id
+	for _, t := range h.tokens {
+		ids = append(ids, t.id)
+	}
+	return &token.Hold{
+		ID:        h.id,
+		Type:      h.typ,
+		Amount:    h.amount,
+		Remaining: h.remaining,
+		Tokens:    ids,
+		Expiry:    h.expiry,
+	}
+}
+
+// holds keeps the holds of a TMS. The tokens of a hold are locked in the locker on behalf of the hold.
+// When a transaction draws down a hold, the locks of the drawn tokens move to the transaction.
+// If the transaction is released or invalid, the drawn tokens and quantity return to the hold.
+// Once the transaction is committed, its draw is settled.
+type holds struct {
+	locker   Locker
+	notifier *Notifier
+	// vault, if not nil, tells the transactions that drew down the holds that are committed or invalid
+	vault Vault
+
+	lock  sync.Mutex
+	holds map[string]*hold
+}
+
+func newHolds(locker Locker, notifier *Notifier, vault Vault) *holds {
+	return &holds{locker: locker, notifier: notifier, vault: vault, holds: map[string]*hold{}}
+}
+
+func (h *holds) reserve(m *manager, holdID string, ownerFilter token.OwnerFilter, q, tokenType string, expiry time.Time) (*token.Hold, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.evictExpired()
+
+	if _, ok := h.holds[holdID]; ok {
+		return nil, errors.Errorf("hold [%s] already exists", holdID)
+	}
+	if !expiry.After(time.Now()) {
+		return nil, errors.Errorf("hold [%s] expires in the past [%s]", holdID, expiry)
+	}
+	// the held tokens would be available to others once their locks expire
+	if leaser, ok := h.locker.(Leaser); ok && expiry.After(time.Now().Add(leaser.LeaseExpiry())) {
+		return nil, errors.Errorf("hold [%s] expires after the lease of its locks, at most [%s] from now", holdID, leaser.LeaseExpiry())
+	}
+	amount, err := token2.ToQuantity(q, m.precision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid quantity [%s]", q)
+	}
+
+	s, err := m.NewSelector(holdLockID(holdID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting selector for hold [%s]", holdID)
+	}
+	ids, _, err := s.Select(ownerFilter, q, tokenType)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed reserving [%s] tokens of type [%s] for hold [%s]", q, tokenType, holdID)
+	}
+	tokens, err := heldTokens(m, ids)
+	if err != nil {
+		h.locker.UnlockByTxID(holdLockID(holdID))
+		return nil, errors.WithMessagef(err, "failed reserving tokens for hold [%s]", holdID)
+	}
+
+	res := &hold{
+		id:          holdID,
+		ownerFilter: ownerFilter,
+		typ:         tokenType,
+		amount:      amount,
+		remaining:   amount,
+		tokens:      tokens,
+		expiry:      expiry,
+		draws:       map[string]*draw{},
+	}
+	h.holds[holdID] = res
+	logger.Debugf("reserved [%s] tokens of type [%s] for hold [%s] until [%s]", q, tokenType, holdID, expiry)
+	return res.toHold(), nil
+}
+
+// drawDown moves to the passed transaction the locks of held tokens covering the passed quantity.
+// If the held tokens are not enough, because the change of previous draws went back to the wallet,
+// the missing quantity is selected from the wallet.
+func (h *holds) drawDown(m *manager, txID string, holdID string, ownerFilter token.OwnerFilter, q, tokenType string) ([]*token2.Id, token2.Quantity, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.evictExpired()
+
+	hd, ok := h.holds[holdID]
+	if !ok {
+		return nil, nil, errors.Errorf("hold [%s] not found", holdID)
+	}
+	if hd.typ != tokenType {
+		return nil, nil, errors.Errorf("hold [%s] reserves tokens of type [%s], not [%s]", holdID, hd.typ, tokenType)
+	}
+	target, err := token2.ToQuantity(q, m.precision)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to convert quantity")
+	}
+	if hd.remaining.Cmp(target) < 0 {
+		return nil, nil, errors.Errorf("hold [%s] has only [%s] remaining, [%s] requested", holdID, hd.remaining.Decimal(), target.Decimal())
+	}
+
+	qs := m.newQueryEngine()
+	sum := token2.NewZeroQuantity(token2.MaxPrecision)
+	var drawn, kept []*heldToken
+	var ids []*token2.Id
+	for _, t := range hd.tokens {
+		if target.Cmp(sum) <= 0 {
+			kept = append(kept, t)
+			continue
+		}
+		// the token might have been spent, or locked by others once the lease of the hold expired
+		if _, err := qs.GetTokens(t.id); err != nil {
+			logger.Warnf("held token [%s] of hold [%s] is not available anymore, drop it [%s]", t.id, holdID, err)
+			continue
+		}
+		if _, err := h.locker.Lock(t.id, holdLockID(holdID)); err != nil {
+			logger.Warnf("held token [%s] of hold [%s] is locked by others, drop it [%s]", t.id, holdID, err)
+			continue
+		}
+		h.locker.UnlockIDs(t.id)
+		if _, err := h.locker.Lock(t.id, txID); err != nil {
+			logger.Warnf("failed moving held token [%s] of hold [%s] to [%s], drop it [%s]", t.id, holdID, txID, err)
+			continue
+		}
+		drawn = append(drawn, t)
+		ids = append(ids, t.id)
+		sum = sum.Add(t.quantity)
+	}
+
+	if target.Cmp(sum) > 0 {
+		missing := target.Sub(sum)
+		if ownerFilter == nil {
+			ownerFilter = hd.ownerFilter
+		}
+		logger.Debugf("held tokens of hold [%s] do not cover [%s], select [%s] from the wallet", holdID, target.Decimal(), missing.Decimal())
+		s, err := m.NewSelector(txID)
+		if err != nil {
+			h.giveBack(holdID, drawn)
+			return nil, nil, errors.WithMessagef(err, "failed getting selector for [%s]", txID)
+		}
+		extra, extraSum, err := s.Select(ownerFilter, missing.Decimal(), tokenType)
+		if err != nil {
+			h.giveBack(holdID, drawn)
+			return nil, nil, errors.WithMessagef(err, "failed selecting the tokens missing from hold [%s]", holdID)
+		}
+		ids = append(ids, extra...)
+		sum = sum.Add(extraSum)
+	}
+
+	hd.tokens = kept
+	hd.remaining = hd.remaining.Sub(target)
+	if d, ok := hd.draws[txID]; ok {
+		d.quantity = d.quantity.Add(target)
+		d.tokens = append(d.tokens, drawn...)
+	} else {
+		hd.draws[txID] = &draw{quantity: target, tokens: drawn}
+	}
+	logger.Debugf("[%s] drew [%s] from hold [%s], remaining [%s]", txID, target.Decimal(), holdID, hd.remaining.Decimal())
+	return ids, sum, nil
+}
+
+// restore returns to their holds the draws of the passed transaction, whose locks have been released
+func (h *holds) restore(txID string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, hd := range h.holds {
+		if d, ok := hd.draws[txID]; ok {
+			h.restoreDraw(hd, txID, d)
+		}
+	}
+}
+
+func (h *holds) restoreDraw(hd *hold, txID string, d *draw) {
+	delete(hd.draws, txID)
+	hd.remaining = hd.remaining.Add(d.quantity)
+	for _, t := range d.tokens {
+		if _, err := h.locker.Lock(t.id, holdLockID(hd.id)); err != nil {
+			logger.Warnf("failed returning token [%s] to hold [%s] [%s]", t.id, hd.id, err)
+			continue
+		}
+		hd.tokens = append(hd.tokens, t)
+	}
+	logger.Debugf("[%s] released, [%s] returned to hold [%s]", txID, d.quantity.Decimal(), hd.id)
+}
+
+// settleDraws drops the draws of the committed transactions, they will never be released,
+// and returns to their holds the draws of the invalid transactions
+func (h *holds) settleDraws() {
+	if h.vault == nil {
+		return
+	}
+	for _, hd := range h.holds {
+		for txID, d := range hd.draws {
+			code, _, err := h.vault.Status(txID)
+			if err != nil {
+				logger.Warnf("failed getting the status of [%s], keep its draw of hold [%s] [%s]", txID, hd.id, err)
+				continue
+			}
+			switch code {
+			case fabric.Valid:
+				logger.Debugf("[%s] committed, its draw of hold [%s] is settled", txID, hd.id)
+				delete(hd.draws, txID)
+			case fabric.Invalid:
+				h.locker.UnlockByTxID(txID)
+				h.restoreDraw(hd, txID, d)
+			}
+		}
+	}
+}
+
+// giveBack moves back to the passed hold the locks of the passed tokens
+func (h *holds) giveBack(holdID string, tokens []*heldToken) {
+	for _, t := range tokens {
+		h.locker.UnlockIDs(t.id)
+		if _, err := h.locker.Lock(t.id, holdLockID(holdID)); err != nil {
+			logger.Warnf("failed returning token [%s] to hold [%s] [%s]", t.id, holdID, err)
+		}
+	}
+}
+
+func (h *holds) release(holdID string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, ok := h.holds[holdID]; !ok {
+		return errors.Errorf("hold [%s] not found", holdID)
+	}
+	h.remove(holdID)
+	return nil
+}
+
+func (h *holds) list() []*token.Hold {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.evictExpired()
+
+	var res []*token.Hold
+	for _, hd := range h.holds {
+		res = append(res, hd.toHold())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// evictExpired removes the expired holds, after settling the draws of the transactions whose status is known
+func (h *holds) evictExpired() {
+	h.settleDraws()
+
+	now := time.Now()
+	for id, hd := range h.holds {
+		if now.After(hd.expiry) {
+			logger.Debugf("hold [%s] expired at [%s], release it", id, hd.expiry)
+			h.remove(id)
+		}
+	}
+}
+
+func (h *holds) remove(holdID string) {
+	delete(h.holds, holdID)
+	h.locker.UnlockByTxID(holdLockID(holdID))
+	h.notifier.Notify()
+}
+
+// heldTokens returns the passed tokens together with their quantities
+func heldTokens(m *manager, ids []*token2.Id) ([]*heldToken, error) {
+	tokens, err := m.newQueryEngine().GetTokens(ids...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting tokens")
+	}
+	res := make([]*heldToken, len(tokens))
+	for i, t := range tokens {
+		q, err := token2.ToQuantity(t.Quantity, m.precision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert quantity of token [%s]", ids[i])
+		}
+		res[i] = &heldToken{id: ids[i], quantity: q}
+	}
+	return res, nil
+}
+
+// holdLockID is the identifier under which the tokens of the passed hold are locked
+func holdLockID(holdID string) string {
+	return holdLockPrefix + holdID
+}
+
+// holdSelector draws down a hold
+type holdSelector struct {
+	manager *manager
+	txID    string
+	holdID  string
+}
+
+func (s *holdSelector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([]*token2.Id, token2.Quantity, error) {
+	return s.manager.holds.drawDown(s.manager, s.txID, s.holdID, ownerFilter, q, tokenType)
+}
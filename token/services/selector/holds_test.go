@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type fakeVault struct {
+	statuses map[string]fabric.ValidationCode
+}
+
+func (f *fakeVault) Status(txID string) (fabric.ValidationCode, []string, error) {
+	code, ok := f.statuses[txID]
+	if !ok {
+		return fabric.Unknown, nil, nil
+	}
+	return code, nil, nil
+}
+
+// leasingLocker is a locker whose locks last one minute
+type leasingLocker struct {
+	*fakeLocker
+}
+
+func (l *leasingLocker) LeaseExpiry() time.Duration {
+	return time.Minute
+}
+
+var _ = Describe("Holds", func() {
+	var (
+		locker *fakeLocker
+		vault  *fakeVault
+		qs     *fakeQueryService
+		m      *manager
+	)
+
+	BeforeEach(func() {
+		locker = &fakeLocker{locks: map[string]string{}}
+		qs = &fakeQueryService{tokens: []*token2.UnspentToken{
+			{Id: &token2.Id{TxId: "tx1", Index: 0}, Owner: &token2.Owner{}, Type: "USD", Quantity: "10"},
+			{Id: &token2.Id{TxId: "tx1", Index: 1}, Owner: &token2.Owner{}, Type: "USD", Quantity: "20"},
+			{Id: &token2.Id{TxId: "tx1", Index: 2}, Owner: &token2.Owner{}, Type: "USD", Quantity: "30"},
+		}}
+		vault = &fakeVault{statuses: map[string]fabric.ValidationCode{}}
+		notifier := NewNotifier()
+		m = newManager(locker, func() QueryService { return qs }, nil, LedgerOrder(), 64, notifier, newHolds(locker, notifier, vault), 1, time.Millisecond, false)
+	})
+
+	It("reserves tokens for a hold", func() {
+		h, err := m.Reserve("order1", nil, "25", "USD", time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h.Amount.Decimal()).To(Equal("25"))
+		Expect(h.Remaining.Decimal()).To(Equal("25"))
+		Expect(h.Tokens).To(HaveLen(2))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:0]", "hold:order1"))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:1]", "hold:order1"))
+
+		// the held tokens are not available to others
+		other, err := m.NewSelector("tx2")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = other.Select(nil, "31", "USD")
+		Expect(err).To(HaveOccurred())
+
+		_, err = m.Reserve("order1", nil, "5", "USD", time.Now().Add(time.Hour))
+		Expect(err).To(MatchError("hold [order1] already exists"))
+	})
+
+	It("draws down a hold across transactions", func() {
+		_, err := m.Reserve("order1", nil, "25", "USD", time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err := m.NewHoldSelector("tx2", "order1")
+		Expect(err).NotTo(HaveOccurred())
+		ids, sum, err := s.Select(nil, "5", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(1))
+		Expect(sum.Decimal()).To(Equal("10"))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:0]", "tx2"))
+
+		holds, err := m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds).To(HaveLen(1))
+		Expect(holds[0].Remaining.Decimal()).To(Equal("20"))
+		Expect(holds[0].Tokens).To(HaveLen(1))
+
+		_, _, err = s.Select(nil, "21", "USD")
+		Expect(err).To(MatchError("hold [order1] has only [20] remaining, [21] requested"))
+
+		// the change of this draw goes back to the wallet, the hold keeps no token
+		ids, sum, err = s.Select(nil, "15", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(1))
+		Expect(sum.Decimal()).To(Equal("20"))
+		holds, err = m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds[0].Remaining.Decimal()).To(Equal("5"))
+		Expect(holds[0].Tokens).To(BeEmpty())
+
+		// the rest is selected from the wallet
+		s, err = m.NewHoldSelector("tx3", "order1")
+		Expect(err).NotTo(HaveOccurred())
+		ids, sum, err = s.Select(nil, "5", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]*token2.Id{{TxId: "tx1", Index: 2}}))
+		Expect(sum.Decimal()).To(Equal("30"))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:2]", "tx3"))
+
+		holds, err = m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds[0].Remaining.Decimal()).To(Equal("0"))
+	})
+
+	It("returns the draws of released transactions", func() {
+		_, err := m.Reserve("order1", nil, "25", "USD", time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		s, err := m.NewHoldSelector("tx2", "order1")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = s.Select(nil, "5", "USD")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(m.Unlock("tx2")).To(Succeed())
+		holds, err := m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds[0].Remaining.Decimal()).To(Equal("25"))
+		Expect(holds[0].Tokens).To(HaveLen(2))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:0]", "hold:order1"))
+	})
+
+	It("releases holds", func() {
+		_, err := m.Reserve("order1", nil, "25", "USD", time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = m.Reserve("order2", nil, "5", "USD", time.Now().Add(time.Millisecond))
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(10 * time.Millisecond)
+
+		holds, err := m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds).To(HaveLen(1))
+		Expect(holds[0].ID).To(Equal("order1"))
+
+		Expect(m.Release("order1")).To(Succeed())
+		Expect(m.Release("order1")).To(MatchError("hold [order1] not found"))
+		Expect(locker.locks).To(BeEmpty())
+	})
+
+	It("settles the draws of committed transactions and returns those of invalid ones", func() {
+		_, err := m.Reserve("order1", nil, "30", "USD", time.Now().Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		s2, err := m.NewHoldSelector("tx2", "order1")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = s2.Select(nil, "10", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		s3, err := m.NewHoldSelector("tx3", "order1")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = s3.Select(nil, "20", "USD")
+		Expect(err).NotTo(HaveOccurred())
+
+		vault.statuses["tx2"] = fabric.Valid
+		vault.statuses["tx3"] = fabric.Invalid
+		holds, err := m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds[0].Remaining.Decimal()).To(Equal("20"))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:1]", "hold:order1"))
+		Expect(locker.locks).To(HaveKeyWithValue("[tx1:0]", "tx2"))
+
+		// the committed transaction does not give back what it spent
+		Expect(m.Unlock("tx2")).To(Succeed())
+		holds, err = m.Holds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(holds[0].Remaining.Decimal()).To(Equal("20"))
+		Expect(holds[0].Tokens).To(HaveLen(1))
+	})
+
+	It("refuses holds that outlive the lease of their locks", func() {
+		leasing := &leasingLocker{fakeLocker: locker}
+		notifier := NewNotifier()
+		m = newManager(leasing, func() QueryService { return qs }, nil, LedgerOrder(), 64, notifier, newHolds(leasing, notifier, vault), 1, time.Millisecond, false)
+
+		_, err := m.Reserve("order1", nil, "25", "USD", time.Now().Add(time.Hour))
+		Expect(err).To(MatchError("hold [order1] expires after the lease of its locks, at most [1m0s] from now"))
+		Expect(locker.locks).To(BeEmpty())
+
+		_, err = m.Reserve("order1", nil, "25", "USD", time.Now().Add(30*time.Second))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/pkg/errors"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
@@ -25,6 +26,7 @@ type Channel interface {
 }
 
 type lockEntry struct {
+	ID         *token2.Id
 	TxID       string
 	Created    time.Time
 	LastAccess time.Time
@@ -40,6 +42,7 @@ type locker struct {
 	locked                       map[string]*lockEntry
 	sleepTimeout                 time.Duration
 	validTxEvictionTimeoutMillis int64
+	reclaims                     selector.ReclaimLog
 }
 
 func NewLocker(ch Channel, timeout time.Duration, validTxEvictionTimeoutMillis int64) selector.Locker {
@@ -63,7 +66,7 @@ func (d *locker) Lock(id *token2.Id, txID string) (string, error) {
 		e.LastAccess = time.Now()
 		// Second chance
 		logger.Debugf("[%s] already locked by [%s], try to reclaim...", id, e)
-		reclaimed, status := d.reclaim(id, e.TxID)
+		reclaimed, status := d.reclaim(id, e.TxID, txID)
 		if !reclaimed {
 			logger.Debugf("[%s] already locked by [%s], reclaim failed, tx status [%s]", id, e, status)
 			return e.TxID, errors.Errorf("already locked by [%s]", e)
@@ -72,7 +75,7 @@ func (d *locker) Lock(id *token2.Id, txID string) (string, error) {
 	}
 	logger.Debugf("locking [%s] for [%s]", id, txID)
 	now := time.Now()
-	d.locked[id.String()] = &lockEntry{TxID: txID, Created: now, LastAccess: now}
+	d.locked[id.String()] = &lockEntry{ID: id, TxID: txID, Created: now, LastAccess: now}
 	return "", nil
 }
 
@@ -107,18 +110,41 @@ func (d *locker) UnlockByTxID(txID string) {
 	}
 }
 
-func (d *locker) reclaim(id *token2.Id, txID string) (bool, fabric.ValidationCode) {
+func (d *locker) reclaim(id *token2.Id, txID string, requester string) (bool, fabric.ValidationCode) {
 	status, _, err := d.ch.Vault().Status(txID)
-	if err != nil {
-		return false, status
-	}
-	switch status {
-	case fabric.Invalid:
+	reclaimed := err == nil && status == fabric.Invalid
+	if reclaimed {
 		delete(d.locked, id.String())
-		return true, status
-	default:
-		return false, status
 	}
+	d.reclaims.Add(&token.ReclaimReport{
+		ID:        id,
+		TxID:      txID,
+		Requester: requester,
+		Status:    selector.StatusString(status, err),
+		Reclaimed: reclaimed,
+		Time:      time.Now(),
+	})
+	return reclaimed, status
+}
+
+func (d *locker) Locks() ([]*token.TokenLock, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	var res []*token.TokenLock
+	for _, entry := range d.locked {
+		res = append(res, &token.TokenLock{
+			ID:         entry.ID,
+			TxID:       entry.TxID,
+			Created:    entry.Created,
+			LastAccess: entry.LastAccess,
+		})
+	}
+	return res, nil
+}
+
+func (d *locker) Reclaims() []*token.ReclaimReport {
+	return d.reclaims.List()
 }
 
 func (d *locker) Start() {
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// MaxReclaimReports is the number of reclaim reports a ReclaimLog keeps
+const MaxReclaimReports = 100
+
+// LockInspector is implemented by the lockers that can list their locks and report the outcomes of their reclaims
+type LockInspector interface {
+	// Locks returns the locks currently held
+	Locks() ([]*token.TokenLock, error)
+	// Reclaims returns the outcomes of the latest reclaims, oldest first
+	Reclaims() []*token.ReclaimReport
+}
+
+// ReclaimLog keeps the latest MaxReclaimReports reclaim reports
+type ReclaimLog struct {
+	lock    sync.Mutex
+	reports []*token.ReclaimReport
+}
+
+// Add appends the passed report, dropping the oldest one if the log is full
+func (l *ReclaimLog) Add(report *token.ReclaimReport) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.reports) == MaxReclaimReports {
+		l.reports = l.reports[1:]
+	}
+	l.reports = append(l.reports, report)
+}
+
+// List returns the reports in the log, oldest first
+func (l *ReclaimLog) List() []*token.ReclaimReport {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	res := make([]*token.ReclaimReport, len(l.reports))
+	copy(res, l.reports)
+	return res
+}
+
+// StatusString returns the passed transaction status as reported in a reclaim report
+func StatusString(status fabric.ValidationCode, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	switch status {
+	case fabric.Valid:
+		return "valid"
+	case fabric.Invalid:
+		return "invalid"
+	case fabric.Busy:
+		return "busy"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,29 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector_test
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+)
+
+var _ = Describe("Reclaim log", func() {
+	It("keeps the latest reports", func() {
+		log := &selector.ReclaimLog{}
+		for i := 0; i < selector.MaxReclaimReports+10; i++ {
+			log.Add(&token.ReclaimReport{TxID: strconv.Itoa(i)})
+		}
+		reports := log.List()
+		Expect(reports).To(HaveLen(selector.MaxReclaimReports))
+		Expect(reports[0].TxID).To(Equal("10"))
+		Expect(reports[selector.MaxReclaimReports-1].TxID).To(Equal(strconv.Itoa(selector.MaxReclaimReports + 9)))
+	})
+})
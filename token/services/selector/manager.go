@@ -8,25 +8,38 @@ package selector
 import (
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type NewQueryEngineFunc func() QueryService
 
 type manager struct {
-	locker               Locker
-	newQueryEngine       NewQueryEngineFunc
-	certClient           CertClient
+	locker         Locker
+	newQueryEngine NewQueryEngineFunc
+	certClient     CertClient
+	strategy       token.SelectionStrategy
+	// strategyErr is the error returned by NewSelector when the configured strategy cannot be loaded
+	strategyErr          error
+	precision            uint64
+	notifier             *Notifier
+	holds                *holds
 	numRetry             int
 	timeout              time.Duration
 	requestCertification bool
 }
 
-func newManager(locker Locker, newQueryEngine NewQueryEngineFunc, certClient CertClient, numRetry int, timeout time.Duration, requestCertification bool) *manager {
+func newManager(locker Locker, newQueryEngine NewQueryEngineFunc, certClient CertClient, strategy token.SelectionStrategy, precision uint64, notifier *Notifier, holds *holds, numRetry int, timeout time.Duration, requestCertification bool) *manager {
 	return &manager{
 		locker:               locker,
 		newQueryEngine:       newQueryEngine,
 		certClient:           certClient,
+		strategy:             strategy,
+		precision:            precision,
+		notifier:             notifier,
+		holds:                holds,
 		numRetry:             numRetry,
 		timeout:              timeout,
 		requestCertification: requestCertification,
@@ -34,10 +47,59 @@ func newManager(locker Locker, newQueryEngine NewQueryEngineFunc, certClient Cer
 }
 
 func (m *manager) NewSelector(id string) (token.Selector, error) {
-	return newSelector(id, m.locker, m.newQueryEngine(), m.certClient, m.numRetry, m.timeout, m.requestCertification), nil
+	if m.strategyErr != nil {
+		return nil, errors.WithMessage(m.strategyErr, "failed loading the selection strategy")
+	}
+	return m.NewSelectorWithStrategy(id, m.strategy)
+}
+
+func (m *manager) NewSelectorWithStrategy(id string, strategy token.SelectionStrategy) (token.Selector, error) {
+	return newSelector(id, m.locker, m.newQueryEngine(), m.certClient, strategy, m.precision, m.notifier, m.numRetry, m.timeout, m.requestCertification), nil
 }
 
 func (m *manager) Unlock(txID string) error {
 	m.locker.UnlockByTxID(txID)
+	// what the transaction drew from the holds returns to them
+	m.holds.restore(txID)
+	// the released tokens might be what the waiting selectors need
+	m.notifier.Notify()
 	return nil
 }
+
+func (m *manager) Reserve(holdID string, ownerFilter token.OwnerFilter, q, tokenType string, expiry time.Time) (*token.Hold, error) {
+	return m.holds.reserve(m, holdID, ownerFilter, q, tokenType, expiry)
+}
+
+func (m *manager) NewHoldSelector(txID string, holdID string) (token.Selector, error) {
+	return &holdSelector{manager: m, txID: txID, holdID: holdID}, nil
+}
+
+func (m *manager) Release(holdID string) error {
+	return m.holds.release(holdID)
+}
+
+func (m *manager) Holds() ([]*token.Hold, error) {
+	return m.holds.list(), nil
+}
+
+func (m *manager) Locks() ([]*token.TokenLock, error) {
+	inspector, ok := m.locker.(LockInspector)
+	if !ok {
+		return nil, errors.New("the locker does not support listing locks")
+	}
+	return inspector.Locks()
+}
+
+func (m *manager) UnlockIDs(ids ...*token2.Id) error {
+	m.locker.UnlockIDs(ids...)
+	m.notifier.Notify()
+	return nil
+}
+
+func (m *manager) Reclaims() ([]*token.ReclaimReport, error) {
+	inspector, ok := m.locker.(LockInspector)
+	if !ok {
+		return nil, errors.New("the locker does not support reclaim reports")
+	}
+	return inspector.Reclaims(), nil
+}
@@ -0,0 +1,37 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"sync"
+)
+
+// Notifier wakes up the selectors waiting for funds, when locked tokens are released or new tokens are received
+type Notifier struct {
+	lock sync.Mutex
+	ch   chan struct{}
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{ch: make(chan struct{})}
+}
+
+// Notify wakes up all the selectors waiting on the channels returned by Wait so far
+func (n *Notifier) Notify() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+// Wait returns a channel that is closed at the next notification
+func (n *Notifier) Wait() <-chan struct{} {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.ch
+}
@@ -0,0 +1,305 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package persistent
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.selector.persistent")
+
+const (
+	lockKeyPrefix = "lock:"
+	// lockKeyEnd is the first key after all the keys with prefix lockKeyPrefix
+	lockKeyEnd = "lock;"
+)
+
+type Channel interface {
+	Vault() *fabric.Vault
+}
+
+type lockEntry struct {
+	ID         *token2.Id
+	TxID       string
+	Created    time.Time
+	LastAccess time.Time
+	// Expiry is the time after which the lock can be reclaimed by any transaction
+	Expiry time.Time
+
+	// raw is the stored lock, compared when the lock is swapped
+	raw []byte
+}
+
+func (l *lockEntry) String() string {
+	return fmt.Sprintf("[[%s] since [%s], last access [%s], expires [%s]]", l.TxID, l.Created, l.LastAccess, l.Expiry)
+}
+
+// maxLockAttempts is the number of times Lock checks and sets a lock that other lockers change concurrently
+const maxLockAttempts = 5
+
+// locker stores the locks in a persistence, so that they survive restarts and can be shared by the replicas of a node.
+// Each lock is a lease: once expired, the token can be locked again.
+// A lock is checked and then set with a compare-and-swap, which fails if another locker changed the lock in between.
+type locker struct {
+	ch                           Channel
+	persistence                  Persistence
+	namespace                    string
+	leaseExpiry                  time.Duration
+	sleepTimeout                 time.Duration
+	validTxEvictionTimeoutMillis int64
+	reclaims                     selector.ReclaimLog
+}
+
+// NewLocker returns a locker that stores its locks in the passed persistence, under the passed namespace.
+// The locks held by a transaction expire after leaseExpiry.
+func NewLocker(ch Channel, persistence Persistence, namespace string, leaseExpiry time.Duration, timeout time.Duration, validTxEvictionTimeoutMillis int64) selector.Locker {
+	r := &locker{
+		ch:                           ch,
+		persistence:                  persistence,
+		namespace:                    namespace,
+		leaseExpiry:                  leaseExpiry,
+		sleepTimeout:                 timeout,
+		validTxEvictionTimeoutMillis: validTxEvictionTimeoutMillis,
+	}
+	r.Start()
+	return r
+}
+
+func (d *locker) Lock(id *token2.Id, txID string) (string, error) {
+	key := lockKey(id)
+	for i := 0; i < maxLockAttempts; i++ {
+		e, err := d.get(key)
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed getting lock for [%s]", id)
+		}
+		now := time.Now()
+		if e != nil && e.TxID != txID {
+			if now.After(e.Expiry) {
+				logger.Debugf("[%s] locked by [%s], lease expired, reclaim", id, e)
+			} else {
+				// Second chance
+				logger.Debugf("[%s] already locked by [%s], try to reclaim...", id, e)
+				reclaimed, status := d.reclaim(id, e.TxID, txID)
+				if !reclaimed {
+					accessed := *e
+					accessed.LastAccess = now
+					// a concurrent change of the lock wins over the last access
+					if _, err := d.swap(key, e, &accessed); err != nil {
+						logger.Warnf("failed updating last access of [%s]: [%s]", id, err)
+					}
+					logger.Debugf("[%s] already locked by [%s], reclaim failed, tx status [%s]", id, e, status)
+					return e.TxID, errors.Errorf("already locked by [%s]", e)
+				}
+				logger.Debugf("[%s] already locked by [%s], reclaimed successful, tx status [%s]", id, e, status)
+			}
+		}
+		logger.Debugf("locking [%s] for [%s]", id, txID)
+		swapped, err := d.swap(key, e, &lockEntry{ID: id, TxID: txID, Created: now, LastAccess: now, Expiry: now.Add(d.leaseExpiry)})
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed locking [%s] for [%s]", id, txID)
+		}
+		if swapped {
+			return "", nil
+		}
+		logger.Debugf("lock of [%s] changed concurrently, check again", id)
+	}
+	return "", errors.Errorf("failed locking [%s] for [%s]: the lock keeps changing", id, txID)
+}
+
+func (d *locker) LeaseExpiry() time.Duration {
+	return d.leaseExpiry
+}
+
+func (d *locker) UnlockIDs(ids ...*token2.Id) {
+	logger.Debugf("unlocking tokens [%v]", ids)
+	var keys []string
+	for _, id := range ids {
+		keys = append(keys, lockKey(id))
+	}
+	if err := d.persistence.DeleteState(d.namespace, keys...); err != nil {
+		logger.Errorf("failed unlocking tokens [%v]: [%s]", ids, err)
+	}
+}
+
+func (d *locker) UnlockByTxID(txID string) {
+	logger.Debugf("unlocking tokens hold by [%s]", txID)
+	entries, err := d.entries()
+	if err != nil {
+		logger.Errorf("failed listing locks: [%s]", err)
+		return
+	}
+	for key, entry := range entries {
+		if entry.TxID == txID {
+			logger.Debugf("unlocking [%s] hold by [%s]", key, entry)
+			d.release(key, entry)
+		}
+	}
+}
+
+func (d *locker) reclaim(id *token2.Id, txID string, requester string) (bool, fabric.ValidationCode) {
+	status, _, err := d.ch.Vault().Status(txID)
+	reclaimed := err == nil && status == fabric.Invalid
+	d.reclaims.Add(&token.ReclaimReport{
+		ID:        id,
+		TxID:      txID,
+		Requester: requester,
+		Status:    selector.StatusString(status, err),
+		Reclaimed: reclaimed,
+		Time:      time.Now(),
+	})
+	return reclaimed, status
+}
+
+func (d *locker) Locks() ([]*token.TokenLock, error) {
+	entries, err := d.entries()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed listing locks")
+	}
+	var res []*token.TokenLock
+	for _, entry := range entries {
+		res = append(res, &token.TokenLock{
+			ID:         entry.ID,
+			TxID:       entry.TxID,
+			Created:    entry.Created,
+			LastAccess: entry.LastAccess,
+			Expiry:     entry.Expiry,
+		})
+	}
+	return res, nil
+}
+
+func (d *locker) Reclaims() []*token.ReclaimReport {
+	return d.reclaims.List()
+}
+
+func (d *locker) Start() {
+	go d.scan()
+}
+
+// scan periodically removes the expired locks and the locks of transactions that are no longer in flight
+func (d *locker) scan() {
+	for {
+		logger.Debugf("token collector: sleep for some time...")
+		time.Sleep(d.sleepTimeout)
+
+		logger.Debugf("token collector: scan locked tokens")
+		entries, err := d.entries()
+		if err != nil {
+			logger.Errorf("token collector: failed listing locks: [%s]", err)
+		}
+		removed := 0
+		now := time.Now()
+		for key, entry := range entries {
+			if now.After(entry.Expiry) {
+				logger.Debugf("token [%s] locked by [%s], lease expired, remove", key, entry)
+				removed += d.release(key, entry)
+				continue
+			}
+			status, _, err := d.ch.Vault().Status(entry.TxID)
+			if err != nil {
+				logger.Warnf("failed getting status for token [%s] locked by [%s], remove", key, entry)
+				removed += d.release(key, entry)
+				continue
+			}
+			switch status {
+			case fabric.Valid:
+				// remove only if elapsed enough time from last access, to avoid concurrency issue
+				if now.Sub(entry.LastAccess).Milliseconds() > d.validTxEvictionTimeoutMillis {
+					logger.Debugf("token [%s] locked by [%s] in status [%s], time elapsed, remove", key, entry, status)
+					removed += d.release(key, entry)
+				}
+			case fabric.Invalid:
+				logger.Debugf("token [%s] locked by [%s] in status [%s], remove", key, entry, status)
+				removed += d.release(key, entry)
+			default:
+				logger.Debugf("token [%s] locked by [%s] in status [%s], skip", key, entry, status)
+			}
+		}
+		logger.Debugf("token collector: freed [%d] items", removed)
+	}
+}
+
+// release removes the passed lock, unless another locker changed it since it was read.
+// It returns the number of removed locks.
+func (d *locker) release(key string, entry *lockEntry) int {
+	released, err := d.swap(key, entry, nil)
+	if err != nil {
+		logger.Errorf("failed releasing lock [%s]: [%s]", key, err)
+		return 0
+	}
+	if !released {
+		logger.Debugf("lock [%s] changed concurrently, keep it", key)
+		return 0
+	}
+	return 1
+}
+
+func (d *locker) get(key string) (*lockEntry, error) {
+	raw, err := d.persistence.GetState(d.namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return unmarshalLock(key, raw)
+}
+
+func (d *locker) entries() (map[string]*lockEntry, error) {
+	values, err := d.persistence.GetStateRange(d.namespace, lockKeyPrefix, lockKeyEnd)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*lockEntry{}
+	for key, raw := range values {
+		entry, err := unmarshalLock(key, raw)
+		if err != nil {
+			return nil, err
+		}
+		res[key] = entry
+	}
+	return res, nil
+}
+
+// swap replaces the lock read as old, nil if there was none, with next, nil to remove the lock.
+// It returns false if the lock is no longer old.
+func (d *locker) swap(key string, old *lockEntry, next *lockEntry) (bool, error) {
+	var oldRaw, nextRaw []byte
+	if old != nil {
+		oldRaw = old.raw
+	}
+	if next != nil {
+		var err error
+		nextRaw, err = json.Marshal(next)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed marshalling lock [%s]", key)
+		}
+	}
+	return d.persistence.CompareAndSwap(d.namespace, key, oldRaw, nextRaw)
+}
+
+func unmarshalLock(key string, raw []byte) (*lockEntry, error) {
+	entry := &lockEntry{}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling lock [%s]", key)
+	}
+	entry.raw = raw
+	return entry, nil
+}
+
+func lockKey(id *token2.Id) string {
+	return lockKeyPrefix + id.String()
+}
@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package persistent_test
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db"
+	_ "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/memory"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/persistent"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var _ = Describe("Persistent locker", func() {
+	var (
+		persistence persistent.Persistence
+		locker      selector.Locker
+		id1, id2    *token2.Id
+	)
+
+	newLocker := func(leaseExpiry time.Duration) selector.Locker {
+		// the collector sleeps long enough to never run during a test
+		return persistent.NewLocker(nil, persistence, "locks", leaseExpiry, time.Hour, 0)
+	}
+
+	BeforeEach(func() {
+		var err error
+		kvs, err := db.Open("memory", "")
+		Expect(err).NotTo(HaveOccurred())
+		persistence = persistent.NewPersistence(kvs)
+		locker = newLocker(time.Hour)
+		id1 = &token2.Id{TxId: "tx", Index: 0}
+		id2 = &token2.Id{TxId: "tx", Index: 1}
+	})
+
+	It("keeps the locks across restarts", func() {
+		_, err := locker.Lock(id1, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+
+		restarted := newLocker(time.Hour)
+		_, err = restarted.Lock(id1, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+		restarted.UnlockIDs(id1)
+
+		_, err = locker.Lock(id1, "tx2")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("lists the locks", func() {
+		_, err := locker.Lock(id1, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = locker.Lock(id2, "tx2")
+		Expect(err).NotTo(HaveOccurred())
+
+		locks, err := locker.(selector.LockInspector).Locks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(locks).To(HaveLen(2))
+		byTx := map[string]*token2.Id{}
+		for _, lock := range locks {
+			Expect(lock.Expiry).To(BeTemporally("~", lock.Created.Add(time.Hour)))
+			byTx[lock.TxID] = lock.ID
+		}
+		Expect(byTx).To(Equal(map[string]*token2.Id{"tx1": id1, "tx2": id2}))
+	})
+
+	It("releases the locks of a transaction", func() {
+		_, err := locker.Lock(id1, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = locker.Lock(id2, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+
+		locker.UnlockByTxID("tx1")
+
+		_, err = locker.Lock(id1, "tx2")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = locker.Lock(id2, "tx2")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("the lease expires", func() {
+		BeforeEach(func() {
+			locker = newLocker(time.Millisecond)
+		})
+		It("lets another transaction lock the token", func() {
+			_, err := locker.Lock(id1, "tx1")
+			Expect(err).NotTo(HaveOccurred())
+			time.Sleep(10 * time.Millisecond)
+			_, err = locker.Lock(id1, "tx2")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package persistent
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver"
+)
+
+// Persistence stores the locks of the persistent lockers.
+// CompareAndSwap must be atomic for all the lockers that share the persistence, in this process or in other ones,
+// so that two transactions never lock the same token.
+type Persistence interface {
+	// GetState returns the value of the passed key, nil if the key does not exist
+	GetState(namespace, key string) ([]byte, error)
+	// GetStateRange returns the values of the keys in [startKey, endKey)
+	GetStateRange(namespace, startKey, endKey string) (map[string][]byte, error)
+	// CompareAndSwap sets the value of the passed key to next, if its current value is old.
+	// A nil old means that the key does not exist, a nil next deletes the key.
+	// It returns false, and changes nothing, if the current value is not old.
+	CompareAndSwap(namespace, key string, old, next []byte) (bool, error)
+	// DeleteState deletes the passed keys, whatever their values
+	DeleteState(namespace string, keys ...string) error
+}
+
+// kvPersistence is a Persistence on top of a persistence of the Fabric Smart Client
+type kvPersistence struct {
+	persistence driver.Persistence
+	lock        sync.Mutex
+}
+
+// NewPersistence returns a Persistence on top of the passed persistence of the Fabric Smart Client, like badger.
+// CompareAndSwap checks and sets under a mutex of the returned Persistence, therefore the lockers must share it.
+// Such a persistence is opened by one process only, the replicas of a node share a SQL persistence instead,
+// see NewSQLPersistence.
+func NewPersistence(persistence driver.Persistence) Persistence {
+	return &kvPersistence{persistence: persistence}
+}
+
+func (p *kvPersistence) GetState(namespace, key string) ([]byte, error) {
+	return p.persistence.GetState(namespace, key)
+}
+
+func (p *kvPersistence) GetStateRange(namespace, startKey, endKey string) (map[string][]byte, error) {
+	it, err := p.persistence.GetStateRangeScanIterator(namespace, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	res := map[string][]byte{}
+	for {
+		next, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return res, nil
+		}
+		if len(next.Raw) != 0 {
+			res[next.Key] = next.Raw
+		}
+	}
+}
+
+func (p *kvPersistence) CompareAndSwap(namespace, key string, old, next []byte) (bool, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	current, err := p.persistence.GetState(namespace, key)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(current, old) {
+		return false, nil
+	}
+	if err := p.persistence.BeginUpdate(); err != nil {
+		return false, err
+	}
+	if len(next) == 0 {
+		err = p.persistence.DeleteState(namespace, key)
+	} else {
+		err = p.persistence.SetState(namespace, key, next)
+	}
+	if err != nil {
+		if err1 := p.persistence.Discard(); err1 != nil {
+			logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+		}
+		return false, err
+	}
+	if err := p.persistence.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *kvPersistence) DeleteState(namespace string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.persistence.BeginUpdate(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := p.persistence.DeleteState(namespace, key); err != nil {
+			if err1 := p.persistence.Discard(); err1 != nil {
+				logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+			}
+			return err
+		}
+	}
+	return p.persistence.Commit()
+}
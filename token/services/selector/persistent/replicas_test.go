@@ -0,0 +1,154 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package persistent_test
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db"
+	_ "github.com/mattn/go-sqlite3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/persistent"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var _ = Describe("Persistent lockers of replicas", func() {
+	var (
+		dir string
+		id  *token2.Id
+	)
+
+	// openReplica opens the shared database with its own connections, like another process would
+	openReplica := func() persistent.Persistence {
+		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", filepath.Join(dir, "locks.db")))
+		Expect(err).NotTo(HaveOccurred())
+		persistence, err := persistent.NewSQLPersistence(db)
+		Expect(err).NotTo(HaveOccurred())
+		return persistence
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "locks")
+		Expect(err).NotTo(HaveOccurred())
+		id = &token2.Id{TxId: "tx", Index: 0}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).NotTo(HaveOccurred())
+	})
+
+	It("see each other's locks", func() {
+		// the collector sleeps long enough to never run during a test
+		l1 := persistent.NewLocker(nil, openReplica(), "locks", time.Hour, time.Hour, 0)
+		l2 := persistent.NewLocker(nil, openReplica(), "locks", time.Hour, time.Hour, 0)
+
+		_, err := l1.Lock(id, "tx1")
+		Expect(err).NotTo(HaveOccurred())
+		locks, err := l2.(selector.LockInspector).Locks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(locks).To(HaveLen(1))
+		Expect(locks[0].ID).To(Equal(id))
+		Expect(locks[0].TxID).To(Equal("tx1"))
+
+		l2.UnlockByTxID("tx1")
+		locks, err = l1.(selector.LockInspector).Locks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(locks).To(BeEmpty())
+
+		_, err = l2.Lock(id, "tx2")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("set a lock only once", func() {
+		replicas := []persistent.Persistence{openReplica(), openReplica()}
+
+		var wg sync.WaitGroup
+		set := make(chan string, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				txID := fmt.Sprintf("tx%d", i)
+				swapped, err := replicas[i%2].CompareAndSwap("locks", "lock:tx:0", nil, []byte(txID))
+				Expect(err).NotTo(HaveOccurred())
+				if swapped {
+					set <- txID
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(set)
+
+		var winners []string
+		for txID := range set {
+			winners = append(winners, txID)
+		}
+		Expect(winners).To(HaveLen(1))
+		value, err := replicas[0].GetState("locks", "lock:tx:0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(value)).To(Equal(winners[0]))
+	})
+})
+
+var _ = Describe("Lock persistences", func() {
+	check := func(persistence persistent.Persistence) {
+		swapped, err := persistence.CompareAndSwap("ns", "lock:a", nil, []byte("v1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+
+		// the value is no longer absent
+		swapped, err = persistence.CompareAndSwap("ns", "lock:a", nil, []byte("v2"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+		// the value is not v2
+		swapped, err = persistence.CompareAndSwap("ns", "lock:a", []byte("v2"), []byte("v3"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+
+		swapped, err = persistence.CompareAndSwap("ns", "lock:a", []byte("v1"), []byte("v2"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+		values, err := persistence.GetStateRange("ns", "lock:", "lock;")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string][]byte{"lock:a": []byte("v2")}))
+
+		swapped, err = persistence.CompareAndSwap("ns", "lock:a", []byte("v1"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+		swapped, err = persistence.CompareAndSwap("ns", "lock:a", []byte("v2"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+		value, err := persistence.GetState("ns", "lock:a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(BeNil())
+	}
+
+	It("compare and swap in memory", func() {
+		kvs, err := db.Open("memory", "")
+		Expect(err).NotTo(HaveOccurred())
+		check(persistent.NewPersistence(kvs))
+	})
+
+	It("compare and swap in sql", func() {
+		sqlDB, err := sql.Open("sqlite3", "file::memory:?cache=shared")
+		Expect(err).NotTo(HaveOccurred())
+		defer sqlDB.Close()
+		persistence, err := persistent.NewSQLPersistence(sqlDB)
+		Expect(err).NotTo(HaveOccurred())
+		check(persistence)
+	})
+})
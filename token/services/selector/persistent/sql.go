@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package persistent
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// The schema and the statements are portable across sqlite and postgres.
+// The values are JSON documents, stored as text.
+const createLocksTable = `CREATE TABLE IF NOT EXISTS token_locks (
+	namespace TEXT NOT NULL,
+	lock_key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (namespace, lock_key)
+)`
+
+// sqlPersistence is a Persistence in a SQL database
+type sqlPersistence struct {
+	db *sql.DB
+}
+
+// NewSQLPersistence returns a Persistence in the passed database. The table is created if it does not exist.
+// CompareAndSwap is a single conditional statement, atomic in the database. Therefore, the lockers of the replicas
+// of a node can share the locks by opening the same database, like a postgres database.
+func NewSQLPersistence(db *sql.DB) (Persistence, error) {
+	if _, err := db.Exec(createLocksTable); err != nil {
+		return nil, errors.Wrap(err, "failed creating table token_locks")
+	}
+	return &sqlPersistence{db: db}, nil
+}
+
+func (p *sqlPersistence) GetState(namespace, key string) ([]byte, error) {
+	var value string
+	err := p.db.QueryRow("SELECT value FROM token_locks WHERE namespace = $1 AND lock_key = $2", namespace, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting lock [%s]", key)
+	}
+	return []byte(value), nil
+}
+
+func (p *sqlPersistence) GetStateRange(namespace, startKey, endKey string) (map[string][]byte, error) {
+	rows, err := p.db.Query("SELECT lock_key, value FROM token_locks WHERE namespace = $1 AND lock_key >= $2 AND lock_key < $3", namespace, startKey, endKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed querying locks")
+	}
+	defer rows.Close()
+
+	res := map[string][]byte{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "failed reading lock")
+		}
+		res[key] = []byte(value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed reading locks")
+	}
+	return res, nil
+}
+
+func (p *sqlPersistence) CompareAndSwap(namespace, key string, old, next []byte) (bool, error) {
+	var res sql.Result
+	var err error
+	switch {
+	case len(old) == 0 && len(next) == 0:
+		return false, errors.Errorf("nothing to swap for [%s]", key)
+	case len(old) == 0:
+		res, err = p.db.Exec("INSERT INTO token_locks (namespace, lock_key, value) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", namespace, key, string(next))
+	case len(next) == 0:
+		res, err = p.db.Exec("DELETE FROM token_locks WHERE namespace = $1 AND lock_key = $2 AND value = $3", namespace, key, string(old))
+	default:
+		res, err = p.db.Exec("UPDATE token_locks SET value = $1 WHERE namespace = $2 AND lock_key = $3 AND value = $4", string(next), namespace, key, string(old))
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed setting lock [%s]", key)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed setting lock [%s]", key)
+	}
+	return n == 1, nil
+}
+
+func (p *sqlPersistence) DeleteState(namespace string, keys ...string) error {
+	for _, key := range keys {
+		if _, err := p.db.Exec("DELETE FROM token_locks WHERE namespace = $1 AND lock_key = $2", namespace, key); err != nil {
+			return errors.Wrapf(err, "failed deleting lock [%s]", key)
+		}
+	}
+	return nil
+}
@@ -9,9 +9,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -41,6 +45,9 @@ type selectorService struct {
 	lock           sync.Mutex
 	lockerProvider LockerProvider
 	lockers        map[string]Locker
+	notifiers      map[string]*Notifier
+	holds          map[string]*holds
+	strategies     map[string]token.SelectionStrategy
 }
 
 func NewProvider(sp view.ServiceProvider, lockerProvider LockerProvider, numRetry int, timeout time.Duration) *selectorService {
@@ -48,6 +55,9 @@ func NewProvider(sp view.ServiceProvider, lockerProvider LockerProvider, numRetr
 		sp:                   sp,
 		lockerProvider:       lockerProvider,
 		lockers:              map[string]Locker{},
+		notifiers:            map[string]*Notifier{},
+		holds:                map[string]*holds{},
+		strategies:           map[string]token.SelectionStrategy{},
 		numRetry:             numRetry,
 		timeout:              timeout,
 		requestCertification: true,
@@ -74,17 +84,69 @@ func (s *selectorService) SelectorManager(network string, channel string, namesp
 	} else {
 		logger.Debugf("in-memory selector for [%s:%s:%s] exists", tms.Network(), tms.Channel(), tms.Namespace())
 	}
+	notifier, ok := s.notifiers[key]
+	if !ok {
+		notifier = NewNotifier()
+		// new tokens might be what the waiting selectors need
+		if indexProvider := index.GetProvider(s.sp); indexProvider != nil {
+			indexProvider.Index(tms.Network(), tms.Channel(), tms.Namespace()).AddListener(notifier.Notify)
+		}
+		s.notifiers[key] = notifier
+	}
+	h, ok := s.holds[key]
+	if !ok {
+		h = newHolds(locker, notifier, fabric.GetChannel(s.sp, tms.Network(), tms.Channel()).Vault())
+		s.holds[key] = h
+	}
+	strategy, ok := s.strategies[key]
+	var strategyErr error
+	if !ok {
+		strategy, strategyErr = s.loadStrategy(tms.Network(), tms.Channel(), tms.Namespace())
+		if strategyErr != nil {
+			// the selectors that use the configured strategy fail until the configuration is fixed
+			logger.Errorf("failed loading selection strategy for [%s:%s:%s]: [%s]", tms.Network(), tms.Channel(), tms.Namespace(), strategyErr)
+		} else {
+			s.strategies[key] = strategy
+		}
+	}
 
-	return newManager(
+	m := newManager(
 		locker,
 		func() QueryService {
 			return tms.Vault().NewQueryEngine()
 		},
 		tms.CertificationClient(),
+		strategy,
+		tms.PublicParametersManager().Precision(),
+		notifier,
+		h,
 		s.numRetry,
 		s.timeout,
 		s.requestCertification,
 	)
+	m.strategyErr = strategyErr
+	return m
+}
+
+// loadStrategy returns the selection strategy configured for the passed TMS, the default strategy if none is configured.
+// It returns an error if the configured strategy is unknown.
+func (s *selectorService) loadStrategy(network, channel, namespace string) (token.SelectionStrategy, error) {
+	var tmsConfigs []*token.TMS
+	if err := view.GetConfigService(s.sp).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	for _, tms := range tmsConfigs {
+		if len(tms.Network) != 0 && tms.Network != network {
+			continue
+		}
+		if tms.Channel == channel && tms.Namespace == namespace {
+			if tms.Selection == nil {
+				break
+			}
+			return NewStrategy(tms.Selection.Strategy)
+		}
+	}
+	return LedgerOrder(), nil
 }
 
 func (s *selectorService) SetNumRetries(n uint) {
@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/driver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// fakeConfig serves the token-sdk configuration of the passed TMSs
+type fakeConfig struct {
+	driver.ConfigProvider
+	tms []*token.TMS
+}
+
+func (c *fakeConfig) UnmarshalKey(key string, rawVal interface{}) error {
+	*(rawVal.(*[]*token.TMS)) = c.tms
+	return nil
+}
+
+type fakeServiceProvider struct {
+	config *fakeConfig
+}
+
+func (sp *fakeServiceProvider) GetService(v interface{}) (interface{}, error) {
+	return sp.config, nil
+}
+
+var _ = Describe("Selection strategy configuration", func() {
+	var (
+		config *fakeConfig
+		s      *selectorService
+	)
+
+	BeforeEach(func() {
+		config = &fakeConfig{tms: []*token.TMS{
+			{Channel: "ch1", Namespace: "ns1", Selection: &token.Selection{Strategy: LargestFirstStrategy}},
+			{Channel: "ch2", Namespace: "ns2"},
+			{Channel: "ch3", Namespace: "ns3", Selection: &token.Selection{Strategy: "cheapest"}},
+		}}
+		s = NewProvider(&fakeServiceProvider{config: config}, nil, 1, time.Millisecond)
+	})
+
+	It("loads the configured strategy", func() {
+		strategy, err := s.loadStrategy("", "ch1", "ns1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strategy).To(Equal(LargestFirst()))
+	})
+
+	It("uses the default strategy when none is configured", func() {
+		strategy, err := s.loadStrategy("", "ch2", "ns2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strategy).To(Equal(LedgerOrder()))
+
+		strategy, err = s.loadStrategy("", "ch4", "ns4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strategy).To(Equal(LedgerOrder()))
+	})
+
+	It("fails on an unknown strategy", func() {
+		_, err := s.loadStrategy("", "ch3", "ns3")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unknown selection strategy [cheapest]"))
+	})
+
+	It("fails the selectors that use a strategy that cannot be loaded", func() {
+		locker := &fakeLocker{locks: map[string]string{}}
+		notifier := NewNotifier()
+		m := newManager(locker, func() QueryService { return &fakeQueryService{} }, nil, nil, 64, notifier, newHolds(locker, notifier, nil), 1, time.Millisecond, false)
+		_, err := s.loadStrategy("", "ch3", "ns3")
+		m.strategyErr = err
+
+		_, err = m.NewSelector("tx1")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("failed loading the selection strategy: unknown selection strategy [cheapest]"))
+
+		// an explicit strategy can still be used
+		_, err = m.NewSelectorWithStrategy("tx1", LargestFirst())
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
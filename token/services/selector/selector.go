@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package selector
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,12 +14,12 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type QueryService interface {
 	ListUnspentTokens() (*token2.UnspentTokens, error)
+	ListUnspentTokensOf(wallet string, typ string) (*token2.UnspentTokens, error)
 	GetTokens(inputs ...*token2.Id) ([]*token2.Token, error)
 }
 
@@ -38,25 +39,35 @@ type Locker interface {
 	UnlockByTxID(txID string)
 }
 
+// Leaser is implemented by the lockers whose locks expire after a lease
+type Leaser interface {
+	// LeaseExpiry returns how long a lock lasts
+	LeaseExpiry() time.Duration
+}
+
 type selector struct {
 	txID         string
 	locker       Locker
 	queryService QueryService
 	certClient   CertClient
+	strategy     token.SelectionStrategy
 	precision    uint64
+	notifier     *Notifier
 
 	numRetry             int
 	timeout              time.Duration
 	requestCertification bool
 }
 
-func newSelector(txID string, locker Locker, service QueryService, certClient CertClient, numRetry int, timeout time.Duration, requestCertification bool) *selector {
+func newSelector(txID string, locker Locker, service QueryService, certClient CertClient, strategy token.SelectionStrategy, precision uint64, notifier *Notifier, numRetry int, timeout time.Duration, requestCertification bool) *selector {
 	return &selector{
 		txID:                 txID,
 		locker:               locker,
 		queryService:         service,
 		certClient:           certClient,
-		precision:            keys.Precision,
+		strategy:             strategy,
+		precision:            precision,
+		notifier:             notifier,
 		numRetry:             numRetry,
 		timeout:              timeout,
 		requestCertification: requestCertification,
@@ -65,171 +76,217 @@ func newSelector(txID string, locker Locker, service QueryService, certClient Ce
 
 // Select selects tokens to be spent based on ownership, quantity, and type
 func (s *selector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([]*token2.Id, token2.Quantity, error) {
+	return s.SelectWithContext(context.Background(), ownerFilter, q, tokenType)
+}
+
+// SelectWithContext selects tokens to be spent based on ownership, quantity, and type, retrying until the passed
+// context is done. If the context has no deadline, the selection gives up after numRetry attempts.
+func (s *selector) SelectWithContext(ctx context.Context, ownerFilter token.OwnerFilter, q, tokenType string, opts ...token.SelectOption) ([]*token2.Id, token2.Quantity, error) {
+	options, err := token.CompileSelectOptions(opts...)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed compiling select options")
+	}
 	if ownerFilter == nil {
 		ownerFilter = &allOwners{}
 	}
-
-	var toBeSpent []*token2.Id
-	var sum token2.Quantity
-	var potentialSumWithLocked token2.Quantity
-	var potentialSumWithNonCertified token2.Quantity
 	target, err := token2.ToQuantity(q, s.precision)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to convert quantity")
 	}
+	_, hasDeadline := ctx.Deadline()
+
+	for i := 0; ; i++ {
+		logger.Debugf("start token selection, iteration [%d]", i)
+		// get the notification channel before selecting, so that the releases happening in the meantime are not missed
+		var notified <-chan struct{}
+		if options.WaitForFunds && s.notifier != nil {
+			notified = s.notifier.Wait()
+		}
 
-	i := 0
-	for {
-		logger.Debugf("start token selection, iteration [%d/%d]", i, s.numRetry)
-		unspentTokens, err := s.queryService.ListUnspentTokens()
+		toBeSpent, sum, selectionErr, err := s.selectOnce(ownerFilter, target, tokenType)
 		if err != nil {
-			return nil, nil, errors.Wrap(err, "token selection failed")
+			return nil, nil, err
+		}
+		if selectionErr == nil {
+			return toBeSpent, sum, nil
 		}
-		logger.Debugf("select token for a quantity of [%s] of type [%s] from [%d] unspent tokens", q, tokenType, len(unspentTokens.Tokens))
-
-		// First select only certified
-		sum = token2.NewZeroQuantity(s.precision)
-		potentialSumWithLocked = token2.NewZeroQuantity(s.precision)
-		potentialSumWithNonCertified = token2.NewZeroQuantity(s.precision)
-		toBeSpent = nil
-		var toBeCertified []*token2.Id
-		var locked []*token2.Id
-
-		for _, t := range unspentTokens.Tokens {
-			q, err := token2.ToQuantity(t.Quantity, s.precision)
-			if err != nil {
-				s.locker.UnlockIDs(toBeSpent...)
-				s.locker.UnlockIDs(toBeCertified...)
-				return nil, nil, errors.Wrap(err, "failed to convert quantity")
-			}
 
-			logger.Debugf("select token [%s,%s,%v]?", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
+		if !hasDeadline && i+1 >= s.numRetry {
+			logger.Debugf("token selection: it is time to fail [%s]", selectionErr)
+			return nil, nil, selectionErr
+		}
 
-			// check type and ownership
-			if t.Type != tokenType {
-				logger.Debugf("token [%s,%s,%v] type does not match", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
-				continue
-			}
+		logger.Debugf("token selection: let's wait [%v] before retry...", s.timeout)
+		timer := time.NewTimer(s.timeout)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Debugf("token selection: context done [%s]", ctx.Err())
+			selectionErr.Interrupted = ctx.Err()
+			return nil, nil, selectionErr
+		case <-notified:
+			timer.Stop()
+			logger.Debugf("token selection: tokens might be available, retry")
+		case <-timer.C:
+		}
+	}
+}
 
-			if !ownerFilter.Contains(t.Owner.Raw) {
-				logger.Debugf("token [%s,%s,%v] owner does not belong to the passed wallet", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
-				continue
-			}
+// selectOnce runs a single selection attempt. If the funds the attempt can spend are not enough,
+// it returns a selection error that describes the funds found.
+func (s *selector) selectOnce(ownerFilter token.OwnerFilter, target token2.Quantity, tokenType string) ([]*token2.Id, token2.Quantity, *token.SelectionError, error) {
+	candidates, err := s.candidates(ownerFilter, target, tokenType)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "token selection failed")
+	}
 
-			// lock the token
-			if _, err := s.locker.Lock(t.Id, s.txID); err != nil {
-				locked = append(locked, t.Id)
-				potentialSumWithLocked = potentialSumWithLocked.Add(q)
+	// First select only certified.
+	// Each token fits the precision, their sums might not
+	sum := token2.NewZeroQuantity(token2.MaxPrecision)
+	lockedSum := token2.NewZeroQuantity(token2.MaxPrecision)
+	notCertifiedSum := token2.NewZeroQuantity(token2.MaxPrecision)
+	var toBeSpent []*token2.Id
+	var toBeCertified []*token2.Id
 
-				logger.Debugf("token [%s,%s,%v] cannot be locked [%s]", q, tokenType, ownerFilter.Contains(t.Owner.Raw), err)
-				continue
-			}
+	for _, t := range candidates {
+		q, err := token2.ToQuantity(t.Quantity, s.precision)
+		if err != nil {
+			s.locker.UnlockIDs(toBeSpent...)
+			s.locker.UnlockIDs(toBeCertified...)
+			return nil, nil, nil, errors.Wrap(err, "failed to convert quantity")
+		}
 
-			// check certification, if needed
-			if s.certClient != nil && !s.certClient.IsCertified(t.Id) {
-				toBeCertified = append(toBeCertified, t.Id)
-				potentialSumWithNonCertified = potentialSumWithNonCertified.Add(q)
+		logger.Debugf("select token [%s,%s,%v]?", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
 
-				logger.Debugf("token [%s,%s,%v] is not certified, skipping", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
-				continue
-			}
+		// lock the token
+		if _, err := s.locker.Lock(t.Id, s.txID); err != nil {
+			lockedSum = lockedSum.Add(q)
 
-			// Append token
-			logger.Debugf("adding quantity [%s]", q.Decimal())
-			toBeSpent = append(toBeSpent, t.Id)
-			sum = sum.Add(q)
-			potentialSumWithLocked = potentialSumWithLocked.Add(q)
-			potentialSumWithNonCertified = potentialSumWithNonCertified.Add(q)
+			logger.Debugf("token [%s,%s,%v] cannot be locked [%s]", q, tokenType, ownerFilter.Contains(t.Owner.Raw), err)
+			continue
+		}
 
-			if target.Cmp(sum) <= 0 {
-				break
-			}
+		// check certification, if needed
+		if s.certClient != nil && !s.certClient.IsCertified(t.Id) {
+			toBeCertified = append(toBeCertified, t.Id)
+			notCertifiedSum = notCertifiedSum.Add(q)
+
+			logger.Debugf("token [%s,%s,%v] is not certified, skipping", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
+			continue
 		}
 
-		concurrencyIssue := false
+		// Append token
+		logger.Debugf("adding quantity [%s]", q.Decimal())
+		toBeSpent = append(toBeSpent, t.Id)
+		sum = sum.Add(q)
+
 		if target.Cmp(sum) <= 0 {
-			err := s.concurrencyCheck(toBeSpent)
-			if err == nil {
-				return toBeSpent, sum, nil
-			}
-			concurrencyIssue = true
-			logger.Errorf("concurrency issue, some of the tokens might not exist anymore [%s]", err)
+			break
 		}
+	}
 
-		// if we reached this point is because there are not enough funds or there is a concurrency issue but why?
+	concurrencyIssue := false
+	if target.Cmp(sum) <= 0 {
+		err := s.concurrencyCheck(toBeSpent)
+		if err == nil {
+			return toBeSpent, sum, nil, nil
+		}
+		concurrencyIssue = true
+		logger.Errorf("concurrency issue, some of the tokens might not exist anymore [%s]", err)
+	}
 
-		// Maybe it is a certification issue?
-		if !concurrencyIssue && target.Cmp(potentialSumWithNonCertified) <= 0 && s.requestCertification {
-			logger.Warnf("token selection failed: missing certifications, request them for [%s]", toBeCertified)
-			// request certification
-			err := s.certClient.RequestCertification(toBeCertified...)
+	// if we reached this point is because there are not enough funds or there is a concurrency issue but why?
+
+	// Maybe it is a certification issue?
+	potentialSumWithNonCertified := sum.Add(notCertifiedSum)
+	if !concurrencyIssue && target.Cmp(potentialSumWithNonCertified) <= 0 && s.requestCertification {
+		logger.Warnf("token selection failed: missing certifications, request them for [%s]", toBeCertified)
+		// request certification
+		err := s.certClient.RequestCertification(toBeCertified...)
+		if err == nil {
+			// TODO: refine this
+			ids := append(toBeSpent, toBeCertified...)
+			err := s.concurrencyCheck(ids)
 			if err == nil {
-				// TODO: refine this
-				ids := append(toBeSpent, toBeCertified...)
-				err := s.concurrencyCheck(ids)
-				if err == nil {
-					return ids, potentialSumWithNonCertified, nil
-				}
-				concurrencyIssue = true
-				logger.Errorf("concurrency issue, some of the tokens might not exist anymore [%s]", err)
-			} else {
-				logger.Warnf("token selection failed: failed requesting token certification for [%v]: [%s]", toBeCertified, err)
+				return ids, potentialSumWithNonCertified, nil, nil
 			}
+			concurrencyIssue = true
+			logger.Errorf("concurrency issue, some of the tokens might not exist anymore [%s]", err)
+		} else {
+			logger.Warnf("token selection failed: failed requesting token certification for [%v]: [%s]", toBeCertified, err)
 		}
+	}
 
-		// Unlock and check the conditions for a retry
-		s.locker.UnlockIDs(toBeSpent...)
-		s.locker.UnlockIDs(toBeCertified...)
-
-		if target.Cmp(potentialSumWithLocked) <= 0 && potentialSumWithLocked.Cmp(sum) != 0 {
-			// funds are potentially enough but they are locked
-			logger.Debugf("token selection: sufficient funds but partially locked")
-		}
-		if target.Cmp(potentialSumWithNonCertified) <= 0 && potentialSumWithNonCertified.Cmp(sum) != 0 {
-			// funds are potentially enough but they are locked
-			logger.Debugf("token selection: sufficient funds but partially not certified")
-		}
-
-		i++
-		if i >= s.numRetry {
-			// it is time to fail but how?
-			if concurrencyIssue {
-				logger.Debugf("concurrency issue, some of the tokens might not exist anymore")
-				return nil, nil, errors.WithMessagef(
-					token.SelectorSufficientFundsButConcurrencyIssue,
-					"token selection failed: sufficient funs but concurrency issue, potential [%s] tokens of type [%s] were available", potentialSumWithLocked, tokenType,
-				)
-			}
+	// Unlock and tell why the selection failed
+	s.locker.UnlockIDs(toBeSpent...)
+	s.locker.UnlockIDs(toBeCertified...)
 
-			if target.Cmp(potentialSumWithLocked) <= 0 && potentialSumWithLocked.Cmp(sum) != 0 {
-				// funds are potentially enough but they are locked
-				logger.Debugf("token selection: it is time to fail but how, sufficient funds but locked")
-				return nil, nil, errors.WithMessagef(
-					token.SelectorSufficientButLockedFunds,
-					"token selection failed: sufficient but partially locked funds, potential [%s] tokens of type [%s] are available", potentialSumWithLocked, tokenType,
-				)
-			}
+	selectionErr := &token.SelectionError{
+		Type:         tokenType,
+		Requested:    target,
+		Available:    sum,
+		Locked:       lockedSum,
+		NotCertified: notCertifiedSum,
+	}
+	potentialSumWithLocked := sum.Add(lockedSum)
+	switch {
+	case concurrencyIssue:
+		logger.Debugf("token selection: concurrency issue, some of the tokens might not exist anymore")
+		selectionErr.Reason = token.SelectorSufficientFundsButConcurrencyIssue
+	case target.Cmp(potentialSumWithLocked) <= 0 && potentialSumWithLocked.Cmp(sum) != 0:
+		// funds are potentially enough but they are locked
+		logger.Debugf("token selection: sufficient funds but partially locked")
+		selectionErr.Reason = token.SelectorSufficientButLockedFunds
+	case target.Cmp(potentialSumWithNonCertified) <= 0 && potentialSumWithNonCertified.Cmp(sum) != 0:
+		// funds are potentially enough but they are not certified
+		logger.Debugf("token selection: sufficient funds but partially not certified")
+		selectionErr.Reason = token.SelectorSufficientButNotCertifiedFunds
+	default:
+		logger.Debugf("token selection: insufficient funds")
+		selectionErr.Reason = token.SelectorInsufficientFunds
+	}
+	return nil, nil, selectionErr, nil
+}
 
-			if target.Cmp(potentialSumWithNonCertified) <= 0 && potentialSumWithNonCertified.Cmp(sum) != 0 {
-				// funds are potentially enough but they are locked
-				logger.Debugf("token selection: it is time to fail but how, sufficient funds but locked")
-				return nil, nil, errors.WithMessagef(
-					token.SelectorSufficientButNotCertifiedFunds,
-					"token selection failed: sufficient but partially not certified, potential [%s] tokens of type [%s] are available", potentialSumWithNonCertified, tokenType,
-				)
-			}
+// candidates returns the unspent tokens of the passed type whose owner is in the passed filter,
+// in the order given by the selection strategy.
+// If the filter is a wallet, its tokens are looked up in the index of the unspent tokens.
+func (s *selector) candidates(ownerFilter token.OwnerFilter, target token2.Quantity, tokenType string) ([]*token2.UnspentToken, error) {
+	var res []*token2.UnspentToken
+	if w, ok := ownerFilter.(wallet); ok {
+		unspentTokens, err := s.queryService.ListUnspentTokensOf(w.ID(), tokenType)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("select token for a quantity of [%s] of type [%s] from [%d] unspent tokens of wallet [%s]", target.Decimal(), tokenType, len(unspentTokens.Tokens), w.ID())
+		return s.order(unspentTokens.Tokens, target)
+	}
 
-			// funds are insufficient
-			logger.Debugf("token selection: it is time to fail but how, insufficient funds")
-			return nil, nil, errors.WithMessagef(
-				token.SelectorInsufficientFunds,
-				"token selection failed: insufficient funds, only [%s] tokens of type [%s] are available", sum, tokenType,
-			)
+	unspentTokens, err := s.queryService.ListUnspentTokens()
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("select token for a quantity of [%s] of type [%s] from [%d] unspent tokens", target.Decimal(), tokenType, len(unspentTokens.Tokens))
+	for _, t := range unspentTokens.Tokens {
+		// check type and ownership
+		if t.Type != tokenType {
+			logger.Debugf("token [%s,%s] type does not match", t.Id, tokenType)
+			continue
+		}
+		if !ownerFilter.Contains(t.Owner.Raw) {
+			logger.Debugf("token [%s,%s] owner does not belong to the passed wallet", t.Id, tokenType)
+			continue
 		}
+		res = append(res, t)
+	}
+	return s.order(res, target)
+}
 
-		logger.Debugf("token selection: let's wait [%v] before retry...", s.timeout)
-		time.Sleep(s.timeout)
+func (s *selector) order(tokens []*token2.UnspentToken, target token2.Quantity) ([]*token2.UnspentToken, error) {
+	if s.strategy == nil {
+		return tokens, nil
 	}
+	return s.strategy.Order(tokens, target, s.precision)
 }
 
 func (s *selector) concurrencyCheck(ids []*token2.Id) error {
@@ -237,6 +294,11 @@ func (s *selector) concurrencyCheck(ids []*token2.Id) error {
 	return err
 }
 
+// wallet is an owner filter that is a wallet
+type wallet interface {
+	ID() string
+}
+
 type allOwners struct{}
 
 func (a *allOwners) Contains(identity view.Identity) bool {
@@ -0,0 +1,198 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type fakeLocker struct {
+	lock  sync.Mutex
+	locks map[string]string
+}
+
+func (f *fakeLocker) Lock(id *token2.Id, txID string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if other, ok := f.locks[id.String()]; ok && other != txID {
+		return other, errors.Errorf("already locked by [%s]", other)
+	}
+	f.locks[id.String()] = txID
+	return "", nil
+}
+
+func (f *fakeLocker) UnlockIDs(ids ...*token2.Id) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range ids {
+		delete(f.locks, id.String())
+	}
+}
+
+func (f *fakeLocker) UnlockByTxID(txID string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for id, other := range f.locks {
+		if other == txID {
+			delete(f.locks, id)
+		}
+	}
+}
+
+type fakeQueryService struct {
+	tokens []*token2.UnspentToken
+}
+
+func (f *fakeQueryService) ListUnspentTokens() (*token2.UnspentTokens, error) {
+	return &token2.UnspentTokens{Tokens: f.tokens}, nil
+}
+
+func (f *fakeQueryService) ListUnspentTokensOf(wallet string, typ string) (*token2.UnspentTokens, error) {
+	return f.ListUnspentTokens()
+}
+
+func (f *fakeQueryService) GetTokens(inputs ...*token2.Id) ([]*token2.Token, error) {
+	var res []*token2.Token
+	for _, id := range inputs {
+		found := false
+		for _, t := range f.tokens {
+			if t.Id.String() == id.String() {
+				res = append(res, &token2.Token{Owner: t.Owner, Type: t.Type, Quantity: t.Quantity})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("token [%s] not found", id)
+		}
+	}
+	return res, nil
+}
+
+var _ = Describe("Selector", func() {
+	var (
+		locker   *fakeLocker
+		notifier *Notifier
+		s        *selector
+	)
+
+	newTestSelector := func(txID string, timeout time.Duration) *selector {
+		qs := &fakeQueryService{tokens: []*token2.UnspentToken{
+			{Id: &token2.Id{TxId: "tx1", Index: 0}, Owner: &token2.Owner{}, Type: "USD", Quantity: "10"},
+			{Id: &token2.Id{TxId: "tx1", Index: 1}, Owner: &token2.Owner{}, Type: "USD", Quantity: "20"},
+		}}
+		return newSelector(txID, locker, qs, nil, LedgerOrder(), 64, notifier, 2, timeout, false)
+	}
+
+	BeforeEach(func() {
+		locker = &fakeLocker{locks: map[string]string{}}
+		notifier = NewNotifier()
+		s = newTestSelector("tx", 10*time.Millisecond)
+	})
+
+	It("selects the requested quantity", func() {
+		ids, sum, err := s.SelectWithContext(context.Background(), nil, "25", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(2))
+		Expect(sum.Decimal()).To(Equal("30"))
+	})
+
+	It("describes insufficient funds", func() {
+		_, _, err := s.Select(nil, "40", "USD")
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Cause(err)).To(Equal(token.SelectorInsufficientFunds))
+		selectionErr, ok := err.(*token.SelectionError)
+		Expect(ok).To(BeTrue())
+		Expect(selectionErr.Requested.Decimal()).To(Equal("40"))
+		Expect(selectionErr.Available.Decimal()).To(Equal("30"))
+		Expect(selectionErr.Locked.Decimal()).To(Equal("0"))
+		Expect(selectionErr.Interrupted).To(BeNil())
+		// the tokens locked by the failed selection are released
+		Expect(locker.locks).To(BeEmpty())
+	})
+
+	It("sums tokens near the maximum of the precision", func() {
+		// 16383 is the largest quantity with 14 bits, the sums below need 15 bits
+		qs := &fakeQueryService{tokens: []*token2.UnspentToken{
+			{Id: &token2.Id{TxId: "tx2", Index: 0}, Owner: &token2.Owner{}, Type: "USD", Quantity: "16000"},
+			{Id: &token2.Id{TxId: "tx2", Index: 1}, Owner: &token2.Owner{}, Type: "USD", Quantity: "16000"},
+			{Id: &token2.Id{TxId: "tx2", Index: 2}, Owner: &token2.Owner{}, Type: "USD", Quantity: "16383"},
+		}}
+		s = newSelector("tx", locker, qs, nil, LedgerOrder(), 14, notifier, 2, 10*time.Millisecond, false)
+
+		_, err := locker.Lock(&token2.Id{TxId: "tx2", Index: 0}, "other")
+		Expect(err).NotTo(HaveOccurred())
+		ids, sum, err := s.Select(nil, "16383", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(2))
+		Expect(sum.Decimal()).To(Equal("32383"))
+		locker.UnlockByTxID("tx")
+
+		_, err = locker.Lock(&token2.Id{TxId: "tx2", Index: 1}, "other")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = locker.Lock(&token2.Id{TxId: "tx2", Index: 2}, "other")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = s.Select(nil, "16383", "USD")
+		Expect(errors.Cause(err)).To(Equal(token.SelectorSufficientButLockedFunds))
+		Expect(err.(*token.SelectionError).Locked.Decimal()).To(Equal("48383"))
+	})
+
+	It("retries until the deadline", func() {
+		_, err := locker.Lock(&token2.Id{TxId: "tx1", Index: 1}, "other")
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, _, err = s.SelectWithContext(ctx, nil, "25", "USD")
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+		Expect(errors.Cause(err)).To(Equal(token.SelectorSufficientButLockedFunds))
+		selectionErr := err.(*token.SelectionError)
+		Expect(selectionErr.Available.Decimal()).To(Equal("10"))
+		Expect(selectionErr.Locked.Decimal()).To(Equal("20"))
+		Expect(selectionErr.Interrupted).To(Equal(context.DeadlineExceeded))
+	})
+
+	It("gives up after the retries without a deadline", func() {
+		_, err := locker.Lock(&token2.Id{TxId: "tx1", Index: 1}, "other")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = s.SelectWithContext(context.Background(), nil, "25", "USD")
+		Expect(errors.Cause(err)).To(Equal(token.SelectorSufficientButLockedFunds))
+		Expect(err.(*token.SelectionError).Interrupted).To(BeNil())
+	})
+
+	It("is woken up when locked tokens are released", func() {
+		_, err := locker.Lock(&token2.Id{TxId: "tx1", Index: 1}, "other")
+		Expect(err).NotTo(HaveOccurred())
+		// without notifications, the selector would not retry before the deadline
+		s = newTestSelector("tx", time.Hour)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			locker.UnlockByTxID("other")
+			notifier.Notify()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		ids, sum, err := s.SelectWithContext(ctx, nil, "25", "USD", token.WithWaitForFunds())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(HaveLen(2))
+		Expect(sum.Decimal()).To(Equal("30"))
+	})
+})
@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+const (
+	// LedgerOrderStrategy selects tokens in the order the vault lists them. It is the default strategy.
+	LedgerOrderStrategy = "ledger-order"
+	// LargestFirstStrategy selects the largest tokens first, minimizing the number of inputs
+	LargestFirstStrategy = "largest-first"
+	// SmallestFirstStrategy selects the smallest tokens first, consolidating dust
+	SmallestFirstStrategy = "smallest-first"
+	// ClosestMatchStrategy prefers the smallest token that covers the target alone, minimizing change outputs
+	ClosestMatchStrategy = "closest-match"
+	// RandomStrategy selects tokens in random order, to make selections harder to link
+	RandomStrategy = "random"
+)
+
+// NewStrategy returns the built-in strategy with the passed name. The empty name is the default strategy.
+func NewStrategy(name string) (token.SelectionStrategy, error) {
+	switch name {
+	case "", LedgerOrderStrategy:
+		return &ledgerOrder{}, nil
+	case LargestFirstStrategy:
+		return &largestFirst{}, nil
+	case SmallestFirstStrategy:
+		return &smallestFirst{}, nil
+	case ClosestMatchStrategy:
+		return &closestMatch{}, nil
+	case RandomStrategy:
+		return NewRandom(), nil
+	default:
+		return nil, errors.Errorf("unknown selection strategy [%s]", name)
+	}
+}
+
+// LedgerOrder returns the strategy that selects tokens in the order the vault lists them
+func LedgerOrder() token.SelectionStrategy {
+	return &ledgerOrder{}
+}
+
+// LargestFirst returns the strategy that selects the largest tokens first
+func LargestFirst() token.SelectionStrategy {
+	return &largestFirst{}
+}
+
+// SmallestFirst returns the strategy that selects the smallest tokens first
+func SmallestFirst() token.SelectionStrategy {
+	return &smallestFirst{}
+}
+
+// ClosestMatch returns the strategy that minimizes the change
+func ClosestMatch() token.SelectionStrategy {
+	return &closestMatch{}
+}
+
+type ledgerOrder struct{}
+
+func (l *ledgerOrder) Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error) {
+	return tokens, nil
+}
+
+type largestFirst struct{}
+
+func (l *largestFirst) Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error) {
+	quantities, err := parseQuantities(tokens, precision)
+	if err != nil {
+		return nil, err
+	}
+	return sortByQuantity(quantities, true), nil
+}
+
+type smallestFirst struct{}
+
+func (s *smallestFirst) Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error) {
+	quantities, err := parseQuantities(tokens, precision)
+	if err != nil {
+		return nil, err
+	}
+	return sortByQuantity(quantities, false), nil
+}
+
+type closestMatch struct{}
+
+// Order puts first the tokens that cover the target alone, smallest first, so that an exact match produces no change.
+// The remaining tokens follow, largest first, to cover the target with as few inputs as possible.
+func (c *closestMatch) Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error) {
+	quantities, err := parseQuantities(tokens, precision)
+	if err != nil {
+		return nil, err
+	}
+	var covering, rest []*quantifiedToken
+	for _, t := range quantities {
+		if target.Cmp(t.q) <= 0 {
+			covering = append(covering, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	return append(sortByQuantity(covering, false), sortByQuantity(rest, true)...), nil
+}
+
+type random struct {
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// NewRandom returns the strategy that selects tokens in random order
+func NewRandom() token.SelectionStrategy {
+	return &random{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (r *random) Order(tokens []*token2.UnspentToken, target token2.Quantity, precision uint64) ([]*token2.UnspentToken, error) {
+	res := make([]*token2.UnspentToken, len(tokens))
+	copy(res, tokens)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.rand.Shuffle(len(res), func(i, j int) {
+		res[i], res[j] = res[j], res[i]
+	})
+	return res, nil
+}
+
+type quantifiedToken struct {
+	t *token2.UnspentToken
+	q token2.Quantity
+}
+
+func parseQuantities(tokens []*token2.UnspentToken, precision uint64) ([]*quantifiedToken, error) {
+	res := make([]*quantifiedToken, len(tokens))
+	for i, t := range tokens {
+		q, err := token2.ToQuantity(t.Quantity, precision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert quantity of token [%s]", t.Id)
+		}
+		res[i] = &quantifiedToken{t: t, q: q}
+	}
+	return res, nil
+}
+
+// sortByQuantity sorts the passed tokens by quantity, preserving the vault order of tokens with the same quantity
+func sortByQuantity(tokens []*quantifiedToken, descending bool) []*token2.UnspentToken {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if descending {
+			return tokens[i].q.Cmp(tokens[j].q) > 0
+		}
+		return tokens[i].q.Cmp(tokens[j].q) < 0
+	})
+	res := make([]*token2.UnspentToken, len(tokens))
+	for i, t := range tokens {
+		res[i] = t.t
+	}
+	return res
+}
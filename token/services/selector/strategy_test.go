@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package selector_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func unspentTokens(quantities ...string) []*token2.UnspentToken {
+	var res []*token2.UnspentToken
+	for i, q := range quantities {
+		res = append(res, &token2.UnspentToken{
+			Id:       &token2.Id{TxId: "tx", Index: uint32(i)},
+			Type:     "USD",
+			Quantity: q,
+		})
+	}
+	return res
+}
+
+func quantities(tokens []*token2.UnspentToken) []string {
+	var res []string
+	for _, t := range tokens {
+		res = append(res, t.Quantity)
+	}
+	return res
+}
+
+func order(strategy token.SelectionStrategy, target string, tokens []*token2.UnspentToken) []string {
+	q, err := token2.ToQuantity(target, 64)
+	Expect(err).NotTo(HaveOccurred())
+	ordered, err := strategy.Order(tokens, q, 64)
+	Expect(err).NotTo(HaveOccurred())
+	return quantities(ordered)
+}
+
+var _ = Describe("Selection strategies", func() {
+	var tokens []*token2.UnspentToken
+
+	BeforeEach(func() {
+		tokens = unspentTokens("0x5", "0x1", "0x14", "0x3", "0xa")
+	})
+
+	It("keeps the ledger order", func() {
+		Expect(order(selector.LedgerOrder(), "0x4", tokens)).To(Equal([]string{"0x5", "0x1", "0x14", "0x3", "0xa"}))
+	})
+
+	It("orders the largest tokens first", func() {
+		Expect(order(selector.LargestFirst(), "0x4", tokens)).To(Equal([]string{"0x14", "0xa", "0x5", "0x3", "0x1"}))
+	})
+
+	It("orders the smallest tokens first", func() {
+		Expect(order(selector.SmallestFirst(), "0x4", tokens)).To(Equal([]string{"0x1", "0x3", "0x5", "0xa", "0x14"}))
+	})
+
+	When("a token covers the target alone", func() {
+		It("orders the closest match first", func() {
+			Expect(order(selector.ClosestMatch(), "0x4", tokens)).To(Equal([]string{"0x5", "0xa", "0x14", "0x3", "0x1"}))
+		})
+	})
+
+	When("no token covers the target alone", func() {
+		It("orders the largest tokens first", func() {
+			Expect(order(selector.ClosestMatch(), "0x20", tokens)).To(Equal([]string{"0x14", "0xa", "0x5", "0x3", "0x1"}))
+		})
+	})
+
+	It("shuffles the tokens without losing any", func() {
+		Expect(order(selector.NewRandom(), "0x4", tokens)).To(ConsistOf("0x5", "0x1", "0x14", "0x3", "0xa"))
+	})
+
+	It("resolves the built-in strategies by name", func() {
+		for _, name := range []string{"", selector.LedgerOrderStrategy, selector.LargestFirstStrategy, selector.SmallestFirstStrategy, selector.ClosestMatchStrategy, selector.RandomStrategy} {
+			strategy, err := selector.NewStrategy(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strategy).NotTo(BeNil())
+		}
+		_, err := selector.NewStrategy("biggest")
+		Expect(err).To(MatchError("unknown selection strategy [biggest]"))
+	})
+})
@@ -0,0 +1,526 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package tcc
+
+import (
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+
+	fabric2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/chaincode"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// NonceSize is the size in bytes of the nonces generated for freeze requests
+const NonceSize = 24
+
+// FreezeRequest asks the token chaincode to freeze, or unfreeze, tokens and owners.
+// It is signed by the authority declared in the public parameters.
+type FreezeRequest struct {
+	// Unfreeze is true if the tokens and the owners must be unfrozen
+	Unfreeze bool
+	TokenIDs []*token2.Id
+	Owners   []view.Identity
+	// Nonce makes each request unique, a request cannot be submitted twice
+	Nonce     []byte
+	Signature []byte
+}
+
+func (r *FreezeRequest) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *FreezeRequest) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, r)
+}
+
+// MessageToSign returns the message the authority signs, the request without its signature
+func (r *FreezeRequest) MessageToSign() ([]byte, error) {
+	req := *r
+	req.Signature = nil
+	return json.Marshal(&req)
+}
+
+// SeizeRequest asks the token chaincode to seize frozen tokens. It accompanies the token request that
+// re-issues the seized funds, and it is signed by the authority declared in the public parameters.
+type SeizeRequest struct {
+	TokenIDs  []*token2.Id
+	Signature []byte
+}
+
+func (r *SeizeRequest) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *SeizeRequest) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, r)
+}
+
+// MessageToSign returns the message the authority signs, the token IDs bound to the passed transaction id
+func (r *SeizeRequest) MessageToSign(txID string) ([]byte, error) {
+	raw, err := json.Marshal(r.TokenIDs)
+	if err != nil {
+		return nil, err
+	}
+	return append(raw, []byte(txID)...), nil
+}
+
+// AdminOU is the organizational unit of the creators allowed to set the first authority
+const AdminOU = "admin"
+
+// AuthorityRequest asks the token chaincode to set the authority declared in the public parameters.
+// Once an authority is set, only a request signed by it can replace it. Before then, the creator of the
+// transaction must be an admin of its organization.
+type AuthorityRequest struct {
+	Authority view.Identity
+	// Nonce makes each request unique, a request cannot be submitted twice
+	Nonce     []byte
+	Signature []byte
+}
+
+func (r *AuthorityRequest) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *AuthorityRequest) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, r)
+}
+
+// MessageToSign returns the message the current authority signs, the request without its signature
+func (r *AuthorityRequest) MessageToSign() ([]byte, error) {
+	req := *r
+	req.Signature = nil
+	return json.Marshal(&req)
+}
+
+func (cc *TokenChaincode) freeze(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	ppm, err := cc.publicParametersManager(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	request := &FreezeRequest{}
+	if err := request.FromBytes(raw); err != nil {
+		return shim.Error("failed to unmarshal freeze request: " + err.Error())
+	}
+	msg, err := request.MessageToSign()
+	if err != nil {
+		return shim.Error("failed to marshal freeze request: " + err.Error())
+	}
+	if err := verifyAuthoritySignature(ppm, msg, request.Signature); err != nil {
+		return shim.Error("failed to verify freeze request: " + err.Error())
+	}
+
+	if err := useNonce(request.Nonce, stub); err != nil {
+		return shim.Error("invalid freeze request: " + err.Error())
+	}
+
+	var recordKeys []string
+	for _, id := range request.TokenIDs {
+		key, err := keys.CreateFrozenTokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		recordKeys = append(recordKeys, key)
+	}
+	for _, owner := range request.Owners {
+		key, err := keys.CreateFrozenOwnerKey(owner)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		recordKeys = append(recordKeys, key)
+	}
+	if len(recordKeys) == 0 {
+		return shim.Error("invalid freeze request: no tokens or owners")
+	}
+
+	recordRaw, err := (&translator.FreezeRecord{TxID: stub.GetTxID()}).Bytes()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	for _, key := range recordKeys {
+		record, err := translator.GetFreezeRecord(stub.GetState, key)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if request.Unfreeze {
+			if record == nil {
+				continue
+			}
+			if record.Seized {
+				return shim.Error("cannot unfreeze a seized token")
+			}
+			if err := stub.DelState(key); err != nil {
+				return shim.Error(err.Error())
+			}
+			continue
+		}
+		if record != nil {
+			// already frozen
+			continue
+		}
+		if err := stub.PutState(key, recordRaw); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (cc *TokenChaincode) seize(raw []byte, tokenRequest []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	ppm, err := cc.publicParametersManager(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	request := &SeizeRequest{}
+	if err := request.FromBytes(raw); err != nil {
+		return shim.Error("failed to unmarshal seize request: " + err.Error())
+	}
+	msg, err := request.MessageToSign(stub.GetTxID())
+	if err != nil {
+		return shim.Error("failed to marshal seize request: " + err.Error())
+	}
+	if err := verifyAuthoritySignature(ppm, msg, request.Signature); err != nil {
+		return shim.Error("failed to verify seize request: " + err.Error())
+	}
+	// the chaincode cannot check that hidden funds are re-issued
+	if ppm.TokenDataHiding() {
+		return shim.Error("invalid seize request: seize is not supported when the public parameters hide the token data")
+	}
+	if len(request.TokenIDs) == 0 {
+		return shim.Error("invalid seize request: no tokens")
+	}
+	tr := &driver.TokenRequest{}
+	if err := tr.FromBytes(tokenRequest); err != nil {
+		return shim.Error("failed to unmarshal token request: " + err.Error())
+	}
+	if len(tr.Issues) == 0 || len(tr.Transfers) != 0 {
+		return shim.Error("invalid seize request: the token request must only re-issue the seized funds")
+	}
+
+	// Delete the seized tokens, their freeze records prevent them from being unfrozen
+	seized := map[string]token2.Quantity{}
+	for _, id := range request.TokenIDs {
+		key, err := keys.CreateFrozenTokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		record, err := translator.GetFreezeRecord(stub.GetState, key)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if record == nil {
+			return shim.Error("cannot seize token " + id.String() + ", it is not frozen")
+		}
+		if record.Seized {
+			return shim.Error("token " + id.String() + " has already been seized")
+		}
+		tokenKey, err := keys.CreateTokenKey(id.TxId, int(id.Index))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		tok, err := stub.GetState(tokenKey)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if len(tok) == 0 {
+			return shim.Error("cannot seize token " + id.String() + ", it does not exist")
+		}
+		if err := addSeized(seized, tok); err != nil {
+			return shim.Error("cannot seize token " + id.String() + ": " + err.Error())
+		}
+		if err := stub.DelState(tokenKey); err != nil {
+			return shim.Error(err.Error())
+		}
+		record.Seized = true
+		recordRaw, err := record.Bytes()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if err := stub.PutState(key, recordRaw); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// the re-issue replaces the seized funds, it must not count against the supply
+	actions, err := cc.verify(tokenRequest, &seizeLedger{ledger: &ledger{stub: stub}, seized: seized}, stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := checkReissued(seized, actions); err != nil {
+		return shim.Error("invalid seize request: " + err.Error())
+	}
+	return cc.write(actions, tokenRequest, &seizeRWSet{rwsWrapper: &rwsWrapper{stub: stub}, seized: seized}, stub)
+}
+
+// seizeLedger shows the validator the supply of the seized types minus the seized quantities
+type seizeLedger struct {
+	*ledger
+	seized map[string]token2.Quantity
+}
+
+func (l *seizeLedger) GetState(key string) ([]byte, error) {
+	return getStateWithoutSeized(l.ledger.GetState, key, l.seized)
+}
+
+// seizeRWSet shows the translator the supply of the seized types minus the seized quantities,
+// so that adding the re-issued quantities leaves the supply unchanged
+type seizeRWSet struct {
+	*rwsWrapper
+	seized map[string]token2.Quantity
+}
+
+func (r *seizeRWSet) GetState(namespace string, key string, opts ...fabric2.GetStateOpt) ([]byte, error) {
+	return getStateWithoutSeized(r.rwsWrapper.stub.GetState, key, r.seized)
+}
+
+// getStateWithoutSeized returns the state stored under the passed key. If the key is the supply key of a seized type,
+// it returns the supply minus the seized quantity of that type, or zero if the supply does not cover it.
+// The latter happens only if the seized tokens were issued before the supply was recorded.
+func getStateWithoutSeized(getState func(key string) ([]byte, error), key string, seized map[string]token2.Quantity) ([]byte, error) {
+	for typ, q := range seized {
+		supplyKey, err := keys.CreateSupplyKey(typ)
+		if err != nil {
+			return nil, err
+		}
+		if key != supplyKey {
+			continue
+		}
+		supply, err := translator.GetSupply(getState, typ)
+		if err != nil {
+			return nil, err
+		}
+		if supply.Cmp(q) < 0 {
+			return []byte(token2.NewZeroQuantity(token2.MaxPrecision).Decimal()), nil
+		}
+		return []byte(supply.Sub(q).Decimal()), nil
+	}
+	return getState(key)
+}
+
+// addSeized adds the quantity of the passed token, as stored on the ledger, to the seized quantity of its type.
+// Only tokens in the clear can be seized, otherwise the re-issued funds cannot be checked.
+// The public parameters that hide the token data are rejected before, this guards against a hidden token anyway.
+func addSeized(seized map[string]token2.Quantity, raw []byte) error {
+	tok := &token2.Token{}
+	if err := json.Unmarshal(raw, tok); err != nil || len(tok.Type) == 0 || len(tok.Quantity) == 0 {
+		return errors.New("the type and the quantity of the token are hidden")
+	}
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	if sum, ok := seized[tok.Type]; ok {
+		q = sum.Add(q)
+	}
+	seized[tok.Type] = q
+	return nil
+}
+
+// checkReissued returns an error unless the passed actions issue exactly the seized quantity of each type
+func checkReissued(seized map[string]token2.Quantity, actions []interface{}) error {
+	issued := map[string]token2.Quantity{}
+	for _, action := range actions {
+		issue, ok := action.(translator.IssueAction)
+		if !ok {
+			return errors.New("the token request must only re-issue the seized funds")
+		}
+		quantities, err := issue.GetIssuedQuantities()
+		if err != nil {
+			return err
+		}
+		if quantities == nil {
+			return errors.New("the re-issued funds are hidden")
+		}
+		for typ, q := range quantities {
+			if sum, ok := issued[typ]; ok {
+				q = sum.Add(q)
+			}
+			issued[typ] = q
+		}
+	}
+	if len(issued) != len(seized) {
+		return errors.Errorf("[%d] token types are re-issued, [%d] are seized", len(issued), len(seized))
+	}
+	for typ, q := range seized {
+		reissued, ok := issued[typ]
+		if !ok {
+			return errors.Errorf("token type [%s] is seized but not re-issued", typ)
+		}
+		if reissued.Cmp(q) != 0 {
+			return errors.Errorf("re-issued [%s] of token type [%s], seized [%s]", reissued.Decimal(), typ, q.Decimal())
+		}
+	}
+	return nil
+}
+
+// useNonce records that the passed nonce of a request signed by the authority has been used,
+// it returns an error if the nonce is empty or has been used already
+func useNonce(nonce []byte, stub shim.ChaincodeStubInterface) error {
+	if len(nonce) == 0 {
+		return errors.New("empty nonce")
+	}
+	nonceKey, err := keys.CreateFreezeNonceKey(nonce)
+	if err != nil {
+		return err
+	}
+	used, err := stub.GetState(nonceKey)
+	if err != nil {
+		return err
+	}
+	if len(used) != 0 {
+		return errors.New("request already submitted")
+	}
+	return stub.PutState(nonceKey, []byte(stub.GetTxID()))
+}
+
+// verifyAuthoritySignature checks the signature of the authority declared in the public parameters on the passed message
+func verifyAuthoritySignature(ppm PublicParametersManager, msg []byte, sigma []byte) error {
+	authority := ppm.Authority()
+	if len(authority) == 0 {
+		return errors.New("no authority is set in the public parameters")
+	}
+	verifier, err := (&fabric.MSPX509IdentityDeserializer{}).GetVerifier(authority)
+	if err != nil {
+		return errors.Wrap(err, "failed to deserialize authority's public key")
+	}
+	if err := verifier.Verify(msg, sigma); err != nil {
+		return errors.Wrap(err, "invalid authority signature")
+	}
+	return nil
+}
+
+// RegisterAuthorityView sets the authority declared in the public parameters.
+// If an authority is already set, it must be a local identity of the node running the view, and it signs the request.
+// Otherwise, the node must invoke the token chaincode with the identity of an admin of its organization.
+type RegisterAuthorityView struct {
+	Network   string
+	Channel   string
+	Namespace string
+	Id        view.Identity
+}
+
+func NewRegisterAuthorityView(network string, channel string, namespace string, id view.Identity) *RegisterAuthorityView {
+	return &RegisterAuthorityView{Network: network, Channel: channel, Namespace: namespace, Id: id}
+}
+
+func (r *RegisterAuthorityView) Call(context view.Context) (interface{}, error) {
+	tms := token.GetManagementService(
+		context,
+		token.WithNetwork(r.Network),
+		token.WithChannel(r.Channel),
+		token.WithNamespace(r.Namespace),
+	)
+	request := &AuthorityRequest{Authority: r.Id}
+	if current := tms.PublicParametersManager().Authority(); len(current) != 0 {
+		request.Nonce = make([]byte, NonceSize)
+		if _, err := rand.Read(request.Nonce); err != nil {
+			return nil, errors.Wrap(err, "failed generating nonce")
+		}
+		msg, err := request.MessageToSign()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed marshalling authority request")
+		}
+		signer, err := view2.GetSigService(context).GetSigner(current)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed getting current authority's signer")
+		}
+		request.Signature, err = signer.Sign(msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed signing authority request")
+		}
+	}
+	raw, err := request.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling authority request")
+	}
+
+	_, err = context.RunView(chaincode.NewInvokeView(
+		tms.Namespace(), AddAuthorityFunction, raw,
+	).WithNetwork(tms.Network()).WithChannel(tms.Channel()))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed authority registration")
+	}
+	if err := tms.PublicParametersManager().ForceFetch(); err != nil {
+		logger.Warnf("failed fetching parameters [%s]", err)
+	}
+	return nil, nil
+}
+
+// FreezeView submits a freeze request, signed by the authority declared in the public parameters.
+// The authority must be a local identity of the node running the view.
+type FreezeView struct {
+	Network   string
+	Channel   string
+	Namespace string
+	Request   *FreezeRequest
+}
+
+// NewFreezeView returns a view that freezes the passed tokens and owners. Frozen tokens, and tokens
+// owned by frozen owners, cannot be spent.
+func NewFreezeView(channel string, namespace string, tokenIDs []*token2.Id, owners []view.Identity) *FreezeView {
+	return &FreezeView{Channel: channel, Namespace: namespace, Request: &FreezeRequest{TokenIDs: tokenIDs, Owners: owners}}
+}
+
+// NewUnfreezeView returns a view that unfreezes the passed tokens and owners. Seized tokens cannot be unfrozen.
+func NewUnfreezeView(channel string, namespace string, tokenIDs []*token2.Id, owners []view.Identity) *FreezeView {
+	return &FreezeView{Channel: channel, Namespace: namespace, Request: &FreezeRequest{Unfreeze: true, TokenIDs: tokenIDs, Owners: owners}}
+}
+
+func (r *FreezeView) Call(context view.Context) (interface{}, error) {
+	tms := token.GetManagementService(
+		context,
+		token.WithNetwork(r.Network),
+		token.WithChannel(r.Channel),
+		token.WithNamespace(r.Namespace),
+	)
+	authority := tms.PublicParametersManager().Authority()
+	if len(authority) == 0 {
+		return nil, errors.New("no authority is set in the public parameters")
+	}
+
+	request := *r.Request
+	if len(request.Nonce) == 0 {
+		request.Nonce = make([]byte, NonceSize)
+		if _, err := rand.Read(request.Nonce); err != nil {
+			return nil, errors.Wrap(err, "failed generating nonce")
+		}
+	}
+	msg, err := request.MessageToSign()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling freeze request")
+	}
+	signer, err := view2.GetSigService(context).GetSigner(authority)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting authority's signer")
+	}
+	request.Signature, err = signer.Sign(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed signing freeze request")
+	}
+	raw, err := request.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling freeze request")
+	}
+
+	_, err = context.RunView(chaincode.NewInvokeView(
+		tms.Namespace(), FreezeFunction, raw,
+	).WithNetwork(tms.Network()).WithChannel(tms.Channel()))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed submitting freeze request")
+	}
+	return nil, nil
+}
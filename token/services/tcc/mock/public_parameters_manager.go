@@ -4,7 +4,9 @@ package mock
 import (
 	"sync"
 
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type PublicParametersManager struct {
@@ -21,6 +23,16 @@ type PublicParametersManager struct {
 		result1 []byte
 		result2 error
 	}
+	AuthorityStub        func() view.Identity
+	authorityMutex       sync.RWMutex
+	authorityArgsForCall []struct {
+	}
+	authorityReturns struct {
+		result1 view.Identity
+	}
+	authorityReturnsOnCall map[int]struct {
+		result1 view.Identity
+	}
 	SetAuditorStub        func([]byte) ([]byte, error)
 	setAuditorMutex       sync.RWMutex
 	setAuditorArgsForCall []struct {
@@ -34,6 +46,19 @@ type PublicParametersManager struct {
 		result1 []byte
 		result2 error
 	}
+	SetAuthorityStub        func([]byte) ([]byte, error)
+	setAuthorityMutex       sync.RWMutex
+	setAuthorityArgsForCall []struct {
+		arg1 []byte
+	}
+	setAuthorityReturns struct {
+		result1 []byte
+		result2 error
+	}
+	setAuthorityReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	SetCertifierStub        func([]byte) ([]byte, error)
 	setCertifierMutex       sync.RWMutex
 	setCertifierArgsForCall []struct {
@@ -47,6 +72,26 @@ type PublicParametersManager struct {
 		result1 []byte
 		result2 error
 	}
+	TokenDataHidingStub        func() bool
+	tokenDataHidingMutex       sync.RWMutex
+	tokenDataHidingArgsForCall []struct {
+	}
+	tokenDataHidingReturns struct {
+		result1 bool
+	}
+	tokenDataHidingReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	TokenTypesStub        func() token.TypeRegistry
+	tokenTypesMutex       sync.RWMutex
+	tokenTypesArgsForCall []struct {
+	}
+	tokenTypesReturns struct {
+		result1 token.TypeRegistry
+	}
+	tokenTypesReturnsOnCall map[int]struct {
+		result1 token.TypeRegistry
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -119,6 +164,58 @@ func (fake *PublicParametersManager) AddIssuerReturnsOnCall(i int, result1 []byt
 	}{result1, result2}
 }
 
+func (fake *PublicParametersManager) Authority() view.Identity {
+	fake.authorityMutex.Lock()
+	ret, specificReturn := fake.authorityReturnsOnCall[len(fake.authorityArgsForCall)]
+	fake.authorityArgsForCall = append(fake.authorityArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Authority", []interface{}{})
+	fake.authorityMutex.Unlock()
+	if fake.AuthorityStub != nil {
+		return fake.AuthorityStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.authorityReturns
+	return fakeReturns.result1
+}
+
+func (fake *PublicParametersManager) AuthorityCallCount() int {
+	fake.authorityMutex.RLock()
+	defer fake.authorityMutex.RUnlock()
+	return len(fake.authorityArgsForCall)
+}
+
+func (fake *PublicParametersManager) AuthorityCalls(stub func() view.Identity) {
+	fake.authorityMutex.Lock()
+	defer fake.authorityMutex.Unlock()
+	fake.AuthorityStub = stub
+}
+
+func (fake *PublicParametersManager) AuthorityReturns(result1 view.Identity) {
+	fake.authorityMutex.Lock()
+	defer fake.authorityMutex.Unlock()
+	fake.AuthorityStub = nil
+	fake.authorityReturns = struct {
+		result1 view.Identity
+	}{result1}
+}
+
+func (fake *PublicParametersManager) AuthorityReturnsOnCall(i int, result1 view.Identity) {
+	fake.authorityMutex.Lock()
+	defer fake.authorityMutex.Unlock()
+	fake.AuthorityStub = nil
+	if fake.authorityReturnsOnCall == nil {
+		fake.authorityReturnsOnCall = make(map[int]struct {
+			result1 view.Identity
+		})
+	}
+	fake.authorityReturnsOnCall[i] = struct {
+		result1 view.Identity
+	}{result1}
+}
+
 func (fake *PublicParametersManager) SetAuditor(arg1 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -143,6 +240,8 @@ func (fake *PublicParametersManager) SetAuditor(arg1 []byte) ([]byte, error) {
 }
 
 func (fake *PublicParametersManager) SetAuditorCallCount() int {
+	fake.authorityMutex.RLock()
+	defer fake.authorityMutex.RUnlock()
 	fake.setAuditorMutex.RLock()
 	defer fake.setAuditorMutex.RUnlock()
 	return len(fake.setAuditorArgsForCall)
@@ -187,6 +286,74 @@ func (fake *PublicParametersManager) SetAuditorReturnsOnCall(i int, result1 []by
 	}{result1, result2}
 }
 
+func (fake *PublicParametersManager) SetAuthority(arg1 []byte) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setAuthorityMutex.Lock()
+	ret, specificReturn := fake.setAuthorityReturnsOnCall[len(fake.setAuthorityArgsForCall)]
+	fake.setAuthorityArgsForCall = append(fake.setAuthorityArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	fake.recordInvocation("SetAuthority", []interface{}{arg1Copy})
+	fake.setAuthorityMutex.Unlock()
+	if fake.SetAuthorityStub != nil {
+		return fake.SetAuthorityStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.setAuthorityReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PublicParametersManager) SetAuthorityCallCount() int {
+	fake.setAuthorityMutex.RLock()
+	defer fake.setAuthorityMutex.RUnlock()
+	return len(fake.setAuthorityArgsForCall)
+}
+
+func (fake *PublicParametersManager) SetAuthorityCalls(stub func([]byte) ([]byte, error)) {
+	fake.setAuthorityMutex.Lock()
+	defer fake.setAuthorityMutex.Unlock()
+	fake.SetAuthorityStub = stub
+}
+
+func (fake *PublicParametersManager) SetAuthorityArgsForCall(i int) []byte {
+	fake.setAuthorityMutex.RLock()
+	defer fake.setAuthorityMutex.RUnlock()
+	argsForCall := fake.setAuthorityArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PublicParametersManager) SetAuthorityReturns(result1 []byte, result2 error) {
+	fake.setAuthorityMutex.Lock()
+	defer fake.setAuthorityMutex.Unlock()
+	fake.SetAuthorityStub = nil
+	fake.setAuthorityReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PublicParametersManager) SetAuthorityReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.setAuthorityMutex.Lock()
+	defer fake.setAuthorityMutex.Unlock()
+	fake.SetAuthorityStub = nil
+	if fake.setAuthorityReturnsOnCall == nil {
+		fake.setAuthorityReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.setAuthorityReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PublicParametersManager) SetCertifier(arg1 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -211,6 +378,8 @@ func (fake *PublicParametersManager) SetCertifier(arg1 []byte) ([]byte, error) {
 }
 
 func (fake *PublicParametersManager) SetCertifierCallCount() int {
+	fake.setAuthorityMutex.RLock()
+	defer fake.setAuthorityMutex.RUnlock()
 	fake.setCertifierMutex.RLock()
 	defer fake.setCertifierMutex.RUnlock()
 	return len(fake.setCertifierArgsForCall)
@@ -255,6 +424,110 @@ func (fake *PublicParametersManager) SetCertifierReturnsOnCall(i int, result1 []
 	}{result1, result2}
 }
 
+func (fake *PublicParametersManager) TokenDataHiding() bool {
+	fake.tokenDataHidingMutex.Lock()
+	ret, specificReturn := fake.tokenDataHidingReturnsOnCall[len(fake.tokenDataHidingArgsForCall)]
+	fake.tokenDataHidingArgsForCall = append(fake.tokenDataHidingArgsForCall, struct {
+	}{})
+	fake.recordInvocation("TokenDataHiding", []interface{}{})
+	fake.tokenDataHidingMutex.Unlock()
+	if fake.TokenDataHidingStub != nil {
+		return fake.TokenDataHidingStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.tokenDataHidingReturns
+	return fakeReturns.result1
+}
+
+func (fake *PublicParametersManager) TokenDataHidingCallCount() int {
+	fake.tokenDataHidingMutex.RLock()
+	defer fake.tokenDataHidingMutex.RUnlock()
+	return len(fake.tokenDataHidingArgsForCall)
+}
+
+func (fake *PublicParametersManager) TokenDataHidingCalls(stub func() bool) {
+	fake.tokenDataHidingMutex.Lock()
+	defer fake.tokenDataHidingMutex.Unlock()
+	fake.TokenDataHidingStub = stub
+}
+
+func (fake *PublicParametersManager) TokenDataHidingReturns(result1 bool) {
+	fake.tokenDataHidingMutex.Lock()
+	defer fake.tokenDataHidingMutex.Unlock()
+	fake.TokenDataHidingStub = nil
+	fake.tokenDataHidingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PublicParametersManager) TokenDataHidingReturnsOnCall(i int, result1 bool) {
+	fake.tokenDataHidingMutex.Lock()
+	defer fake.tokenDataHidingMutex.Unlock()
+	fake.TokenDataHidingStub = nil
+	if fake.tokenDataHidingReturnsOnCall == nil {
+		fake.tokenDataHidingReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.tokenDataHidingReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PublicParametersManager) TokenTypes() token.TypeRegistry {
+	fake.tokenTypesMutex.Lock()
+	ret, specificReturn := fake.tokenTypesReturnsOnCall[len(fake.tokenTypesArgsForCall)]
+	fake.tokenTypesArgsForCall = append(fake.tokenTypesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("TokenTypes", []interface{}{})
+	fake.tokenTypesMutex.Unlock()
+	if fake.TokenTypesStub != nil {
+		return fake.TokenTypesStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.tokenTypesReturns
+	return fakeReturns.result1
+}
+
+func (fake *PublicParametersManager) TokenTypesCallCount() int {
+	fake.tokenTypesMutex.RLock()
+	defer fake.tokenTypesMutex.RUnlock()
+	return len(fake.tokenTypesArgsForCall)
+}
+
+func (fake *PublicParametersManager) TokenTypesCalls(stub func() token.TypeRegistry) {
+	fake.tokenTypesMutex.Lock()
+	defer fake.tokenTypesMutex.Unlock()
+	fake.TokenTypesStub = stub
+}
+
+func (fake *PublicParametersManager) TokenTypesReturns(result1 token.TypeRegistry) {
+	fake.tokenTypesMutex.Lock()
+	defer fake.tokenTypesMutex.Unlock()
+	fake.TokenTypesStub = nil
+	fake.tokenTypesReturns = struct {
+		result1 token.TypeRegistry
+	}{result1}
+}
+
+func (fake *PublicParametersManager) TokenTypesReturnsOnCall(i int, result1 token.TypeRegistry) {
+	fake.tokenTypesMutex.Lock()
+	defer fake.tokenTypesMutex.Unlock()
+	fake.TokenTypesStub = nil
+	if fake.tokenTypesReturnsOnCall == nil {
+		fake.tokenTypesReturnsOnCall = make(map[int]struct {
+			result1 token.TypeRegistry
+		})
+	}
+	fake.tokenTypesReturnsOnCall[i] = struct {
+		result1 token.TypeRegistry
+	}{result1}
+}
+
 func (fake *PublicParametersManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -264,6 +537,10 @@ func (fake *PublicParametersManager) Invocations() map[string][][]interface{} {
 	defer fake.setAuditorMutex.RUnlock()
 	fake.setCertifierMutex.RLock()
 	defer fake.setCertifierMutex.RUnlock()
+	fake.tokenDataHidingMutex.RLock()
+	defer fake.tokenDataHidingMutex.RUnlock()
+	fake.tokenTypesMutex.RLock()
+	defer fake.tokenTypesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
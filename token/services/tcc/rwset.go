@@ -6,8 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 package tcc
 
 import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
 )
 
 type rwsWrapper struct {
@@ -53,3 +57,20 @@ func (rwset *rwsWrapper) NumWrites(ns string) int {
 func (rwset *rwsWrapper) Namespaces() []string {
 	return nil
 }
+
+// ledger gives the validator access to the world state and to the timestamp of the transaction
+type ledger struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func (l *ledger) GetState(key string) ([]byte, error) {
+	return l.stub.GetState(key)
+}
+
+func (l *ledger) TxTimestamp() (time.Time, error) {
+	ts, err := l.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed getting the timestamp of the transaction")
+	}
+	return ptypes.Timestamp(ts)
+}
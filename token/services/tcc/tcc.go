@@ -15,6 +15,7 @@ import (
 	"os"
 	"runtime/debug"
 
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/pkg/errors"
@@ -22,7 +23,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
-	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -36,7 +37,10 @@ const (
 	AddAuditorFunction        = "addAuditor"
 	AddIssuerFunction         = "addIssuer"
 	AddCertifierFunction      = "addCertifier"
+	AddAuthorityFunction      = "addAuthority"
 	QueryTokensFunctions      = "queryTokens"
+	FreezeFunction            = "freeze"
+	SeizeFunction             = "seize"
 
 	PublicParamsPathVarEnv = "PUBLIC_PARAMS_FILE_PATH"
 )
@@ -49,12 +53,6 @@ func (a *SetupAction) GetSetupParameters() ([]byte, error) {
 	return a.SetupParameters, nil
 }
 
-type allIssuersValid struct{}
-
-func (i *allIssuersValid) Validate(creator view2.Identity, tokenType string) error {
-	return nil
-}
-
 //go:generate counterfeiter -o mock/validator.go -fake-name Validator . Validator
 
 type Validator interface {
@@ -67,6 +65,10 @@ type PublicParametersManager interface {
 	AddIssuer(issuer []byte) ([]byte, error)
 	SetAuditor(auditor []byte) ([]byte, error)
 	SetCertifier(certifier []byte) ([]byte, error)
+	SetAuthority(authority []byte) ([]byte, error)
+	Authority() view.Identity
+	TokenTypes() token2.TypeRegistry
+	TokenDataHiding() bool
 }
 
 type TokenChaincode struct {
@@ -104,7 +106,8 @@ func (cc *TokenChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 		return shim.Error("failed to decode public parameters: " + err.Error())
 	}
 
-	issuingValidator := &allIssuersValid{}
+	// no issue action is written here
+	issuingValidator := translator.NewIssuingValidator(nil)
 	rwset := &rwsWrapper{stub: stub}
 	w := translator.New(issuingValidator, "", rwset, "")
 	action := &SetupAction{
@@ -158,11 +161,26 @@ func (cc *TokenChaincode) Invoke(stub shim.ChaincodeStubInterface) (res pb.Respo
 				return shim.Error("request to add certifier is empty")
 			}
 			return cc.addCertifier(args[1], stub)
+		case AddAuthorityFunction:
+			if len(args) != 2 {
+				return shim.Error("request to add authority is empty")
+			}
+			return cc.addAuthority(args[1], stub)
 		case QueryTokensFunctions:
 			if len(args) != 2 {
 				return shim.Error("request to retrieve tokens is empty")
 			}
 			return cc.queryTokens(args[1], stub)
+		case FreezeFunction:
+			if len(args) != 2 {
+				return shim.Error("empty freeze request")
+			}
+			return cc.freeze(args[1], stub)
+		case SeizeFunction:
+			if len(args) != 3 {
+				return shim.Error("seize request must carry the seize request and the token request")
+			}
+			return cc.seize(args[1], args[2], stub)
 		default:
 			return shim.Error(fmt.Sprintf("function not [%s] recognized", f))
 		}
@@ -206,8 +224,7 @@ func (cc *TokenChaincode) init(stub shim.ChaincodeStubInterface) error {
 	logger.Infof("reading public parameters...")
 
 	rwset := &rwsWrapper{stub: stub}
-	issuingValidator := &allIssuersValid{}
-	w := translator.New(issuingValidator, stub.GetTxID(), rwset, "")
+	w := translator.New(translator.NewIssuingValidator(nil), stub.GetTxID(), rwset, "")
 	ppRaw, err := w.ReadSetupParameters()
 	if err != nil {
 		return errors.Wrapf(err, "failed to retrieve public parameters")
@@ -244,29 +261,38 @@ func (cc *TokenChaincode) init(stub shim.ChaincodeStubInterface) error {
 }
 
 func (cc *TokenChaincode) invoke(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
-	validator, err := cc.validator(stub)
+	actions, err := cc.verify(raw, &ledger{stub: stub}, stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
+	return cc.write(actions, raw, &rwsWrapper{stub: stub}, stub)
+}
 
-	// Verify
-	actions, err := validator.UnmarshallAndVerify(stub, stub.GetTxID(), raw)
+// verify returns the actions of the passed token request, once verified against the passed ledger
+func (cc *TokenChaincode) verify(raw []byte, l token.Ledger, stub shim.ChaincodeStubInterface) ([]interface{}, error) {
+	validator, err := cc.validator(stub)
 	if err != nil {
-		return shim.Error("failed to verify token request: " + err.Error())
+		return nil, err
+	}
+	actions, err := validator.UnmarshallAndVerify(l, stub.GetTxID(), raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify token request")
 	}
+	return actions, nil
+}
 
-	// Write
-	rwset := &rwsWrapper{stub: stub}
-	issuingValidator := &allIssuersValid{}
+// write translates the passed actions, and the token request they come from, into the passed rwset
+func (cc *TokenChaincode) write(actions []interface{}, raw []byte, rwset translator.RWSet, stub shim.ChaincodeStubInterface) pb.Response {
+	// Write, only the issuers listed in the public parameters can issue.
+	// The public parameters manager has been instantiated together with the validator.
+	issuingValidator := translator.NewIssuingValidator(cc.PublicParametersManager.TokenTypes())
 	w := translator.New(issuingValidator, stub.GetTxID(), rwset, "")
 	for _, action := range actions {
-		err = w.Write(action)
-		if err != nil {
+		if err := w.Write(action); err != nil {
 			return shim.Error("failed to write token action: " + err.Error())
 		}
 	}
-	err = w.CommitTokenRequest(raw)
-	if err != nil {
+	if err := w.CommitTokenRequest(raw); err != nil {
 		return shim.Error("failed to write token request:" + err.Error())
 	}
 	return shim.Success(nil)
@@ -274,8 +300,7 @@ func (cc *TokenChaincode) invoke(raw []byte, stub shim.ChaincodeStubInterface) p
 
 func (cc *TokenChaincode) queryPublicParams(stub shim.ChaincodeStubInterface) pb.Response {
 	rwset := &rwsWrapper{stub: stub}
-	issuingValidator := &allIssuersValid{}
-	w := translator.New(issuingValidator, stub.GetTxID(), rwset, "")
+	w := translator.New(translator.NewIssuingValidator(nil), stub.GetTxID(), rwset, "")
 	raw, err := w.ReadSetupParameters()
 	if err != nil {
 		shim.Error("failed to retrieve public parameters: " + err.Error())
@@ -297,9 +322,8 @@ func (cc *TokenChaincode) addIssuer(args [][]byte, stub shim.ChaincodeStubInterf
 		return shim.Error("failed to serialize public parameters")
 	}
 
-	issuingValidator := &allIssuersValid{}
 	rwset := &rwsWrapper{stub: stub}
-	w := translator.New(issuingValidator, "", rwset, "")
+	w := translator.New(translator.NewIssuingValidator(nil), "", rwset, "")
 	setupAction := &SetupAction{SetupParameters: raw}
 	if err := w.Write(setupAction); err != nil {
 		return shim.Error("failed to update issuing policy: " + err.Error())
@@ -360,6 +384,58 @@ func (cc *TokenChaincode) addCertifier(certifier []byte, stub shim.ChaincodeStub
 	return shim.Success(raw)
 }
 
+func (cc *TokenChaincode) addAuthority(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	ppm, err := cc.publicParametersManager(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	request := &AuthorityRequest{}
+	if err := request.FromBytes(raw); err != nil {
+		return shim.Error("failed to unmarshal authority request: " + err.Error())
+	}
+	if len(request.Authority) == 0 {
+		return shim.Error("invalid authority request: empty authority")
+	}
+	if err := authenticateAuthorityRequest(ppm, request, stub); err != nil {
+		return shim.Error("failed to authenticate authority request: " + err.Error())
+	}
+
+	raw, err = ppm.SetAuthority(request.Authority)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	w := &translator.Translator{RWSet: &rwsWrapper{stub: stub}}
+	setupAction := &SetupAction{SetupParameters: raw}
+	if err := w.Write(setupAction); err != nil {
+		return shim.Error("failed to write authority key")
+	}
+	return shim.Success(raw)
+}
+
+// authenticateAuthorityRequest checks that the passed request is signed by the current authority, if any.
+// Otherwise, the creator of the transaction must be an admin.
+func authenticateAuthorityRequest(ppm PublicParametersManager, request *AuthorityRequest, stub shim.ChaincodeStubInterface) error {
+	if len(ppm.Authority()) == 0 {
+		admin, err := cid.HasOUValue(stub, AdminOU)
+		if err != nil {
+			return errors.Wrap(err, "failed getting the creator of the transaction")
+		}
+		if !admin {
+			return errors.New("the first authority must be set by an admin")
+		}
+		return nil
+	}
+	msg, err := request.MessageToSign()
+	if err != nil {
+		return err
+	}
+	if err := verifyAuthoritySignature(ppm, msg, request.Signature); err != nil {
+		return err
+	}
+	return useNonce(request.Nonce, stub)
+}
+
 func (cc *TokenChaincode) queryTokens(idsRaw []byte, stub shim.ChaincodeStubInterface) pb.Response {
 	var ids []*token2.Id
 	if err := json.Unmarshal(idsRaw, &ids); err != nil {
@@ -370,8 +446,7 @@ func (cc *TokenChaincode) queryTokens(idsRaw []byte, stub shim.ChaincodeStubInte
 	logger.Debugf("query tokens [%v]...", ids)
 
 	rwset := &rwsWrapper{stub: stub}
-	issuingValidator := &allIssuersValid{}
-	w := translator.New(issuingValidator, stub.GetTxID(), rwset, "")
+	w := translator.New(translator.NewIssuingValidator(nil), stub.GetTxID(), rwset, "")
 	res, err := w.QueryTokens(ids)
 	if err != nil {
 		logger.Errorf("failed query tokens [%v]: [%s]", ids, err)
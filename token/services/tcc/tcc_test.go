@@ -6,10 +6,29 @@ SPDX-License-Identifier: Apache-2.0
 package tcc_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	chaincode2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+	mock2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator/mock"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -154,6 +173,290 @@ var _ = Describe("ccvalidator", func() {
 			})
 		})
 
+		Describe("Add Authority", func() {
+			var (
+				authority       []byte
+				authoritySigner driver.Signer
+				request         *chaincode2.AuthorityRequest
+			)
+			BeforeEach(func() {
+				var err error
+				authority, authoritySigner, _, err = fabric.NewSigner()
+				Expect(err).NotTo(HaveOccurred())
+				request = &chaincode2.AuthorityRequest{Authority: []byte("authority")}
+				fakePPM.SetAuthorityReturns([]byte("authority was added"), nil)
+			})
+			invoke := func() *pb.Response {
+				raw, err := request.Bytes()
+				Expect(err).NotTo(HaveOccurred())
+				fakestub.GetArgsReturns([][]byte{[]byte("addAuthority"), raw})
+				response := chaincode.Invoke(fakestub)
+				return &response
+			}
+			When("no authority is set and the creator is an admin", func() {
+				BeforeEach(func() {
+					fakestub.GetCreatorReturns(creator("admin"), nil)
+				})
+				It("succeeds", func() {
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(200)))
+					Expect(response.Payload).To(Equal([]byte("authority was added")))
+					Expect(fakePPM.SetAuthorityArgsForCall(0)).To(Equal([]byte("authority")))
+				})
+			})
+			When("no authority is set and the creator is not an admin", func() {
+				BeforeEach(func() {
+					fakestub.GetCreatorReturns(creator("client"), nil)
+				})
+				It("fails", func() {
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("the first authority must be set by an admin"))
+					Expect(fakePPM.SetAuthorityCallCount()).To(Equal(0))
+				})
+			})
+			When("the request is signed by the current authority", func() {
+				BeforeEach(func() {
+					fakePPM.AuthorityReturns(authority)
+					request.Nonce = []byte("nonce")
+					msg, err := request.MessageToSign()
+					Expect(err).NotTo(HaveOccurred())
+					request.Signature, err = authoritySigner.Sign(msg)
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("succeeds", func() {
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(200)))
+					Expect(fakePPM.SetAuthorityArgsForCall(0)).To(Equal([]byte("authority")))
+				})
+				It("cannot be submitted twice", func() {
+					fakestub.GetStateReturnsOnCall(1, []byte("a previous transaction"), nil)
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("request already submitted"))
+					Expect(fakePPM.SetAuthorityCallCount()).To(Equal(0))
+				})
+			})
+			When("an authority is set and the request is not signed by it", func() {
+				BeforeEach(func() {
+					fakePPM.AuthorityReturns(authority)
+					fakestub.GetCreatorReturns(creator("admin"), nil)
+					request.Nonce = []byte("nonce")
+					request.Signature = []byte("signature")
+				})
+				It("fails", func() {
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("invalid authority signature"))
+					Expect(fakePPM.SetAuthorityCallCount()).To(Equal(0))
+				})
+			})
+			When("addAuthority fails", func() {
+				BeforeEach(func() {
+					fakestub.GetCreatorReturns(creator("admin"), nil)
+					fakePPM.SetAuthorityReturns(nil, errors.New("flying monkeys"))
+				})
+				It("fails", func() {
+					response := invoke()
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("flying monkeys"))
+				})
+			})
+		})
+
+		Describe("Freeze", func() {
+			BeforeEach(func() {
+				request := &chaincode2.FreezeRequest{
+					TokenIDs:  []*token2.Id{{TxId: "tx1", Index: 0}},
+					Nonce:     []byte("nonce"),
+					Signature: []byte("signature"),
+				}
+				raw, err := request.Bytes()
+				Expect(err).NotTo(HaveOccurred())
+				fakestub.GetArgsReturns([][]byte{[]byte("freeze"), raw})
+			})
+			When("no authority is set", func() {
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response).NotTo(BeNil())
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("no authority is set in the public parameters"))
+					Expect(fakestub.PutStateCallCount()).To(Equal(0))
+				})
+			})
+			When("the authority is not a valid x509 identity", func() {
+				BeforeEach(func() {
+					fakePPM.AuthorityReturns([]byte("authority"))
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response).NotTo(BeNil())
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("failed to verify freeze request"))
+					Expect(fakestub.PutStateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("Seize is called without a seize request", func() {
+			BeforeEach(func() {
+				fakestub.GetArgsReturns([][]byte{[]byte("seize"), []byte("token request")})
+			})
+			It("fails", func() {
+				response := chaincode.Invoke(fakestub)
+				Expect(response).NotTo(BeNil())
+				Expect(response.Status).To(Equal(int32(500)))
+			})
+		})
+
+		Describe("Seize", func() {
+			var (
+				state        map[string][]byte
+				fakeIssue    *mock2.IssueAction
+				tokenRequest *driver.TokenRequest
+			)
+			BeforeEach(func() {
+				authority, authoritySigner, _, err := fabric.NewSigner()
+				Expect(err).NotTo(HaveOccurred())
+				fakePPM.AuthorityReturns(authority)
+				fakePPM.TokenTypesReturns(token2.TypeRegistry{}.AddIssuer("USD", []byte("alice")))
+				fakestub.GetTxIDReturns("tx2")
+
+				setupKey, err := keys.CreateSetupKey()
+				Expect(err).NotTo(HaveOccurred())
+				frozenKey, err := keys.CreateFrozenTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				tokenKey, err := keys.CreateTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				record, err := (&translator.FreezeRecord{TxID: "tx0"}).Bytes()
+				Expect(err).NotTo(HaveOccurred())
+				tok, err := json.Marshal(&token2.Token{Owner: &token2.Owner{Raw: []byte("bob")}, Type: "USD", Quantity: "0x0a"})
+				Expect(err).NotTo(HaveOccurred())
+				state = map[string][]byte{setupKey: []byte("public parameters"), frozenKey: record, tokenKey: tok}
+				fakestub.GetStateStub = func(key string) ([]byte, error) {
+					return state[key], nil
+				}
+
+				fakeIssue = &mock2.IssueAction{}
+				fakeIssue.GetIssuerReturns([]byte("alice"))
+				fakeIssue.GetTokenTypesReturns([]string{"USD"})
+				fakeIssue.GetIssuedQuantitiesReturns(map[string]token2.Quantity{"USD": token2.NewQuantityFromUInt64(10)}, nil)
+				fakeValidator.UnmarshallAndVerifyReturns([]interface{}{fakeIssue}, nil)
+				tokenRequest = &driver.TokenRequest{Issues: [][]byte{[]byte("issue")}}
+
+				request := &chaincode2.SeizeRequest{TokenIDs: []*token2.Id{{TxId: "tx1", Index: 0}}}
+				msg, err := request.MessageToSign("tx2")
+				Expect(err).NotTo(HaveOccurred())
+				request.Signature, err = authoritySigner.Sign(msg)
+				Expect(err).NotTo(HaveOccurred())
+				raw, err := request.Bytes()
+				Expect(err).NotTo(HaveOccurred())
+				fakestub.GetArgsStub = func() [][]byte {
+					tr, err := tokenRequest.Bytes()
+					Expect(err).NotTo(HaveOccurred())
+					return [][]byte{[]byte("seize"), raw, tr}
+				}
+			})
+			When("the seized funds are re-issued", func() {
+				It("succeeds", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(200)))
+					Expect(fakestub.DelStateCallCount()).To(Equal(1))
+				})
+			})
+			When("the supply of the seized type is recorded", func() {
+				var supplyKey string
+				BeforeEach(func() {
+					var err error
+					supplyKey, err = keys.CreateSupplyKey("USD")
+					Expect(err).NotTo(HaveOccurred())
+					state[supplyKey] = []byte("100")
+				})
+				It("validates and writes the re-issue without changing the supply", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(200)))
+
+					// the validator checks the max supply against the supply without the seized funds
+					l, _, _ := fakeValidator.UnmarshallAndVerifyArgsForCall(0)
+					raw, err := l.GetState(supplyKey)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(raw)).To(Equal("90"))
+
+					var written []string
+					for i := 0; i < fakestub.PutStateCallCount(); i++ {
+						key, value := fakestub.PutStateArgsForCall(i)
+						if key == supplyKey {
+							written = append(written, string(value))
+						}
+					}
+					Expect(written).To(Equal([]string{"100"}))
+				})
+			})
+			When("the public parameters hide the token data", func() {
+				BeforeEach(func() {
+					fakePPM.TokenDataHidingReturns(true)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("seize is not supported when the public parameters hide the token data"))
+					Expect(fakestub.DelStateCallCount()).To(Equal(0))
+				})
+			})
+			When("the re-issued quantity differs from the seized one", func() {
+				BeforeEach(func() {
+					fakeIssue.GetIssuedQuantitiesReturns(map[string]token2.Quantity{"USD": token2.NewQuantityFromUInt64(20)}, nil)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("re-issued [20] of token type [USD], seized [10]"))
+				})
+			})
+			When("the re-issued type differs from the seized one", func() {
+				BeforeEach(func() {
+					fakeIssue.GetIssuedQuantitiesReturns(map[string]token2.Quantity{"EUR": token2.NewQuantityFromUInt64(10)}, nil)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("token type [USD] is seized but not re-issued"))
+				})
+			})
+			When("the re-issued funds are hidden", func() {
+				BeforeEach(func() {
+					fakeIssue.GetIssuedQuantitiesReturns(nil, nil)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("the re-issued funds are hidden"))
+				})
+			})
+			When("the seized token is hidden", func() {
+				BeforeEach(func() {
+					tokenKey, err := keys.CreateTokenKey("tx1", 0)
+					Expect(err).NotTo(HaveOccurred())
+					state[tokenKey] = []byte("a commitment")
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("the type and the quantity of the token are hidden"))
+				})
+			})
+			When("the token request also transfers tokens", func() {
+				BeforeEach(func() {
+					tokenRequest.Transfers = [][]byte{[]byte("transfer")}
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("the token request must only re-issue the seized funds"))
+				})
+			})
+		})
+
 		Context("Invoke is called correctly with a token request", func() {
 			BeforeEach(func() {
 				var err error
@@ -171,6 +474,26 @@ var _ = Describe("ccvalidator", func() {
 			})
 		})
 
+		Context("Invoke is called with an issue by an issuer that is not allowed", func() {
+			BeforeEach(func() {
+				args := make([][]byte, 2)
+				args[0] = []byte("invoke")
+				args[1] = []byte("token request")
+				fakestub.GetArgsReturns(args)
+				fakeIssue := &mock2.IssueAction{}
+				fakeIssue.GetIssuerReturns([]byte("bob"))
+				fakeIssue.GetTokenTypesReturns([]string{"USD"})
+				fakeValidator.UnmarshallAndVerifyReturns([]interface{}{fakeIssue}, nil)
+				fakePPM.TokenTypesReturns(token2.TypeRegistry{}.AddIssuer("USD", []byte("alice")))
+			})
+			It("fails", func() {
+				response := chaincode.Invoke(fakestub)
+				Expect(response).NotTo(BeNil())
+				Expect(response.Status).To(Equal(int32(500)))
+				Expect(response.Message).To(ContainSubstring("issuer is not allowed to issue token type [USD]"))
+			})
+		})
+
 		Context("When VerifyTokenRequest fails", func() {
 			BeforeEach(func() {
 				var err error
@@ -191,3 +514,23 @@ var _ = Describe("ccvalidator", func() {
 
 	})
 })
+
+// creator returns the serialized identity of a transaction creator whose certificate has the passed organizational unit
+func creator(ou string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "creator", OrganizationalUnit: []string{ou}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	raw, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   "Org1MSP",
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return raw
+}
@@ -166,9 +166,19 @@ func (a *AuditApproveView) Call(context view.Context) (interface{}, error) {
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed getting audit records for tx [%s]", a.tx.ID())
 	}
-	if err := auditdb.GetAuditDB(context, a.w).Append(auditRecord); err != nil {
+	// the audit policy is evaluated before signing, the records are appended only if the transaction complies
+	if err := auditor.Admit(context, a.tx.tokenService(), auditdb.GetAuditDB(context, a.w), auditRecord); err != nil {
+		if v, ok := err.(*auditor.Violation); ok {
+			// let the initiator know why the transaction is rejected
+			if err := context.Session().SendError([]byte(v.Error())); err != nil {
+				logger.Errorf("failed sending the rejection of tx [%s] [%s]", a.tx.ID(), err)
+			}
+			return nil, v
+		}
 		return nil, errors.WithMessagef(err, "failed appening audit records for tx [%s]", a.tx.ID())
 	}
+	// the records are confirmed, or deleted, once the ledger decides on the transaction
+	auditdb.GetFinality(context, a.tx.Network(), a.tx.Channel()).Track(a.tx.ID())
 	logger.Debugf("store audit records...done")
 
 	if err := a.tx.EndorseWithSigner(aid, signer); err != nil {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 
 	"github.com/pkg/errors"
 
@@ -105,7 +106,7 @@ func (c *collectActionsView) Call(context view.Context) (interface{}, error) {
 func (c *collectActionsView) collectLocal(context view.Context, actionTransfer *ActionTransfer, w *token.OwnerWallet) error {
 	party := actionTransfer.From
 
-	err := c.tx.Transfer(w, actionTransfer.Type, []uint64{actionTransfer.Amount}, []view.Identity{actionTransfer.Recipient})
+	err := c.tx.Transfer(w, actionTransfer.Type, []token2.Quantity{token2.NewQuantityFromUInt64(actionTransfer.Amount)}, []view.Identity{actionTransfer.Recipient})
 	if err != nil {
 		return errors.Wrap(err, "failed creating transfer for action")
 	}
@@ -17,6 +17,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.zkat")
@@ -43,7 +44,7 @@ func NewNamespace(tx *endorser.Transaction, opts ...TxOption) (*Namespace, error
 	return n, nil
 }
 
-func (t *Namespace) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q uint64) error {
+func (t *Namespace) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q token2.Quantity) error {
 	action, err := t.TokenRequest.Issue(wallet, receiver, typ, q)
 	if err != nil {
 		return errors.Wrapf(err, "failed issuing")
@@ -51,7 +52,7 @@ func (t *Namespace) Issue(wallet *token.IssuerWallet, receiver view.Identity, ty
 	return t.updateRWSetAndMetadata(action)
 }
 
-func (t *Namespace) Transfer(wallet *token.OwnerWallet, typ string, values []uint64, owners []view.Identity, opts ...token.TransferOption) error {
+func (t *Namespace) Transfer(wallet *token.OwnerWallet, typ string, values []token2.Quantity, owners []view.Identity, opts ...token.TransferOption) error {
 	action, err := t.TokenRequest.Transfer(wallet, typ, values, owners, opts...)
 	if err != nil {
 		return errors.Wrapf(err, "failed issuing")
@@ -81,6 +82,9 @@ func (t *Namespace) Signers() []view.Identity {
 		ids = append(ids, issue.Issuer)
 	}
 	for _, transfer := range t.TokenRequest.Transfers() {
+		if transfer.UnsignedSenders {
+			continue
+		}
 		for _, sender := range transfer.Senders {
 			if t.tokenService().WalletManager().OwnerWalletByIdentity(sender) != nil {
 				ids = append(ids, sender)
@@ -177,7 +181,7 @@ func (t *Namespace) updateRWSetAndMetadata(action interface{}) error {
 
 	// commit action, if any
 	if action != nil {
-		issuingValidator := &allIssuersValid{}
+		issuingValidator := translator.NewIssuingValidator(t.TokenRequest.TokenService.PublicParametersManager().TokenTypes())
 		w := translator.New(issuingValidator, t.tx.ID(), rws, ns)
 		err = w.Write(action)
 		if err != nil {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 
 	"github.com/pkg/errors"
 
@@ -74,7 +75,7 @@ func (c *collectActionsView) collectLocal(context view.Context, actionTransfer *
 	party := actionTransfer.From
 	logger.Debugf("collect local from [%s]", party)
 
-	err := c.tx.Transfer(w, actionTransfer.Type, []uint64{actionTransfer.Amount}, []view.Identity{actionTransfer.Recipient})
+	err := c.tx.Transfer(w, actionTransfer.Type, []token2.Quantity{token2.NewQuantityFromUInt64(actionTransfer.Amount)}, []view.Identity{actionTransfer.Recipient})
 	if err != nil {
 		return errors.Wrap(err, "failed creating transfer for action")
 	}
@@ -0,0 +1,213 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+const (
+	// DefaultMaxInputs is the default maximum number of inputs of a consolidation transaction
+	DefaultMaxInputs = 16
+	// DefaultConsolidationThreshold is the default number of tokens a wallet must exceed to be consolidated
+	DefaultConsolidationThreshold = 1
+)
+
+// Consolidation contains the input information to merge the tokens of a wallet
+type Consolidation struct {
+	// Wallet is the identifier of the owner wallet whose tokens are merged
+	Wallet string
+	// Type of the tokens to merge
+	Type string
+	// MaxInputs is the maximum number of inputs of each consolidation transaction, DefaultMaxInputs if zero
+	MaxInputs int
+	// Threshold is the number of tokens the wallet must exceed to be consolidated, DefaultConsolidationThreshold if zero
+	Threshold int
+	// MaxTransactions is the maximum number of consolidation transactions, unbounded if zero
+	MaxTransactions int
+	// TxOptions are the options used to create the transactions
+	TxOptions []TxOption
+}
+
+// ConsolidateView merges the smallest unspent tokens of a wallet into a single token, owned by a fresh identity
+// of the same wallet. Each transaction spends at most MaxInputs tokens, and it goes through the regular endorsement,
+// ordering and finality flow. Transactions follow one another until the wallet holds no more than Threshold tokens
+// of the type, or MaxTransactions are committed.
+// The view returns the identifiers of the committed transactions.
+type ConsolidateView struct {
+	*Consolidation
+}
+
+func NewConsolidateView(consolidation *Consolidation) *ConsolidateView {
+	return &ConsolidateView{Consolidation: consolidation}
+}
+
+func (c *ConsolidateView) Call(context view.Context) (interface{}, error) {
+	maxInputs := c.MaxInputs
+	if maxInputs == 0 {
+		maxInputs = DefaultMaxInputs
+	}
+	if maxInputs < 2 {
+		return nil, errors.Errorf("a consolidation needs at least 2 inputs, got [%d]", maxInputs)
+	}
+	threshold := c.Threshold
+	if threshold == 0 {
+		threshold = DefaultConsolidationThreshold
+	}
+
+	var txIDs []string
+	// each transaction must reduce the number of tokens, otherwise the consolidation stops
+	numTokens := -1
+	for c.MaxTransactions == 0 || len(txIDs) < c.MaxTransactions {
+		txID, n, err := c.consolidate(context, maxInputs, threshold, numTokens)
+		if err != nil {
+			return txIDs, errors.WithMessagef(err, "failed consolidating tokens of type [%s] in wallet [%s]", c.Type, c.Wallet)
+		}
+		if len(txID) == 0 {
+			break
+		}
+		txIDs = append(txIDs, txID)
+		numTokens = n
+	}
+	logger.Debugf("consolidated tokens of type [%s] in wallet [%s] with [%d] transactions", c.Type, c.Wallet, len(txIDs))
+	return txIDs, nil
+}
+
+// consolidate runs a consolidation transaction, if the wallet holds more than threshold tokens, and less than
+// the passed previous number of tokens, if not negative.
+// It returns the identifier of the transaction, the empty string if there is nothing to consolidate,
+// and the number of tokens of the wallet before the transaction.
+func (c *ConsolidateView) consolidate(context view.Context, maxInputs int, threshold int, previous int) (string, int, error) {
+	tx, err := NewAnonymousTransaction(context, c.TxOptions...)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed creating transaction")
+	}
+	tms := tx.TokenService()
+	wallet := tms.WalletManager().OwnerWallet(c.Wallet)
+	if wallet == nil {
+		return "", 0, errors.Errorf("wallet [%s] not found", c.Wallet)
+	}
+
+	unspentTokens, err := wallet.ListUnspentTokens(token.WithType(c.Type))
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed listing unspent tokens")
+	}
+	n := len(unspentTokens.Tokens)
+	if !needsConsolidation(n, threshold, previous) {
+		logger.Debugf("[%d] tokens of type [%s] in wallet [%s], nothing to consolidate", n, c.Type, c.Wallet)
+		return "", n, nil
+	}
+
+	// merge the smallest tokens that are not locked by other transactions
+	locked := map[string]bool{}
+	if locks, err := tms.SelectorManager().Locks(); err == nil {
+		for _, lock := range locks {
+			if lock.ID != nil {
+				locked[lock.ID.String()] = true
+			}
+		}
+	}
+	ppm := tms.PublicParametersManager()
+	quantities, sum, err := consolidationInputs(unspentTokens.Tokens, locked, maxInputs, ppm.Precision(), ppm.MaxTokenValue())
+	if err != nil {
+		return "", n, err
+	}
+	if len(quantities) < 2 {
+		logger.Debugf("[%d] tokens of type [%s] in wallet [%s] can be merged, nothing to consolidate", len(quantities), c.Type, c.Wallet)
+		return "", n, nil
+	}
+
+	// The selector walks the tokens smallest first and skips the locked ones.
+	// Each skipped token is replaced by a larger one, therefore the inputs are at most maxInputs.
+	recipient, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return "", n, errors.Wrap(err, "failed getting a fresh identity")
+	}
+	if err := tx.Transfer(
+		wallet,
+		c.Type,
+		[]token2.Quantity{sum},
+		[]view.Identity{recipient},
+		token.WithSelectionStrategy(selector.SmallestFirst()),
+	); err != nil {
+		tx.Release()
+		return "", n, errors.Wrapf(err, "failed merging [%d] tokens", len(quantities))
+	}
+
+	if _, err := context.RunView(NewCollectEndorsementsView(tx)); err != nil {
+		tx.Release()
+		return "", n, errors.Wrap(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(NewOrderingAndFinalityView(tx)); err != nil {
+		tx.Release()
+		return "", n, errors.Wrap(err, "failed ordering transaction")
+	}
+	logger.Debugf("merged [%d] tokens of type [%s] in wallet [%s] into [%s], tx [%s]", len(quantities), c.Type, c.Wallet, sum.Decimal(), tx.ID())
+	return tx.ID(), n, nil
+}
+
+// needsConsolidation returns true if a wallet with the passed number of tokens holds more than threshold tokens,
+// and less than the passed previous number of tokens, if not negative
+func needsConsolidation(n int, threshold int, previous int) bool {
+	if n <= threshold || n < 2 {
+		return false
+	}
+	// each transaction must reduce the number of tokens
+	return previous < 0 || n < previous
+}
+
+// consolidationInputs returns the quantities of the tokens to merge, smallest first, and their sum.
+// The locked tokens are skipped. The inputs are at most maxInputs, and their sum fits a token of the passed precision.
+// With a precision of at most 64 bits, the sum is also at most maxValue, the range proofs might not cover all the values of the precision.
+func consolidationInputs(tokens []*token2.UnspentToken, locked map[string]bool, maxInputs int, precision uint64, maxValue uint64) ([]token2.Quantity, token2.Quantity, error) {
+	max := new(big.Int).SetUint64(maxValue)
+	var quantities []token2.Quantity
+	for _, t := range tokens {
+		if locked[t.Id.String()] {
+			continue
+		}
+		q, err := token2.ToQuantity(t.Quantity, precision)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to convert quantity of token [%s]", t.Id)
+		}
+		quantities = append(quantities, q)
+	}
+	sort.Slice(quantities, func(i, j int) bool {
+		return quantities[i].Cmp(quantities[j]) < 0
+	})
+
+	// sum at the largest precision, the sum of tokens of the same precision might not fit it
+	sum := token2.NewZeroQuantity(token2.MaxPrecision)
+	var inputs []token2.Quantity
+	for _, q := range quantities {
+		if len(inputs) == maxInputs {
+			break
+		}
+		next := sum.Add(q)
+		if _, err := token2.ToQuantity(next.Decimal(), precision); err != nil {
+			// the next tokens are larger, they would not fit either
+			break
+		}
+		if precision <= 64 && next.ToBigInt().Cmp(max) > 0 {
+			break
+		}
+		sum = next
+		inputs = append(inputs, q)
+	}
+	res, err := token2.ToQuantity(sum.Decimal(), precision)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid sum [%s]", sum.Decimal())
+	}
+	return inputs, res, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"math"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var _ = Describe("Consolidation", func() {
+	tokens := func(quantities ...string) []*token2.UnspentToken {
+		var res []*token2.UnspentToken
+		for i, q := range quantities {
+			res = append(res, &token2.UnspentToken{Id: &token2.Id{TxId: "tx" + strconv.Itoa(i)}, Type: "USD", Quantity: q})
+		}
+		return res
+	}
+	decimals := func(quantities []token2.Quantity) []string {
+		var res []string
+		for _, q := range quantities {
+			res = append(res, q.Decimal())
+		}
+		return res
+	}
+
+	It("consolidates only above the threshold and while the tokens decrease", func() {
+		Expect(needsConsolidation(3, 3, -1)).To(BeFalse())
+		Expect(needsConsolidation(4, 3, -1)).To(BeTrue())
+		Expect(needsConsolidation(1, 0, -1)).To(BeFalse())
+		Expect(needsConsolidation(4, 3, 5)).To(BeTrue())
+		Expect(needsConsolidation(5, 3, 5)).To(BeFalse())
+	})
+
+	It("merges the smallest unlocked tokens, up to the maximum number of inputs", func() {
+		locked := map[string]bool{(&token2.Id{TxId: "tx1"}).String(): true}
+		inputs, sum, err := consolidationInputs(tokens("5", "1", "3", "2", "4"), locked, 3, 64, math.MaxUint64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decimals(inputs)).To(Equal([]string{"2", "3", "4"}))
+		Expect(sum.Decimal()).To(Equal("9"))
+	})
+
+	It("stops before the sum overflows the precision of a token", func() {
+		// with 8 bits, a token holds at most 255
+		inputs, sum, err := consolidationInputs(tokens("200", "50", "5", "1"), nil, 16, 8, 255)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decimals(inputs)).To(Equal([]string{"1", "5", "50"}))
+		Expect(sum.Decimal()).To(Equal("56"))
+
+		inputs, _, err = consolidationInputs(tokens("200", "255", "100"), nil, 16, 8, 255)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decimals(inputs)).To(Equal([]string{"100"}))
+	})
+
+	It("stops before the sum exceeds the maximum token value", func() {
+		// 14 bits hold 16383, the range proofs cover up to 9999
+		inputs, sum, err := consolidationInputs(tokens("5000", "4000", "3000"), nil, 16, 14, 9999)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decimals(inputs)).To(Equal([]string{"3000", "4000"}))
+		Expect(sum.Decimal()).To(Equal("7000"))
+	})
+})
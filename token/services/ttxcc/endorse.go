@@ -18,6 +18,8 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
 )
 
 type signatureRequest struct {
@@ -32,6 +34,11 @@ func (sr *signatureRequest) MessageToSign() []byte {
 
 type collectEndorsementsView struct {
 	tx *Transaction
+	// notified contains the FSC nodes of the co-owners of multisig identities that have received the transaction
+	notified map[string]bool
+	// multisigs contains the signatures collected so far for each multisig identity, so that the co-owners
+	// are asked only once for the identities that appear in more than one input
+	multisigs map[string]*MultisigSignature
 }
 
 // NewCollectEndorsementsView returns an instance of the collectEndorsementsView struct.
@@ -43,7 +50,7 @@ type collectEndorsementsView struct {
 // Depending on the token driver implementation, the recipient's signature might or might not be needed to make
 // the token transaction valid.
 func NewCollectEndorsementsView(tx *Transaction) *collectEndorsementsView {
-	return &collectEndorsementsView{tx: tx}
+	return &collectEndorsementsView{tx: tx, notified: map[string]bool{}, multisigs: map[string]*MultisigSignature{}}
 }
 
 // Call executes the view.
@@ -189,15 +196,39 @@ func (c *collectEndorsementsView) requestSignaturesOnTransfers(context view.Cont
 	transfers := c.tx.TokenRequest.Transfers()
 	logger.Debugf("collecting signature on [%d] request transfer", len(transfers))
 
+	// The co-owners of multisig identities that signed come first, so that they receive the transaction
+	// on the session used to collect their signatures
+	var signers []view.Identity
 	var distributionList []view.Identity
 	for i, transfer := range transfers {
-		distributionList = append(distributionList, transfer.Senders...)
-		distributionList = append(distributionList, transfer.Receivers...)
+		distributionList = append(distributionList, c.distributionParties(context, transfer.Senders)...)
+		distributionList = append(distributionList, c.distributionParties(context, transfer.Receivers)...)
 
+		if transfer.UnsignedSenders {
+			logger.Debugf("[%d]-th request transfer does not need the signatures of the senders", i)
+			continue
+		}
 		logger.Debugf("collecting signature on [%d]-th request transfer, signers [%d]", i, len(transfer.Senders))
 
 		// contact transfer and ask for the signature unless it is me
 		for _, party := range transfer.Senders {
+			if multisig.IsMultisig(party) {
+				if sig, ok := c.multisigs[party.UniqueID()]; ok {
+					c.tx.TokenRequest.AppendSignature(sig.Sigma)
+					continue
+				}
+				logger.Debugf("collecting signatures on request (transfer) from the co-owners of [%s]", party.UniqueID())
+				boxed, err := context.RunView(&collectMultisigSignaturesView{tx: c.tx, party: party, notified: c.notified})
+				if err != nil {
+					return nil, errors.WithMessagef(err, "failed collecting signatures for [%s]", party.UniqueID())
+				}
+				sig := boxed.(*MultisigSignature)
+				c.multisigs[party.UniqueID()] = sig
+				c.tx.TokenRequest.AppendSignature(sig.Sigma)
+				signers = append(signers, sig.Parties...)
+				continue
+			}
+
 			signatureRequest := &signatureRequest{
 				Request: requestRaw,
 				TxID:    []byte(c.tx.ID()),
@@ -206,13 +237,13 @@ func (c *collectEndorsementsView) requestSignaturesOnTransfers(context view.Cont
 
 			logger.Debugf("collecting signature on request (transfer) from [%s]", party.UniqueID())
 
-			if w := token.GetManagementService(context, token.WithChannel(c.tx.Channel())).WalletManager().OwnerWalletByIdentity(party); w != nil {
+			si, err := c.localTransferSigner(context, party)
+			if err != nil {
+				return nil, err
+			}
+			if si != nil {
 				logger.Debugf("collecting signature on request (transfer) from [%s], it is me!", party.UniqueID())
 				// Sign
-				si, err := w.GetSigner(party)
-				if err != nil {
-					return nil, err
-				}
 				logger.Debugf("signing tx-id [%s,nonce=%s]", c.tx.ID(), base64.StdEncoding.EncodeToString(c.tx.Id.Nonce))
 				sigma, err := si.Sign(signatureRequest.MessageToSign())
 				if err != nil {
@@ -277,7 +308,64 @@ func (c *collectEndorsementsView) requestSignaturesOnTransfers(context view.Cont
 		}
 	}
 
-	return distributionList, nil
+	return append(signers, distributionList...), nil
+}
+
+// localTransferSigner returns the signer of the passed owner, if the owner is local, nil otherwise.
+// An owner is local if it belongs to an owner wallet, or if it is a script whose signer has been registered.
+func (c *collectEndorsementsView) localTransferSigner(context view.Context, party view.Identity) (token.Signer, error) {
+	if w := token.GetManagementService(context, token.WithChannel(c.tx.Channel())).WalletManager().OwnerWalletByIdentity(party); w != nil {
+		return w.GetSigner(party)
+	}
+	if htlc.IsScript(party) {
+		signer, err := c.tx.TokenService().SigService().GetSigner(party)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed getting signer for script [%s]", party)
+		}
+		return signer, nil
+	}
+	return nil, nil
+}
+
+// distributionParties returns the parties the transaction must be distributed to for the passed owners.
+// Scripts are replaced by their sender and recipient. Once the deadline of a script has expired,
+// only its local parties are returned, so that the sender can reclaim without reaching the recipient.
+// Multisig identities are replaced by all their identities.
+func (c *collectEndorsementsView) distributionParties(context view.Context, owners []view.Identity) []view.Identity {
+	var res []view.Identity
+	for _, owner := range owners {
+		if multisig.IsMultisig(owner) {
+			m, err := multisig.FromIdentity(owner)
+			if err != nil {
+				logger.Warnf("failed unmarshalling multisig identity [%s]: %s", owner, err)
+				res = append(res, owner)
+				continue
+			}
+			res = append(res, m.Identities...)
+			continue
+		}
+		if !htlc.IsScript(owner) {
+			res = append(res, owner)
+			continue
+		}
+		script, err := htlc.ScriptFromIdentity(owner)
+		if err != nil {
+			logger.Warnf("failed unmarshalling script [%s]: %s", owner, err)
+			res = append(res, owner)
+			continue
+		}
+		if !script.Expired(time.Now()) {
+			res = append(res, script.Sender, script.Recipient)
+			continue
+		}
+		wm := token.GetManagementService(context, token.WithChannel(c.tx.Channel())).WalletManager()
+		for _, party := range []view.Identity{script.Sender, script.Recipient} {
+			if wm.OwnerWalletByIdentity(party) != nil {
+				res = append(res, party)
+			}
+		}
+	}
+	return res
 }
 
 func (c *collectEndorsementsView) callChaincode(context view.Context) (*fabric.Envelope, error) {
@@ -288,8 +376,12 @@ func (c *collectEndorsementsView) callChaincode(context view.Context) (*fabric.E
 
 	logger.Debugf("call chaincode for endorsement [nonce=%s]", base64.StdEncoding.EncodeToString(c.tx.Id.Nonce))
 
+	function, args := "invoke", []interface{}{requestRaw}
+	if len(c.tx.SeizeRequest) != 0 {
+		function, args = "seize", []interface{}{c.tx.SeizeRequest, requestRaw}
+	}
 	env, err := fabric.GetChannel(context, c.tx.Network(), c.tx.Channel()).Chaincode(c.tx.Namespace()).Endorse(
-		"invoke", requestRaw,
+		function, args...,
 	).WithInvokerIdentity(c.tx.Signer).WithTxID(c.tx.Payload.Id).Call()
 	if err != nil {
 		return nil, err
@@ -472,11 +564,30 @@ func NewEndorseView(tx *Transaction) *endorseView {
 // to be processed at time of committing.
 // 4. It sends back an ack.
 func (s *endorseView) Call(context view.Context) (interface{}, error) {
+	wm := s.tx.TokenService().WalletManager()
+	isLocal := func(id view.Identity) bool { return wm.OwnerWalletByIdentity(id) != nil }
+
+	if s.tx.Payload.FabricEnvelope != nil {
+		// A co-owner of a multisig identity that did not sign receives the endorsed transaction directly
+		ok, err := hasLocalMultisigSender(s.tx.TokenRequest.Transfers(), isLocal)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.Errorf("transaction [%s] is already endorsed", s.tx.ID())
+		}
+		return context.RunView(NewAcceptView(s.tx))
+	}
+
 	// Process signature requests
-	requestsToBeSigned, err := s.requestsToBeSigned()
+	requestsToBeSigned, err := signatureRequests(s.tx.TokenRequest.Transfers(), isLocal)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed collecting requests of signature")
 	}
+	expected := map[string]int{}
+	for _, id := range requestsToBeSigned {
+		expected[id.UniqueID()]++
+	}
 
 	session := context.Session()
 	for range requestsToBeSigned {
@@ -499,6 +610,10 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "failed unmarshalling signature request")
 		}
+		if expected[signatureRequest.Signer.UniqueID()] == 0 {
+			return nil, errors.Errorf("unexpected signature request for [%s]", signatureRequest.Signer.UniqueID())
+		}
+		expected[signatureRequest.Signer.UniqueID()]--
 		if !fabric.GetFabricNetworkService(context, s.tx.Network()).LocalMembership().IsMe(signatureRequest.Signer) {
 			return nil, errors.Errorf("identity [%s] is not me", signatureRequest.Signer.UniqueID())
 		}
@@ -563,14 +678,63 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 	return tx, nil
 }
 
-func (s *endorseView) requestsToBeSigned() ([]*token.Transfer, error) {
-	var res []*token.Transfer
-	for _, transfer := range s.tx.TokenRequest.Transfers() {
+// signatureRequests returns the signers of the signature requests the initiator sends for the passed transfers,
+// restricted to the identities for which isLocal returns true.
+// A local sender is asked once for each input it owns.
+// A multisig identity is signed once, even if it owns more than one input, and each of its local
+// identities is asked once.
+// The senders of a transfer that does not need their signatures are not asked.
+func signatureRequests(transfers []*token.Transfer, isLocal func(view.Identity) bool) ([]view.Identity, error) {
+	var res []view.Identity
+	multisigs := map[string]bool{}
+	for _, transfer := range transfers {
+		if transfer.UnsignedSenders {
+			continue
+		}
 		for _, sender := range transfer.Senders {
-			if s.tx.TokenService().WalletManager().OwnerWalletByIdentity(sender) != nil {
-				res = append(res, transfer)
+			if isLocal(sender) {
+				res = append(res, sender)
+				continue
+			}
+			if !multisig.IsMultisig(sender) || multisigs[sender.UniqueID()] {
+				continue
+			}
+			multisigs[sender.UniqueID()] = true
+			m, err := multisig.FromIdentity(sender)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range m.Identities {
+				if isLocal(id) {
+					res = append(res, id)
+				}
 			}
 		}
 	}
 	return res, nil
 }
+
+// hasLocalMultisigSender returns true if one of the senders of the passed transfers is a multisig identity
+// that contains an identity for which isLocal returns true
+func hasLocalMultisigSender(transfers []*token.Transfer, isLocal func(view.Identity) bool) (bool, error) {
+	for _, transfer := range transfers {
+		if transfer.UnsignedSenders {
+			continue
+		}
+		for _, sender := range transfer.Senders {
+			if !multisig.IsMultisig(sender) {
+				continue
+			}
+			m, err := multisig.FromIdentity(sender)
+			if err != nil {
+				return false, err
+			}
+			for _, id := range m.Identities {
+				if isLocal(id) {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
+)
+
+var _ = Describe("Endorse view", func() {
+	var (
+		alice, bob, charlie, dave view.Identity
+		owner                     view.Identity
+		isLocal                   func(view.Identity) bool
+	)
+
+	BeforeEach(func() {
+		alice, bob, charlie, dave = view.Identity("alice"), view.Identity("bob"), view.Identity("charlie"), view.Identity("dave")
+		var err error
+		owner, err = (&multisig.MultiIdentity{Identities: []view.Identity{alice, bob, charlie}, Threshold: 2}).Identity()
+		Expect(err).NotTo(HaveOccurred())
+		// alice and bob are hosted by this node
+		isLocal = func(id view.Identity) bool { return id.Equal(alice) || id.Equal(bob) }
+	})
+
+	Describe("signature requests", func() {
+		It("expects a request for each input of a local sender", func() {
+			requests, err := signatureRequests([]*token.Transfer{
+				{Senders: []view.Identity{alice, alice, dave}},
+				{Senders: []view.Identity{bob}},
+			}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]view.Identity{alice, alice, bob}))
+		})
+
+		It("expects a request for each local co-owner of a multisig identity", func() {
+			requests, err := signatureRequests([]*token.Transfer{{Senders: []view.Identity{owner}}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]view.Identity{alice, bob}))
+		})
+
+		It("expects the co-owners once for a multisig identity that owns more than one input", func() {
+			requests, err := signatureRequests([]*token.Transfer{
+				{Senders: []view.Identity{owner, owner}},
+				{Senders: []view.Identity{owner}},
+			}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]view.Identity{alice, bob}))
+		})
+
+		It("expects no request when no sender is local", func() {
+			other, err := (&multisig.MultiIdentity{Identities: []view.Identity{charlie, dave}, Threshold: 1}).Identity()
+			Expect(err).NotTo(HaveOccurred())
+			requests, err := signatureRequests([]*token.Transfer{{Senders: []view.Identity{charlie, other}}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("expects no request for the senders of a transfer that does not need their signatures", func() {
+			requests, err := signatureRequests([]*token.Transfer{
+				{Senders: []view.Identity{alice, owner}, UnsignedSenders: true},
+				{Senders: []view.Identity{bob}},
+			}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]view.Identity{bob}))
+		})
+	})
+
+	Describe("endorsed transactions", func() {
+		It("are accepted by the co-owners of a multisig sender", func() {
+			ok, err := hasLocalMultisigSender([]*token.Transfer{{Senders: []view.Identity{dave}}, {Senders: []view.Identity{owner}}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+
+		It("are not accepted when the local identities are plain senders", func() {
+			ok, err := hasLocalMultisigSender([]*token.Transfer{{Senders: []view.Identity{alice}, Receivers: []view.Identity{owner}}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("are not accepted when no co-owner of the multisig sender is local", func() {
+			other, err := (&multisig.MultiIdentity{Identities: []view.Identity{charlie, dave}, Threshold: 1}).Identity()
+			Expect(err).NotTo(HaveOccurred())
+			ok, err := hasLocalMultisigSender([]*token.Transfer{{Senders: []view.Identity{other}}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("are not accepted when the multisig sender does not sign", func() {
+			ok, err := hasLocalMultisigSender([]*token.Transfer{{Senders: []view.Identity{owner}, UnsignedSenders: true}}, isLocal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
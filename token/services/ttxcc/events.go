@@ -0,0 +1,94 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// notifyInvalidated publishes a TransactionInvalidated event for each local owner wallet and token type involved
+// in the passed transaction, if the passed finality error is not nil.
+// Valid transactions are notified by the transaction processor at commit time.
+func notifyInvalidated(context view.Context, tx *Transaction, finalityErr error) {
+	if finalityErr == nil {
+		return
+	}
+	tms := tx.TokenService()
+	hub := token.GetEventHub(context, tms)
+	if hub == nil {
+		return
+	}
+	involved, err := involvedWallets(tx)
+	if err != nil {
+		logger.Warnf("failed getting the wallets involved in [%s] [%s]", tx.ID(), err)
+		return
+	}
+	var events []*token.Event
+	for _, k := range involved {
+		events = append(events, &token.Event{
+			Type:      token.TransactionInvalidated,
+			TxID:      tx.ID(),
+			Wallet:    k[0],
+			TokenType: k[1],
+		})
+	}
+	hub.Publish(events...)
+}
+
+// involvedWallets returns the pairs of local owner wallet and token type of the inputs and outputs of the passed transaction
+func involvedWallets(tx *Transaction) ([][2]string, error) {
+	tms := tx.TokenService()
+	involved := map[[2]string]bool{}
+
+	outputs, err := tx.TokenRequest.Outputs()
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range outputs.Outputs() {
+		if w := tms.WalletManager().OwnerWalletByIdentity(output.Owner); w != nil {
+			involved[[2]string{w.ID(), output.Type}] = true
+		}
+	}
+
+	inputs, err := tx.TokenRequest.Inputs()
+	if err != nil {
+		return nil, err
+	}
+	qe := tms.Vault().NewQueryEngine()
+	for i := 0; i < inputs.Count(); i++ {
+		input := inputs.At(i)
+		w := tms.WalletManager().OwnerWalletByIdentity(input.Owner)
+		if w == nil {
+			continue
+		}
+		typ := input.Type
+		if len(typ) == 0 {
+			// the transaction is not committed, the input is still in the vault
+			toks, err := qe.GetTokens(input.Id)
+			if err != nil {
+				return nil, err
+			}
+			typ = toks[0].Type
+		}
+		involved[[2]string{w.ID(), typ}] = true
+	}
+
+	keys := make([][2]string, 0, len(involved))
+	for k := range involved {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys, nil
+}
@@ -18,6 +18,8 @@ type finalityView struct {
 // NewFinalityView returns an instance of the finalityView.
 // The view does the following: It waits for the finality of the passed transaction.
 // If the transaction is final, the vault is updated.
+// The transaction history of the local wallets records the transaction as pending, and as deleted if it is not final.
+// If the transaction is not final, a TransactionInvalidated event is published.
 func NewFinalityView(tx *Transaction) *finalityView {
 	return &finalityView{tx: tx}
 }
@@ -26,9 +28,15 @@ func NewFinalityView(tx *Transaction) *finalityView {
 // The view does the following: It waits for the finality of the passed transaction.
 // If the transaction is final, the vault is updated.
 func (f *finalityView) Call(context view.Context) (interface{}, error) {
+	appendPending(context, f.tx)
 	fs := fabric.GetChannel(context, f.tx.Network(), f.tx.Channel()).Finality()
+	var err error
 	if len(f.endpoints) != 0 {
-		return nil, fs.IsFinalForParties(f.tx.ID(), f.endpoints...)
+		err = fs.IsFinalForParties(f.tx.ID(), f.endpoints...)
+	} else {
+		err = fs.IsFinal(f.tx.ID())
 	}
-	return nil, fs.IsFinal(f.tx.ID())
+	recordFinality(context, f.tx, err)
+	notifyInvalidated(context, f.tx, err)
+	return nil, err
 }
@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+)
+
+// appendPending records the passed transaction as pending in the transaction history of the local wallets.
+// The history is best effort, failures are logged and do not stop the transaction.
+func appendPending(context view.Context, tx *Transaction) {
+	manager := txdb.GetManager(context)
+	if manager == nil {
+		return
+	}
+	db, err := manager.TransactionDB(tx.TokenService())
+	if err != nil {
+		logger.Warnf("failed getting transaction db for [%s] [%s]", tx.ID(), err)
+		return
+	}
+	movements, err := txdb.MovementsOf(tx.TokenRequest)
+	if err != nil {
+		logger.Warnf("failed computing the movements of [%s] [%s]", tx.ID(), err)
+		return
+	}
+	if err := db.AppendPending(tx.ID(), movements); err != nil {
+		logger.Warnf("failed recording [%s] as pending [%s]", tx.ID(), err)
+	}
+}
+
+// recordFinality marks the passed transaction as deleted in the transaction history of the local wallets,
+// if the passed finality error is not nil and the ledger says the transaction is invalid.
+// Otherwise, the transaction might still commit and stays pending.
+// Valid transactions are confirmed by the transaction processor once committed.
+func recordFinality(context view.Context, tx *Transaction, finalityErr error) {
+	if finalityErr == nil {
+		return
+	}
+	manager := txdb.GetManager(context)
+	if manager == nil {
+		return
+	}
+	code, _, err := fabric.GetChannel(context, tx.Network(), tx.Channel()).Vault().Status(tx.ID())
+	if err != nil {
+		logger.Warnf("transaction [%s] is not final [%s] and its status is not available [%s], leave it pending", tx.ID(), finalityErr, err)
+		return
+	}
+	if code != fabric.Invalid {
+		logger.Debugf("transaction [%s] is not final [%s] but not invalid [%d], leave it pending", tx.ID(), finalityErr, code)
+		return
+	}
+	db, err := manager.TransactionDB(tx.TokenService())
+	if err != nil {
+		logger.Warnf("failed getting transaction db for [%s] [%s]", tx.ID(), err)
+		return
+	}
+	logger.Debugf("transaction [%s] is invalid [%s], mark it as deleted", tx.ID(), finalityErr)
+	if err := db.SetStatus(tx.ID(), txdb.Deleted); err != nil {
+		logger.Warnf("failed marking [%s] as deleted [%s]", tx.ID(), err)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// MultisigIdentityData is sent to the co-owners of a multisig identity, once all its identities are known
+type MultisigIdentityData struct {
+	Channel string
+	// Identity is the multisig owner identity
+	Identity view.Identity
+	// AuditInfos contains the audit information of each identity of the multisig identity
+	AuditInfos [][]byte
+	// Nodes contains the FSC node of each identity of the multisig identity
+	Nodes []view.Identity
+}
+
+func (m *MultisigIdentityData) Bytes() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (m *MultisigIdentityData) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, m)
+}
+
+type RequestMultisigIdentityView struct {
+	Channel   string
+	Threshold int
+	// Parties are the FSC nodes, or the local wallets, that provide the identities of the multisig identity
+	Parties []view.Identity
+}
+
+// RequestMultisigIdentity executes the RequestMultisigIdentityView.
+// Each party, identified via the passed view identity, provides an identity as in RequestRecipientIdentity.
+// The parties then receive the resulting multisig identity that requires the signatures of threshold of them.
+func RequestMultisigIdentity(context view.Context, threshold int, parties ...view.Identity) (view.Identity, error) {
+	boxed, err := context.RunView(&RequestMultisigIdentityView{Threshold: threshold, Parties: parties})
+	if err != nil {
+		return nil, err
+	}
+	return boxed.(view.Identity), nil
+}
+
+func (f *RequestMultisigIdentityView) Call(context view.Context) (interface{}, error) {
+	ts := token.GetManagementService(context, token.WithChannel(f.Channel))
+
+	m := &multisig.MultiIdentity{Threshold: f.Threshold}
+	auditInfos := make([][]byte, len(f.Parties))
+	nodes := make([]view.Identity, len(f.Parties))
+	for i, party := range f.Parties {
+		id, err := RequestRecipientIdentity(context, party)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting identity from [%s]", party)
+		}
+		m.Identities = append(m.Identities, id)
+		if w := ts.WalletManager().OwnerWalletByIdentity(party); w != nil {
+			auditInfos[i], err = w.GetAuditInfo(id)
+			nodes[i] = context.Me()
+		} else {
+			auditInfos[i], err = view2.GetSigService(context).GetAuditInfo(id)
+			nodes[i] = party
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting audit info of [%s]", id)
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid multisig identity")
+	}
+	owner, err := m.Identity()
+	if err != nil {
+		return nil, err
+	}
+	if err := registerMultisigIdentity(context, ts, owner, m, auditInfos); err != nil {
+		return nil, err
+	}
+
+	// Let the remote co-owners know the multisig identity
+	data := &MultisigIdentityData{Channel: f.Channel, Identity: owner, AuditInfos: auditInfos, Nodes: nodes}
+	raw, err := data.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling multisig identity")
+	}
+	for _, party := range f.Parties {
+		if ts.WalletManager().OwnerWalletByIdentity(party) != nil {
+			continue
+		}
+		session, err := context.GetSession(context.Initiator(), party)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed getting session")
+		}
+		if err := session.Send(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed sending multisig identity to [%s]", party)
+		}
+		if _, err := session2.ReadMessageWithTimeout(session, 60*time.Second); err != nil {
+			return nil, errors.WithMessagef(err, "failed receiving ack from [%s]", party)
+		}
+	}
+
+	return owner, nil
+}
+
+type RespondRequestMultisigIdentityView struct {
+	Wallet string
+}
+
+// RespondRequestMultisigIdentity executes the RespondRequestMultisigIdentityView.
+// The co-owner sends back an identity, taken from the default wallet, and receives the multisig identity
+// that contains it.
+func RespondRequestMultisigIdentity(context view.Context) (view.Identity, error) {
+	id, err := context.RunView(&RespondRequestMultisigIdentityView{})
+	if err != nil {
+		return nil, err
+	}
+	return id.(view.Identity), nil
+}
+
+func (s *RespondRequestMultisigIdentityView) Call(context view.Context) (interface{}, error) {
+	meBoxed, err := context.RunView(&RespondRequestRecipientIdentityView{Wallet: s.Wallet})
+	if err != nil {
+		return nil, err
+	}
+	me := meBoxed.(view.Identity)
+
+	session := context.Session()
+	payload, err := session2.ReadMessageWithTimeout(session, 60*time.Second)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving multisig identity")
+	}
+	data := &MultisigIdentityData{}
+	if err := data.FromBytes(payload); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling multisig identity")
+	}
+	m, err := multisig.FromIdentity(data.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid multisig identity")
+	}
+	if len(data.AuditInfos) != len(m.Identities) || len(data.Nodes) != len(m.Identities) {
+		return nil, errors.Errorf("expected audit info and node for [%d] identities", len(m.Identities))
+	}
+	found := false
+	resolver := view2.GetEndpointService(context)
+	for i, id := range m.Identities {
+		if id.Equal(me) {
+			found = true
+			continue
+		}
+		// Let this node collect signatures from the other co-owners as well
+		if err := resolver.Bind(data.Nodes[i], id); err != nil {
+			return nil, errors.Wrapf(err, "failed binding identity at index [%d]", i)
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("multisig identity does not contain [%s]", me)
+	}
+
+	ts := token.GetManagementService(context, token.WithChannel(data.Channel))
+	if err := registerMultisigIdentity(context, ts, data.Identity, m, data.AuditInfos); err != nil {
+		return nil, err
+	}
+	if err := session.Send([]byte("ack")); err != nil {
+		return nil, err
+	}
+	return data.Identity, nil
+}
+
+// multisigSigner is registered for multisig identities. Their signatures are assembled by
+// the collectMultisigSignaturesView, from the signatures of the co-owners.
+type multisigSigner struct{}
+
+func (m *multisigSigner) Sign(message []byte) ([]byte, error) {
+	return nil, errors.New("multisig signatures are collected by the endorsement view")
+}
+
+// registerMultisigIdentity registers the verifier and the audit information of the passed multisig identity,
+// so that the token drivers and the endorsement views can use it as any other owner
+func registerMultisigIdentity(context view.Context, ts *token.ManagementService, id view.Identity, m *multisig.MultiIdentity, auditInfos [][]byte) error {
+	verifiers := make([]driver.Verifier, len(m.Identities))
+	for i, identity := range m.Identities {
+		v, err := ts.SigService().GetVerifier(identity)
+		if err != nil {
+			return errors.Wrapf(err, "failed getting verifier of identity at index [%d]", i)
+		}
+		verifiers[i] = v
+	}
+	sigService := view2.GetSigService(context)
+	if err := sigService.RegisterSigner(id, &multisigSigner{}, &multisig.Verifier{Identity: m, Verifiers: verifiers}); err != nil {
+		return errors.Wrapf(err, "failed registering multisig identity [%s]", id)
+	}
+	raw, err := (&multisig.AuditInfo{Infos: auditInfos}).Bytes()
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling multisig audit info")
+	}
+	return sigService.RegisterAuditInfo(id, raw)
+}
+
+// MultisigSignature is the result of the collectMultisigSignaturesView
+type MultisigSignature struct {
+	// Sigma is the serialized multisig.Signature
+	Sigma []byte
+	// Parties contains the identities that signed, one for each FSC node contacted
+	Parties []view.Identity
+}
+
+type collectMultisigSignaturesView struct {
+	tx    *Transaction
+	party view.Identity
+	// notified contains the long-term identities of the FSC nodes that have already received the transaction
+	notified map[string]bool
+}
+
+// NewCollectMultisigSignaturesView returns an instance of the collectMultisigSignaturesView.
+// The view collects, on the token request of the passed transaction, the signatures of the co-owners of
+// the passed multisig identity, until the threshold is met.
+// The co-owners of local wallets sign directly. Each remote FSC node receives the transaction first, and then
+// a signature request for each of its identities. It is expected to run ReceiveTransaction and the endorse view.
+func NewCollectMultisigSignaturesView(tx *Transaction, party view.Identity) *collectMultisigSignaturesView {
+	return &collectMultisigSignaturesView{tx: tx, party: party, notified: map[string]bool{}}
+}
+
+func (c *collectMultisigSignaturesView) Call(context view.Context) (interface{}, error) {
+	m, err := multisig.FromIdentity(c.party)
+	if err != nil {
+		return nil, err
+	}
+	requestRaw, err := c.tx.TokenRequest.MarshallToSign()
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &multisig.Signature{Signatures: make([][]byte, len(m.Identities))}
+	signed := 0
+	var parties []view.Identity
+
+	// Local co-owners
+	type node struct {
+		longTerm view.Identity
+		indexes  []int
+	}
+	var nodes []*node
+	wm := c.tx.TokenService().WalletManager()
+	for i, id := range m.Identities {
+		if w := wm.OwnerWalletByIdentity(id); w != nil {
+			signer, err := w.GetSigner(id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed getting signer for [%s]", id)
+			}
+			request := &signatureRequest{Request: requestRaw, TxID: []byte(c.tx.ID()), Signer: id}
+			sig.Signatures[i], err = signer.Sign(request.MessageToSign())
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed signing with [%s]", id)
+			}
+			signed++
+			parties = append(parties, id)
+			continue
+		}
+		longTerm, _, _, err := view2.GetEndpointService(context).Resolve(id)
+		if err != nil {
+			logger.Warnf("cannot resolve co-owner [%s], skipping: %s", id, err)
+			continue
+		}
+		var n *node
+		for _, candidate := range nodes {
+			if candidate.longTerm.Equal(longTerm) {
+				n = candidate
+				break
+			}
+		}
+		if n == nil {
+			n = &node{longTerm: longTerm}
+			nodes = append(nodes, n)
+		}
+		n.indexes = append(n.indexes, i)
+	}
+
+	// Remote co-owners
+	for _, n := range nodes {
+		if signed >= m.Threshold {
+			break
+		}
+		signatures, err := c.requestSignatures(context, requestRaw, n.longTerm, m.Identities, n.indexes)
+		if err != nil {
+			logger.Warnf("failed collecting signatures from [%s], skipping: %s", n.longTerm, err)
+			continue
+		}
+		for j, index := range n.indexes {
+			sig.Signatures[index] = signatures[j]
+		}
+		signed += len(n.indexes)
+		parties = append(parties, m.Identities[n.indexes[0]])
+	}
+
+	if signed < m.Threshold {
+		return nil, errors.Errorf("not enough signatures for [%s]: expected [%d], got [%d]", c.party, m.Threshold, signed)
+	}
+	sigma, err := json.Marshal(sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling multisig signature")
+	}
+	return &MultisigSignature{Sigma: sigma, Parties: parties}, nil
+}
+
+// requestSignatures asks the FSC node of the passed identities for their signatures.
+// The session is opened towards the first identity, that is then used to distribute the transaction.
+func (c *collectMultisigSignaturesView) requestSignatures(context view.Context, requestRaw []byte, longTerm view.Identity, ids []view.Identity, indexes []int) ([][]byte, error) {
+	session, err := context.GetSession(context.Initiator(), ids[indexes[0]])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting session")
+	}
+	if !c.notified[longTerm.UniqueID()] {
+		txRaw, err := c.tx.Bytes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed marshalling transaction content")
+		}
+		if err := session.Send(txRaw); err != nil {
+			return nil, errors.Wrap(err, "failed sending transaction content")
+		}
+		c.notified[longTerm.UniqueID()] = true
+	}
+
+	signatures := make([][]byte, len(indexes))
+	for j, index := range indexes {
+		request := &signatureRequest{Request: requestRaw, TxID: []byte(c.tx.ID()), Signer: ids[index]}
+		raw, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		if err := session.Send(raw); err != nil {
+			return nil, errors.Wrap(err, "failed sending signature request")
+		}
+		sigma, err := session2.ReadMessageWithTimeout(session, 60*time.Second)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed receiving signature from [%s]", ids[index])
+		}
+		verifier, err := c.tx.TokenService().SigService().GetVerifier(ids[index])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed getting verifier for [%s]", ids[index])
+		}
+		if err := verifier.Verify(request.MessageToSign(), sigma); err != nil {
+			return nil, errors.Wrapf(err, "failed verifying signature from [%s]", ids[index])
+		}
+		signatures[j] = sigma
+	}
+	return signatures, nil
+}
@@ -40,6 +40,8 @@ type orderingAndFinalityView struct {
 // 1. It broadcasts the token token transaction to the proper Fabric ordering service.
 // 2. It waits for finality of the token transaction by listening to delivery events from one of the
 // Fabric peer nodes trusted by the FSC node.
+// The transaction history of the local wallets records the transaction as pending, and as deleted if it is not final.
+// If the transaction is not final, a TransactionInvalidated event is published.
 func NewOrderingAndFinalityView(tx *Transaction) *orderingAndFinalityView {
 	return &orderingAndFinalityView{tx: tx}
 }
@@ -50,8 +52,14 @@ func NewOrderingAndFinalityView(tx *Transaction) *orderingAndFinalityView {
 // 2. It waits for finality of the token transaction by listening to delivery events from one of the
 // Fabric peer nodes trusted by the FSC node.
 func (o *orderingAndFinalityView) Call(context view.Context) (interface{}, error) {
+	appendPending(context, o.tx)
 	if err := fabric.GetDefaultNetwork(context).Ordering().Broadcast(o.tx.Payload.FabricEnvelope); err != nil {
+		recordFinality(context, o.tx, err)
+		notifyInvalidated(context, o.tx, err)
 		return nil, err
 	}
-	return nil, fabric.GetChannel(context, o.tx.Network(), o.tx.Channel()).Finality().IsFinal(o.tx.ID())
+	err := fabric.GetChannel(context, o.tx.Network(), o.tx.Channel()).Finality().IsFinal(o.tx.ID())
+	recordFinality(context, o.tx, err)
+	notifyInvalidated(context, o.tx, err)
+	return nil, err
 }
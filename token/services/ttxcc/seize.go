@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Seize contains the input information to seize frozen tokens
+type Seize struct {
+	// TokenIDs are the identifiers of the frozen tokens to seize
+	TokenIDs []*token2.Id
+	// IssuerWallet is the identifier of the issuer wallet used to re-issue the seized funds
+	IssuerWallet string
+	// Type of the seized tokens
+	Type string
+	// Quantity to re-issue, the seized tokens' total quantity
+	Quantity token2.Quantity
+	// Recipient is the identity of the FSC node that receives the seized funds
+	Recipient view.Identity
+	// TxOptions are the options used to create the transaction
+	TxOptions []TxOption
+}
+
+// SeizeView seizes frozen tokens and re-issues their funds to the recipient in the same transaction.
+// The seize request is signed by the authority declared in the public parameters, the authority must be a local
+// identity of the node running the view.
+// Seize is not supported when the public parameters hide the token data.
+type SeizeView struct {
+	*Seize
+}
+
+func NewSeizeView(seize *Seize) *SeizeView {
+	return &SeizeView{Seize: seize}
+}
+
+func (s *SeizeView) Call(context view.Context) (interface{}, error) {
+	if len(s.TokenIDs) == 0 {
+		return nil, errors.New("no tokens to seize")
+	}
+	recipient, err := RequestRecipientIdentity(context, s.Recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting recipient identity")
+	}
+
+	tx, err := NewAnonymousTransaction(context, s.TxOptions...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating transaction")
+	}
+	tms := tx.TokenService()
+	if tms.PublicParametersManager().TokenDataHiding() {
+		return nil, errors.New("seize is not supported when the public parameters hide the token data")
+	}
+	wallet := tms.WalletManager().IssuerWallet(s.IssuerWallet)
+	if wallet == nil {
+		return nil, errors.Errorf("issuer wallet [%s] not found", s.IssuerWallet)
+	}
+	if err := tx.Issue(wallet, recipient, s.Type, s.Quantity); err != nil {
+		return nil, errors.Wrap(err, "failed re-issuing seized funds")
+	}
+
+	authority := tms.PublicParametersManager().Authority()
+	if len(authority) == 0 {
+		return nil, errors.New("no authority is set in the public parameters")
+	}
+	request := &tcc.SeizeRequest{TokenIDs: s.TokenIDs}
+	msg, err := request.MessageToSign(tx.ID())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling seize request")
+	}
+	signer, err := view2.GetSigService(context).GetSigner(authority)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting authority's signer")
+	}
+	request.Signature, err = signer.Sign(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed signing seize request")
+	}
+	tx.SeizeRequest, err = request.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling seize request")
+	}
+
+	if _, err := context.RunView(NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(NewOrderingAndFinalityView(tx)); err != nil {
+		return nil, errors.Wrap(err, "failed ordering transaction")
+	}
+	return tx.ID(), nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type Payload struct {
@@ -26,6 +27,9 @@ type Payload struct {
 	Transient fabric.TransientMap
 
 	TokenRequest *token.Request
+	// SeizeRequest is the authority's request to seize frozen tokens, if any.
+	// The token request re-issues the seized funds.
+	SeizeRequest []byte `json:",omitempty"`
 
 	FabricEnvelope *fabric.Envelope
 }
@@ -154,18 +158,31 @@ func (t *Transaction) Bytes() ([]byte, error) {
 }
 
 // Issue appends a new Issue operation to the TokenRequest inside this transaction
-func (t *Transaction) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q uint64) error {
+func (t *Transaction) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q token2.Quantity) error {
 	_, err := t.TokenRequest.Issue(wallet, receiver, typ, q)
 	return err
 }
 
+// IssueNFT appends a new Issue operation of a non-fungible token to the TokenRequest inside this transaction
+func (t *Transaction) IssueNFT(wallet *token.IssuerWallet, receiver view.Identity, nft *token2.NFT) error {
+	_, err := t.TokenRequest.IssueNFT(wallet, receiver, nft)
+	return err
+}
+
 // Transfer appends a new Transfer operation to the TokenRequest inside this transaction
-func (t *Transaction) Transfer(wallet *token.OwnerWallet, typ string, values []uint64, owners []view.Identity, opts ...token.TransferOption) error {
+func (t *Transaction) Transfer(wallet *token.OwnerWallet, typ string, values []token2.Quantity, owners []view.Identity, opts ...token.TransferOption) error {
 	_, err := t.TokenRequest.Transfer(wallet, typ, values, owners, opts...)
 	return err
 }
 
-func (t *Transaction) Redeem(wallet *token.OwnerWallet, typ string, value uint64, opts ...token.TransferOption) error {
+// TransferNFT appends a new Transfer operation that moves the whole non-fungible token with the passed identifier
+func (t *Transaction) TransferNFT(wallet *token.OwnerWallet, id string, receiver view.Identity, opts ...token.TransferOption) error {
+	_, err := t.TokenRequest.TransferNFT(wallet, id, receiver, opts...)
+	return err
+}
+
+// Redeem appends a new Redeem operation to the TokenRequest inside this transaction
+func (t *Transaction) Redeem(wallet *token.OwnerWallet, typ string, value token2.Quantity, opts ...token.TransferOption) error {
 	return t.TokenRequest.Redeem(wallet, typ, value, opts...)
 }
 
@@ -70,8 +70,7 @@ func (v *approver) Validate(sp SignatureProvider, tokenRequest *token.Request) e
 	if err != nil {
 		return errors.Wrap(err, "failed creating new rws")
 	}
-	// TODO: use a proper issuerValidator
-	issuingValidator := &allIssuersValid{}
+	issuingValidator := translator.NewIssuingValidator(tokenRequest.TokenService.PublicParametersManager().TokenTypes())
 	translator := translator.New(issuingValidator, v.TxID, rwset, v.namespace)
 	for _, action := range actions {
 		err = translator.Write(action)
@@ -96,12 +95,6 @@ func (v *approver) Validate(sp SignatureProvider, tokenRequest *token.Request) e
 	return nil
 }
 
-type allIssuersValid struct{}
-
-func (i *allIssuersValid) Validate(creator view.Identity, tokenType string) error {
-	return nil
-}
-
 type backend struct {
 	qe        *fabric.QueryExecutor
 	sp        SignatureProvider
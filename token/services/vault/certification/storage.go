@@ -20,16 +20,27 @@ type Channel interface {
 	Vault() *fabric.Vault
 }
 
+// Verifier checks certifications before they are stored
+type Verifier interface {
+	VerifyCertifications(ids []*token.Id, certifications [][]byte) error
+}
+
 type Storage struct {
 	sp        view.ServiceProvider
 	channel   Channel
 	namespace string
+	verifier  Verifier
 }
 
 func NewStorage(sp view.ServiceProvider, channel Channel, namespace string) *Storage {
 	return &Storage{sp: sp, channel: channel, namespace: namespace}
 }
 
+// SetVerifier sets the verifier used to check certifications before they are stored
+func (v *Storage) SetVerifier(verifier Verifier) {
+	v.verifier = verifier
+}
+
 func (v *Storage) Exists(id *token.Id) bool {
 	k := kvs.CreateCompositeKeyOrPanic(
 		"token-sdk.certifier.certification",
@@ -44,6 +55,17 @@ func (v *Storage) Exists(id *token.Id) bool {
 }
 
 func (v *Storage) Store(certifications map[*token.Id][]byte) error {
+	if v.verifier != nil {
+		var ids []*token.Id
+		var raws [][]byte
+		for id, certification := range certifications {
+			ids = append(ids, id)
+			raws = append(raws, certification)
+		}
+		if err := v.verifier.VerifyCertifications(ids, raws); err != nil {
+			return errors.WithMessagef(err, "invalid certifications for [%v]", ids)
+		}
+	}
 	for id, certification := range certifications {
 		k := kvs.CreateCompositeKeyOrPanic(
 			"token-sdk.certifier.certification",
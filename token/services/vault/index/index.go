@@ -0,0 +1,463 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package index
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.vault.index")
+
+// Loader returns all the unspent tokens in the vault
+type Loader func() (*token2.UnspentTokens, error)
+
+// Listener is called when new unspent tokens are added to the index
+type Listener func()
+
+// WalletResolver returns the identifier of the owner wallet that contains the passed owner,
+// the empty string if no wallet does
+type WalletResolver func(owner view.Identity) string
+
+type entry struct {
+	owner    string
+	token    *token2.UnspentToken
+	quantity token2.Quantity
+}
+
+type bucket struct {
+	tokens  map[string]*entry
+	balance token2.Quantity
+}
+
+// op is an update received before the index is loaded
+type op struct {
+	token  *token2.UnspentToken
+	delete *token2.Id
+}
+
+// Update collects the changes of a transaction to the index. They are applied when the transaction
+// is committed, in the order the updates were staged.
+type Update struct {
+	txID      string
+	ops       []*op
+	committed bool
+}
+
+// NewUpdate returns a new empty update for the passed transaction
+func NewUpdate(txID string) *Update {
+	return &Update{txID: txID}
+}
+
+// Add adds the passed unspent token to the update
+func (u *Update) Add(tok *token2.UnspentToken) {
+	u.ops = append(u.ops, &op{token: tok})
+}
+
+// Delete adds the removal of the unspent token with the passed identifier to the update
+func (u *Update) Delete(id *token2.Id) {
+	u.ops = append(u.ops, &op{delete: id})
+}
+
+// Index keeps the unspent tokens of a namespace in memory, by owner and token type,
+// together with the balance of each owner and type.
+// The index is loaded from the vault on first use, and then kept up to date with the tokens
+// added and deleted by the committed transactions.
+// The owners are resolved to their wallets when their first token is added. The owners that belong to no wallet yet
+// are resolved again at lookup time, so that the tokens of a wallet registered after the index is loaded are found too.
+type Index struct {
+	resolver WalletResolver
+
+	loadLock sync.Mutex
+	lock     sync.RWMutex
+	loaded   bool
+	// journal holds the updates received before the index is loaded, they are applied after loading
+	journal []*op
+	tokens  map[string]*entry
+	owners  map[string]map[string]*bucket
+	// pending holds the updates of the transactions not yet committed, in the order they were staged
+	pending []*Update
+
+	walletsLock sync.Mutex
+	// wallets maps each wallet to its owners that have tokens in the index, an owner never moves to another wallet
+	wallets map[string]map[string]bool
+	// walletOf maps each owner in wallets to its wallet
+	walletOf map[string]string
+	// unresolved contains the owners that have tokens in the index and belong to no wallet yet
+	unresolved map[string]bool
+
+	listenersLock sync.RWMutex
+	listeners     []Listener
+}
+
+// New returns a new empty index that uses the passed resolver to find the owner wallets of the tokens it holds
+func New(resolver WalletResolver) *Index {
+	return &Index{
+		resolver:   resolver,
+		tokens:     map[string]*entry{},
+		owners:     map[string]map[string]*bucket{},
+		wallets:    map[string]map[string]bool{},
+		walletOf:   map[string]string{},
+		unresolved: map[string]bool{},
+	}
+}
+
+// Load loads the index with the unspent tokens returned by the passed loader, if not already loaded
+func (i *Index) Load(loader Loader) error {
+	i.loadLock.Lock()
+	defer i.loadLock.Unlock()
+
+	i.lock.RLock()
+	loaded := i.loaded
+	i.lock.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	// the vault is scanned without holding the lock, updates are journaled in the meantime
+	logger.Debugf("loading index...")
+	unspentTokens, err := loader()
+	if err != nil {
+		return errors.WithMessage(err, "failed loading unspent tokens")
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	for _, t := range unspentTokens.Tokens {
+		if err := i.add(t); err != nil {
+			return err
+		}
+	}
+	if err := i.apply(i.journal); err != nil {
+		return err
+	}
+	i.journal = nil
+	i.loaded = true
+	logger.Debugf("loading index...done, [%d] unspent tokens", len(i.tokens))
+	return nil
+}
+
+// AddListener registers a listener that is called each time an unspent token is added to the index
+func (i *Index) AddListener(listener Listener) {
+	i.listenersLock.Lock()
+	defer i.listenersLock.Unlock()
+
+	i.listeners = append(i.listeners, listener)
+}
+
+// Add adds the passed unspent token to the index
+func (i *Index) Add(tok *token2.UnspentToken) error {
+	i.lock.Lock()
+	err := i.applyOrJournal([]*op{{token: tok}})
+	i.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	i.notify()
+	return nil
+}
+
+// Delete removes the unspent token with the passed identifier from the index
+func (i *Index) Delete(id *token2.Id) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if err := i.applyOrJournal([]*op{{delete: id}}); err != nil {
+		logger.Errorf("failed deleting token [%s] [%s]", id, err)
+	}
+}
+
+// Stage queues the passed update. The update is applied once Commit is called for its transaction,
+// and after the updates staged before it.
+func (i *Index) Stage(u *Update) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.pending = append(i.pending, u)
+}
+
+// Commit applies the update of the passed transaction, once the updates staged before it are committed or discarded.
+// Call it after the transaction is committed in the vault.
+func (i *Index) Commit(txID string) error {
+	i.lock.Lock()
+	for _, u := range i.pending {
+		if u.txID == txID {
+			u.committed = true
+		}
+	}
+	added, err := i.drain()
+	i.lock.Unlock()
+
+	if added {
+		i.notify()
+	}
+	return errors.WithMessagef(err, "failed applying the update of [%s]", txID)
+}
+
+// Discard drops the update of the passed transaction, which the vault has not committed.
+// The update has not been applied yet, the updates committed after it are applied.
+func (i *Index) Discard(txID string) {
+	i.lock.Lock()
+	for j, u := range i.pending {
+		if u.txID == txID {
+			i.pending = append(i.pending[:j], i.pending[j+1:]...)
+			break
+		}
+	}
+	logger.Debugf("update of [%s] discarded", txID)
+	added, err := i.drain()
+	i.lock.Unlock()
+
+	if added {
+		i.notify()
+	}
+	if err != nil {
+		logger.Errorf("failed applying the updates following [%s] [%s]", txID, err)
+	}
+}
+
+// drain applies the committed updates at the head of the pending ones.
+// It returns true if any of them adds a token.
+func (i *Index) drain() (bool, error) {
+	added := false
+	for len(i.pending) != 0 && i.pending[0].committed {
+		u := i.pending[0]
+		i.pending = i.pending[1:]
+		for _, o := range u.ops {
+			added = added || o.token != nil
+		}
+		if err := i.applyOrJournal(u.ops); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// ListUnspentTokens returns the unspent tokens owned by the passed wallet and of the passed type, in ledger order.
+// The empty type matches all types.
+func (i *Index) ListUnspentTokens(wallet string, typ string) (*token2.UnspentTokens, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if !i.loaded {
+		return nil, errors.New("index not loaded")
+	}
+	var tokens []*token2.UnspentToken
+	for _, types := range i.walletOwners(wallet) {
+		for t, b := range types {
+			if len(typ) != 0 && t != typ {
+				continue
+			}
+			for _, e := range b.tokens {
+				tokens = append(tokens, e.token)
+			}
+		}
+	}
+	sortInLedgerOrder(tokens)
+	return &token2.UnspentTokens{Tokens: tokens}, nil
+}
+
+// Balance returns the sum of the unspent tokens owned by the passed wallet and of the passed type,
+// with the largest precision
+func (i *Index) Balance(wallet string, typ string) (token2.Quantity, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if !i.loaded {
+		return nil, errors.New("index not loaded")
+	}
+	sum := token2.NewZeroQuantity(token2.MaxPrecision)
+	for _, types := range i.walletOwners(wallet) {
+		if b, ok := types[typ]; ok {
+			sum = sum.Add(b.balance)
+		}
+	}
+	return sum, nil
+}
+
+// Balances returns the balance of each token type owned by the passed wallet, with the largest precision
+func (i *Index) Balances(wallet string) (map[string]token2.Quantity, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if !i.loaded {
+		return nil, errors.New("index not loaded")
+	}
+	res := map[string]token2.Quantity{}
+	for _, types := range i.walletOwners(wallet) {
+		for t, b := range types {
+			sum, ok := res[t]
+			if !ok {
+				sum = token2.NewZeroQuantity(token2.MaxPrecision)
+			}
+			res[t] = sum.Add(b.balance)
+		}
+	}
+	return res, nil
+}
+
+// walletOwners returns the tokens, by type, of the owners that belong to the passed wallet.
+// The owners that belong to no wallet yet are resolved again first.
+// Tokens that belong to no owner wallet, such as the tokens owned by scripts, are never returned.
+func (i *Index) walletOwners(wallet string) []map[string]*bucket {
+	if len(wallet) == 0 {
+		return nil
+	}
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+
+	for owner := range i.unresolved {
+		if w := i.resolver(view.Identity(owner)); len(w) != 0 {
+			delete(i.unresolved, owner)
+			i.addOwner(w, owner)
+		}
+	}
+	var res []map[string]*bucket
+	for owner := range i.wallets[wallet] {
+		res = append(res, i.owners[owner])
+	}
+	return res
+}
+
+// track resolves the wallet of the passed owner, whose first token is being added to the index
+func (i *Index) track(owner string) {
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+
+	w := i.resolver(view.Identity(owner))
+	if len(w) == 0 {
+		i.unresolved[owner] = true
+		return
+	}
+	i.addOwner(w, owner)
+}
+
+// untrack forgets the passed owner, whose last token has been deleted from the index
+func (i *Index) untrack(owner string) {
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+
+	delete(i.unresolved, owner)
+	w, ok := i.walletOf[owner]
+	if !ok {
+		return
+	}
+	delete(i.walletOf, owner)
+	delete(i.wallets[w], owner)
+	if len(i.wallets[w]) == 0 {
+		delete(i.wallets, w)
+	}
+}
+
+func (i *Index) addOwner(wallet string, owner string) {
+	owners, ok := i.wallets[wallet]
+	if !ok {
+		owners = map[string]bool{}
+		i.wallets[wallet] = owners
+	}
+	owners[owner] = true
+	i.walletOf[owner] = wallet
+}
+
+func (i *Index) notify() {
+	i.listenersLock.RLock()
+	defer i.listenersLock.RUnlock()
+	for _, listener := range i.listeners {
+		listener()
+	}
+}
+
+// applyOrJournal applies the passed operations, or journals them if the index is not loaded yet
+func (i *Index) applyOrJournal(ops []*op) error {
+	if !i.loaded {
+		i.journal = append(i.journal, ops...)
+		return nil
+	}
+	return i.apply(ops)
+}
+
+func (i *Index) apply(ops []*op) error {
+	for _, o := range ops {
+		if o.delete != nil {
+			i.delete(o.delete)
+			continue
+		}
+		if err := i.add(o.token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Index) add(tok *token2.UnspentToken) error {
+	key := tok.Id.String()
+	if _, ok := i.tokens[key]; ok {
+		return nil
+	}
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s] for token [%s]", tok.Quantity, key)
+	}
+	var owner string
+	if tok.Owner != nil {
+		owner = string(tok.Owner.Raw)
+	}
+	e := &entry{owner: owner, token: tok, quantity: q}
+	i.tokens[key] = e
+
+	types, ok := i.owners[owner]
+	if !ok {
+		types = map[string]*bucket{}
+		i.owners[owner] = types
+		i.track(owner)
+	}
+	b, ok := types[tok.Type]
+	if !ok {
+		b = &bucket{tokens: map[string]*entry{}, balance: token2.NewZeroQuantity(token2.MaxPrecision)}
+		types[tok.Type] = b
+	}
+	b.tokens[key] = e
+	b.balance = b.balance.Add(q)
+	return nil
+}
+
+func (i *Index) delete(id *token2.Id) {
+	key := id.String()
+	e, ok := i.tokens[key]
+	if !ok {
+		return
+	}
+	delete(i.tokens, key)
+
+	types := i.owners[e.owner]
+	b := types[e.token.Type]
+	delete(b.tokens, key)
+	b.balance = b.balance.Sub(e.quantity)
+	if len(b.tokens) == 0 {
+		delete(types, e.token.Type)
+	}
+	if len(types) == 0 {
+		delete(i.owners, e.owner)
+		i.untrack(e.owner)
+	}
+}
+
+// sortInLedgerOrder sorts the passed tokens in the order of their keys in the vault
+func sortInLedgerOrder(tokens []*token2.UnspentToken) {
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Id.TxId != tokens[j].Id.TxId {
+			return tokens[i].Id.TxId < tokens[j].Id.TxId
+		}
+		return strconv.FormatUint(uint64(tokens[i].Id.Index), 10) < strconv.FormatUint(uint64(tokens[j].Id.Index), 10)
+	})
+}
@@ -0,0 +1,238 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package index_test
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func unspentToken(txID string, i uint32, owner string, typ string, q string) *token2.UnspentToken {
+	return &token2.UnspentToken{
+		Id:       &token2.Id{TxId: txID, Index: i},
+		Owner:    &token2.Owner{Raw: []byte(owner)},
+		Type:     typ,
+		Quantity: q,
+	}
+}
+
+func ids(tokens *token2.UnspentTokens) []string {
+	var res []string
+	for _, t := range tokens.Tokens {
+		res = append(res, t.Id.String())
+	}
+	return res
+}
+
+func balance(idx *index.Index, wallet string, typ string) string {
+	q, err := idx.Balance(wallet, typ)
+	Expect(err).NotTo(HaveOccurred())
+	return q.Decimal()
+}
+
+var _ = Describe("Index", func() {
+	var (
+		idx    *index.Index
+		vault  []*token2.UnspentToken
+		loader index.Loader
+	)
+
+	BeforeEach(func() {
+		// owners are resolved to the wallet with the same name
+		idx = index.New(func(owner view.Identity) string {
+			return string(owner)
+		})
+		vault = []*token2.UnspentToken{
+			unspentToken("tx1", 0, "alice", "USD", "10"),
+			unspentToken("tx1", 1, "bob", "USD", "20"),
+			unspentToken("tx2", 0, "alice", "EUR", "5"),
+		}
+		loader = func() (*token2.UnspentTokens, error) {
+			return &token2.UnspentTokens{Tokens: vault}, nil
+		}
+	})
+
+	It("fails before loading", func() {
+		_, err := idx.ListUnspentTokens("alice", "")
+		Expect(err).To(MatchError("index not loaded"))
+		_, err = idx.Balance("alice", "USD")
+		Expect(err).To(MatchError("index not loaded"))
+	})
+
+	It("does not return the tokens that belong to no wallet", func() {
+		// a script owner is resolved to no wallet
+		vault = append(vault, unspentToken("tx2", 1, "", "USD", "3"))
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(idx.Add(unspentToken("tx3", 0, "", "USD", "7"))).To(Succeed())
+
+		tokens, err := idx.ListUnspentTokens("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.Tokens).To(BeEmpty())
+		Expect(balance(idx, "", "USD")).To(Equal("0"))
+		Expect(balance(idx, "alice", "USD")).To(Equal("10"))
+	})
+
+	It("keeps the balances by wallet and type", func() {
+		Expect(idx.Load(loader)).To(Succeed())
+
+		Expect(balance(idx, "alice", "USD")).To(Equal("10"))
+		Expect(balance(idx, "alice", "EUR")).To(Equal("5"))
+		Expect(balance(idx, "bob", "USD")).To(Equal("20"))
+		Expect(balance(idx, "bob", "EUR")).To(Equal("0"))
+		Expect(balance(idx, "charlie", "USD")).To(Equal("0"))
+
+		Expect(idx.Add(unspentToken("tx3", 0, "alice", "USD", "7"))).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("17"))
+
+		idx.Delete(&token2.Id{TxId: "tx1", Index: 0})
+		Expect(balance(idx, "alice", "USD")).To(Equal("7"))
+
+		balances, err := idx.Balances("alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(balances).To(HaveLen(2))
+		Expect(balances["USD"].Decimal()).To(Equal("7"))
+		Expect(balances["EUR"].Decimal()).To(Equal("5"))
+	})
+
+	It("lists the unspent tokens of a wallet in ledger order", func() {
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(idx.Add(unspentToken("tx0", 1, "alice", "USD", "1"))).To(Succeed())
+
+		tokens, err := idx.ListUnspentTokens("alice", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(tokens)).To(Equal([]string{"[tx0:1]", "[tx1:0]", "[tx2:0]"}))
+
+		tokens, err = idx.ListUnspentTokens("alice", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(tokens)).To(Equal([]string{"[tx0:1]", "[tx1:0]"}))
+	})
+
+	It("applies the updates received before loading", func() {
+		Expect(idx.Add(unspentToken("tx3", 0, "bob", "USD", "3"))).To(Succeed())
+		idx.Delete(&token2.Id{TxId: "tx1", Index: 1})
+
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(balance(idx, "bob", "USD")).To(Equal("3"))
+
+		// a token both in the vault and in the journal is counted once
+		vault = append(vault, unspentToken("tx4", 0, "alice", "USD", "1"))
+		idx = index.New(func(owner view.Identity) string {
+			return string(owner)
+		})
+		Expect(idx.Add(unspentToken("tx4", 0, "alice", "USD", "1"))).To(Succeed())
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("11"))
+	})
+
+	It("resolves the wallets of the owners at lookup time", func() {
+		wallets := map[string]string{"alice": "w1"}
+		idx = index.New(func(owner view.Identity) string {
+			return wallets[string(owner)]
+		})
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(balance(idx, "w1", "USD")).To(Equal("10"))
+		Expect(balance(idx, "w2", "USD")).To(Equal("0"))
+
+		// bob's wallet is registered after loading
+		wallets["bob"] = "w2"
+		Expect(balance(idx, "w2", "USD")).To(Equal("20"))
+		tokens, err := idx.ListUnspentTokens("w2", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(tokens)).To(Equal([]string{"[tx1:1]"}))
+
+		// two owners of the same wallet
+		wallets["bob"] = "w1"
+		idx = index.New(func(owner view.Identity) string {
+			return wallets[string(owner)]
+		})
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(balance(idx, "w1", "USD")).To(Equal("30"))
+		balances, err := idx.Balances("w1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(balances["USD"].Decimal()).To(Equal("30"))
+		Expect(balances["EUR"].Decimal()).To(Equal("5"))
+	})
+
+	It("applies the staged updates once committed, in order", func() {
+		listened := 0
+		idx.AddListener(func() { listened++ })
+		Expect(idx.Load(loader)).To(Succeed())
+
+		u1 := index.NewUpdate("tx3")
+		u1.Add(unspentToken("tx3", 0, "alice", "USD", "7"))
+		u1.Delete(&token2.Id{TxId: "tx1", Index: 0})
+		idx.Stage(u1)
+		u2 := index.NewUpdate("tx4")
+		u2.Delete(&token2.Id{TxId: "tx3", Index: 0})
+		idx.Stage(u2)
+		Expect(balance(idx, "alice", "USD")).To(Equal("10"))
+
+		// tx4 waits for tx3, that it spends from
+		Expect(idx.Commit("tx4")).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("10"))
+		Expect(listened).To(Equal(0))
+
+		Expect(idx.Commit("tx3")).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("0"))
+		Expect(listened).To(Equal(1))
+	})
+
+	It("drops only the discarded update", func() {
+		listened := 0
+		idx.AddListener(func() { listened++ })
+		Expect(idx.Load(loader)).To(Succeed())
+
+		u1 := index.NewUpdate("tx3")
+		u1.Add(unspentToken("tx3", 0, "alice", "USD", "7"))
+		u1.Delete(&token2.Id{TxId: "tx1", Index: 0})
+		idx.Stage(u1)
+		u2 := index.NewUpdate("tx4")
+		u2.Add(unspentToken("tx4", 0, "alice", "USD", "1"))
+		idx.Stage(u2)
+		Expect(idx.Commit("tx4")).To(Succeed())
+		Expect(listened).To(Equal(0))
+
+		// tx4 no longer waits for tx3
+		idx.Discard("tx3")
+		Expect(listened).To(Equal(1))
+		Expect(balance(idx, "alice", "USD")).To(Equal("11"))
+		tokens, err := idx.ListUnspentTokens("alice", "USD")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids(tokens)).To(Equal([]string{"[tx1:0]", "[tx4:0]"}))
+	})
+
+	It("resolves each owner once", func() {
+		resolved := map[string]int{}
+		idx = index.New(func(owner view.Identity) string {
+			resolved[string(owner)]++
+			if string(owner) == "bob" {
+				return ""
+			}
+			return string(owner)
+		})
+		Expect(idx.Load(loader)).To(Succeed())
+		Expect(idx.Add(unspentToken("tx3", 0, "alice", "USD", "7"))).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("17"))
+		Expect(balance(idx, "alice", "EUR")).To(Equal("5"))
+		Expect(resolved["alice"]).To(Equal(1))
+
+		// bob belongs to no wallet yet, the owner is resolved again at each lookup
+		Expect(resolved["bob"]).To(Equal(3))
+
+		// an owner without tokens is forgotten
+		idx.Delete(&token2.Id{TxId: "tx1", Index: 0})
+		idx.Delete(&token2.Id{TxId: "tx2", Index: 0})
+		idx.Delete(&token2.Id{TxId: "tx3", Index: 0})
+		Expect(balance(idx, "alice", "USD")).To(Equal("0"))
+		Expect(idx.Add(unspentToken("tx4", 0, "alice", "USD", "1"))).To(Succeed())
+		Expect(balance(idx, "alice", "USD")).To(Equal("1"))
+		Expect(resolved["alice"]).To(Equal(2))
+	})
+})
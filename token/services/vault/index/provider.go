@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package index
+
+import (
+	"sync"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// Provider holds the index of each namespace, shared by the vaults and the transaction processors
+type Provider struct {
+	sp view2.ServiceProvider
+
+	lock    sync.Mutex
+	indexes map[string]*Index
+}
+
+func NewProvider(sp view2.ServiceProvider) *Provider {
+	return &Provider{sp: sp, indexes: map[string]*Index{}}
+}
+
+// Index returns the index of the passed namespace
+func (p *Provider) Index(network string, channel string, namespace string) *Index {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := network + ":" + channel + ":" + namespace
+	idx, ok := p.indexes[key]
+	if !ok {
+		logger.Debugf("new index for [%s]", key)
+		idx = New(NewWalletResolver(p.sp, network, channel, namespace))
+		p.indexes[key] = idx
+	}
+	return idx
+}
+
+// GetProvider returns the index provider registered in the passed service provider, nil if none is registered
+func GetProvider(sp view2.ServiceProvider) *Provider {
+	s, err := sp.GetService(&Provider{})
+	if err != nil {
+		logger.Debugf("no index provider registered [%s]", err)
+		return nil
+	}
+	return s.(*Provider)
+}
+
+// NewWalletResolver returns a resolver that looks up the owner wallets of the token management service
+// of the passed network, channel and namespace
+func NewWalletResolver(sp view2.ServiceProvider, network string, channel string, namespace string) WalletResolver {
+	return func(owner view.Identity) string {
+		tms := token.GetManagementService(
+			sp,
+			token.WithNetwork(network),
+			token.WithChannel(channel),
+			token.WithNamespace(namespace),
+		)
+		w := tms.WalletManager().OwnerWalletByIdentity(owner)
+		if w == nil {
+			return ""
+		}
+		return w.ID()
+	}
+}
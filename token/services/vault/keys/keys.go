@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package keys
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"unicode/utf8"
@@ -16,26 +18,31 @@ import (
 )
 
 const (
-	minUnicodeRuneValue                = 0            //U+0000
-	MaxUnicodeRuneValue                = utf8.MaxRune //U+10FFFF - maximum (and unallocated) code point
-	CompositeKeyNamespace              = "\x00"
-	TokenKeyPrefix                     = "ztoken"
-	FabTokenKeyPrefix                  = "token"
-	AuditTokenKeyPrefix                = "audittoken"
-	TokenMineKeyPrefix                 = "mine"
-	TokenSetupKeyPrefix                = "setup"
-	IssuedHistoryTokenKeyPrefix        = "issued"
-	TokenAuditorKeyPrefix              = "auditor"
-	TokenNameSpace                     = "zkat"
-	numComponentsInKey                 = 2 // 2 components: txid, index, excluding TokenKeyPrefix
-	Action                             = "action"
-	ActionIssue                        = "issue"
-	ActionTransfer                     = "transfer"
-	Precision                   uint64 = 64
-	Info                               = "info"
-	TokenRequestKeyPrefix              = "token_request"
-	OwnerSeparator                     = "/"
-	SerialNumber                       = "sn"
+	minUnicodeRuneValue         = 0            //U+0000
+	MaxUnicodeRuneValue         = utf8.MaxRune //U+10FFFF - maximum (and unallocated) code point
+	CompositeKeyNamespace       = "\x00"
+	TokenKeyPrefix              = "ztoken"
+	FabTokenKeyPrefix           = "token"
+	AuditTokenKeyPrefix         = "audittoken"
+	TokenMineKeyPrefix          = "mine"
+	TokenSetupKeyPrefix         = "setup"
+	IssuedHistoryTokenKeyPrefix = "issued"
+	TokenAuditorKeyPrefix       = "auditor"
+	TokenNameSpace              = "zkat"
+	numComponentsInKey          = 2 // 2 components: txid, index, excluding TokenKeyPrefix
+	Action                      = "action"
+	ActionIssue                 = "issue"
+	ActionTransfer              = "transfer"
+	Info                        = "info"
+	TokenRequestKeyPrefix       = "token_request"
+	OwnerSeparator              = "/"
+	SerialNumber                = "sn"
+	NFT                         = "nft"
+	Supply                      = "supply"
+	Freeze                      = "freeze"
+	FreezeToken                 = "token"
+	FreezeOwner                 = "owner"
+	FreezeNonce                 = "nonce"
 )
 
 func GetTokenIdFromKey(key string) (*token2.Id, error) {
@@ -85,6 +92,39 @@ func CreateSNKey(sn string) (string, error) {
 	return CreateCompositeKey(TokenKeyPrefix, []string{SerialNumber, sn})
 }
 
+// CreateNFTKey creates the rwset key that binds the unique identifier of a non-fungible token
+// to its metadata. The key prevents the same identifier from being issued twice.
+func CreateNFTKey(id string) (string, error) {
+	return CreateCompositeKey(TokenKeyPrefix, []string{NFT, id})
+}
+
+// CreateNFTTokenKey creates the rwset key that marks the token with the passed ID as the holder of
+// a non-fungible token. The key stores the unique identifier of the non-fungible token.
+func CreateNFTTokenKey(txID string, index int) (string, error) {
+	return CreateCompositeKey(TokenKeyPrefix, []string{NFT, txID, strconv.Itoa(index)})
+}
+
+// CreateSupplyKey creates the rwset key that stores the total quantity of the passed token type issued so far
+func CreateSupplyKey(tokenType string) (string, error) {
+	return CreateCompositeKey(TokenKeyPrefix, []string{Supply, tokenType})
+}
+
+// CreateFrozenTokenKey creates the rwset key of the freeze record of the token with the passed ID
+func CreateFrozenTokenKey(txID string, index int) (string, error) {
+	return CreateCompositeKey(TokenKeyPrefix, []string{Freeze, FreezeToken, txID, strconv.Itoa(index)})
+}
+
+// CreateFrozenOwnerKey creates the rwset key of the freeze record of the passed owner identity
+func CreateFrozenOwnerKey(owner []byte) (string, error) {
+	h := sha256.Sum256(owner)
+	return CreateCompositeKey(TokenKeyPrefix, []string{Freeze, FreezeOwner, hex.EncodeToString(h[:])})
+}
+
+// CreateFreezeNonceKey creates the rwset key that records that the passed nonce of a request signed by the authority has been used
+func CreateFreezeNonceKey(nonce []byte) (string, error) {
+	return CreateCompositeKey(TokenKeyPrefix, []string{Freeze, FreezeNonce, hex.EncodeToString(nonce)})
+}
+
 // TODO: move index to uint32 of uint64
 func CreateFabtokenKey(txID string, index int) (string, error) {
 	return CreateCompositeKey(FabTokenKeyPrefix, []string{txID, strconv.Itoa(index)})
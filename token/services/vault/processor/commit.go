@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+)
+
+// commitRetryInterval is the time between two waits for a transaction the ledger has not decided on yet
+var commitRetryInterval = time.Minute
+
+// Ledger gives the finality and the status of the transactions of a channel
+type Ledger interface {
+	// IsFinal waits for the passed transaction to be committed, it returns an error if the transaction
+	// is not valid or the wait fails
+	IsFinal(txID string) error
+	// Status returns the status of the passed transaction in the vault
+	Status(txID string) (fabric.ValidationCode, error)
+}
+
+type channelLedger struct {
+	ch *fabric.Channel
+}
+
+// NewLedger returns the ledger of the passed channel
+func NewLedger(ch *fabric.Channel) Ledger {
+	return &channelLedger{ch: ch}
+}
+
+func (l *channelLedger) IsFinal(txID string) error {
+	return l.ch.Finality().IsFinal(txID)
+}
+
+func (l *channelLedger) Status(txID string) (fabric.ValidationCode, error) {
+	code, _, err := l.ch.Vault().Status(txID)
+	return code, err
+}
+
+// afterCommit calls, in the background, onCommit once the vault has committed the passed transaction as valid,
+// or onFailure if the transaction is invalid.
+// The processors run before the vault commits the read-write set of the transaction: whatever must be consistent
+// with the committed state waits for the commit with this function. The wait is on the finality listener of the
+// channel, notified by the committer. If the ledger has not decided on the transaction when the wait ends, it is
+// waited for again after commitRetryInterval.
+func afterCommit(ledger Ledger, txID string, onCommit func(), onFailure func()) {
+	go func() {
+		for {
+			err := ledger.IsFinal(txID)
+			if err == nil {
+				onCommit()
+				return
+			}
+			// the wait might have failed for reasons other than the validity of the transaction, ask the vault
+			code, err2 := ledger.Status(txID)
+			switch {
+			case err2 != nil:
+				logger.Warnf("transaction [%s] is not final [%s] and its status is not available [%s], retry later", txID, err, err2)
+			case code == fabric.Valid:
+				onCommit()
+				return
+			case code == fabric.Invalid:
+				logger.Debugf("transaction [%s] is invalid", txID)
+				onFailure()
+				return
+			default:
+				logger.Warnf("transaction [%s] is not final [%s], retry later", txID, err)
+			}
+			time.Sleep(commitRetryInterval)
+		}
+	}()
+}
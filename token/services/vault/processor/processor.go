@@ -14,6 +14,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 )
 
@@ -111,12 +114,19 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 		return err
 	}
 
+	// the index is updated once the vault has committed the transaction
+	update := index.NewUpdate(txID)
+	// the tracker feeds the transaction history of the local wallets and the token events
+	t := newTracker(txID)
+
 	if tms.PublicParametersManager().GraphHiding() {
 		// Delete inputs
 		for _, id := range metadata.SpentTokenID() {
+			r.spendToken(t, tms, ns, id.TxId, int(id.Index), rws)
 			if err := r.deleteFabToken(ns, id.TxId, int(id.Index), rws); err != nil {
 				return err
 			}
+			r.unindexToken(update, id.TxId, int(id.Index))
 		}
 	}
 
@@ -144,6 +154,12 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 		case keys.SerialNumber:
 			logger.Debugf("expected key without the serial number prefix, skipping")
 			continue
+		case keys.NFT:
+			logger.Debugf("expected key without the nft prefix, skipping")
+			continue
+		case keys.Freeze:
+			logger.Debugf("expected key without the freeze prefix, skipping")
+			continue
 		}
 
 		index, err := strconv.Atoi(components[1])
@@ -154,9 +170,11 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 
 		// This is a delete, add a delete for fabtoken
 		if len(val) == 0 {
+			r.spendToken(t, tms, ns, components[0], index, rws)
 			if err := r.deleteFabToken(ns, components[0], index, rws); err != nil {
 				return err
 			}
+			r.unindexToken(update, components[0], index)
 			continue
 		}
 
@@ -177,7 +195,10 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 			continue
 		}
 
-		if tms.WalletManager().OwnerWalletByIdentity(tok.Owner.Raw) != nil {
+		mine := isMine(tms, tok.Owner.Raw)
+		r.createToken(t, tms, txID, index, tok, mine)
+
+		if mine {
 			logger.Debugf("transaction [%s], found a token and it is mine", txID)
 			// Add a lookup key to identity quickly that this token belongs to this
 			mineTokenID, err := keys.CreateTokenMineKey(components[0], index)
@@ -193,6 +214,9 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 			if err := r.storeFabToken(ns, txID, index, tok, rws, tokenInfoRaw); err != nil {
 				return err
 			}
+			if err := r.indexToken(update, txID, index, tok); err != nil {
+				return err
+			}
 		} else {
 			logger.Debugf("transaction [%s], found a token and I must be the auditor", txID)
 			if err := r.storeAuditToken(ns, txID, index, tok, rws, tokenInfoRaw); err != nil {
@@ -202,14 +226,77 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 
 		if !issuer.IsNone() && tms.WalletManager().IssuerWalletByIdentity(issuer) != nil {
 			logger.Debugf("transaction [%s], found a token and I have issued it", txID)
-			if err := r.storeIssuedHistoryToken(ns, txID, index, tok, rws, tokenInfoRaw, issuer); err != nil {
+			if err := r.storeIssuedHistoryToken(ns, txID, index, tok, rws, tokenInfoRaw, issuer, tms.PublicParametersManager().Precision()); err != nil {
 				return err
 			}
 		}
 
 		logger.Debugf("Done parsing write key [%s]", key)
 	}
+	idx := r.unspentIndex(tx.Network(), tx.Channel(), ns)
+	if idx != nil {
+		idx.Stage(update)
+	}
+	afterCommit(
+		NewLedger(ch),
+		txID,
+		func() {
+			if idx != nil {
+				if err := idx.Commit(txID); err != nil {
+					logger.Errorf("failed updating the index with [%s] [%s]", txID, err)
+				}
+			}
+			r.recordTransaction(t, tms, metadata, txID)
+			r.publishEvents(t, tms)
+		},
+		func() {
+			if idx != nil {
+				idx.Discard(txID)
+			}
+		},
+	)
 	logger.Debugf("transaction [%s] is known, extract tokens, done!", txID)
 
 	return nil
 }
+
+// unspentIndex returns the index of the unspent tokens of the passed namespace, nil if no index provider is registered
+func (r *RWSetProcessor) unspentIndex(network string, channel string, ns string) *index.Index {
+	provider := index.GetProvider(r.sp)
+	if provider == nil {
+		return nil
+	}
+	return provider.Index(network, channel, ns)
+}
+
+// isMine returns true if the passed owner belongs to a local owner wallet.
+// A token owned by a script is mine if either the sender or the recipient of the script is.
+// A token owned by a multisig identity is mine if any of its identities is.
+func isMine(tms *token.ManagementService, owner []byte) bool {
+	if tms.WalletManager().OwnerWalletByIdentity(owner) != nil {
+		return true
+	}
+	if multisig.IsMultisig(owner) {
+		m, err := multisig.FromIdentity(owner)
+		if err != nil {
+			logger.Warnf("failed unmarshalling multisig owner [%s]", err)
+			return false
+		}
+		for _, id := range m.Identities {
+			if tms.WalletManager().OwnerWalletByIdentity(id) != nil {
+				return true
+			}
+		}
+		return false
+	}
+	if !htlc.IsScript(owner) {
+		return false
+	}
+	script, err := htlc.ScriptFromIdentity(owner)
+	if err != nil {
+		logger.Warnf("failed unmarshalling script owner [%s]", err)
+		return false
+	}
+	return tms.WalletManager().OwnerWalletByIdentity(script.Sender) != nil ||
+		tms.WalletManager().OwnerWalletByIdentity(script.Recipient) != nil
+}
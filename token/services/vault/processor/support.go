@@ -13,6 +13,9 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
@@ -52,7 +55,121 @@ func (r *RWSetProcessor) storeFabToken(ns string, txID string, index int, tok *t
 	return nil
 }
 
-func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index int, tok *token2.Token, rws *fabric.RWSet, infoRaw []byte, issuer view.Identity) error {
+// indexToken adds the passed token to the passed index update.
+// The index resolves the owner wallet of the token at lookup time.
+func (r *RWSetProcessor) indexToken(update *index.Update, txID string, index int, tok *token2.Token) error {
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	logger.Debugf("transaction [%s], index token [%s:%d]", txID, txID, index)
+	update.Add(&token2.UnspentToken{
+		Id:       &token2.Id{TxId: txID, Index: uint32(index)},
+		Owner:    tok.Owner,
+		Type:     tok.Type,
+		Quantity: q.Decimal(),
+	})
+	return nil
+}
+
+// unindexToken adds the removal of the token with the passed identifier to the passed index update
+func (r *RWSetProcessor) unindexToken(update *index.Update, txID string, index int) {
+	update.Delete(&token2.Id{TxId: txID, Index: uint32(index)})
+}
+
+// spendToken adds to the passed tracker the token with the passed identifier, if it is stored in the vault.
+// It must be called before the token is deleted.
+func (r *RWSetProcessor) spendToken(t *tracker, tms *token.ManagementService, ns string, txID string, index int, rws *fabric.RWSet) {
+	outputID, err := keys.CreateFabtokenKey(txID, index)
+	if err != nil {
+		logger.Warnf("failed creating output ID [%s:%d] [%s]", txID, index, err)
+		return
+	}
+	raw, err := rws.GetState(ns, outputID)
+	if err != nil {
+		logger.Warnf("failed getting spent token [%s:%d] [%s]", txID, index, err)
+		return
+	}
+	if len(raw) == 0 {
+		// the token does not belong to a local wallet
+		return
+	}
+	tok := &token2.Token{}
+	if err := json.Unmarshal(raw, tok); err != nil {
+		logger.Warnf("failed unmarshalling spent token [%s:%d] [%s]", txID, index, err)
+		return
+	}
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		logger.Warnf("invalid quantity [%s] for spent token [%s:%d] [%s]", tok.Quantity, txID, index, err)
+		return
+	}
+	t.spent(ownerWalletID(tms, tok.Owner.Raw), &token2.Id{TxId: txID, Index: uint32(index)}, tok, q)
+}
+
+// createToken adds to the passed tracker the passed token, created by the transaction.
+// If mine is true, the token is stored in the vault and a TokenReceived event is emitted.
+func (r *RWSetProcessor) createToken(t *tracker, tms *token.ManagementService, txID string, index int, tok *token2.Token, mine bool) {
+	q, err := token2.ToQuantity(tok.Quantity, token2.MaxPrecision)
+	if err != nil {
+		logger.Warnf("invalid quantity [%s] for created token [%s]", tok.Quantity, err)
+		return
+	}
+	wallet := ownerWalletID(tms, tok.Owner.Raw)
+	t.movements.AddOutput(wallet, tok.Owner.Raw, tok.Type, q)
+	if mine {
+		t.received(wallet, &token2.Id{TxId: txID, Index: uint32(index)}, tok, q)
+	}
+}
+
+// recordTransaction confirms the passed transaction in the transaction history of the local wallets.
+// A failure does not stop the commit, the history is not part of the ledger.
+func (r *RWSetProcessor) recordTransaction(t *tracker, tms *token.ManagementService, metadata *token.Metadata, txID string) {
+	m := t.movements
+	manager := txdb.GetManager(r.sp)
+	if manager == nil {
+		return
+	}
+	for _, transfer := range metadata.Transfers() {
+		for _, sender := range transfer.Senders {
+			m.AddInput(ownerWalletID(tms, sender), sender, "", nil)
+		}
+	}
+	for _, issue := range metadata.Issues() {
+		m.AddIssuer(issue.Issuer)
+	}
+	db, err := manager.TransactionDB(tms)
+	if err != nil {
+		logger.Errorf("failed getting transaction db for [%s] [%s]", tms, err)
+		return
+	}
+	if err := db.Confirm(txID, m); err != nil {
+		logger.Errorf("failed recording transaction [%s] [%s]", txID, err)
+	}
+}
+
+// publishEvents publishes the token events collected by the passed tracker, followed by the transaction events
+func (r *RWSetProcessor) publishEvents(t *tracker, tms *token.ManagementService) {
+	hub := token.GetEventHub(r.sp, tms)
+	if hub == nil || len(t.events) == 0 {
+		return
+	}
+	hub.Publish(t.confirmed()...)
+}
+
+// ownerWalletID returns the identifier of the local owner wallet the passed identity belongs to, the empty string if none does
+func ownerWalletID(tms *token.ManagementService, id view.Identity) string {
+	if len(id) == 0 {
+		return ""
+	}
+	w := tms.WalletManager().OwnerWalletByIdentity(id)
+	if w == nil {
+		return ""
+	}
+	return w.ID()
+}
+
+func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index int, tok *token2.Token, rws *fabric.RWSet, infoRaw []byte, issuer view.Identity, precision uint64) error {
 	outputID, err := keys.CreateIssuedHistoryTokenKey(txID, index)
 	if err != nil {
 		return errors.Wrapf(err, "error creating output ID: [%s,%d]", txID, index)
@@ -71,7 +188,7 @@ func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index i
 	}
 	raw := MarshalOrPanic(issuedToken)
 
-	q, err := token2.ToQuantity(tok.Quantity, 64)
+	q, err := token2.ToQuantity(tok.Quantity, precision)
 	if err != nil {
 		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
 	}
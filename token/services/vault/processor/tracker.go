@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/txdb"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// tracker collects what a transaction does to the local owner wallets:
+// the movements of the transaction history and the token events
+type tracker struct {
+	txID      string
+	movements *txdb.Movements
+	events    []*token.Event
+}
+
+func newTracker(txID string) *tracker {
+	return &tracker{txID: txID, movements: txdb.NewMovements()}
+}
+
+// spent records that the passed token, owned by the passed wallet, has been spent
+func (t *tracker) spent(wallet string, id *token2.Id, tok *token2.Token, q token2.Quantity) {
+	t.movements.AddInput(wallet, tok.Owner.Raw, tok.Type, q)
+	t.events = append(t.events, &token.Event{
+		Type:      token.TokenSpent,
+		TxID:      t.txID,
+		Wallet:    wallet,
+		TokenType: tok.Type,
+		Token:     &token2.UnspentToken{Id: id, Owner: tok.Owner, Type: tok.Type, Quantity: q.Decimal()},
+	})
+}
+
+// received records that the passed token, owned by the passed wallet, has been created
+func (t *tracker) received(wallet string, id *token2.Id, tok *token2.Token, q token2.Quantity) {
+	t.events = append(t.events, &token.Event{
+		Type:      token.TokenReceived,
+		TxID:      t.txID,
+		Wallet:    wallet,
+		TokenType: tok.Type,
+		Token:     &token2.UnspentToken{Id: id, Owner: tok.Owner, Type: tok.Type, Quantity: q.Decimal()},
+	})
+}
+
+// confirmed returns the token events followed by a TransactionConfirmed event for each wallet and token type involved
+func (t *tracker) confirmed() []*token.Event {
+	return append(t.events, transactionEvents(token.TransactionConfirmed, t.txID, t.events)...)
+}
+
+// transactionEvents returns an event of the passed type for each wallet and token type of the passed token events
+func transactionEvents(typ token.EventType, txID string, events []*token.Event) []*token.Event {
+	involved := map[[2]string]bool{}
+	for _, e := range events {
+		involved[[2]string{e.Wallet, e.TokenType}] = true
+	}
+	keys := make([][2]string, 0, len(involved))
+	for k := range involved {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	var res []*token.Event
+	for _, k := range keys {
+		res = append(res, &token.Event{Type: typ, TxID: txID, Wallet: k[0], TokenType: k[1]})
+	}
+	return res
+}
@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// scanBatchSize is the number of vault entries scanned each time the vault is opened
+const scanBatchSize = 100
+
+type scanned struct {
+	key   string
+	value interface{}
+}
+
+// parseFunc returns the value stored under the passed key, nil if the value must be skipped
+type parseFunc func(key string, raw []byte) (interface{}, error)
+
+// scanner scans a key range of the vault in batches. The vault is open only while a batch is read,
+// therefore the scan is not a snapshot: it sees the transactions committed in the meantime.
+type scanner struct {
+	channel   Channel
+	namespace string
+	endKey    string
+	parse     parseFunc
+
+	// from is the key the next batch starts from
+	from    string
+	lastKey string
+	buffer  []*scanned
+	done    bool
+}
+
+func newScanner(channel Channel, namespace string, startKey string, endKey string, cursor string, parse parseFunc) (*scanner, error) {
+	from := startKey
+	if len(cursor) != 0 {
+		lastKey, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if lastKey < startKey || lastKey >= endKey {
+			return nil, errors.New("invalid cursor, it does not belong to the queried range")
+		}
+		from = nextKey(lastKey)
+	}
+	return &scanner{
+		channel:   channel,
+		namespace: namespace,
+		endKey:    endKey,
+		parse:     parse,
+		from:      from,
+	}, nil
+}
+
+func (s *scanner) Next() (interface{}, error) {
+	for len(s.buffer) == 0 {
+		if s.done {
+			return nil, nil
+		}
+		if err := s.fill(); err != nil {
+			return nil, err
+		}
+	}
+	next := s.buffer[0]
+	s.buffer = s.buffer[1:]
+	s.lastKey = next.key
+	return next.value, nil
+}
+
+// Cursor returns the cursor that resumes the scan after the last value returned by Next
+func (s *scanner) Cursor() string {
+	if len(s.lastKey) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(s.lastKey))
+}
+
+func (s *scanner) Close() {
+	s.buffer = nil
+	s.done = true
+}
+
+// fill reads the next batch of entries from the vault
+func (s *scanner) fill() error {
+	qe, err := s.channel.Vault().NewQueryExecutor()
+	if err != nil {
+		return err
+	}
+	defer qe.Done()
+
+	logger.Debugf("scan batch [%s,%s]", s.from, s.endKey)
+	iterator, err := qe.GetStateRangeScanIterator(s.namespace, s.from, s.endKey)
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+
+	for i := 0; i < scanBatchSize; i++ {
+		next, err := iterator.Next()
+		if err != nil {
+			logger.Errorf("scan failed [%s]", err)
+			return err
+		}
+		if next == nil {
+			s.done = true
+			return nil
+		}
+		s.from = nextKey(next.Key)
+		if len(next.Raw) == 0 {
+			continue
+		}
+		value, err := s.parse(next.Key, next.Raw)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			s.buffer = append(s.buffer, &scanned{key: next.Key, value: value})
+		}
+	}
+	return nil
+}
+
+type unspentTokensIterator struct {
+	*scanner
+}
+
+func (u *unspentTokensIterator) Next() (*token.UnspentToken, error) {
+	next, err := u.scanner.Next()
+	if err != nil || next == nil {
+		return nil, err
+	}
+	return next.(*token.UnspentToken), nil
+}
+
+type issuedTokensIterator struct {
+	*scanner
+}
+
+func (i *issuedTokensIterator) Next() (*token.IssuedToken, error) {
+	next, err := i.scanner.Next()
+	if err != nil || next == nil {
+		return nil, err
+	}
+	return next.(*token.IssuedToken), nil
+}
+
+// UnspentTokensIterator returns an iterator over the unspent tokens that match the passed filter, in ledger order.
+// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+func (e *Engine) UnspentTokensIterator(filter *driver.TokenFilter, cursor string) (driver.UnspentTokensIterator, error) {
+	if filter == nil {
+		filter = &driver.TokenFilter{}
+	}
+	startKey, endKey, err := rangeOf(keys.FabTokenKeyPrefix, filter)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newScanner(e.channel, e.namespace, startKey, endKey, cursor, func(key string, raw []byte) (interface{}, error) {
+		output, err := UnmarshallFabtoken(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve unspent tokens for [%s]", key)
+		}
+		id, err := keys.GetTokenIdFromKey(key)
+		if err != nil {
+			return nil, err
+		}
+		// The vault does not know the public parameters, therefore the largest precision is used.
+		q, err := token.ToQuantity(output.Quantity, token.MaxPrecision)
+		if err != nil {
+			return nil, err
+		}
+		if !e.matches(filter, output.Owner, output.Type, q) {
+			return nil, nil
+		}
+		return &token.UnspentToken{
+			Id:       id,
+			Owner:    output.Owner,
+			Type:     output.Type,
+			Quantity: q.Decimal(),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &unspentTokensIterator{scanner: s}, nil
+}
+
+// IssuedTokensIterator returns an iterator over the tokens issued by this node that match the passed filter, in ledger order.
+// The owner filters apply to the owners of the issued tokens.
+// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+func (e *Engine) IssuedTokensIterator(filter *driver.TokenFilter, cursor string) (driver.IssuedTokensIterator, error) {
+	if filter == nil {
+		filter = &driver.TokenFilter{}
+	}
+	startKey, endKey, err := rangeOf(keys.IssuedHistoryTokenKeyPrefix, filter)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newScanner(e.channel, e.namespace, startKey, endKey, cursor, func(key string, raw []byte) (interface{}, error) {
+		output, err := UnmarshallIssuedToken(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve issued tokens for [%s]", key)
+		}
+		id, err := keys.GetTokenIdFromKey(key)
+		if err != nil {
+			return nil, err
+		}
+		q, err := token.ToQuantity(output.Quantity, token.MaxPrecision)
+		if err != nil {
+			return nil, err
+		}
+		if !e.matches(filter, output.Owner, output.Type, q) {
+			return nil, nil
+		}
+		return &token.IssuedToken{
+			Id:       id,
+			Owner:    output.Owner,
+			Type:     output.Type,
+			Quantity: q.Decimal(),
+			Issuer:   output.Issuer,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &issuedTokensIterator{scanner: s}, nil
+}
+
+// matches returns true if a token with the passed owner, type and quantity matches the passed filter
+func (e *Engine) matches(filter *driver.TokenFilter, owner *token.Owner, typ string, q token.Quantity) bool {
+	if len(filter.Type) != 0 && filter.Type != typ {
+		return false
+	}
+	if filter.MinQuantity != nil && q.Cmp(filter.MinQuantity) < 0 {
+		return false
+	}
+	if filter.MaxQuantity != nil && q.Cmp(filter.MaxQuantity) > 0 {
+		return false
+	}
+	var raw []byte
+	if owner != nil {
+		raw = owner.Raw
+	}
+	if len(filter.Owner) != 0 && !bytes.Equal(filter.Owner, raw) {
+		return false
+	}
+	if len(filter.Wallet) != 0 && (e.resolver == nil || e.resolver(raw) != filter.Wallet) {
+		return false
+	}
+	return true
+}
+
+// rangeOf returns the key range of the tokens with the passed prefix that might match the passed filter
+func rangeOf(prefix string, filter *driver.TokenFilter) (string, string, error) {
+	var attributes []string
+	if len(filter.TxID) != 0 {
+		attributes = []string{filter.TxID}
+	}
+	startKey, err := keys.CreateCompositeKey(prefix, attributes)
+	if err != nil {
+		return "", "", err
+	}
+	return startKey, startKey + string(keys.MaxUnicodeRuneValue), nil
+}
+
+// nextKey returns the smallest key larger than the passed one
+func nextKey(key string) string {
+	return key + "\x00"
+}
+
+func decodeCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid cursor [%s]", cursor)
+	}
+	return string(raw), nil
+}
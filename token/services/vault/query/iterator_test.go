@@ -0,0 +1,122 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func quantity(q string) token.Quantity {
+	res, err := token.ToQuantity(q, token.MaxPrecision)
+	Expect(err).NotTo(HaveOccurred())
+	return res
+}
+
+var _ = Describe("Iterator", func() {
+	var engine *Engine
+
+	BeforeEach(func() {
+		engine = NewEngine(nil, "zkat", nil, func(owner view.Identity) string {
+			return "wallet-" + string(owner)
+		})
+	})
+
+	Describe("filter", func() {
+		alice := &token.Owner{Raw: []byte("alice")}
+
+		It("matches everything when empty", func() {
+			Expect(engine.matches(&driver.TokenFilter{}, alice, "USD", quantity("10"))).To(BeTrue())
+			Expect(engine.matches(&driver.TokenFilter{}, nil, "EUR", quantity("0"))).To(BeTrue())
+		})
+
+		It("matches wallet, owner and type", func() {
+			Expect(engine.matches(&driver.TokenFilter{Wallet: "wallet-alice"}, alice, "USD", quantity("10"))).To(BeTrue())
+			Expect(engine.matches(&driver.TokenFilter{Wallet: "wallet-bob"}, alice, "USD", quantity("10"))).To(BeFalse())
+			Expect(engine.matches(&driver.TokenFilter{Owner: view.Identity("alice")}, alice, "USD", quantity("10"))).To(BeTrue())
+			Expect(engine.matches(&driver.TokenFilter{Owner: view.Identity("bob")}, alice, "USD", quantity("10"))).To(BeFalse())
+			Expect(engine.matches(&driver.TokenFilter{Owner: view.Identity("bob")}, nil, "USD", quantity("10"))).To(BeFalse())
+			Expect(engine.matches(&driver.TokenFilter{Type: "USD"}, alice, "USD", quantity("10"))).To(BeTrue())
+			Expect(engine.matches(&driver.TokenFilter{Type: "EUR"}, alice, "USD", quantity("10"))).To(BeFalse())
+		})
+
+		It("matches quantity bounds inclusively", func() {
+			filter := &driver.TokenFilter{MinQuantity: quantity("5"), MaxQuantity: quantity("10")}
+			Expect(engine.matches(filter, alice, "USD", quantity("4"))).To(BeFalse())
+			Expect(engine.matches(filter, alice, "USD", quantity("5"))).To(BeTrue())
+			Expect(engine.matches(filter, alice, "USD", quantity("10"))).To(BeTrue())
+			Expect(engine.matches(filter, alice, "USD", quantity("11"))).To(BeFalse())
+		})
+	})
+
+	Describe("range", func() {
+		It("narrows to the tokens of a transaction", func() {
+			start, end, err := rangeOf(keys.FabTokenKeyPrefix, &driver.TokenFilter{TxID: "tx1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			in, err := keys.CreateFabtokenKey("tx1", 3)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(in >= start && in < end).To(BeTrue())
+
+			for _, txID := range []string{"tx0", "tx10", "tx2"} {
+				out, err := keys.CreateFabtokenKey(txID, 0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out >= start && out < end).To(BeFalse(), txID)
+			}
+		})
+	})
+
+	Describe("cursor", func() {
+		var start, end, key string
+
+		BeforeEach(func() {
+			var err error
+			start, end, err = rangeOf(keys.FabTokenKeyPrefix, &driver.TokenFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			key, err = keys.CreateFabtokenKey("tx1", 0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("resumes after the last returned key", func() {
+			s, err := newScanner(nil, "zkat", start, end, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Cursor()).To(BeEmpty())
+			Expect(s.from).To(Equal(start))
+
+			s.buffer = []*scanned{{key: key, value: "token"}}
+			s.done = true
+			v, err := s.Next()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(Equal("token"))
+			v, err = s.Next()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v).To(BeNil())
+
+			resumed, err := newScanner(nil, "zkat", start, end, s.Cursor(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed.from > key).To(BeTrue())
+			next, err := keys.CreateFabtokenKey("tx1", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed.from <= next).To(BeTrue())
+		})
+
+		It("rejects cursors outside the range", func() {
+			_, err := newScanner(nil, "zkat", start, end, "not base64!", nil)
+			Expect(err).To(HaveOccurred())
+
+			other, err := keys.CreateIssuedHistoryTokenKey("tx1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			s := &scanner{lastKey: other}
+			_, err = newScanner(nil, "zkat", start, end, s.Cursor(), nil)
+			Expect(err).To(MatchError(ContainSubstring("invalid cursor")))
+		})
+	})
+})
@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
@@ -27,12 +28,19 @@ type Channel interface {
 type Engine struct {
 	channel   Channel
 	namespace string
+	index     *index.Index
+	resolver  index.WalletResolver
 }
 
-func NewEngine(channel Channel, namespace string) *Engine {
+// NewEngine returns a query engine for the passed namespace.
+// The unspent tokens of a wallet are looked up in the passed index, shared with the transaction processor.
+// If the index is nil, they are looked up in the vault, using the passed resolver to find their wallet.
+func NewEngine(channel Channel, namespace string, index *index.Index, resolver index.WalletResolver) *Engine {
 	return &Engine{
 		channel:   channel,
 		namespace: namespace,
+		index:     index,
+		resolver:  resolver,
 	}
 }
 
@@ -110,8 +118,9 @@ func (e *Engine) ListUnspentTokens() (*token.UnspentTokens, error) {
 			if err != nil {
 				return nil, err
 			}
-			// Convert quantity to decimal
-			q, err := token.ToQuantity(output.Quantity, keys.Precision)
+			// Convert quantity to decimal.
+			// The vault does not know the public parameters, therefore the largest precision is used.
+			q, err := token.ToQuantity(output.Quantity, token.MaxPrecision)
 			if err != nil {
 				return nil, err
 			}
@@ -126,6 +135,47 @@ func (e *Engine) ListUnspentTokens() (*token.UnspentTokens, error) {
 	}
 }
 
+// ListUnspentTokensOf returns the unspent tokens owned by the passed wallet and of the passed type.
+// The empty type matches all types.
+func (e *Engine) ListUnspentTokensOf(wallet string, typ string) (*token.UnspentTokens, error) {
+	idx, err := e.unspentIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.ListUnspentTokens(wallet, typ)
+}
+
+// Balance returns the sum of the unspent tokens owned by the passed wallet and of the passed type
+func (e *Engine) Balance(wallet string, typ string) (token.Quantity, error) {
+	idx, err := e.unspentIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Balance(wallet, typ)
+}
+
+// Balances returns the balance of each token type owned by the passed wallet
+func (e *Engine) Balances(wallet string) (map[string]token.Quantity, error) {
+	idx, err := e.unspentIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Balances(wallet)
+}
+
+// unspentIndex returns the loaded index of the unspent tokens. Without a shared index,
+// a new one is loaded from the vault at each call.
+func (e *Engine) unspentIndex() (*index.Index, error) {
+	idx := e.index
+	if idx == nil {
+		idx = index.New(e.resolver)
+	}
+	if err := idx.Load(e.ListUnspentTokens); err != nil {
+		return nil, errors.WithMessage(err, "failed loading unspent tokens index")
+	}
+	return idx, nil
+}
+
 func (e *Engine) ListAuditTokens(ids ...*token.Id) ([]*token.Token, error) {
 	logger.Debugf("retrieve inputs for auditing...")
 	qe, err := e.channel.Vault().NewQueryExecutor()
@@ -211,8 +261,9 @@ func (e *Engine) ListHistoryIssuedTokens() (*token.IssuedTokens, error) {
 			if err != nil {
 				return nil, err
 			}
-			// Convert quantity to decimal
-			q, err := token.ToQuantity(output.Quantity, keys.Precision)
+			// Convert quantity to decimal.
+			// The vault does not know the public parameters, therefore the largest precision is used.
+			q, err := token.ToQuantity(output.Quantity, token.MaxPrecision)
 			if err != nil {
 				return nil, err
 			}
@@ -293,6 +344,32 @@ func (e *Engine) GetTokenCommitments(ids []*token.Id, callback driver.QueryCallb
 	return nil
 }
 
+// GetNFT returns the non-fungible token with the passed unique identifier, as recorded on the ledger at issuance time
+func (e *Engine) GetNFT(id string) (*token.NFT, error) {
+	qe, err := e.channel.Vault().NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+
+	key, err := keys.CreateNFTKey(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed generating nft key [%s]", id)
+	}
+	raw, err := qe.GetState(e.namespace, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting nft for key [%s]", key)
+	}
+	if len(raw) == 0 {
+		return nil, errors.Errorf("nft not found for key [%s]", key)
+	}
+	nft := &token.NFT{}
+	if err := json.Unmarshal(raw, nft); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling nft for key [%s]", key)
+	}
+	return nft, nil
+}
+
 func (e *Engine) GetTokens(ids ...*token.Id) ([]*token.Token, error) {
 	logger.Debugf("retrieve tokens from ids...")
 	qe, err := e.channel.Vault().NewQueryExecutor()
@@ -5,6 +5,8 @@ SPDX-License-Identifier: Apache-2.0
 */
 package translator
 
+import token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+
 type SetupAction interface {
 	GetSetupParameters() ([]byte, error)
 }
@@ -17,6 +19,11 @@ type IssueAction interface {
 	GetSerializedOutputs() ([][]byte, error)
 	IsAnonymous() bool
 	GetIssuer() []byte
+	GetNFTs() []*token2.NFT
+	// GetTokenTypes returns the types of the issued tokens, or nil if the action hides them
+	GetTokenTypes() []string
+	// GetIssuedQuantities returns the quantity issued of each token type, or nil if the action hides them
+	GetIssuedQuantities() (map[string]token2.Quantity, error)
 }
 
 //go:generate counterfeiter -o mock/transfer_action.go -fake-name TransferAction . TransferAction
@@ -29,4 +36,6 @@ type TransferAction interface {
 	SerializeOutputAt(index int) ([]byte, error)
 	GetInputs() ([]string, error)
 	IsGraphHiding() bool
+	// GetNFTs returns the non-fungible tokens moved by this action, one for each output, or nil
+	GetNFTs() []*token2.NFT
 }
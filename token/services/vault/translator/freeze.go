@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package translator
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+)
+
+// FreezeRecord is stored on the ledger for each frozen token and owner
+type FreezeRecord struct {
+	// TxID is the transaction that froze the token or the owner
+	TxID string
+	// Seized is true if the frozen token has been seized. A seized token cannot be unfrozen.
+	Seized bool `json:",omitempty"`
+}
+
+// Bytes returns the json encoding of the record
+func (r *FreezeRecord) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// GetFreezeRecord returns the freeze record stored under the passed key, or nil if there is none
+func GetFreezeRecord(getState func(key string) ([]byte, error), key string) (*FreezeRecord, error) {
+	raw, err := getState(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting freeze record [%s]", key)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	record := &FreezeRecord{}
+	if err := json.Unmarshal(raw, record); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling freeze record [%s]", key)
+	}
+	return record, nil
+}
+
+// CheckNotFrozen returns an error if the token stored under the passed ledger key, or the passed owner, is frozen.
+// If the owner is nil, only the token is checked. Keys that do not identify a token are not checked.
+func CheckNotFrozen(getState func(key string) ([]byte, error), tokenKey string, owner []byte) error {
+	id, err := keys.GetTokenIdFromKey(tokenKey)
+	if err != nil {
+		logger.Debugf("key [%s] does not identify a token, skipping freeze check", tokenKey)
+		return nil
+	}
+	key, err := keys.CreateFrozenTokenKey(id.TxId, int(id.Index))
+	if err != nil {
+		return err
+	}
+	record, err := GetFreezeRecord(getState, key)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		return errors.Errorf("token [%s] is frozen", id)
+	}
+	if len(owner) == 0 {
+		return nil
+	}
+	key, err = keys.CreateFrozenOwnerKey(owner)
+	if err != nil {
+		return err
+	}
+	record, err = GetFreezeRecord(getState, key)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		return errors.Errorf("the owner of token [%s] is frozen", id)
+	}
+	return nil
+}
@@ -5,12 +5,32 @@ SPDX-License-Identifier: Apache-2.0
 */
 package translator
 
-import "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 //go:generate counterfeiter -o mock/issuing_validator.go -fake-name IssuingValidator . IssuingValidator
 
 // IssuingValidator is used to establish if the creator can issue tokens of the passed type.
 type IssuingValidator interface {
-	// Validate returns no error if the passed creator can issue tokens of the passed type,, an error otherwise.
+	// Validate returns no error if the passed creator can issue tokens of the passed type, an error otherwise.
 	Validate(creator view.Identity, tokenType string) error
 }
+
+// NewIssuingValidator returns an IssuingValidator that checks the creator against the issuers of the token types
+// registered in the passed registry.
+// Issuing is denied by default, an empty registry does not allow any creator to issue any type.
+func NewIssuingValidator(registry token2.TypeRegistry) IssuingValidator {
+	return &registryIssuingValidator{registry: registry}
+}
+
+type registryIssuingValidator struct {
+	registry token2.TypeRegistry
+}
+
+func (v *registryIssuingValidator) Validate(creator view.Identity, tokenType string) error {
+	_, err := v.registry.CheckIssue(tokenType, creator)
+	return err
+}
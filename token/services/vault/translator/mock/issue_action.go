@@ -5,9 +5,22 @@ import (
 	"sync"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type IssueAction struct {
+	GetIssuedQuantitiesStub        func() (map[string]token.Quantity, error)
+	getIssuedQuantitiesMutex       sync.RWMutex
+	getIssuedQuantitiesArgsForCall []struct {
+	}
+	getIssuedQuantitiesReturns struct {
+		result1 map[string]token.Quantity
+		result2 error
+	}
+	getIssuedQuantitiesReturnsOnCall map[int]struct {
+		result1 map[string]token.Quantity
+		result2 error
+	}
 	GetIssuerStub        func() []byte
 	getIssuerMutex       sync.RWMutex
 	getIssuerArgsForCall []struct {
@@ -18,6 +31,16 @@ type IssueAction struct {
 	getIssuerReturnsOnCall map[int]struct {
 		result1 []byte
 	}
+	GetNFTsStub        func() []*token.NFT
+	getNFTsMutex       sync.RWMutex
+	getNFTsArgsForCall []struct {
+	}
+	getNFTsReturns struct {
+		result1 []*token.NFT
+	}
+	getNFTsReturnsOnCall map[int]struct {
+		result1 []*token.NFT
+	}
 	GetSerializedOutputsStub        func() ([][]byte, error)
 	getSerializedOutputsMutex       sync.RWMutex
 	getSerializedOutputsArgsForCall []struct {
@@ -30,6 +53,16 @@ type IssueAction struct {
 		result1 [][]byte
 		result2 error
 	}
+	GetTokenTypesStub        func() []string
+	getTokenTypesMutex       sync.RWMutex
+	getTokenTypesArgsForCall []struct {
+	}
+	getTokenTypesReturns struct {
+		result1 []string
+	}
+	getTokenTypesReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	IsAnonymousStub        func() bool
 	isAnonymousMutex       sync.RWMutex
 	isAnonymousArgsForCall []struct {
@@ -66,6 +99,61 @@ type IssueAction struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *IssueAction) GetIssuedQuantities() (map[string]token.Quantity, error) {
+	fake.getIssuedQuantitiesMutex.Lock()
+	ret, specificReturn := fake.getIssuedQuantitiesReturnsOnCall[len(fake.getIssuedQuantitiesArgsForCall)]
+	fake.getIssuedQuantitiesArgsForCall = append(fake.getIssuedQuantitiesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetIssuedQuantities", []interface{}{})
+	fake.getIssuedQuantitiesMutex.Unlock()
+	if fake.GetIssuedQuantitiesStub != nil {
+		return fake.GetIssuedQuantitiesStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getIssuedQuantitiesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *IssueAction) GetIssuedQuantitiesCallCount() int {
+	fake.getIssuedQuantitiesMutex.RLock()
+	defer fake.getIssuedQuantitiesMutex.RUnlock()
+	return len(fake.getIssuedQuantitiesArgsForCall)
+}
+
+func (fake *IssueAction) GetIssuedQuantitiesCalls(stub func() (map[string]token.Quantity, error)) {
+	fake.getIssuedQuantitiesMutex.Lock()
+	defer fake.getIssuedQuantitiesMutex.Unlock()
+	fake.GetIssuedQuantitiesStub = stub
+}
+
+func (fake *IssueAction) GetIssuedQuantitiesReturns(result1 map[string]token.Quantity, result2 error) {
+	fake.getIssuedQuantitiesMutex.Lock()
+	defer fake.getIssuedQuantitiesMutex.Unlock()
+	fake.GetIssuedQuantitiesStub = nil
+	fake.getIssuedQuantitiesReturns = struct {
+		result1 map[string]token.Quantity
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *IssueAction) GetIssuedQuantitiesReturnsOnCall(i int, result1 map[string]token.Quantity, result2 error) {
+	fake.getIssuedQuantitiesMutex.Lock()
+	defer fake.getIssuedQuantitiesMutex.Unlock()
+	fake.GetIssuedQuantitiesStub = nil
+	if fake.getIssuedQuantitiesReturnsOnCall == nil {
+		fake.getIssuedQuantitiesReturnsOnCall = make(map[int]struct {
+			result1 map[string]token.Quantity
+			result2 error
+		})
+	}
+	fake.getIssuedQuantitiesReturnsOnCall[i] = struct {
+		result1 map[string]token.Quantity
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *IssueAction) GetIssuer() []byte {
 	fake.getIssuerMutex.Lock()
 	ret, specificReturn := fake.getIssuerReturnsOnCall[len(fake.getIssuerArgsForCall)]
@@ -118,6 +206,58 @@ func (fake *IssueAction) GetIssuerReturnsOnCall(i int, result1 []byte) {
 	}{result1}
 }
 
+func (fake *IssueAction) GetNFTs() []*token.NFT {
+	fake.getNFTsMutex.Lock()
+	ret, specificReturn := fake.getNFTsReturnsOnCall[len(fake.getNFTsArgsForCall)]
+	fake.getNFTsArgsForCall = append(fake.getNFTsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetNFTs", []interface{}{})
+	fake.getNFTsMutex.Unlock()
+	if fake.GetNFTsStub != nil {
+		return fake.GetNFTsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.getNFTsReturns
+	return fakeReturns.result1
+}
+
+func (fake *IssueAction) GetNFTsCallCount() int {
+	fake.getNFTsMutex.RLock()
+	defer fake.getNFTsMutex.RUnlock()
+	return len(fake.getNFTsArgsForCall)
+}
+
+func (fake *IssueAction) GetNFTsCalls(stub func() []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = stub
+}
+
+func (fake *IssueAction) GetNFTsReturns(result1 []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = nil
+	fake.getNFTsReturns = struct {
+		result1 []*token.NFT
+	}{result1}
+}
+
+func (fake *IssueAction) GetNFTsReturnsOnCall(i int, result1 []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = nil
+	if fake.getNFTsReturnsOnCall == nil {
+		fake.getNFTsReturnsOnCall = make(map[int]struct {
+			result1 []*token.NFT
+		})
+	}
+	fake.getNFTsReturnsOnCall[i] = struct {
+		result1 []*token.NFT
+	}{result1}
+}
+
 func (fake *IssueAction) GetSerializedOutputs() ([][]byte, error) {
 	fake.getSerializedOutputsMutex.Lock()
 	ret, specificReturn := fake.getSerializedOutputsReturnsOnCall[len(fake.getSerializedOutputsArgsForCall)]
@@ -173,6 +313,58 @@ func (fake *IssueAction) GetSerializedOutputsReturnsOnCall(i int, result1 [][]by
 	}{result1, result2}
 }
 
+func (fake *IssueAction) GetTokenTypes() []string {
+	fake.getTokenTypesMutex.Lock()
+	ret, specificReturn := fake.getTokenTypesReturnsOnCall[len(fake.getTokenTypesArgsForCall)]
+	fake.getTokenTypesArgsForCall = append(fake.getTokenTypesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetTokenTypes", []interface{}{})
+	fake.getTokenTypesMutex.Unlock()
+	if fake.GetTokenTypesStub != nil {
+		return fake.GetTokenTypesStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.getTokenTypesReturns
+	return fakeReturns.result1
+}
+
+func (fake *IssueAction) GetTokenTypesCallCount() int {
+	fake.getTokenTypesMutex.RLock()
+	defer fake.getTokenTypesMutex.RUnlock()
+	return len(fake.getTokenTypesArgsForCall)
+}
+
+func (fake *IssueAction) GetTokenTypesCalls(stub func() []string) {
+	fake.getTokenTypesMutex.Lock()
+	defer fake.getTokenTypesMutex.Unlock()
+	fake.GetTokenTypesStub = stub
+}
+
+func (fake *IssueAction) GetTokenTypesReturns(result1 []string) {
+	fake.getTokenTypesMutex.Lock()
+	defer fake.getTokenTypesMutex.Unlock()
+	fake.GetTokenTypesStub = nil
+	fake.getTokenTypesReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *IssueAction) GetTokenTypesReturnsOnCall(i int, result1 []string) {
+	fake.getTokenTypesMutex.Lock()
+	defer fake.getTokenTypesMutex.Unlock()
+	fake.GetTokenTypesStub = nil
+	if fake.getTokenTypesReturnsOnCall == nil {
+		fake.getTokenTypesReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.getTokenTypesReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *IssueAction) IsAnonymous() bool {
 	fake.isAnonymousMutex.Lock()
 	ret, specificReturn := fake.isAnonymousReturnsOnCall[len(fake.isAnonymousArgsForCall)]
@@ -335,10 +527,16 @@ func (fake *IssueAction) SerializeReturnsOnCall(i int, result1 []byte, result2 e
 func (fake *IssueAction) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getIssuedQuantitiesMutex.RLock()
+	defer fake.getIssuedQuantitiesMutex.RUnlock()
 	fake.getIssuerMutex.RLock()
 	defer fake.getIssuerMutex.RUnlock()
+	fake.getNFTsMutex.RLock()
+	defer fake.getNFTsMutex.RUnlock()
 	fake.getSerializedOutputsMutex.RLock()
 	defer fake.getSerializedOutputsMutex.RUnlock()
+	fake.getTokenTypesMutex.RLock()
+	defer fake.getTokenTypesMutex.RUnlock()
 	fake.isAnonymousMutex.RLock()
 	defer fake.isAnonymousMutex.RUnlock()
 	fake.numOutputsMutex.RLock()
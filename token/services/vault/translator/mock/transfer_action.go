@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 type TransferAction struct {
@@ -20,6 +21,16 @@ type TransferAction struct {
 		result1 []string
 		result2 error
 	}
+	GetNFTsStub        func() []*token.NFT
+	getNFTsMutex       sync.RWMutex
+	getNFTsArgsForCall []struct {
+	}
+	getNFTsReturns struct {
+		result1 []*token.NFT
+	}
+	getNFTsReturnsOnCall map[int]struct {
+		result1 []*token.NFT
+	}
 	GetSerializedOutputsStub        func() ([][]byte, error)
 	getSerializedOutputsMutex       sync.RWMutex
 	getSerializedOutputsArgsForCall []struct {
@@ -112,6 +123,8 @@ func (fake *TransferAction) GetInputs() ([]string, error) {
 func (fake *TransferAction) GetInputsCallCount() int {
 	fake.getInputsMutex.RLock()
 	defer fake.getInputsMutex.RUnlock()
+	fake.getNFTsMutex.RLock()
+	defer fake.getNFTsMutex.RUnlock()
 	return len(fake.getInputsArgsForCall)
 }
 
@@ -147,6 +160,58 @@ func (fake *TransferAction) GetInputsReturnsOnCall(i int, result1 []string, resu
 	}{result1, result2}
 }
 
+func (fake *TransferAction) GetNFTs() []*token.NFT {
+	fake.getNFTsMutex.Lock()
+	ret, specificReturn := fake.getNFTsReturnsOnCall[len(fake.getNFTsArgsForCall)]
+	fake.getNFTsArgsForCall = append(fake.getNFTsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetNFTs", []interface{}{})
+	fake.getNFTsMutex.Unlock()
+	if fake.GetNFTsStub != nil {
+		return fake.GetNFTsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.getNFTsReturns
+	return fakeReturns.result1
+}
+
+func (fake *TransferAction) GetNFTsCallCount() int {
+	fake.getNFTsMutex.RLock()
+	defer fake.getNFTsMutex.RUnlock()
+	return len(fake.getNFTsArgsForCall)
+}
+
+func (fake *TransferAction) GetNFTsCalls(stub func() []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = stub
+}
+
+func (fake *TransferAction) GetNFTsReturns(result1 []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = nil
+	fake.getNFTsReturns = struct {
+		result1 []*token.NFT
+	}{result1}
+}
+
+func (fake *TransferAction) GetNFTsReturnsOnCall(i int, result1 []*token.NFT) {
+	fake.getNFTsMutex.Lock()
+	defer fake.getNFTsMutex.Unlock()
+	fake.GetNFTsStub = nil
+	if fake.getNFTsReturnsOnCall == nil {
+		fake.getNFTsReturnsOnCall = make(map[int]struct {
+			result1 []*token.NFT
+		})
+	}
+	fake.getNFTsReturnsOnCall[i] = struct {
+		result1 []*token.NFT
+	}{result1}
+}
+
 func (fake *TransferAction) GetSerializedOutputs() ([][]byte, error) {
 	fake.getSerializedOutputsMutex.Lock()
 	ret, specificReturn := fake.getSerializedOutputsReturnsOnCall[len(fake.getSerializedOutputsArgsForCall)]
@@ -489,6 +554,8 @@ func (fake *TransferAction) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.getInputsMutex.RLock()
 	defer fake.getInputsMutex.RUnlock()
+	fake.getNFTsMutex.RLock()
+	defer fake.getNFTsMutex.RUnlock()
 	fake.getSerializedOutputsMutex.RLock()
 	defer fake.getSerializedOutputsMutex.RUnlock()
 	fake.isGraphHidingMutex.RLock()
@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package translator
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// GetSupply returns the total quantity of the passed token type issued so far, as recorded on the ledger.
+// Only the issues that reveal their quantities are recorded.
+func GetSupply(getState func(key string) ([]byte, error), tokenType string) (token2.Quantity, error) {
+	key, err := keys.CreateSupplyKey(tokenType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating supply key for [%s]", tokenType)
+	}
+	raw, err := getState(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting supply of [%s]", tokenType)
+	}
+	if len(raw) == 0 {
+		return token2.NewZeroQuantity(token2.MaxPrecision), nil
+	}
+	q, err := token2.ToQuantity(string(raw), token2.MaxPrecision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid supply of [%s]", tokenType)
+	}
+	return q, nil
+}
+
+// addSupply adds the quantities issued by the passed action to the supply recorded on the ledger
+func (w *Translator) addSupply(issueAction IssueAction) error {
+	quantities, err := issueAction.GetIssuedQuantities()
+	if err != nil {
+		return errors.WithMessage(err, "failed getting issued quantities")
+	}
+	types := make([]string, 0, len(quantities))
+	for typ := range quantities {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	getState := func(key string) ([]byte, error) {
+		return w.RWSet.GetState(w.namespace, key)
+	}
+	for _, typ := range types {
+		supply, err := GetSupply(getState, typ)
+		if err != nil {
+			return err
+		}
+		key, err := keys.CreateSupplyKey(typ)
+		if err != nil {
+			return errors.Wrapf(err, "failed creating supply key for [%s]", typ)
+		}
+		if err := w.RWSet.SetState(w.namespace, key, []byte(supply.Add(quantities[typ]).Decimal())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package translator
 
 import (
+	"encoding/json"
 	"strconv"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
@@ -112,6 +113,22 @@ func (w *Translator) checkIssue(issue IssueAction) error {
 			return err
 		}
 	}
+
+	// check that the unique identifiers of the issued non-fungible tokens have never been used
+	ids := map[string]bool{}
+	for _, nft := range issue.GetNFTs() {
+		if nft == nil {
+			return errors.New("invalid issue: nil nft")
+		}
+		if ids[nft.ID] {
+			return errors.Errorf("invalid issue: nft [%s] is issued more than once", nft.ID)
+		}
+		ids[nft.ID] = true
+		err = w.checkNFTDoesNotExist(nft.ID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -129,6 +146,11 @@ func (w *Translator) checkTransfer(t TransferAction) error {
 			if len(bytes) == 0 {
 				return errors.Errorf("invalid transfer: input is already spent [%s]", key)
 			}
+			if err := CheckNotFrozen(func(k string) ([]byte, error) {
+				return w.RWSet.GetState(w.namespace, k)
+			}, key, nil); err != nil {
+				return errors.Wrapf(err, "invalid transfer: input cannot be spent [%s]", key)
+			}
 		}
 	} else {
 		for _, key := range keys {
@@ -171,9 +193,43 @@ func (w *Translator) checkTokenDoesNotExist(index int, txID string) error {
 	return nil
 }
 
+func (w *Translator) checkNFTDoesNotExist(id string) error {
+	nftKey, err := keys.CreateNFTKey(id)
+	if err != nil {
+		return errors.Wrapf(err, "error creating nft key for [%s]", id)
+	}
+
+	nftBytes, err := w.RWSet.GetState(w.namespace, nftKey)
+	if err != nil {
+		return err
+	}
+	if len(nftBytes) != 0 {
+		return errors.Errorf("nft already exists: %s", id)
+	}
+	return nil
+}
+
+// checkIssuePolicy checks the issuer against the types of the issued tokens.
+// The types of the declared non-fungible tokens are bound to the outputs by the validator.
+// Only anonymous issues can hide their types: the validator checked their proof against the issuer keys
+// listed in the public parameters.
 func (w *Translator) checkIssuePolicy(issue IssueAction) error {
-	// TODO: retrieve type from action
-	return w.IssuingValidator.Validate(issue.GetIssuer(), "")
+	types := issue.GetTokenTypes()
+	for _, nft := range issue.GetNFTs() {
+		types = append(types, nft.Type())
+	}
+	if len(types) == 0 {
+		if issue.IsAnonymous() {
+			return nil
+		}
+		return errors.New("the issue action hides the types of its tokens")
+	}
+	for _, typ := range types {
+		if err := w.IssuingValidator.Validate(issue.GetIssuer(), typ); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (w *Translator) commitProcess(action interface{}) error {
@@ -241,10 +297,47 @@ func (w *Translator) commitIssueAction(issueAction IssueAction) error {
 			return err
 		}
 	}
+	for _, nft := range issueAction.GetNFTs() {
+		nftKey, err := keys.CreateNFTKey(nft.ID)
+		if err != nil {
+			return errors.Errorf("error creating nft key: %s", err)
+		}
+		raw, err := json.Marshal(nft)
+		if err != nil {
+			return errors.Wrapf(err, "failed marshalling nft [%s]", nft.ID)
+		}
+		if err := w.RWSet.SetState(w.namespace, nftKey, raw); err != nil {
+			return err
+		}
+	}
+	if err := w.markNFTs(issueAction.GetNFTs(), base, nil); err != nil {
+		return err
+	}
+	if err := w.addSupply(issueAction); err != nil {
+		return err
+	}
 	w.counter = w.counter + len(outputs)
 	return nil
 }
 
+// markNFTs marks the outputs, starting at base, that hold the passed non-fungible tokens, one for each output.
+// Redeemed outputs are not marked.
+func (w *Translator) markNFTs(nfts []*token2.NFT, base int, isRedeemAt func(int) bool) error {
+	for i, nft := range nfts {
+		if nft == nil || (isRedeemAt != nil && isRedeemAt(i)) {
+			continue
+		}
+		key, err := keys.CreateNFTTokenKey(w.TxID, base+i)
+		if err != nil {
+			return errors.Errorf("error creating nft token key: %s", err)
+		}
+		if err := w.RWSet.SetState(w.namespace, key, []byte(nft.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // commitTransferAction is called for both transfer and redeem transactions
 // Check the owner of each output to determine how to generate the key
 func (w *Translator) commitTransferAction(transferAction TransferAction) error {
@@ -270,6 +363,9 @@ func (w *Translator) commitTransferAction(transferAction TransferAction) error {
 			}
 		}
 	}
+	if err := w.markNFTs(transferAction.GetNFTs(), base, transferAction.IsRedeemAt); err != nil {
+		return err
+	}
 	ids, err := transferAction.GetInputs()
 	if err != nil {
 		return err
@@ -296,6 +392,10 @@ func (w *Translator) spendTokens(ids []string, graphHiding bool) error {
 			if err != nil {
 				return err
 			}
+
+			if err := w.unmarkNFT(id); err != nil {
+				return err
+			}
 		}
 	} else {
 		for _, id := range ids {
@@ -310,6 +410,28 @@ func (w *Translator) spendTokens(ids []string, graphHiding bool) error {
 	return nil
 }
 
+// unmarkNFT removes the non-fungible token mark of the spent token with the passed key, if any
+func (w *Translator) unmarkNFT(tokenKey string) error {
+	id, err := keys.GetTokenIdFromKey(tokenKey)
+	if err != nil {
+		logger.Debugf("key [%s] does not identify a token, skipping nft mark", tokenKey)
+		return nil
+	}
+	key, err := keys.CreateNFTTokenKey(id.TxId, int(id.Index))
+	if err != nil {
+		return errors.Errorf("error creating nft token key: %s", err)
+	}
+	raw, err := w.RWSet.GetState(w.namespace, key)
+	if err != nil {
+		return errors.Wrapf(err, "failed getting state [%s]", key)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	logger.Debugf("Delete nft mark %s\n", key)
+	return w.RWSet.DeleteState(w.namespace, key)
+}
+
 func (w *Translator) ReadSetupParameters() ([]byte, error) {
 	setupKey, err := keys.CreateSetupKey()
 	if err != nil {
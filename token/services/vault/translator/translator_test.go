@@ -8,9 +8,11 @@ package translator_test
 import (
 	"strconv"
 
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	writer2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
 	mock "github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator/mock"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -65,6 +67,7 @@ var _ = Describe("Translator", func() {
 		BeforeEach(func() {
 			fakeissue.GetSerializedOutputsReturns([][]byte{[]byte("output-1"), []byte("output-2")}, nil)
 			fakeissue.NumOutputsReturns(2)
+			fakeissue.GetTokenTypesReturns([]string{"ABC"})
 		})
 		When("issue action is valid", func() {
 			It("succeeds", func() {
@@ -139,6 +142,158 @@ var _ = Describe("Translator", func() {
 			})
 		})
 
+		When("issue action reveals the token types", func() {
+			BeforeEach(func() {
+				fakeissue.GetIssuerReturns([]byte("alice"))
+				fakeissue.GetTokenTypesReturns([]string{"USD", "EUR"})
+			})
+			It("validates each type", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeIssuingValidator.ValidateCallCount()).To(Equal(2))
+				creator, typ := fakeIssuingValidator.ValidateArgsForCall(0)
+				Expect([]byte(creator)).To(Equal([]byte("alice")))
+				Expect(typ).To(Equal("USD"))
+				_, typ = fakeIssuingValidator.ValidateArgsForCall(1)
+				Expect(typ).To(Equal("EUR"))
+			})
+		})
+
+		When("issue action hides the token types", func() {
+			BeforeEach(func() {
+				fakeissue.GetTokenTypesReturns(nil)
+			})
+			It("issue fails if the action is not anonymous", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the issue action hides the types of its tokens"))
+				Expect(fakeIssuingValidator.ValidateCallCount()).To(Equal(0))
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(0))
+			})
+			It("succeeds if the action is anonymous", func() {
+				fakeissue.IsAnonymousReturns(true)
+				err := writer.Write(fakeissue)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeIssuingValidator.ValidateCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the registry is empty", func() {
+			BeforeEach(func() {
+				writer = writer2.New(writer2.NewIssuingValidator(nil), "0", fakeRWSet, "zkat")
+				fakeissue.GetIssuerReturns([]byte("alice"))
+			})
+			It("issue fails", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("token type [ABC] is not registered"))
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(0))
+			})
+		})
+
+		When("issuer is not in the issuer list of the token type", func() {
+			BeforeEach(func() {
+				registry := token2.TypeRegistry{}.AddIssuer("USD", []byte("alice"))
+				writer = writer2.New(writer2.NewIssuingValidator(registry), "0", fakeRWSet, "zkat")
+				fakeissue.GetIssuerReturns([]byte("bob"))
+				fakeissue.GetTokenTypesReturns([]string{"USD"})
+			})
+			It("issue fails", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("issuer is not allowed to issue token type [USD]"))
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(0))
+			})
+		})
+
+		When("issue action creates a non-fungible token", func() {
+			BeforeEach(func() {
+				fakeissue.GetSerializedOutputsReturns([][]byte{[]byte("output-1")}, nil)
+				fakeissue.NumOutputsReturns(1)
+				fakeissue.GetNFTsReturns([]*token2.NFT{{ID: "painting", URI: "ipfs://painting"}})
+			})
+			It("succeeds and records the nft", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRWSet.GetStateCallCount()).To(Equal(2))
+				_, id, _ := fakeRWSet.GetStateArgsForCall(1)
+				key, err := keys.CreateNFTKey("painting")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(Equal(key))
+
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(3))
+				ns, id, out := fakeRWSet.SetStateArgsForCall(1)
+				Expect(ns).To(Equal(tokenNameSpace))
+				Expect(id).To(Equal(key))
+				Expect(out).To(MatchJSON(`{"id":"painting","uri":"ipfs://painting"}`))
+
+				// the output holding the nft is marked
+				ns, id, out = fakeRWSet.SetStateArgsForCall(2)
+				Expect(ns).To(Equal(tokenNameSpace))
+				key, err = keys.CreateNFTTokenKey("0", 0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(Equal(key))
+				Expect(out).To(Equal([]byte("painting")))
+			})
+		})
+
+		When("issue action reveals its quantities", func() {
+			BeforeEach(func() {
+				fakeissue.GetSerializedOutputsReturns([][]byte{[]byte("output-1")}, nil)
+				fakeissue.NumOutputsReturns(1)
+				q, err := token2.ToQuantity("50", token2.MaxPrecision)
+				Expect(err).NotTo(HaveOccurred())
+				fakeissue.GetIssuedQuantitiesReturns(map[string]token2.Quantity{"USD": q}, nil)
+				supplyKey, err := keys.CreateSupplyKey("USD")
+				Expect(err).NotTo(HaveOccurred())
+				fakeRWSet.GetStateStub = func(ns string, key string, opts ...fabric.GetStateOpt) ([]byte, error) {
+					if key == supplyKey {
+						return []byte("100"), nil
+					}
+					return nil, nil
+				}
+			})
+			It("succeeds and adds them to the supply", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(2))
+				ns, id, out := fakeRWSet.SetStateArgsForCall(1)
+				Expect(ns).To(Equal(tokenNameSpace))
+				key, err := keys.CreateSupplyKey("USD")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(Equal(key))
+				Expect(out).To(Equal([]byte("150")))
+			})
+		})
+
+		When("the non-fungible token already exists", func() {
+			BeforeEach(func() {
+				fakeissue.GetSerializedOutputsReturns([][]byte{[]byte("output-1")}, nil)
+				fakeissue.NumOutputsReturns(1)
+				fakeissue.GetNFTsReturns([]*token2.NFT{{ID: "painting", URI: "ipfs://painting"}})
+				fakeRWSet.GetStateReturnsOnCall(1, []byte(`{"id":"painting"}`), nil)
+			})
+			It("issue fails", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("nft already exists: painting"))
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the same non-fungible token is issued twice in the same action", func() {
+			BeforeEach(func() {
+				fakeissue.GetNFTsReturns([]*token2.NFT{{ID: "painting"}, {ID: "painting"}})
+			})
+			It("issue fails", func() {
+				err := writer.Write(fakeissue)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("nft [painting] is issued more than once"))
+			})
+		})
+
 	})
 	Describe("Transfer: transaction graph revealed", func() {
 		BeforeEach(func() {
@@ -187,6 +342,69 @@ var _ = Describe("Translator", func() {
 
 			})
 		})
+		When("an input token holds a non-fungible token", func() {
+			var input, mark string
+			BeforeEach(func() {
+				var err error
+				input, err = keys.CreateTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				mark, err = keys.CreateNFTTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				faketransfer.GetInputsReturns([]string{input}, nil)
+				faketransfer.NumOutputsReturns(1)
+				faketransfer.GetNFTsReturns([]*token2.NFT{{ID: "painting"}})
+				fakeRWSet.GetStateStub = func(ns string, key string, opts ...fabric.GetStateOpt) ([]byte, error) {
+					switch key {
+					case input:
+						return []byte("token-1"), nil
+					case mark:
+						return []byte("painting"), nil
+					}
+					return nil, nil
+				}
+			})
+			It("moves the mark to the output", func() {
+				err := writer.Write(faketransfer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(2))
+				_, id, out := fakeRWSet.SetStateArgsForCall(1)
+				key, err := keys.CreateNFTTokenKey("0", 0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(Equal(key))
+				Expect(out).To(Equal([]byte("painting")))
+
+				Expect(fakeRWSet.DeleteStateCallCount()).To(Equal(2))
+				_, id = fakeRWSet.DeleteStateArgsForCall(0)
+				Expect(id).To(Equal(input))
+				_, id = fakeRWSet.DeleteStateArgsForCall(1)
+				Expect(id).To(Equal(mark))
+			})
+		})
+		When("an input token is frozen", func() {
+			BeforeEach(func() {
+				input, err := keys.CreateTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				frozen, err := keys.CreateFrozenTokenKey("tx1", 0)
+				Expect(err).NotTo(HaveOccurred())
+				faketransfer.GetInputsReturns([]string{input}, nil)
+				fakeRWSet.GetStateStub = func(ns string, key string, opts ...fabric.GetStateOpt) ([]byte, error) {
+					switch key {
+					case input:
+						return []byte("token-1"), nil
+					case frozen:
+						return (&writer2.FreezeRecord{TxID: "tx2"}).Bytes()
+					}
+					return nil, nil
+				}
+			})
+			It("transfer fails", func() {
+				err := writer.Write(faketransfer)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("token [[tx1:0]] is frozen"))
+				Expect(fakeRWSet.SetStateCallCount()).To(Equal(0))
+			})
+		})
 		When("created tokens already exist", func() {
 			BeforeEach(func() {
 				fakeRWSet.GetStateReturnsOnCall(3, []byte("this is already occupied"), nil)
@@ -10,6 +10,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/certification"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/index"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/query"
 )
 
@@ -24,9 +25,18 @@ type Vault struct {
 	certificationStorage *certification.Storage
 }
 
-func NewVault(sp view.ServiceProvider, channel Channel, namespace string) *Vault {
+func NewVault(sp view.ServiceProvider, network string, channel Channel, namespace string) *Vault {
+	var idx *index.Index
+	if provider := index.GetProvider(sp); provider != nil {
+		idx = provider.Index(network, channel.Name(), namespace)
+	}
 	return &Vault{
-		queryEngine:          query.NewEngine(channel, namespace),
+		queryEngine: query.NewEngine(
+			channel,
+			namespace,
+			idx,
+			index.NewWalletResolver(sp, network, channel.Name(), namespace),
+		),
 		certificationStorage: certification.NewStorage(sp, channel, namespace),
 	}
 }
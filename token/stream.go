@@ -13,7 +13,20 @@ import (
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
+// ActionType is the kind of action an output belongs to
+type ActionType int
+
+const (
+	// IssueActionType is the type of the issue actions
+	IssueActionType ActionType = iota
+	// TransferActionType is the type of the transfer actions, redeems included
+	TransferActionType
+)
+
 type Output struct {
+	// ActionType is the kind of the action the output belongs to.
+	// ActionIndex is the index of the action among the actions of that kind.
+	ActionType   ActionType
 	ActionIndex  int
 	Owner        view.Identity
 	EnrollmentID string
@@ -126,7 +126,16 @@ func (t *ManagementService) Validator() *Validator {
 }
 
 func (t *ManagementService) Vault() *Vault {
-	return &Vault{v: t.vaultProvider.Vault(t.network, t.channel, t.namespace)}
+	return &Vault{
+		v:      t.vaultProvider.Vault(t.network, t.channel, t.namespace),
+		events: GetEventHub(t.sp, t),
+	}
+}
+
+// Subscribe returns a subscription to the token events of this service selected by the passed filter.
+// See Vault.Subscribe.
+func (t *ManagementService) Subscribe(filter *EventFilter, bufferSize int) (*Subscription, error) {
+	return t.Vault().Subscribe(filter, bufferSize)
 }
 
 func (t *ManagementService) WalletManager() *WalletManager {
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// NFTTypePrefix is the prefix of the type of any non-fungible token.
+	// The type of a non-fungible token is NFTTypePrefix followed by its unique identifier.
+	NFTTypePrefix = "nft:"
+	// NFTQuantity is the quantity carried by any non-fungible token
+	NFTQuantity uint64 = 1
+)
+
+// NFT describes a non-fungible token, that is a token of quantity one bound to a unique identifier
+type NFT struct {
+	// ID is the unique identifier of the token
+	ID string `json:"id"`
+	// URI points to the metadata attached to the token
+	URI string `json:"uri,omitempty"`
+}
+
+// Type returns the token type of the non-fungible token
+func (n *NFT) Type() string {
+	return NFTTypePrefix + n.ID
+}
+
+// Validate returns an error if the non-fungible token is not well-formed
+func (n *NFT) Validate() error {
+	if len(n.ID) == 0 {
+		return errors.New("invalid nft: empty identifier")
+	}
+	if strings.ContainsRune(n.ID, 0) {
+		return errors.Errorf("invalid nft: identifier [%s] contains the null character", n.ID)
+	}
+	return nil
+}
+
+// IsNFTType returns true if the passed token type is the type of a non-fungible token
+func IsNFTType(typ string) bool {
+	return strings.HasPrefix(typ, NFTTypePrefix) && len(typ) > len(NFTTypePrefix)
+}
+
+// NFTIDFromType returns the unique identifier encoded in the passed token type.
+// It returns an error if the type is not the type of a non-fungible token.
+func NFTIDFromType(typ string) (string, error) {
+	if !IsNFTType(typ) {
+		return "", errors.Errorf("type [%s] is not a non-fungible token type", typ)
+	}
+	return strings.TrimPrefix(typ, NFTTypePrefix), nil
+}
@@ -0,0 +1,34 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token_test
+
+import (
+	"testing"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNFTType(t *testing.T) {
+	nft := &token2.NFT{ID: "painting-42", URI: "ipfs://QmPainting42"}
+	assert.NoError(t, nft.Validate())
+	assert.Equal(t, "nft:painting-42", nft.Type())
+	assert.True(t, token2.IsNFTType(nft.Type()))
+
+	id, err := token2.NFTIDFromType(nft.Type())
+	assert.NoError(t, err)
+	assert.Equal(t, "painting-42", id)
+
+	assert.False(t, token2.IsNFTType("USD"))
+	assert.False(t, token2.IsNFTType(token2.NFTTypePrefix))
+	_, err = token2.NFTIDFromType("USD")
+	assert.Equal(t, "type [USD] is not a non-fungible token type", err.Error())
+
+	assert.Equal(t, "invalid nft: empty identifier", (&token2.NFT{URI: "ipfs://"}).Validate().Error())
+	assert.Error(t, (&token2.NFT{ID: "a\x00b"}).Validate())
+}
@@ -10,10 +10,18 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// DefaultPrecision is the precision, in bits, of token quantities when the public parameters do not declare one
+	DefaultPrecision uint64 = 64
+	// MaxPrecision is the largest precision, in bits, that public parameters can declare for token quantities
+	MaxPrecision uint64 = 256
+)
+
 // Quantity models an immutable token quantity and its basic operations.
 type Quantity interface {
 
@@ -36,6 +44,10 @@ type Quantity interface {
 
 	// ToBigInt returns the big int representation of this quantity
 	ToBigInt() *big.Int
+
+	// Display returns the decimal representation of this quantity in a unit with the passed number of decimals.
+	// For instance, 12345 with 2 decimals is displayed as 123.45
+	Display(decimals uint64) string
 }
 
 type BigQuantity struct {
@@ -71,6 +83,38 @@ func NewZeroQuantity(precision uint64) Quantity {
 	return &b
 }
 
+// ParseDisplay converts an amount expressed in a unit with the passed number of decimals to a BigQuantity of a given precision.
+// For instance, 123.45 with 2 decimals is the quantity 12345.
+// The amount cannot have more fractional digits than decimals.
+func ParseDisplay(amount string, decimals uint64, precision uint64) (Quantity, error) {
+	integer, fraction := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		integer, fraction = amount[:i], amount[i+1:]
+	}
+	if len(integer) == 0 || !isDigits(integer) || !isDigits(fraction) {
+		return nil, errors.Errorf("invalid amount [%s]", amount)
+	}
+	if uint64(len(fraction)) > decimals {
+		return nil, errors.Errorf("amount [%s] has more than %d decimals", amount, decimals)
+	}
+	fraction += strings.Repeat("0", int(decimals)-len(fraction))
+	// leading zeros are trimmed, ToQuantity would otherwise read the digits as an octal number
+	digits := strings.TrimLeft(integer+fraction, "0")
+	if len(digits) == 0 {
+		digits = "0"
+	}
+	return ToQuantity(digits, precision)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func NewQuantityFromUInt64(q uint64) Quantity {
 	v, _ := big.NewInt(0).SetString(strconv.FormatUint(q, 10), 10)
 	return &BigQuantity{Int: v, Precision: 64}
@@ -139,6 +183,17 @@ func (q *BigQuantity) String() string {
 	return q.Int.Text(10)
 }
 
+func (q *BigQuantity) Display(decimals uint64) string {
+	s := q.Int.Text(10)
+	if decimals == 0 {
+		return s
+	}
+	if uint64(len(s)) <= decimals {
+		s = strings.Repeat("0", int(decimals)-len(s)+1) + s
+	}
+	return s[:uint64(len(s))-decimals] + "." + s[uint64(len(s))-decimals:]
+}
+
 func (q *BigQuantity) ToBigInt() *big.Int {
 	return (&big.Int{}).Set(q.Int)
 }
@@ -80,6 +80,34 @@ func TestOverflow(t *testing.T) {
 	})
 }
 
+func TestDisplay(t *testing.T) {
+	q, err := token2.ToQuantity("12345", 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345", q.Display(0))
+	assert.Equal(t, "123.45", q.Display(2))
+	assert.Equal(t, "0.012345", q.Display(6))
+	assert.Equal(t, "0.00", token2.NewZeroQuantity(64).Display(2))
+
+	q, err = token2.ParseDisplay("123.45", 2, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345", q.Decimal())
+	q, err = token2.ParseDisplay("0.012", 6, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "12000", q.Decimal())
+	q, err = token2.ParseDisplay("0", 2, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", q.Decimal())
+
+	_, err = token2.ParseDisplay("1.234", 2, 64)
+	assert.Equal(t, "amount [1.234] has more than 2 decimals", err.Error())
+	_, err = token2.ParseDisplay("-1.2", 2, 64)
+	assert.Equal(t, "invalid amount [-1.2]", err.Error())
+	_, err = token2.ParseDisplay(".5", 2, 64)
+	assert.Equal(t, "invalid amount [.5]", err.Error())
+	_, err = token2.ParseDisplay("2.56", 2, 8)
+	assert.Equal(t, "256 has precision 9 > 8", err.Error())
+}
+
 func ToHex(q uint64) string {
 	return "0x" + strconv.FormatUint(q, 16)
 }
@@ -0,0 +1,188 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+// AnyType, when registered, stands for all the token types that are not registered otherwise,
+// non-fungible token types included. Registering it without issuers lets any issuer issue any type.
+const AnyType = "*"
+
+// TypeInfo describes a token type registered in the public parameters
+type TypeInfo struct {
+	// Type is the token type, as passed to Issue and Transfer
+	Type string `json:"type"`
+	// Symbol is the symbol used to display quantities of this type
+	Symbol string `json:"symbol,omitempty"`
+	// Decimals is the number of decimal digits of the display unit.
+	// A quantity q of this type is displayed as q/10^Decimals.
+	Decimals uint64 `json:"decimals"`
+	// MaxSupply is the decimal representation of the largest total quantity of this type that can be issued.
+	// When empty, the supply is not bounded.
+	MaxSupply string `json:"max_supply,omitempty"`
+	// Issuers are the identities allowed to issue tokens of this type.
+	// When empty, any issuer is allowed.
+	Issuers [][]byte `json:"issuers,omitempty"`
+}
+
+// Validate returns an error if the type information is not well-formed for the passed precision
+func (t *TypeInfo) Validate(precision uint64) error {
+	if len(t.Type) == 0 {
+		return errors.New("invalid token type: empty type")
+	}
+	if IsNFTType(t.Type) {
+		return errors.Errorf("invalid token type: type [%s] is reserved to non-fungible tokens", t.Type)
+	}
+	if len(t.MaxSupply) != 0 {
+		if t.Type == AnyType {
+			return errors.Errorf("invalid token type: type [%s] cannot have a max supply", AnyType)
+		}
+		if _, err := ToQuantity(t.MaxSupply, precision); err != nil {
+			return errors.Wrapf(err, "invalid max supply for token type [%s]", t.Type)
+		}
+	}
+	return nil
+}
+
+// CanIssue returns true if the passed identity is allowed to issue tokens of this type
+func (t *TypeInfo) CanIssue(issuer []byte) bool {
+	if len(t.Issuers) == 0 {
+		return true
+	}
+	for _, id := range t.Issuers {
+		if bytes.Equal(id, issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSupply returns an error if the passed quantity exceeds the max supply of this type
+func (t *TypeInfo) CheckSupply(q Quantity) error {
+	if len(t.MaxSupply) == 0 {
+		return nil
+	}
+	max, err := ToQuantity(t.MaxSupply, MaxPrecision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid max supply for token type [%s]", t.Type)
+	}
+	if q.ToBigInt().Cmp(max.ToBigInt()) > 0 {
+		return errors.Errorf("quantity [%s] of type [%s] exceeds the max supply [%s]", q.Decimal(), t.Type, t.MaxSupply)
+	}
+	return nil
+}
+
+// Format returns the passed quantity in the display unit of this type, followed by its symbol, if any
+func (t *TypeInfo) Format(q Quantity) string {
+	if len(t.Symbol) == 0 {
+		return q.Display(t.Decimals)
+	}
+	return q.Display(t.Decimals) + " " + t.Symbol
+}
+
+// Parse converts an amount expressed in the display unit of this type to a quantity of the passed precision
+func (t *TypeInfo) Parse(amount string, precision uint64) (Quantity, error) {
+	return ParseDisplay(amount, t.Decimals, precision)
+}
+
+// TypeIssuer authorizes an identity to issue tokens of a given type
+type TypeIssuer struct {
+	// Type is the token type
+	Type string `json:"type"`
+	// Issuer is the identity allowed to issue tokens of the type
+	Issuer []byte `json:"issuer"`
+}
+
+// TypeRegistry lists the token types registered in the public parameters
+type TypeRegistry []*TypeInfo
+
+// Lookup returns the information about the passed type, or nil if the type is not registered
+func (r TypeRegistry) Lookup(typ string) *TypeInfo {
+	for _, info := range r {
+		if info.Type == typ {
+			return info
+		}
+	}
+	return nil
+}
+
+// Register returns a registry that contains the passed type information.
+// The information replaces any previous entry for the same type.
+func (r TypeRegistry) Register(info *TypeInfo) TypeRegistry {
+	res := make(TypeRegistry, 0, len(r)+1)
+	for _, entry := range r {
+		if entry.Type != info.Type {
+			res = append(res, entry)
+		}
+	}
+	return append(res, info)
+}
+
+// AddIssuer returns a registry where the passed issuer is allowed to issue tokens of the passed type.
+// If the type is not registered, it is registered with no decimals.
+// Notice that once a type has an issuer, only the issuers in its list can issue it.
+func (r TypeRegistry) AddIssuer(typ string, issuer []byte) TypeRegistry {
+	info := &TypeInfo{Type: typ}
+	if old := r.Lookup(typ); old != nil {
+		copied := *old
+		info = &copied
+	}
+	for _, id := range info.Issuers {
+		if bytes.Equal(id, issuer) {
+			return r
+		}
+	}
+	info.Issuers = append(append([][]byte{}, info.Issuers...), issuer)
+	return r.Register(info)
+}
+
+// ReservesNFTTypes returns true if the issuers of non-fungible tokens are restricted by registering the type NFTTypePrefix.
+// Then, an issue that hides its type must prove that it does not create non-fungible tokens.
+func (r TypeRegistry) ReservesNFTTypes() bool {
+	return r.Lookup(NFTTypePrefix) != nil
+}
+
+// CheckIssue returns an error if the passed issuer cannot issue tokens of the passed type.
+// Issuing is denied by default: the type, or AnyType, must be registered.
+// The issuers of non-fungible tokens are the ones of the type NFTTypePrefix, or of AnyType if NFTTypePrefix is not registered.
+// It returns the information about the type, if registered.
+func (r TypeRegistry) CheckIssue(typ string, issuer []byte) (*TypeInfo, error) {
+	if typ == AnyType || typ == NFTTypePrefix {
+		return nil, errors.Errorf("token type [%s] is reserved", typ)
+	}
+	if IsNFTType(typ) {
+		info := r.Lookup(NFTTypePrefix)
+		if info == nil {
+			info = r.Lookup(AnyType)
+		}
+		if info == nil {
+			return nil, errors.New("non-fungible tokens are not registered")
+		}
+		if !info.CanIssue(issuer) {
+			return nil, errors.Errorf("issuer is not allowed to issue non-fungible tokens")
+		}
+		return nil, nil
+	}
+	info := r.Lookup(typ)
+	if info == nil {
+		wildcard := r.Lookup(AnyType)
+		if wildcard == nil {
+			return nil, errors.Errorf("token type [%s] is not registered", typ)
+		}
+		if !wildcard.CanIssue(issuer) {
+			return nil, errors.Errorf("issuer is not allowed to issue token type [%s]", typ)
+		}
+		return nil, nil
+	}
+	if !info.CanIssue(issuer) {
+		return nil, errors.Errorf("issuer is not allowed to issue token type [%s]", typ)
+	}
+	return info, nil
+}
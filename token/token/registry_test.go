@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token_test
+
+import (
+	"testing"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeRegistry(t *testing.T) {
+	usd := &token2.TypeInfo{Type: "USD", Symbol: "$", Decimals: 2, MaxSupply: "1000000", Issuers: [][]byte{[]byte("alice")}}
+	assert.NoError(t, usd.Validate(64))
+	assert.Equal(t, "invalid token type: empty type", (&token2.TypeInfo{}).Validate(64).Error())
+	assert.Error(t, (&token2.TypeInfo{Type: "nft:painting"}).Validate(64))
+	assert.Error(t, (&token2.TypeInfo{Type: "EUR", MaxSupply: "0x1ff"}).Validate(8))
+	assert.Error(t, (&token2.TypeInfo{Type: token2.AnyType, MaxSupply: "10"}).Validate(64))
+
+	// an empty registry denies any issue
+	var registry token2.TypeRegistry
+	_, err := registry.CheckIssue("EUR", []byte("bob"))
+	assert.Equal(t, "token type [EUR] is not registered", err.Error())
+	_, err = registry.CheckIssue("nft:painting", []byte("bob"))
+	assert.Equal(t, "non-fungible tokens are not registered", err.Error())
+
+	registry = registry.Register(usd)
+	info, err := registry.CheckIssue("USD", []byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, usd, info)
+	_, err = registry.CheckIssue("USD", []byte("bob"))
+	assert.Equal(t, "issuer is not allowed to issue token type [USD]", err.Error())
+	_, err = registry.CheckIssue("UDS", []byte("alice"))
+	assert.Equal(t, "token type [UDS] is not registered", err.Error())
+	_, err = registry.CheckIssue("nft:painting", []byte("bob"))
+	assert.Error(t, err)
+
+	// registering the same type again replaces the previous entry
+	registry = registry.Register(&token2.TypeInfo{Type: "USD", Decimals: 2})
+	assert.Len(t, registry, 1)
+	_, err = registry.CheckIssue("USD", []byte("bob"))
+	assert.NoError(t, err)
+
+	// adding an issuer restricts the type to the listed issuers
+	registry = registry.AddIssuer("USD", []byte("alice"))
+	registry = registry.AddIssuer("USD", []byte("alice"))
+	assert.Len(t, registry.Lookup("USD").Issuers, 1)
+	assert.Equal(t, uint64(2), registry.Lookup("USD").Decimals)
+	_, err = registry.CheckIssue("USD", []byte("bob"))
+	assert.Error(t, err)
+	registry = registry.AddIssuer("EUR", []byte("bob"))
+	_, err = registry.CheckIssue("EUR", []byte("bob"))
+	assert.NoError(t, err)
+
+	// registering the nft prefix restricts the issuers of non-fungible tokens
+	registry = registry.AddIssuer(token2.NFTTypePrefix, []byte("alice"))
+	_, err = registry.CheckIssue("nft:painting", []byte("alice"))
+	assert.NoError(t, err)
+	_, err = registry.CheckIssue("nft:painting", []byte("bob"))
+	assert.Equal(t, "issuer is not allowed to issue non-fungible tokens", err.Error())
+
+	// registering AnyType opens the types that are not registered otherwise
+	registry = registry.Register(&token2.TypeInfo{Type: token2.AnyType})
+	info, err = registry.CheckIssue("GBP", []byte("carol"))
+	assert.NoError(t, err)
+	assert.Nil(t, info)
+	_, err = registry.CheckIssue("USD", []byte("carol"))
+	assert.Error(t, err)
+	_, err = registry.CheckIssue("nft:painting", []byte("carol"))
+	assert.Error(t, err)
+	_, err = registry.CheckIssue(token2.AnyType, []byte("carol"))
+	assert.Equal(t, "token type [*] is reserved", err.Error())
+
+	assert.NoError(t, usd.CheckSupply(token2.NewQuantityFromUInt64(1000000)))
+	assert.Equal(t, "quantity [1000001] of type [USD] exceeds the max supply [1000000]", usd.CheckSupply(token2.NewQuantityFromUInt64(1000001)).Error())
+
+	q, err := usd.Parse("12.5", 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "1250", q.Decimal())
+	assert.Equal(t, "12.50 $", usd.Format(q))
+}
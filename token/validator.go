@@ -40,9 +40,7 @@ func (c *Validator) Verify(ledger Ledger, sp SignatureProvider, binding string,
 }
 
 func (c *Validator) UnmarshallAndVerify(ledger Ledger, binding string, raw []byte) ([]interface{}, error) {
-	actions, err := c.backend.VerifyTokenRequestFromRaw(func(key string) ([]byte, error) {
-		return ledger.GetState(key)
-	}, binding, raw)
+	actions, err := c.backend.VerifyTokenRequestFromRaw(ledger, binding, raw)
 	if err != nil {
 		return nil, err
 	}
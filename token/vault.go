@@ -6,10 +6,57 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
+// TokenFilter selects tokens. Empty fields match all tokens.
+type TokenFilter struct {
+	// Wallet is the identifier of the owner wallet the tokens must belong to
+	Wallet string
+	// Owner is the identity that must own the tokens
+	Owner view.Identity
+	// Type is the type of the tokens
+	Type string
+	// MinQuantity is the minimum quantity of the tokens, inclusive
+	MinQuantity token2.Quantity
+	// MaxQuantity is the maximum quantity of the tokens, inclusive
+	MaxQuantity token2.Quantity
+	// TxID is the identifier of the transaction that created the tokens
+	TxID string
+}
+
+func (f *TokenFilter) toDriver() *driver.TokenFilter {
+	if f == nil {
+		return &driver.TokenFilter{}
+	}
+	return &driver.TokenFilter{
+		Wallet:      f.Wallet,
+		Owner:       f.Owner,
+		Type:        f.Type,
+		MinQuantity: f.MinQuantity,
+		MaxQuantity: f.MaxQuantity,
+		TxID:        f.TxID,
+	}
+}
+
+// UnspentTokensPage is a page of unspent tokens
+type UnspentTokensPage struct {
+	Tokens []*token2.UnspentToken
+	// NextCursor is the cursor of the next page, the empty string if this is the last page
+	NextCursor string
+}
+
+// IssuedTokensPage is a page of issued tokens
+type IssuedTokensPage struct {
+	Tokens []*token2.IssuedToken
+	// NextCursor is the cursor of the next page, the empty string if this is the last page
+	NextCursor string
+}
+
 type QueryEngine struct {
 	qe driver.QueryEngine
 }
@@ -22,6 +69,42 @@ func (q *QueryEngine) ListUnspentTokens() (*token2.UnspentTokens, error) {
 	return q.qe.ListUnspentTokens()
 }
 
+// ListUnspentTokensOf returns the unspent tokens owned by the passed wallet and of the passed type.
+// The empty type matches all types.
+func (q *QueryEngine) ListUnspentTokensOf(wallet string, typ string) (*token2.UnspentTokens, error) {
+	return q.qe.ListUnspentTokensOf(wallet, typ)
+}
+
+// Balance returns the sum of the unspent tokens owned by the passed wallet and of the passed type
+func (q *QueryEngine) Balance(wallet string, typ string) (token2.Quantity, error) {
+	return q.qe.Balance(wallet, typ)
+}
+
+// Balances returns the balance of each token type owned by the passed wallet
+func (q *QueryEngine) Balances(wallet string) (map[string]token2.Quantity, error) {
+	return q.qe.Balances(wallet)
+}
+
+// UnspentTokensIterator returns an iterator over the unspent tokens that match the passed filter, in ledger order.
+// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+// The iterator must be closed.
+func (q *QueryEngine) UnspentTokensIterator(filter *TokenFilter, cursor string) (driver.UnspentTokensIterator, error) {
+	return q.qe.UnspentTokensIterator(filter.toDriver(), cursor)
+}
+
+// ListUnspentTokensPage returns at most pageSize unspent tokens that match the passed filter, starting after the passed cursor.
+// The empty cursor selects the first page.
+func (q *QueryEngine) ListUnspentTokensPage(filter *TokenFilter, cursor string, pageSize int) (*UnspentTokensPage, error) {
+	if pageSize <= 0 {
+		return nil, errors.Errorf("invalid page size [%d]", pageSize)
+	}
+	it, err := q.UnspentTokensIterator(filter, cursor)
+	if err != nil {
+		return nil, err
+	}
+	return readUnspentTokensPage(it, pageSize)
+}
+
 func (q *QueryEngine) ListAuditTokens(ids ...*token2.Id) ([]*token2.Token, error) {
 	return q.qe.ListAuditTokens(ids...)
 }
@@ -30,6 +113,45 @@ func (q *QueryEngine) ListHistoryIssuedTokens() (*token2.IssuedTokens, error) {
 	return q.qe.ListHistoryIssuedTokens()
 }
 
+// IssuedTokensIterator returns an iterator over the issued tokens that match the passed filter, in ledger order.
+// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+// The iterator must be closed.
+func (q *QueryEngine) IssuedTokensIterator(filter *TokenFilter, cursor string) (driver.IssuedTokensIterator, error) {
+	return q.qe.IssuedTokensIterator(filter.toDriver(), cursor)
+}
+
+// ListIssuedTokensPage returns at most pageSize issued tokens that match the passed filter, starting after the passed cursor.
+// The empty cursor selects the first page.
+func (q *QueryEngine) ListIssuedTokensPage(filter *TokenFilter, cursor string, pageSize int) (*IssuedTokensPage, error) {
+	if pageSize <= 0 {
+		return nil, errors.Errorf("invalid page size [%d]", pageSize)
+	}
+	it, err := q.IssuedTokensIterator(filter, cursor)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	page := &IssuedTokensPage{}
+	var last string
+	for {
+		next, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed reading issued tokens")
+		}
+		if next == nil {
+			return page, nil
+		}
+		if len(page.Tokens) == pageSize {
+			// there is at least another token, the next page starts after the last token of this page
+			page.NextCursor = last
+			return page, nil
+		}
+		page.Tokens = append(page.Tokens, next)
+		last = it.Cursor()
+	}
+}
+
 func (q *QueryEngine) PublicParams() ([]byte, error) {
 	return q.qe.PublicParams()
 }
@@ -38,8 +160,14 @@ func (q *QueryEngine) GetTokens(inputs ...*token2.Id) ([]*token2.Token, error) {
 	return q.qe.GetTokens(inputs...)
 }
 
+// GetNFT returns the non-fungible token with the passed unique identifier
+func (q *QueryEngine) GetNFT(id string) (*token2.NFT, error) {
+	return q.qe.GetNFT(id)
+}
+
 type Vault struct {
-	v driver.Vault
+	v      driver.Vault
+	events *EventHub
 }
 
 func (v *Vault) NewQueryEngine() *QueryEngine {
@@ -47,3 +175,36 @@ func (v *Vault) NewQueryEngine() *QueryEngine {
 		qe: v.v.QueryEngine(),
 	}
 }
+
+// Subscribe returns a subscription to the token events selected by the passed filter, buffering up to bufferSize events.
+// If bufferSize is not positive, DefaultEventBufferSize is used. The subscription must be cancelled with Unsubscribe.
+func (v *Vault) Subscribe(filter *EventFilter, bufferSize int) (*Subscription, error) {
+	if v.events == nil {
+		return nil, errors.New("token events not available")
+	}
+	return v.events.Subscribe(filter, bufferSize), nil
+}
+
+// readUnspentTokensPage reads at most pageSize tokens from the passed iterator, and closes it
+func readUnspentTokensPage(it driver.UnspentTokensIterator, pageSize int) (*UnspentTokensPage, error) {
+	defer it.Close()
+
+	page := &UnspentTokensPage{}
+	var last string
+	for {
+		next, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed reading unspent tokens")
+		}
+		if next == nil {
+			return page, nil
+		}
+		if len(page.Tokens) == pageSize {
+			// there is at least another token, the next page starts after the last token of this page
+			page.NextCursor = last
+			return page, nil
+		}
+		page.Tokens = append(page.Tokens, next)
+		last = it.Cursor()
+	}
+}
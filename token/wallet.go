@@ -7,6 +7,7 @@ package token
 
 import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
 
 	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -201,6 +202,32 @@ func (o *OwnerWallet) ListUnspentTokens(opts ...ListTokensOption) (*token2.Unspe
 	return o.w.ListTokens(compiledOpts)
 }
 
+// UnspentTokensIterator returns an iterator over the unspent tokens owned by identities in this wallet that match
+// the passed filter, in ledger order. The wallet field of the filter is ignored.
+// If the cursor is not empty, the iteration resumes after the token the cursor was taken at.
+// The iterator must be closed.
+func (o *OwnerWallet) UnspentTokensIterator(filter *TokenFilter, cursor string) (api2.UnspentTokensIterator, error) {
+	return o.w.UnspentTokensIterator(filter.toDriver(), cursor)
+}
+
+// ListUnspentTokensPage returns at most pageSize unspent tokens owned by identities in this wallet that match
+// the passed filter, starting after the passed cursor. The empty cursor selects the first page.
+func (o *OwnerWallet) ListUnspentTokensPage(filter *TokenFilter, cursor string, pageSize int) (*UnspentTokensPage, error) {
+	if pageSize <= 0 {
+		return nil, errors.Errorf("invalid page size [%d]", pageSize)
+	}
+	it, err := o.UnspentTokensIterator(filter, cursor)
+	if err != nil {
+		return nil, err
+	}
+	return readUnspentTokensPage(it, pageSize)
+}
+
+// ListNFTs returns the non-fungible tokens owned by identities in this wallet, together with their metadata URI.
+func (o *OwnerWallet) ListNFTs() ([]*token2.NFT, error) {
+	return o.w.ListNFTs()
+}
+
 type IssuerWallet struct {
 	w api2.IssuerWallet
 }